@@ -0,0 +1,18 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrMiss is returned by Get when the key is not cached or has expired.
+var ErrMiss = errors.New("cache: miss")
+
+// Cache is a collection of behaviour of a key value cache.
+type Cache interface {
+	// Get returns the value of key, or ErrMiss.
+	Get(ctx context.Context, key string) (value []byte, err error)
+	// Set caches value under key for ttl, a non positive ttl never expires.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) (err error)
+}
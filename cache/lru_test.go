@@ -0,0 +1,50 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ijalalfrz/sirclo-weight-test/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRU_Success(t *testing.T) {
+	c := cache.NewLRU(2)
+
+	assert.NoError(t, c.Set(context.TODO(), "a", []byte("1"), 0))
+	value, err := c.Get(context.TODO(), "a")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("1"), value)
+
+	assert.NoError(t, c.Set(context.TODO(), "a", []byte("2"), 0))
+	value, _ = c.Get(context.TODO(), "a")
+	assert.Equal(t, []byte("2"), value, "should be replaced")
+	assert.Equal(t, 1, c.Len())
+}
+
+func TestLRU_Success_EvictLeastRecentlyUsed(t *testing.T) {
+	c := cache.NewLRU(2)
+
+	c.Set(context.TODO(), "a", []byte("1"), 0)
+	c.Set(context.TODO(), "b", []byte("2"), 0)
+	c.Get(context.TODO(), "a")
+	c.Set(context.TODO(), "c", []byte("3"), 0)
+
+	_, err := c.Get(context.TODO(), "b")
+	assert.Equal(t, cache.ErrMiss, err, "should evict the least recently used key")
+	_, err = c.Get(context.TODO(), "a")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, c.Len())
+}
+
+func TestLRU_Error_Expired(t *testing.T) {
+	c := cache.NewLRU(2)
+
+	c.Set(context.TODO(), "a", []byte("1"), time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	_, err := c.Get(context.TODO(), "a")
+	assert.Equal(t, cache.ErrMiss, err, "should be expired")
+	assert.Equal(t, 0, c.Len())
+}
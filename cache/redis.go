@@ -0,0 +1,36 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Redis is a concrete struct of a cache on a server speaking the Redis protocol.
+type Redis struct {
+	client redis.UniversalClient
+}
+
+// NewRedis is a constructor.
+func NewRedis(client redis.UniversalClient) *Redis {
+	return &Redis{client: client}
+}
+
+// Get returns the value of key.
+func (c *Redis) Get(ctx context.Context, key string) (value []byte, err error) {
+	value, err = c.client.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		err = ErrMiss
+	}
+	return
+}
+
+// Set caches value under key, the key is expired by the server.
+func (c *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) (err error) {
+	if ttl < 0 {
+		ttl = 0
+	}
+	err = c.client.Set(ctx, key, value, ttl).Err()
+	return
+}
@@ -0,0 +1,48 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/ijalalfrz/sirclo-weight-test/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRedis(t *testing.T) (*miniredis.Miniredis, *cache.Redis) {
+	server, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(server.Close)
+
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() {
+		client.Close()
+	})
+	return server, cache.NewRedis(client)
+}
+
+func TestRedis_Success(t *testing.T) {
+	server, c := newRedis(t)
+
+	assert.NoError(t, c.Set(context.TODO(), "a", []byte("1"), time.Minute))
+	value, err := c.Get(context.TODO(), "a")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("1"), value)
+	assert.Equal(t, time.Minute, server.TTL("a"))
+
+	server.FastForward(time.Minute)
+	_, err = c.Get(context.TODO(), "a")
+	assert.Equal(t, cache.ErrMiss, err, "should be expired")
+}
+
+func TestRedis_Error_Unavailable(t *testing.T) {
+	server, c := newRedis(t)
+	server.Close()
+
+	_, err := c.Get(context.TODO(), "a")
+	assert.Error(t, err, "should be error")
+	assert.NotEqual(t, cache.ErrMiss, err, "should not be a miss")
+}
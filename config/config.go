@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path"
 	"runtime"
@@ -11,20 +12,100 @@ import (
 	_ "github.com/joho/godotenv/autoload" // for development
 	"github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
+)
+
+// collection of storage driver
+const (
+	StorageMongodb  = "mongodb"
+	StorageMemory   = "memory"
+	StorageFile     = "file"
+	StoragePostgres = "postgres"
+	StorageSQLite   = "sqlite"
+)
+
+// collection of cache driver
+const (
+	CacheNone   = "none"
+	CacheMemory = "memory"
+	CacheRedis  = "redis"
+)
+
+// collection of outbox publisher
+const (
+	OutboxNone  = "none"
+	OutboxKafka = "kafka"
+)
+
+// collection of stream source
+const (
+	StreamLocal        = "local"
+	StreamChangeStream = "changestream"
 )
 
 // Config is an app configuration.
 type Config struct {
 	Application struct {
-		Port string
-		Name string
+		Port            string
+		Name            string
+		ShutdownTimeout time.Duration
+		Location        *time.Location
 	}
 	Logger struct {
 		Formatter logrus.Formatter
 	}
 	Mongodb struct {
-		ClientOptions *options.ClientOptions
-		Database      string
+		ClientOptions  *options.ClientOptions
+		Database       string
+		MigrateOnStart bool
+	}
+	Storage struct {
+		Driver          string
+		FilePath        string
+		HistoryFilePath string
+		DSN             string
+		MigrateOnStart  bool
+	}
+	Cache struct {
+		Driver   string
+		TTL      time.Duration
+		Size     int
+		RedisURL string
+	}
+	Outbox struct {
+		Publisher  string
+		Interval   time.Duration
+		BatchSize  int64
+		MaxBackoff time.Duration
+	}
+	Webhook struct {
+		Interval    time.Duration
+		BatchSize   int64
+		MaxAttempts int
+		MaxBackoff  time.Duration
+		Timeout     time.Duration
+	}
+	Stream struct {
+		Source string
+	}
+	Kafka struct {
+		Brokers  []string
+		Topic    string
+		Username string
+		Password string
+		Timeout  time.Duration
+	}
+	Tracing struct {
+		Exporter string
+		Endpoint string
+		Insecure bool
+		FilePath string
+	}
+	Auth struct {
+		Secret       string
+		Users        map[string]string
+		SessionTTL   time.Duration
+		SecureCookie bool
 	}
 }
 
@@ -34,9 +115,56 @@ func Load() *Config {
 	cfg.logFormatter()
 	cfg.app()
 	cfg.mongodb()
+	cfg.storage()
+	cfg.cache()
+	cfg.outbox()
+	cfg.webhook()
+	cfg.stream()
+	cfg.kafka()
+	cfg.auth()
+	cfg.tracing()
 	return cfg
 }
 
+// Validate reports the first driver, publisher or source which is unknown or can not be used with the storage
+// driver, so the service fails before it opens any connection.
+func (cfg *Config) Validate() (err error) {
+	switch cfg.Storage.Driver {
+	case StorageMongodb, StorageMemory, StorageFile, StoragePostgres, StorageSQLite:
+	default:
+		return fmt.Errorf("unknown storage driver %q", cfg.Storage.Driver)
+	}
+
+	switch cfg.Cache.Driver {
+	case CacheNone, CacheMemory, CacheRedis:
+	default:
+		return fmt.Errorf("unknown cache driver %q", cfg.Cache.Driver)
+	}
+
+	switch cfg.Outbox.Publisher {
+	case OutboxNone:
+	case OutboxKafka:
+		// the events are committed with the write, which the memory and file drivers can not do.
+		if cfg.Storage.Driver == StorageMemory || cfg.Storage.Driver == StorageFile {
+			return fmt.Errorf("outbox publisher %q requires a transactional storage driver, not %q", cfg.Outbox.Publisher, cfg.Storage.Driver)
+		}
+	default:
+		return fmt.Errorf("unknown outbox publisher %q", cfg.Outbox.Publisher)
+	}
+
+	switch cfg.Stream.Source {
+	case StreamLocal:
+	case StreamChangeStream:
+		if cfg.Storage.Driver != StorageMongodb {
+			return fmt.Errorf("stream source %q requires the %q storage driver", cfg.Stream.Source, StorageMongodb)
+		}
+	default:
+		return fmt.Errorf("unknown stream source %q", cfg.Stream.Source)
+	}
+
+	return
+}
+
 func (cfg *Config) logFormatter() {
 	formatter := &logrus.JSONFormatter{
 		TimestampFormat: "2006-01-02 15:04:05",
@@ -54,9 +182,21 @@ func (cfg *Config) logFormatter() {
 func (cfg *Config) app() {
 	appName := os.Getenv("APP_NAME")
 	port := os.Getenv("PORT")
+	shutdownTimeout, _ := strconv.ParseInt(os.Getenv("SHUTDOWN_TIMEOUT_SECONDS"), 10, 64)
+	if shutdownTimeout < 1 {
+		shutdownTimeout = 30
+	}
 
 	cfg.Application.Port = port
 	cfg.Application.Name = appName
+	cfg.Application.ShutdownTimeout = time.Second * time.Duration(shutdownTimeout)
+
+	// APP_TIMEZONE is the IANA name of the timezone used when the user does not send one.
+	location, err := time.LoadLocation(os.Getenv("APP_TIMEZONE"))
+	if err != nil {
+		location = time.UTC
+	}
+	cfg.Application.Location = location
 }
 
 func (cfg *Config) mongodb() {
@@ -72,8 +212,198 @@ func (cfg *Config) mongodb() {
 		SetAppName(appName).
 		SetMinPoolSize(minPoolSize).
 		SetMaxPoolSize(maxPoolSize).
-		SetMaxConnIdleTime(time.Millisecond * time.Duration(maxConnIdleTime))
+		SetMaxConnIdleTime(time.Millisecond * time.Duration(maxConnIdleTime)).
+		SetMonitor(otelmongo.NewMonitor())
+
+	migrateOnStart, err := strconv.ParseBool(os.Getenv("MONGODB_MIGRATE_ON_START"))
+	if err != nil {
+		migrateOnStart = true
+	}
 
 	cfg.Mongodb.ClientOptions = opts
 	cfg.Mongodb.Database = db
+	cfg.Mongodb.MigrateOnStart = migrateOnStart
+}
+
+func (cfg *Config) storage() {
+	// STORAGE_DRIVER selects where the weights are stored, mongodb, memory, file, postgres or sqlite.
+	driver := strings.ToLower(os.Getenv("STORAGE_DRIVER"))
+	if driver == "" {
+		driver = StorageMongodb
+	}
+	filePath := os.Getenv("STORAGE_FILE_PATH")
+	if filePath == "" {
+		filePath = "weights.json"
+	}
+	// STORAGE_HISTORY_FILE_PATH is the JSON Lines file of the weight history of the file driver.
+	historyFilePath := os.Getenv("STORAGE_HISTORY_FILE_PATH")
+	if historyFilePath == "" {
+		historyFilePath = "weights.history.jsonl"
+	}
+
+	// STORAGE_SQL_DSN is the data source name of the sql drivers, postgres also reads the standard PG* variables.
+	dsn := os.Getenv("STORAGE_SQL_DSN")
+	if dsn == "" && driver == StorageSQLite {
+		dsn = "weights.db"
+	}
+	migrateOnStart, err := strconv.ParseBool(os.Getenv("STORAGE_MIGRATE_ON_START"))
+	if err != nil {
+		migrateOnStart = true
+	}
+
+	cfg.Storage.Driver = driver
+	cfg.Storage.FilePath = filePath
+	cfg.Storage.HistoryFilePath = historyFilePath
+	cfg.Storage.DSN = dsn
+	cfg.Storage.MigrateOnStart = migrateOnStart
+}
+
+func (cfg *Config) cache() {
+	// CACHE_DRIVER selects the cache of the weight reads, none, memory or redis.
+	driver := strings.ToLower(os.Getenv("CACHE_DRIVER"))
+	if driver == "" {
+		driver = CacheNone
+	}
+	ttl, _ := strconv.ParseInt(os.Getenv("CACHE_TTL_SECONDS"), 10, 64)
+	if ttl < 1 {
+		ttl = 60
+	}
+	size, _ := strconv.Atoi(os.Getenv("CACHE_SIZE"))
+	if size < 1 {
+		size = 1024
+	}
+
+	cfg.Cache.Driver = driver
+	cfg.Cache.TTL = time.Second * time.Duration(ttl)
+	cfg.Cache.Size = size
+	cfg.Cache.RedisURL = os.Getenv("CACHE_REDIS_URL")
+}
+
+func (cfg *Config) outbox() {
+	// OUTBOX_PUBLISHER selects where the weight events are published, none or kafka.
+	publisher := strings.ToLower(os.Getenv("OUTBOX_PUBLISHER"))
+	if publisher == "" {
+		publisher = OutboxNone
+	}
+	interval, _ := strconv.ParseInt(os.Getenv("OUTBOX_INTERVAL_MS"), 10, 64)
+	if interval < 1 {
+		interval = 1000
+	}
+	batchSize, _ := strconv.ParseInt(os.Getenv("OUTBOX_BATCH_SIZE"), 10, 64)
+	if batchSize < 1 {
+		batchSize = 100
+	}
+	maxBackoff, _ := strconv.ParseInt(os.Getenv("OUTBOX_MAX_BACKOFF_SECONDS"), 10, 64)
+	if maxBackoff < 1 {
+		maxBackoff = 300
+	}
+
+	cfg.Outbox.Publisher = publisher
+	cfg.Outbox.Interval = time.Millisecond * time.Duration(interval)
+	cfg.Outbox.BatchSize = batchSize
+	cfg.Outbox.MaxBackoff = time.Second * time.Duration(maxBackoff)
+}
+
+func (cfg *Config) webhook() {
+	interval, _ := strconv.ParseInt(os.Getenv("WEBHOOK_INTERVAL_MS"), 10, 64)
+	if interval < 1 {
+		interval = 1000
+	}
+	batchSize, _ := strconv.ParseInt(os.Getenv("WEBHOOK_BATCH_SIZE"), 10, 64)
+	if batchSize < 1 {
+		batchSize = 100
+	}
+	// WEBHOOK_MAX_ATTEMPTS is the number of attempts after which a delivery is dead.
+	maxAttempts, _ := strconv.Atoi(os.Getenv("WEBHOOK_MAX_ATTEMPTS"))
+	if maxAttempts < 1 {
+		maxAttempts = 8
+	}
+	maxBackoff, _ := strconv.ParseInt(os.Getenv("WEBHOOK_MAX_BACKOFF_SECONDS"), 10, 64)
+	if maxBackoff < 1 {
+		maxBackoff = 3600
+	}
+	timeout, _ := strconv.ParseInt(os.Getenv("WEBHOOK_TIMEOUT_SECONDS"), 10, 64)
+	if timeout < 1 {
+		timeout = 10
+	}
+
+	cfg.Webhook.Interval = time.Millisecond * time.Duration(interval)
+	cfg.Webhook.BatchSize = batchSize
+	cfg.Webhook.MaxAttempts = maxAttempts
+	cfg.Webhook.MaxBackoff = time.Second * time.Duration(maxBackoff)
+	cfg.Webhook.Timeout = time.Second * time.Duration(timeout)
+}
+
+func (cfg *Config) stream() {
+	// STREAM_SOURCE selects what feeds the live weight stream, local writes or the mongodb changestream.
+	source := strings.ToLower(os.Getenv("STREAM_SOURCE"))
+	if source == "" {
+		source = StreamLocal
+	}
+
+	cfg.Stream.Source = source
+}
+
+func (cfg *Config) kafka() {
+	// KAFKA_BROKERS is a comma separated list of host:port.
+	var brokers []string
+	for _, broker := range strings.Split(os.Getenv("KAFKA_BROKERS"), ",") {
+		if broker = strings.TrimSpace(broker); broker != "" {
+			brokers = append(brokers, broker)
+		}
+	}
+	topic := os.Getenv("KAFKA_TOPIC")
+	if topic == "" {
+		topic = "weight-events"
+	}
+	timeout, _ := strconv.ParseInt(os.Getenv("KAFKA_TIMEOUT_SECONDS"), 10, 64)
+	if timeout < 1 {
+		timeout = 10
+	}
+
+	cfg.Kafka.Brokers = brokers
+	cfg.Kafka.Topic = topic
+	cfg.Kafka.Username = os.Getenv("KAFKA_USERNAME")
+	cfg.Kafka.Password = os.Getenv("KAFKA_PASSWORD")
+	cfg.Kafka.Timeout = time.Second * time.Duration(timeout)
+}
+
+func (cfg *Config) auth() {
+	secret := os.Getenv("AUTH_SECRET")
+	sessionTTL, _ := strconv.ParseInt(os.Getenv("AUTH_SESSION_TTL_MINUTES"), 10, 64)
+	if sessionTTL < 1 {
+		sessionTTL = 60 * 24
+	}
+
+	// AUTH_USERS is a comma separated list of username:bcrypt-hash.
+	users := make(map[string]string)
+	for _, user := range strings.Split(os.Getenv("AUTH_USERS"), ",") {
+		credential := strings.SplitN(strings.TrimSpace(user), ":", 2)
+		if len(credential) != 2 {
+			continue
+		}
+		users[credential[0]] = credential[1]
+	}
+
+	cfg.Auth.Secret = secret
+	cfg.Auth.Users = users
+	cfg.Auth.SessionTTL = time.Minute * time.Duration(sessionTTL)
+	cfg.Auth.SecureCookie, _ = strconv.ParseBool(os.Getenv("AUTH_SECURE_COOKIE"))
+}
+
+func (cfg *Config) tracing() {
+	exporter := os.Getenv("TRACING_EXPORTER")
+	if exporter == "" {
+		exporter = "none"
+	}
+	filePath := os.Getenv("TRACING_FILE_PATH")
+	if filePath == "" {
+		filePath = "traces.json"
+	}
+	insecure, _ := strconv.ParseBool(os.Getenv("TRACING_OTLP_INSECURE"))
+
+	cfg.Tracing.Exporter = exporter
+	cfg.Tracing.Endpoint = os.Getenv("TRACING_OTLP_ENDPOINT")
+	cfg.Tracing.Insecure = insecure
+	cfg.Tracing.FilePath = filePath
 }
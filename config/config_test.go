@@ -3,6 +3,7 @@ package config_test
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/ijalalfrz/sirclo-weight-test/config"
 	"github.com/sirupsen/logrus"
@@ -11,6 +12,9 @@ import (
 
 func TestConfig(t *testing.T) {
 	os.Setenv("KAFKA_USERNAME", "test_username")
+	os.Setenv("KAFKA_BROKERS", "localhost:9092, localhost:9093")
+	os.Setenv("AUTH_USERS", "john:hash-of-john, jane:hash-of-jane")
+	os.Setenv("AUTH_SECURE_COOKIE", "true")
 	cfg := config.Load()
 
 	assert.NotNil(t, cfg)
@@ -23,4 +27,81 @@ func TestConfig(t *testing.T) {
 		logger.Info("called")
 	})
 
+	t.Run("when config is being used for auth", func(t *testing.T) {
+		assert.Equal(t, "hash-of-john", cfg.Auth.Users["john"])
+		assert.Equal(t, "hash-of-jane", cfg.Auth.Users["jane"])
+		assert.NotZero(t, cfg.Auth.SessionTTL)
+		assert.True(t, cfg.Auth.SecureCookie)
+	})
+
+	t.Run("when config is being used for tracing", func(t *testing.T) {
+		assert.Equal(t, "none", cfg.Tracing.Exporter)
+		assert.NotNil(t, cfg.Mongodb.ClientOptions.Monitor)
+	})
+
+	t.Run("when config is being used for mongodb", func(t *testing.T) {
+		assert.True(t, cfg.Mongodb.MigrateOnStart)
+	})
+
+	t.Run("when config is being used for storage", func(t *testing.T) {
+		assert.Equal(t, config.StorageMongodb, cfg.Storage.Driver)
+		assert.Equal(t, "weights.json", cfg.Storage.FilePath)
+		assert.Equal(t, "weights.history.jsonl", cfg.Storage.HistoryFilePath)
+		assert.True(t, cfg.Storage.MigrateOnStart)
+	})
+
+	t.Run("when config is being used for cache", func(t *testing.T) {
+		assert.Equal(t, config.CacheNone, cfg.Cache.Driver)
+		assert.Equal(t, time.Minute, cfg.Cache.TTL)
+		assert.Equal(t, 1024, cfg.Cache.Size)
+	})
+
+	t.Run("when config is being used for outbox", func(t *testing.T) {
+		assert.Equal(t, config.OutboxNone, cfg.Outbox.Publisher)
+		assert.Equal(t, time.Second, cfg.Outbox.Interval)
+		assert.Equal(t, int64(100), cfg.Outbox.BatchSize)
+		assert.Equal(t, []string{"localhost:9092", "localhost:9093"}, cfg.Kafka.Brokers)
+		assert.Equal(t, "weight-events", cfg.Kafka.Topic)
+		assert.Equal(t, "test_username", cfg.Kafka.Username)
+	})
+
+	t.Run("when config is being used for webhook", func(t *testing.T) {
+		assert.Equal(t, 8, cfg.Webhook.MaxAttempts)
+		assert.Equal(t, time.Hour, cfg.Webhook.MaxBackoff)
+		assert.Equal(t, time.Second*10, cfg.Webhook.Timeout)
+	})
+
+	t.Run("when config is being used for stream", func(t *testing.T) {
+		assert.Equal(t, config.StreamLocal, cfg.Stream.Source)
+	})
+
+	t.Run("when config is being used for application", func(t *testing.T) {
+		assert.Equal(t, time.Second*30, cfg.Application.ShutdownTimeout)
+		assert.Equal(t, time.UTC, cfg.Application.Location)
+	})
+
+}
+
+func TestConfig_Validate(t *testing.T) {
+	cfg := config.Load()
+	assert.NoError(t, cfg.Validate(), "should accept the defaults")
+
+	for name, tc := range map[string]func(cfg *config.Config){
+		"unknown storage driver":   func(cfg *config.Config) { cfg.Storage.Driver = "oracle" },
+		"unknown cache driver":     func(cfg *config.Config) { cfg.Cache.Driver = "memcached" },
+		"unknown outbox publisher": func(cfg *config.Config) { cfg.Outbox.Publisher = "rabbitmq" },
+		"unknown stream source":    func(cfg *config.Config) { cfg.Stream.Source = "polling" },
+		"outbox without transactions": func(cfg *config.Config) {
+			cfg.Storage.Driver = config.StorageFile
+			cfg.Outbox.Publisher = config.OutboxKafka
+		},
+		"changestream without mongodb": func(cfg *config.Config) {
+			cfg.Storage.Driver = config.StorageSQLite
+			cfg.Stream.Source = config.StreamChangeStream
+		},
+	} {
+		invalid := config.Load()
+		tc(invalid)
+		assert.Error(t, invalid.Validate(), name)
+	}
 }
@@ -0,0 +1,15 @@
+package entity
+
+// Event is an entity to represent a domain event waiting in outbox collection to be published.
+// The events of a key are published in the order they were created. Payload is the JSON of the subject of the event.
+// Attempts, NextAttemptAt and LastError record the failed publications, an event is removed once it is published.
+type Event struct {
+	ID            string `bson:"_id"`
+	Type          string `bson:"type"`
+	Key           string `bson:"key"`
+	Payload       []byte `bson:"payload"`
+	CreatedAt     int64  `bson:"createdAt"`
+	Attempts      int    `bson:"attempts"`
+	NextAttemptAt int64  `bson:"nextAttemptAt"`
+	LastError     string `bson:"lastError,omitempty"`
+}
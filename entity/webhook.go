@@ -0,0 +1,31 @@
+package entity
+
+// WebhookSubscription is an entity to represent a subscription of webhook collection.
+// Events are the event types delivered to URL, an empty list subscribes to every event.
+// Secret is the key of the HMAC signature of every delivery.
+type WebhookSubscription struct {
+	ID        string   `bson:"_id"`
+	Owner     string   `bson:"owner,omitempty"`
+	URL       string   `bson:"url"`
+	Secret    string   `bson:"secret"`
+	Events    []string `bson:"events,omitempty"`
+	CreatedAt int64    `bson:"createdAt"`
+}
+
+// WebhookDelivery is an entity to represent a single event sent to a subscription in webhook_delivery collection.
+// Payload is the signed JSON body. A delivery is pending until the subscriber answers with a 2xx status,
+// it is dead once it has failed the maximum number of attempts. ResponseCode and LastError record the last attempt.
+type WebhookDelivery struct {
+	ID             string `bson:"_id"`
+	SubscriptionID string `bson:"subscriptionId"`
+	Owner          string `bson:"owner,omitempty"`
+	Event          string `bson:"event"`
+	Payload        []byte `bson:"payload"`
+	Status         string `bson:"status"`
+	Attempts       int    `bson:"attempts"`
+	NextAttemptAt  int64  `bson:"nextAttemptAt"`
+	ResponseCode   int    `bson:"responseCode,omitempty"`
+	LastError      string `bson:"lastError,omitempty"`
+	CreatedAt      int64  `bson:"createdAt"`
+	DeliveredAt    int64  `bson:"deliveredAt,omitempty"`
+}
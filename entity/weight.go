@@ -1,9 +1,61 @@
 package entity
 
-// Weight is an entity to represent weight collection
+// Weight is an entity to represent weight collection.
+// Date is the unix nano of the UTC midnight of the calendar date, Day is the same date as 2006-01-02.
+// Version starts at one and is incremented by every write. On an update it carries the expected version,
+// zero means the update is unconditional.
 type Weight struct {
-	Date int64 `json:"date"`
-	Max  int   `json:"max"`
-	Min  int   `json:"min"`
-	Diff int   `json:"diff"`
+	Owner   string `json:"owner,omitempty" bson:"owner,omitempty"`
+	Date    int64  `json:"date"`
+	Day     string `json:"day" bson:"day"`
+	Max     int    `json:"max"`
+	Min     int    `json:"min"`
+	Diff    int    `json:"diff"`
+	Version int64  `json:"version" bson:"version,omitempty"`
+}
+
+// WeightStatistic is an entity to represent aggregated statistics of weight collection
+type WeightStatistic struct {
+	Period WeightStatisticPeriod `bson:"_id"`
+	Count  int64                 `bson:"count"`
+	Max    WeightStatisticField  `bson:"max"`
+	Min    WeightStatisticField  `bson:"min"`
+	Diff   WeightStatisticField  `bson:"diff"`
+}
+
+// WeightAverage is an entity to represent the averages of the weights matching a filter
+type WeightAverage struct {
+	Max  float64 `bson:"max"`
+	Min  float64 `bson:"min"`
+	Diff float64 `bson:"diff"`
+}
+
+// WeightStatisticPeriod is an entity to represent the group key of aggregated statistics
+type WeightStatisticPeriod struct {
+	Year  int `bson:"year"`
+	Month int `bson:"month"`
+	Week  int `bson:"week"`
+}
+
+// WeightStatisticField is an entity to represent aggregated statistics of a single weight field
+type WeightStatisticField struct {
+	Min    int     `bson:"min"`
+	Max    int     `bson:"max"`
+	Mean   float64 `bson:"mean"`
+	StdDev float64 `bson:"stdDev"`
+	Values []int   `bson:"values"`
+}
+
+// WeightRevision is an entity to represent a single change of a weight in weight_history collection.
+// Before is nil for an insert and After is nil for a delete.
+type WeightRevision struct {
+	ID        string  `bson:"_id"`
+	Owner     string  `bson:"owner,omitempty"`
+	Date      int64   `bson:"date"`
+	Action    string  `bson:"action"`
+	Before    *Weight `bson:"before,omitempty"`
+	After     *Weight `bson:"after,omitempty"`
+	Actor     string  `bson:"actor,omitempty"`
+	RequestID string  `bson:"requestId,omitempty"`
+	CreatedAt int64   `bson:"createdAt"`
 }
@@ -15,4 +15,5 @@ var (
 	ErrGatewayTimeout      error = fmt.Errorf("Gateway timeout")
 	ErrTimeout             error = fmt.Errorf("Request time out")
 	ErrLocked              error = fmt.Errorf("Locked")
+	ErrServiceUnavailable  error = fmt.Errorf("Service unavailable")
 )
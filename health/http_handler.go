@@ -0,0 +1,121 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/ijalalfrz/sirclo-weight-test/exception"
+	"github.com/ijalalfrz/sirclo-weight-test/lifecycle"
+	"github.com/ijalalfrz/sirclo-weight-test/model"
+	"github.com/ijalalfrz/sirclo-weight-test/requestctx"
+	"github.com/ijalalfrz/sirclo-weight-test/response"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	livenessPath  = "/healthz"
+	readinessPath = "/readyz"
+	checkTimeout  = time.Second * 2
+)
+
+// collection of dependency status
+const (
+	statusUp   = "UP"
+	statusDown = "DOWN"
+)
+
+// collection of health message
+const (
+	aliveMessage    = "Application is alive"
+	readyMessage    = "Application is ready"
+	notReadyMessage = "Application is not ready"
+)
+
+// Paths are the paths of health endpoints, they should be public for the orchestrator.
+var Paths = []string{livenessPath, readinessPath}
+
+// Check is a named health check of a dependency.
+type Check struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// HTTPHandler is a concrete struct of health http handler.
+type HTTPHandler struct {
+	Logger *logrus.Logger
+	State  func() lifecycle.State
+	Checks []Check
+}
+
+// NewHealthHTTPHandler registers liveness and readiness endpoints to the router.
+func NewHealthHTTPHandler(logger *logrus.Logger, router *mux.Router, state func() lifecycle.State, checks ...Check) {
+	handler := &HTTPHandler{
+		Logger: logger,
+		State:  state,
+		Checks: checks,
+	}
+	router.HandleFunc(livenessPath, handler.Liveness).Methods(http.MethodGet)
+	router.HandleFunc(readinessPath, handler.Readiness).Methods(http.MethodGet)
+}
+
+// Liveness reports that the process is alive. It does not touch any dependency.
+func (handler HTTPHandler) Liveness(w http.ResponseWriter, r *http.Request) {
+	resp := response.NewSuccessResponse(nil, response.StatOK, aliveMessage)
+	response.JSON(w, resp)
+}
+
+// Readiness runs every check concurrently and reports the status and latency of each dependency.
+// It responds 503 when the application is not in ready state or any dependency is down.
+func (handler HTTPHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), checkTimeout)
+	defer cancel()
+
+	data := model.HealthResponse{
+		State:        string(handler.State()),
+		Dependencies: make([]model.DependencyStatus, len(handler.Checks)),
+	}
+
+	var wg sync.WaitGroup
+	for i, check := range handler.Checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			data.Dependencies[i] = handler.run(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	ready := data.State == string(lifecycle.StateReady)
+	for _, dependency := range data.Dependencies {
+		if dependency.Status != statusUp {
+			ready = false
+		}
+	}
+
+	if !ready {
+		resp := response.NewErrorResponse(exception.ErrServiceUnavailable, http.StatusServiceUnavailable, data, response.StatUnavailable, notReadyMessage)
+		response.JSON(w, resp)
+		return
+	}
+
+	resp := response.NewSuccessResponse(data, response.StatOK, readyMessage)
+	response.JSON(w, resp)
+}
+
+func (handler HTTPHandler) run(ctx context.Context, check Check) (status model.DependencyStatus) {
+	start := time.Now()
+	err := check.Check(ctx)
+
+	status.Name = check.Name
+	status.Status = statusUp
+	status.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+	if err != nil {
+		requestctx.Logger(ctx, handler.Logger).Error(err)
+		status.Status = statusDown
+		status.Error = err.Error()
+	}
+	return
+}
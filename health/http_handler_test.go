@@ -0,0 +1,118 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/ijalalfrz/sirclo-weight-test/health"
+	"github.com/ijalalfrz/sirclo-weight-test/lifecycle"
+	"github.com/ijalalfrz/sirclo-weight-test/model"
+	"github.com/ijalalfrz/sirclo-weight-test/mongodb/mocks"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type readinessBody struct {
+	Success bool                 `json:"success"`
+	Status  string               `json:"status"`
+	Data    model.HealthResponse `json:"data"`
+}
+
+func readyState() lifecycle.State {
+	return lifecycle.StateReady
+}
+
+func TestNewHealthHTTPHandler(t *testing.T) {
+	router := mux.NewRouter()
+	health.NewHealthHTTPHandler(logrus.New(), router, readyState)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestHttpHandler_Liveness_Success(t *testing.T) {
+	hh := health.HTTPHandler{
+		Logger: logrus.New(),
+		State:  func() lifecycle.State { return lifecycle.StateDraining },
+	}
+
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.Liveness)
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/just/for/testing", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestHttpHandler_Readiness_Success(t *testing.T) {
+	client := new(mocks.Client)
+	client.On("Ping", mock.Anything, mock.Anything).Return(nil)
+
+	hh := health.HTTPHandler{
+		Logger: logrus.New(),
+		State:  readyState,
+		Checks: []health.Check{
+			{Name: "mongodb", Check: func(ctx context.Context) error { return client.Ping(ctx, nil) }},
+		},
+	}
+
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.Readiness)
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/just/for/testing", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	body := readinessBody{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+	assert.True(t, body.Success)
+	assert.Equal(t, "READY", body.Data.State)
+	assert.Equal(t, "mongodb", body.Data.Dependencies[0].Name)
+	assert.Equal(t, "UP", body.Data.Dependencies[0].Status)
+	client.AssertExpectations(t)
+}
+
+func TestHttpHandler_Readiness_Error_DependencyDown(t *testing.T) {
+	client := new(mocks.Client)
+	client.On("Ping", mock.Anything, mock.Anything).Return(errors.New("server selection timeout"))
+
+	hh := health.HTTPHandler{
+		Logger: logrus.New(),
+		State:  readyState,
+		Checks: []health.Check{
+			{Name: "mongodb", Check: func(ctx context.Context) error { return client.Ping(ctx, nil) }},
+		},
+	}
+
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.Readiness)
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/just/for/testing", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+
+	body := readinessBody{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+	assert.False(t, body.Success)
+	assert.Equal(t, "SERVICE_UNAVAILABLE", body.Status)
+	assert.Equal(t, "DOWN", body.Data.Dependencies[0].Status)
+	assert.Equal(t, "server selection timeout", body.Data.Dependencies[0].Error)
+	client.AssertExpectations(t)
+}
+
+func TestHttpHandler_Readiness_Error_Draining(t *testing.T) {
+	hh := health.HTTPHandler{
+		Logger: logrus.New(),
+		State:  func() lifecycle.State { return lifecycle.StateDraining },
+	}
+
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.Readiness)
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/just/for/testing", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+}
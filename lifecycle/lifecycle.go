@@ -0,0 +1,148 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// State is a state of the application lifecycle.
+type State string
+
+// Collection of lifecycle state.
+const (
+	StateStarting State = "STARTING"
+	StateReady    State = "READY"
+	StateDraining State = "DRAINING"
+	StateStopped  State = "STOPPED"
+)
+
+// reserve is the time kept for each of the components after the one being closed.
+const reserve = time.Second
+
+const (
+	signalMessage  string = "Received signal %s, shutting down."
+	failureMessage string = "Component failed, shutting down: %v"
+	closingMessage string = "Closing %s within %s."
+)
+
+// CloseFunc releases a component. It must return once ctx is done.
+type CloseFunc func(ctx context.Context) error
+
+type component struct {
+	name  string
+	close CloseFunc
+}
+
+// Manager is a concrete struct of application lifecycle.
+// It keeps the state of the application and closes the registered components in order on shutdown.
+type Manager struct {
+	logger     *logrus.Logger
+	timeout    time.Duration
+	mu         sync.RWMutex
+	state      State
+	components []component
+}
+
+// NewManager is a constructor. The timeout is the budget of the whole shutdown.
+func NewManager(logger *logrus.Logger, timeout time.Duration) *Manager {
+	return &Manager{
+		logger:  logger,
+		timeout: timeout,
+		state:   StateStarting,
+	}
+}
+
+// Register registers a component to be closed on shutdown.
+// Components are closed in the same order as they are registered,
+// so the http server should be registered before the database it depends on.
+func (m *Manager) Register(name string, fn CloseFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.components = append(m.components, component{name: name, close: fn})
+}
+
+// State returns current state of the application.
+func (m *Manager) State() State {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.state
+}
+
+// Ready marks the application as ready to serve.
+func (m *Manager) Ready() {
+	m.setState(StateReady)
+}
+
+// Wait blocks until a signal is received or a component reports a failure on errs, then shuts down.
+// The failure of the component is returned so the caller can exit with non zero code.
+func (m *Manager) Wait(signals <-chan os.Signal, errs <-chan error) (err error) {
+	select {
+	case sig := <-signals:
+		m.logger.Info(fmt.Sprintf(signalMessage, sig))
+	case cause, ok := <-errs:
+		if ok && cause != nil {
+			m.logger.Error(fmt.Sprintf(failureMessage, cause))
+			err = cause
+		}
+	}
+
+	if shutdownErr := m.Shutdown(); err == nil {
+		err = shutdownErr
+	}
+	return
+}
+
+// Shutdown flips the state to draining and closes every registered component within the shutdown timeout.
+// Each component has its own deadline, the budget that is left less a reserve for every component after it,
+// so the http server can drain its requests for most of the timeout while a component that hangs until its
+// deadline does not leave the components after it without time. A component never gets less than an equal
+// share of the budget that is left, and the time it does not use goes to the next one. All components are
+// closed even if one of them fails, the first error is returned.
+func (m *Manager) Shutdown() (err error) {
+	m.setState(StateDraining)
+
+	deadline := time.Now().Add(m.timeout)
+
+	m.mu.RLock()
+	components := m.components
+	m.mu.RUnlock()
+
+	for i, c := range components {
+		left := time.Until(deadline)
+		timeout := left - reserve*time.Duration(len(components)-i-1)
+		if share := left / time.Duration(len(components)-i); timeout < share {
+			timeout = share
+		}
+		if closeErr := m.close(c, timeout); closeErr != nil {
+			m.logger.Error(closeErr)
+			if err == nil {
+				err = fmt.Errorf("close %s: %w", c.name, closeErr)
+			}
+		}
+	}
+
+	m.setState(StateStopped)
+	return
+}
+
+func (m *Manager) close(c component, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	m.logger.Info(fmt.Sprintf(closingMessage, c.name, timeout.Round(time.Millisecond)))
+	return c.close(ctx)
+}
+
+func (m *Manager) setState(state State) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.state = state
+}
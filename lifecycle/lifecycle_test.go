@@ -0,0 +1,132 @@
+package lifecycle_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/ijalalfrz/sirclo-weight-test/lifecycle"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager_State(t *testing.T) {
+	manager := lifecycle.NewManager(logrus.New(), 0)
+	assert.Equal(t, lifecycle.StateStarting, manager.State())
+
+	manager.Ready()
+	assert.Equal(t, lifecycle.StateReady, manager.State())
+}
+
+func TestManager_Shutdown_Success(t *testing.T) {
+	manager := lifecycle.NewManager(logrus.New(), time.Second)
+	manager.Ready()
+
+	var closed []string
+	manager.Register("http server", func(ctx context.Context) error {
+		assert.Equal(t, lifecycle.StateDraining, manager.State(), "should be draining while closing")
+		closed = append(closed, "http server")
+		return nil
+	})
+	manager.Register("mongodb", func(ctx context.Context) error {
+		closed = append(closed, "mongodb")
+		return nil
+	})
+
+	err := manager.Shutdown()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"http server", "mongodb"}, closed, "should close in registration order")
+	assert.Equal(t, lifecycle.StateStopped, manager.State())
+}
+
+func TestManager_Shutdown_Error(t *testing.T) {
+	manager := lifecycle.NewManager(logrus.New(), time.Second)
+
+	mongoClosed := false
+	manager.Register("http server", func(ctx context.Context) error {
+		return context.DeadlineExceeded
+	})
+	manager.Register("mongodb", func(ctx context.Context) error {
+		mongoClosed = true
+		return nil
+	})
+
+	err := manager.Shutdown()
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.True(t, mongoClosed, "should close the rest of components")
+}
+
+func TestManager_Shutdown_Success_SharedDeadline(t *testing.T) {
+	manager := lifecycle.NewManager(logrus.New(), time.Second)
+
+	var budgets []time.Duration
+	record := func(ctx context.Context) error {
+		deadline, ok := ctx.Deadline()
+		assert.True(t, ok, "should have a deadline")
+		budgets = append(budgets, time.Until(deadline))
+		return nil
+	}
+	manager.Register("http server", func(ctx context.Context) error {
+		record(ctx)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	manager.Register("outbox relay", record)
+	manager.Register("mongodb", record)
+
+	err := manager.Shutdown()
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	if assert.Len(t, budgets, 3) {
+		assert.InDelta(t, time.Second/3, budgets[0], float64(50*time.Millisecond), "should only give a share of the budget to a component")
+		assert.InDelta(t, time.Second/3, budgets[1], float64(50*time.Millisecond), "should leave time to the components after a hanging one")
+		assert.InDelta(t, 2*time.Second/3, budgets[2], float64(50*time.Millisecond), "should pass the unused time on")
+	}
+}
+
+func TestManager_Shutdown_Success_RemainingDeadline(t *testing.T) {
+	manager := lifecycle.NewManager(logrus.New(), 5*time.Second)
+
+	var budgets []time.Duration
+	record := func(ctx context.Context) error {
+		deadline, _ := ctx.Deadline()
+		budgets = append(budgets, time.Until(deadline))
+		return nil
+	}
+	manager.Register("http server", record)
+	manager.Register("outbox relay", record)
+	manager.Register("mongodb", record)
+
+	err := manager.Shutdown()
+	assert.NoError(t, err)
+	if assert.Len(t, budgets, 3) {
+		assert.InDelta(t, 3*time.Second, budgets[0], float64(50*time.Millisecond), "should give the http server all but the reserve of the others")
+		assert.InDelta(t, 4*time.Second, budgets[1], float64(50*time.Millisecond), "should pass the unused time on")
+		assert.InDelta(t, 5*time.Second, budgets[2], float64(50*time.Millisecond), "should give the last component the rest")
+	}
+}
+
+func TestManager_Wait_Signal(t *testing.T) {
+	manager := lifecycle.NewManager(logrus.New(), time.Second)
+
+	signals := make(chan os.Signal, 1)
+	signals <- syscall.SIGTERM
+
+	err := manager.Wait(signals, make(chan error))
+	assert.NoError(t, err)
+	assert.Equal(t, lifecycle.StateStopped, manager.State())
+}
+
+func TestManager_Wait_Error_Component(t *testing.T) {
+	manager := lifecycle.NewManager(logrus.New(), time.Second)
+	failure := errors.New("listener failed")
+
+	errs := make(chan error, 1)
+	errs <- failure
+
+	err := manager.Wait(make(chan os.Signal), errs)
+	assert.Equal(t, failure, err)
+	assert.Equal(t, lifecycle.StateStopped, manager.State())
+}
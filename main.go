@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
@@ -11,16 +10,25 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/go-redis/redis/v8"
+	"github.com/ijalalfrz/sirclo-weight-test/cache"
+	"github.com/ijalalfrz/sirclo-weight-test/health"
+	"github.com/ijalalfrz/sirclo-weight-test/lifecycle"
+	"github.com/ijalalfrz/sirclo-weight-test/metrics"
+	"github.com/ijalalfrz/sirclo-weight-test/migration"
 	"github.com/ijalalfrz/sirclo-weight-test/mongodb"
+	"github.com/ijalalfrz/sirclo-weight-test/outbox"
+	"github.com/ijalalfrz/sirclo-weight-test/sqldb"
+	"github.com/ijalalfrz/sirclo-weight-test/webhook"
 	"github.com/ijalalfrz/sirclo-weight-test/weight"
 
 	"github.com/ijalalfrz/sirclo-weight-test/middleware"
 
-	"github.com/ijalalfrz/sirclo-weight-test/response"
+	"github.com/ijalalfrz/sirclo-weight-test/router"
 	"github.com/ijalalfrz/sirclo-weight-test/server"
+	"github.com/ijalalfrz/sirclo-weight-test/tracing"
 
 	gctx "github.com/gorilla/context"
-	"github.com/gorilla/mux"
 
 	"github.com/sirupsen/logrus"
 
@@ -28,9 +36,9 @@ import (
 )
 
 var (
-	cfg          *config.Config
-	location     *time.Location
-	indexMessage string = "Application is running properly"
+	cfg      *config.Config
+	location *time.Location
+	manager  *lifecycle.Manager
 )
 
 func init() {
@@ -43,53 +51,335 @@ func main() {
 	logger.SetFormatter(cfg.Logger.Formatter)
 	logger.SetReportCaller(true)
 
-	// init validator
-	vld := validator.New()
+	// reject the unknown drivers before any connection is opened
+	if err := cfg.Validate(); err != nil {
+		logger.Fatal(err)
+	}
+
+	// timezone of the users who do not send theirs
+	location = cfg.Application.Location
+
+	// init lifecycle manager
+	manager = lifecycle.NewManager(logger, cfg.Application.ShutdownTimeout)
 
-	// set mongodb
-	mc, err := mongo.NewClient(cfg.Mongodb.ClientOptions)
+	// init metrics
+	mtr := metrics.NewMetrics()
+
+	// init tracing
+	tp, err := tracing.NewProvider(context.Background(), tracing.Property{
+		ServiceName: cfg.Application.Name,
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		Insecure:    cfg.Tracing.Insecure,
+		FilePath:    cfg.Tracing.FilePath,
+	})
 	if err != nil {
 		logger.Fatal(err)
 	}
-	mca := mongodb.NewClientAdapter(mc)
-	if err := mca.Connect(context.Background()); err != nil {
-		logger.Fatal(err)
+	tracer := tracing.Tracer()
+
+	// init validator
+	vld := validator.New()
+
+	// init storage, `app migrate` only migrates and exits.
+	migrateOnly := len(os.Args) > 1 && os.Args[1] == "migrate"
+	var (
+		weightRepository        weight.Repository
+		weightHistoryRepository weight.HistoryRepository
+		outboxRepository        outbox.Repository
+		transactor              outbox.Transactor
+		webhookRepository       webhook.Repository         = webhook.NewMemoryRepository()
+		deliveryRepository      webhook.DeliveryRepository = webhook.NewMemoryDeliveryRepository()
+		watchDatabase           mongodb.Database
+		checks                  []health.Check
+		closeStorage            lifecycle.CloseFunc = func(ctx context.Context) error { return nil }
+	)
+	switch cfg.Storage.Driver {
+	case config.StorageMemory:
+		weightRepository = weight.NewMemoryRepository(logger)
+		weightHistoryRepository = weight.NewMemoryHistoryRepository()
+	case config.StorageFile:
+		weightRepository, err = weight.NewFileRepository(logger, cfg.Storage.FilePath)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		weightHistoryRepository, err = weight.NewFileHistoryRepository(logger, cfg.Storage.HistoryFilePath)
+		if err != nil {
+			logger.Fatal(err)
+		}
+	case config.StoragePostgres, config.StorageSQLite:
+		driver := sqldb.DriverPostgres
+		if cfg.Storage.Driver == config.StorageSQLite {
+			driver = sqldb.DriverSQLite
+		}
+		db, err := sqldb.Open(driver, cfg.Storage.DSN)
+		if err != nil {
+			logger.Fatal(err)
+		}
+
+		// run schema migrations
+		if migrateOnly || cfg.Storage.MigrateOnStart {
+			migrator := migration.NewSQLMigrator(logger, db, weight.SQLMigrations()...)
+			if _, err := migrator.Up(context.Background()); err != nil {
+				db.Close()
+				logger.Fatal(err)
+			}
+		}
+
+		weightRepository = weight.NewSQLRepository(logger, db)
+		weightHistoryRepository = weight.NewSQLHistoryRepository(logger, db)
+		outboxRepository = outbox.NewSQLRepository(logger, db)
+		transactor = db
+		closeStorage = func(ctx context.Context) error {
+			return db.Close()
+		}
+		checks = append(checks, health.Check{
+			Name:  cfg.Storage.Driver,
+			Check: db.PingContext,
+		})
+	case config.StorageMongodb:
+		mc, err := mongo.NewClient(cfg.Mongodb.ClientOptions)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		mca := mongodb.NewClientAdapter(mc)
+		if err := mca.Connect(context.Background()); err != nil {
+			logger.Fatal(err)
+		}
+		mdb := mongodb.NewMetricsDatabase(mca.Database(cfg.Mongodb.Database), mtr.MongoDuration)
+
+		// run schema migrations
+		if migrateOnly || cfg.Mongodb.MigrateOnStart {
+			migrator := migration.NewMigrator(logger, mdb, weight.Migrations(logger)...)
+			if _, err := migrator.Up(context.Background()); err != nil {
+				mca.Disconnect(context.Background())
+				logger.Fatal(err)
+			}
+		}
+
+		weightRepository = weight.NewWeightRepository(logger, mdb)
+		weightHistoryRepository = weight.NewWeightHistoryRepository(logger, mdb)
+		outboxRepository = outbox.NewOutboxRepository(logger, mdb)
+		// a standalone server fails every transaction, its writes are not atomic with their revision.
+		supported, err := mongodb.SupportsTransactions(context.Background(), mdb)
+		if err != nil {
+			mca.Disconnect(context.Background())
+			logger.Fatal(err)
+		}
+		if supported {
+			transactor = mca
+		} else {
+			logger.Warn("mongodb does not support transactions, the revisions are not written atomically with the weights")
+		}
+		webhookRepository = webhook.NewWebhookRepository(logger, mdb)
+		deliveryRepository = webhook.NewDeliveryRepository(logger, mdb)
+		watchDatabase = mdb
+		closeStorage = mca.Disconnect
+		checks = append(checks, health.Check{
+			Name: "mongodb",
+			Check: func(ctx context.Context) error {
+				return mca.Ping(ctx, nil)
+			},
+		})
+	}
+	if migrateOnly {
+		closeStorage(context.Background())
+		return
+	}
+
+	// init cache of the weight reads
+	var (
+		weightCache cache.Cache
+		closeCache  lifecycle.CloseFunc = func(ctx context.Context) error { return nil }
+	)
+	switch cfg.Cache.Driver {
+	case config.CacheNone:
+	case config.CacheMemory:
+		weightCache = cache.NewLRU(cfg.Cache.Size)
+	case config.CacheRedis:
+		opts, err := redis.ParseURL(cfg.Cache.RedisURL)
+		if err != nil {
+			closeStorage(context.Background())
+			logger.Fatal(err)
+		}
+		rc := redis.NewClient(opts)
+		weightCache = cache.NewRedis(rc)
+		closeCache = func(ctx context.Context) error {
+			return rc.Close()
+		}
+		checks = append(checks, health.Check{
+			Name: "redis",
+			Check: func(ctx context.Context) error {
+				return rc.Ping(ctx).Err()
+			},
+		})
 	}
-	mdb := mca.Database(cfg.Mongodb.Database)
 
-	// init router object
-	router := mux.NewRouter()
-	router.HandleFunc("/", index)
+	// init publisher of the weight events
+	var (
+		publisher      outbox.Publisher
+		closePublisher lifecycle.CloseFunc = func(ctx context.Context) error { return nil }
+	)
+	switch cfg.Outbox.Publisher {
+	case config.OutboxNone:
+	case config.OutboxKafka:
+		// a standalone mongodb has no transactions, which is only known once connected.
+		if transactor == nil {
+			closeStorage(context.Background())
+			logger.Fatalf("outbox publisher %q requires a transactional storage, %q is not", cfg.Outbox.Publisher, cfg.Storage.Driver)
+		}
+		kafkaPublisher := outbox.NewKafkaPublisher(outbox.KafkaProperty{
+			Brokers:  cfg.Kafka.Brokers,
+			Topic:    cfg.Kafka.Topic,
+			Username: cfg.Kafka.Username,
+			Password: cfg.Kafka.Password,
+			Timeout:  cfg.Kafka.Timeout,
+		})
+		publisher = kafkaPublisher
+		closePublisher = kafkaPublisher.Close
+	}
 
 	// init domain object
-	weightRepository := weight.NewWeightRepository(logger, mdb)
-	weightUsecase := weight.NewWeightUsecase(weight.UsecaseProperty{
-		ServiceName: cfg.Application.Name,
+	if publisher != nil {
+		weightRepository = weight.NewOutboxRepository(weightRepository, outboxRepository, transactor, logger)
+	}
+	weightRepository = weight.NewAuditRepository(weightRepository, weightHistoryRepository, transactor, logger)
+	if weightCache != nil {
+		weightRepository = weight.NewCacheRepository(weightRepository, weightCache, cfg.Cache.TTL, mtr.CacheRequests, logger)
+	}
+	weightRepository = weight.NewTracingRepository(weightRepository, tracer)
+	dispatcher := webhook.NewDispatcher(webhook.DispatcherProperty{
 		Logger:      logger,
-		Repository:  weightRepository,
+		Repository:  webhookRepository,
+		Deliveries:  deliveryRepository,
+		Interval:    cfg.Webhook.Interval,
+		BatchSize:   cfg.Webhook.BatchSize,
+		MaxAttempts: cfg.Webhook.MaxAttempts,
+		MaxBackoff:  cfg.Webhook.MaxBackoff,
+		Timeout:     cfg.Webhook.Timeout,
+	})
+
+	// init broadcaster of the live weight stream, the changestream feeds it with the writes of every instance.
+	broadcaster := weight.NewBroadcaster(0)
+	var (
+		feed     *weight.ChangeStreamFeed
+		notifier weight.Notifier = weight.Notifiers{dispatcher, broadcaster}
+	)
+	switch cfg.Stream.Source {
+	case config.StreamLocal:
+	case config.StreamChangeStream:
+		feed = weight.NewChangeStreamFeed(weight.ChangeStreamProperty{
+			Logger:      logger,
+			Database:    watchDatabase,
+			Broadcaster: broadcaster,
+		})
+		notifier = dispatcher
+	}
+
+	weightUsecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName:  cfg.Application.Name,
+		Logger:       logger,
+		Repository:   weightRepository,
+		History:      weightHistoryRepository,
+		Notifier:     notifier,
+		RequireOwner: cfg.Auth.Secret != "",
+	})
+	weightUsecase = weight.NewTracingUsecase(weightUsecase, tracer)
+	weightUsecase = weight.NewMetricsUsecase(weightUsecase, mtr.UsecaseOutcomes)
+	webhookUsecase := webhook.NewWebhookUsecase(webhook.UsecaseProperty{
+		Logger:       logger,
+		Repository:   webhookRepository,
+		Deliveries:   deliveryRepository,
+		Events:       weight.EventTypes(),
+		RequireOwner: cfg.Auth.Secret != "",
 	})
 
 	// init http handler
-	weight.NewWeightHTTPHandler(logger, vld, router, weightUsecase)
+	var auth *middleware.AuthProperty
+	if cfg.Auth.Secret != "" {
+		auth = &middleware.AuthProperty{
+			Logger:       logger,
+			Secret:       cfg.Auth.Secret,
+			Users:        cfg.Auth.Users,
+			SessionTTL:   cfg.Auth.SessionTTL,
+			SecureCookie: cfg.Auth.SecureCookie,
+			TemplatePath: "./middleware/template/",
+		}
+	}
+	httpRouter, authenticator := router.New(router.Property{
+		Logger:         logger,
+		Validator:      vld,
+		Metrics:        mtr,
+		State:          manager.State,
+		Checks:         checks,
+		WeightUsecase:  weightUsecase,
+		WebhookUsecase: webhookUsecase,
+		Broadcaster:    broadcaster,
+		Auth:           auth,
+	})
 
 	// middleware]
-	httpHandler := gctx.ClearHandler(router)
+	httpHandler := gctx.ClearHandler(httpRouter)
+	if authenticator != nil {
+		httpHandler = middleware.Auth(authenticator, httpHandler)
+	} else {
+		logger.Warn("AUTH_SECRET is not set, authentication is disabled")
+	}
+	httpHandler = middleware.Timezone(location, httpHandler)
 	httpHandler = middleware.Recovery(logger, httpHandler)
+	httpHandler = mtr.HTTP(httpHandler)
+	httpHandler = tracing.HTTP(logger, httpHandler)
+	httpHandler = middleware.RequestLogger(logger, httpHandler)
 	httpHandler = middleware.CORS(httpHandler)
 
 	// initiate server
 	srv := server.NewServer(logger, httpHandler, cfg.Application.Port)
-	srv.Start()
+	if err := srv.Start(); err != nil {
+		closeStorage(context.Background())
+		logger.Fatal(err)
+	}
+
+	// the events written until the relay starts are published by it.
+	var relay *outbox.Relay
+	if publisher != nil {
+		relay = outbox.NewRelay(outbox.RelayProperty{
+			Logger:     logger,
+			Repository: outboxRepository,
+			Publisher:  publisher,
+			Interval:   cfg.Outbox.Interval,
+			BatchSize:  cfg.Outbox.BatchSize,
+			MaxBackoff: cfg.Outbox.MaxBackoff,
+		})
+		relay.Start()
+	}
+	dispatcher.Start()
+	if feed != nil {
+		feed.Start()
+	}
+
+	// the live streams are ended first so they do not hold the server open,
+	// then the server is closed so in-flight requests can still reach the storage,
+	// then the relay and the dispatcher so their last batch can still reach the storage, the broker and the subscribers.
+	manager.Register("weight stream", broadcaster.Close)
+	manager.Register("http server", srv.Close)
+	if relay != nil {
+		manager.Register("outbox relay", relay.Close)
+	}
+	manager.Register("webhook dispatcher", dispatcher.Close)
+	if feed != nil {
+		manager.Register("weight change stream", feed.Close)
+	}
+	manager.Register("outbox publisher", closePublisher)
+	manager.Register(cfg.Storage.Driver, closeStorage)
+	manager.Register("cache", closeCache)
+	manager.Register("tracer provider", tp.Close)
+	manager.Ready()
 
 	sigterm := make(chan os.Signal, 1)
-	signal.Notify(sigterm, syscall.SIGINT, syscall.SIGTERM, syscall.SIGKILL, os.Interrupt)
-	<-sigterm
+	signal.Notify(sigterm, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
 
 	// closing service for a gracefull shutdown.
-	srv.Close()
-}
-
-func index(w http.ResponseWriter, r *http.Request) {
-	resp := response.NewSuccessResponse(nil, response.StatOK, indexMessage)
-	response.JSON(w, resp)
+	if err := manager.Wait(sigterm, srv.Errors()); err != nil {
+		logger.Fatal(err)
+	}
 }
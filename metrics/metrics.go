@@ -0,0 +1,122 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/ijalalfrz/sirclo-weight-test/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	// Path is the path of the prometheus scrape endpoint.
+	Path = "/metrics"
+
+	namespace      = "weight"
+	unmatchedRoute = "unmatched"
+)
+
+// Metrics is a concrete struct of the prometheus collectors of the service.
+type Metrics struct {
+	Registry        *prometheus.Registry
+	HTTPRequests    *prometheus.CounterVec
+	HTTPDuration    *prometheus.HistogramVec
+	UsecaseOutcomes *prometheus.CounterVec
+	MongoDuration   *prometheus.HistogramVec
+	CacheRequests   *prometheus.CounterVec
+}
+
+// NewMetrics is a constructor. Every collector is registered to a dedicated registry.
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		HTTPRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of HTTP requests by method, route template and status code.",
+		}, []string{"method", "route", "code"}),
+		HTTPDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Latency of HTTP requests by method and route template.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		UsecaseOutcomes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "usecase_outcomes_total",
+			Help:      "Number of usecase calls by method and response status.",
+		}, []string{"method", "status"}),
+		MongoDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "mongodb_operation_duration_seconds",
+			Help:      "Latency of MongoDB operations by collection, operation and outcome.",
+			Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"collection", "operation", "outcome"}),
+		CacheRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_requests_total",
+			Help:      "Number of weight cache lookups by repository operation and result, hit, miss or error.",
+		}, []string{"operation", "result"}),
+	}
+
+	m.Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.HTTPRequests,
+		m.HTTPDuration,
+		m.UsecaseOutcomes,
+		m.MongoDuration,
+		m.CacheRequests,
+	)
+
+	return m
+}
+
+// Handler returns the scrape handler of the registry.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{})
+}
+
+// HTTP records the count and the latency of every request by route template, it must wrap the whole router
+// so the requests which match no route, answered with 404 or 405, are counted as unmatched.
+// The route template is only known inside the router, it is reported by Route.
+func (m *Metrics) HTTP(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rr := middleware.NewResponseRecorder(w)
+		matched := &matchedRoute{template: unmatchedRoute}
+
+		handler.ServeHTTP(rr, r.WithContext(context.WithValue(r.Context(), matchedRouteContextKey, matched)))
+
+		m.HTTPRequests.WithLabelValues(r.Method, matched.template, strconv.Itoa(rr.Status())).Inc()
+		m.HTTPDuration.WithLabelValues(r.Method, matched.template).Observe(time.Since(start).Seconds())
+	})
+}
+
+// Route reports the matched route template of the request to HTTP.
+// It must be attached with mux.Router.Use so the matched route is known.
+func (m *Metrics) Route(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		matched, ok := r.Context().Value(matchedRouteContextKey).(*matchedRoute)
+		if current := mux.CurrentRoute(r); ok && current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				matched.template = template
+			}
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+type contextKey string
+
+const matchedRouteContextKey contextKey = "matchedRoute"
+
+// matchedRoute carries the route template from Route back to HTTP.
+type matchedRoute struct {
+	template string
+}
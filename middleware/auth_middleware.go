@@ -0,0 +1,286 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/ijalalfrz/sirclo-weight-test/exception"
+	"github.com/ijalalfrz/sirclo-weight-test/requestctx"
+	"github.com/ijalalfrz/sirclo-weight-test/response"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	loginPath         = "/login"
+	logoutPath        = "/logout"
+	apiPathPrefix     = "/api/"
+	sessionCookieName = "session"
+	bearerPrefix      = "Bearer "
+)
+
+// collection of auth message
+const (
+	unauthorizedErrMessage      = "Unauthorized"
+	invalidCredentialErrMessage = "Invalid username or password"
+)
+
+var (
+	jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	// dummyHash is compared with the password of an unknown username, so the response time
+	// of a login does not tell whether the username exists. Its cost is bcrypt.DefaultCost.
+	dummyHash = []byte("$2a$10$FeF5.L77CqnQ6jfE.FQquO3fFS3rBZ6ph5n017WucA4UsbF9grRlW")
+)
+
+// AuthProperty is a property of authenticator.
+// SecureCookie marks the session cookie as secure even when the request is not over TLS,
+// e.g. when TLS is terminated by a proxy in front of the service.
+type AuthProperty struct {
+	Logger       *logrus.Logger
+	Secret       string
+	Users        map[string]string // username to bcrypt hash of the password.
+	SessionTTL   time.Duration
+	SecureCookie bool
+	TemplatePath string
+	PublicPaths  []string
+}
+
+// Authenticator is a concrete struct of authentication for template pages and api.
+type Authenticator struct {
+	logger       *logrus.Logger
+	secret       []byte
+	users        map[string]string
+	sessionTTL   time.Duration
+	secureCookie bool
+	templatePath string
+	publicPaths  map[string]bool
+}
+
+type tokenHeader struct {
+	Algorithm string `json:"alg"`
+}
+
+type claims struct {
+	Subject   string `json:"sub"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// NewAuthenticator is a constructor. It also registers login and logout pages to the router.
+func NewAuthenticator(property AuthProperty, router *mux.Router) *Authenticator {
+	publicPaths := map[string]bool{
+		loginPath:  true,
+		logoutPath: true,
+	}
+	for _, path := range property.PublicPaths {
+		publicPaths[path] = true
+	}
+
+	a := &Authenticator{
+		logger:       property.Logger,
+		secret:       []byte(property.Secret),
+		users:        property.Users,
+		sessionTTL:   property.SessionTTL,
+		secureCookie: property.SecureCookie,
+		templatePath: property.TemplatePath,
+		publicPaths:  publicPaths,
+	}
+
+	router.HandleFunc(loginPath, a.LoginForm).Methods(http.MethodGet)
+	router.HandleFunc(loginPath, a.Login).Methods(http.MethodPost)
+	router.HandleFunc(logoutPath, a.Logout).Methods(http.MethodPost)
+
+	return a
+}
+
+// Auth is an authentication middleware.
+// API requests must carry a HMAC signed bearer token, template pages must carry a signed session cookie.
+// The subject of the token or session becomes the owner of the request.
+func Auth(a *Authenticator, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.publicPaths[r.URL.Path] {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		owner, err := a.authenticate(r)
+		if err != nil {
+			if strings.HasPrefix(r.URL.Path, apiPathPrefix) || r.Header.Get("Authorization") != "" {
+				resp := response.NewErrorResponse(exception.ErrUnauthorized, http.StatusUnauthorized, nil, response.StatUnauthorized, unauthorizedErrMessage)
+				response.JSON(w, resp)
+				return
+			}
+
+			http.Redirect(w, r, loginPath+"?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusSeeOther)
+			return
+		}
+
+		ctx := requestctx.WithOwner(r.Context(), owner)
+		ctx = requestctx.WithLogger(ctx, requestctx.Logger(ctx, a.logger).WithField("owner", owner))
+		handler.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// SignToken returns a HMAC signed token of the subject which expires after ttl.
+// The token is used both as bearer token and as session cookie value.
+func (a *Authenticator) SignToken(subject string, ttl time.Duration) (token string, err error) {
+	payload, err := json.Marshal(claims{
+		Subject:   subject,
+		ExpiresAt: time.Now().Add(ttl).Unix(),
+	})
+	if err != nil {
+		return
+	}
+
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	token = unsigned + "." + a.sign(unsigned)
+	return
+}
+
+// VerifyToken verifies the signature and the expiry of the token and returns its subject.
+func (a *Authenticator) VerifyToken(token string) (subject string, err error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		err = exception.ErrUnauthorized
+		return
+	}
+
+	rawHeader, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		err = exception.ErrUnauthorized
+		return
+	}
+
+	header := tokenHeader{}
+	if err = json.Unmarshal(rawHeader, &header); err != nil || header.Algorithm != "HS256" {
+		err = exception.ErrUnauthorized
+		return
+	}
+
+	expected := a.sign(parts[0] + "." + parts[1])
+	if !hmac.Equal([]byte(expected), []byte(parts[2])) {
+		err = exception.ErrUnauthorized
+		return
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		err = exception.ErrUnauthorized
+		return
+	}
+
+	c := claims{}
+	if err = json.Unmarshal(payload, &c); err != nil {
+		err = exception.ErrUnauthorized
+		return
+	}
+
+	if c.Subject == "" || time.Now().Unix() >= c.ExpiresAt {
+		err = exception.ErrUnauthorized
+		return
+	}
+
+	subject = c.Subject
+	return
+}
+
+// LoginForm renders login page.
+func (a *Authenticator) LoginForm(w http.ResponseWriter, r *http.Request) {
+	data := map[string]interface{}{
+		"Error": r.Header.Get("error"),
+		"Next":  r.FormValue("next"),
+	}
+	tmpl := template.Must(template.ParseFiles(fmt.Sprintf("%s%s", a.templatePath, "login.html")))
+
+	tmpl.Execute(w, data)
+	return
+}
+
+// Login verifies the credential and sets the session cookie.
+func (a *Authenticator) Login(w http.ResponseWriter, r *http.Request) {
+	username := r.FormValue("username")
+	password := r.FormValue("password")
+
+	hash, ok := a.users[username]
+	if !ok {
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+	}
+	if !ok || bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+		r.Header.Set("error", invalidCredentialErrMessage)
+		a.LoginForm(w, r)
+		return
+	}
+
+	token, err := a.SignToken(username, a.sessionTTL)
+	if err != nil {
+		a.logger.Error(err)
+		r.Header.Set("error", unauthorizedErrMessage)
+		a.LoginForm(w, r)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    token,
+		Path:     "/",
+		Expires:  time.Now().Add(a.sessionTTL),
+		HttpOnly: true,
+		Secure:   a.isSecure(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	next := r.FormValue("next")
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		next = "/weight"
+	}
+	http.Redirect(w, r, next, http.StatusSeeOther)
+}
+
+// Logout clears the session cookie. It only accepts POST, so a link or an image of another site
+// can not sign the user out.
+func (a *Authenticator) Logout(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   a.isSecure(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, loginPath, http.StatusSeeOther)
+}
+
+func (a *Authenticator) authenticate(r *http.Request) (owner string, err error) {
+	authorization := r.Header.Get("Authorization")
+	if strings.HasPrefix(authorization, bearerPrefix) {
+		return a.VerifyToken(strings.TrimPrefix(authorization, bearerPrefix))
+	}
+
+	cookie, err := r.Cookie(sessionCookieName)
+	if err != nil {
+		err = exception.ErrUnauthorized
+		return
+	}
+
+	return a.VerifyToken(cookie.Value)
+}
+
+// isSecure reports whether the session cookie must only be sent over https.
+func (a *Authenticator) isSecure(r *http.Request) bool {
+	return a.secureCookie || r.TLS != nil
+}
+
+func (a *Authenticator) sign(unsigned string) string {
+	mac := hmac.New(sha256.New, a.secret)
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,207 @@
+package middleware_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/ijalalfrz/sirclo-weight-test/middleware"
+	"github.com/ijalalfrz/sirclo-weight-test/requestctx"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func newAuthenticator(t *testing.T) (*middleware.Authenticator, *mux.Router) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret-password"), bcrypt.MinCost)
+	assert.NoError(t, err)
+
+	router := mux.NewRouter()
+	authenticator := middleware.NewAuthenticator(middleware.AuthProperty{
+		Logger:       logrus.New(),
+		Secret:       "test-secret",
+		Users:        map[string]string{"john": string(hash)},
+		SessionTTL:   time.Hour,
+		TemplatePath: "./template/",
+		PublicPaths:  []string{"/"},
+	}, router)
+
+	router.HandleFunc("/weight", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(requestctx.Owner(r.Context())))
+	})
+	router.HandleFunc("/api/v1/weights", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(requestctx.Owner(r.Context())))
+	})
+
+	return authenticator, router
+}
+
+func TestAuth_Token(t *testing.T) {
+	authenticator, _ := newAuthenticator(t)
+
+	t.Run("when token is valid", func(t *testing.T) {
+		token, err := authenticator.SignToken("john", time.Minute)
+		assert.NoError(t, err)
+
+		subject, err := authenticator.VerifyToken(token)
+		assert.NoError(t, err)
+		assert.Equal(t, "john", subject)
+	})
+
+	t.Run("when token is expired", func(t *testing.T) {
+		token, err := authenticator.SignToken("john", -time.Minute)
+		assert.NoError(t, err)
+
+		_, err = authenticator.VerifyToken(token)
+		assert.Error(t, err)
+	})
+
+	t.Run("when token is tampered", func(t *testing.T) {
+		token, err := authenticator.SignToken("john", time.Minute)
+		assert.NoError(t, err)
+
+		other, err := authenticator.SignToken("jane", time.Minute)
+		assert.NoError(t, err)
+
+		parts := strings.Split(token, ".")
+		otherParts := strings.Split(other, ".")
+		_, err = authenticator.VerifyToken(parts[0] + "." + otherParts[1] + "." + parts[2])
+		assert.Error(t, err)
+	})
+
+	t.Run("when token is malformed", func(t *testing.T) {
+		_, err := authenticator.VerifyToken("not-a-token")
+		assert.Error(t, err)
+	})
+}
+
+func TestAuth_Middleware(t *testing.T) {
+	authenticator, router := newAuthenticator(t)
+	handler := middleware.Auth(authenticator, router)
+
+	t.Run("when api request has valid bearer token", func(t *testing.T) {
+		token, _ := authenticator.SignToken("john", time.Minute)
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/weights", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		recorder := httptest.NewRecorder()
+
+		handler.ServeHTTP(recorder, r)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "john", recorder.Body.String())
+	})
+
+	t.Run("when api request has no token", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/weights", nil)
+		recorder := httptest.NewRecorder()
+
+		handler.ServeHTTP(recorder, r)
+		assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	})
+
+	t.Run("when page request has no session", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/weight", nil)
+		recorder := httptest.NewRecorder()
+
+		handler.ServeHTTP(recorder, r)
+		assert.Equal(t, http.StatusSeeOther, recorder.Code)
+		assert.Equal(t, "/login?next=%2Fweight", recorder.Header().Get("Location"))
+	})
+
+	t.Run("when page request is public", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/login", nil)
+		recorder := httptest.NewRecorder()
+
+		handler.ServeHTTP(recorder, r)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	})
+
+	t.Run("when login succeeds the session is accepted", func(t *testing.T) {
+		var bodyStr = []byte(`username=john&password=secret-password&next=/weight`)
+		r := httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(bodyStr))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		recorder := httptest.NewRecorder()
+
+		handler.ServeHTTP(recorder, r)
+		assert.Equal(t, http.StatusSeeOther, recorder.Code)
+		assert.Equal(t, "/weight", recorder.Header().Get("Location"))
+
+		cookies := recorder.Result().Cookies()
+		assert.Len(t, cookies, 1)
+		assert.False(t, cookies[0].Secure, "should not be secure on a plain http request")
+
+		r = httptest.NewRequest(http.MethodGet, "/weight", nil)
+		r.AddCookie(cookies[0])
+		recorder = httptest.NewRecorder()
+
+		handler.ServeHTTP(recorder, r)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "john", recorder.Body.String())
+	})
+
+	t.Run("when login fails", func(t *testing.T) {
+		var bodyStr = []byte(`username=john&password=wrong`)
+		r := httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(bodyStr))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		recorder := httptest.NewRecorder()
+
+		handler.ServeHTTP(recorder, r)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Empty(t, recorder.Result().Cookies())
+	})
+
+	t.Run("when login of an unknown username fails", func(t *testing.T) {
+		var bodyStr = []byte(`username=jane&password=secret-password`)
+		r := httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(bodyStr))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		recorder := httptest.NewRecorder()
+
+		handler.ServeHTTP(recorder, r)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Empty(t, recorder.Result().Cookies())
+	})
+
+	t.Run("when login is over tls the session is secure", func(t *testing.T) {
+		var bodyStr = []byte(`username=john&password=secret-password`)
+		r := httptest.NewRequest(http.MethodPost, "https://example.com/login", bytes.NewReader(bodyStr))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		recorder := httptest.NewRecorder()
+
+		handler.ServeHTTP(recorder, r)
+		cookies := recorder.Result().Cookies()
+		if assert.Len(t, cookies, 1) {
+			assert.True(t, cookies[0].Secure)
+		}
+	})
+
+	t.Run("when login redirects to another host", func(t *testing.T) {
+		var bodyStr = []byte(`username=john&password=secret-password&next=//evil.example`)
+		r := httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(bodyStr))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		recorder := httptest.NewRecorder()
+
+		handler.ServeHTTP(recorder, r)
+		assert.Equal(t, "/weight", recorder.Header().Get("Location"))
+	})
+
+	t.Run("when logout clears the session", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/logout", nil)
+		recorder := httptest.NewRecorder()
+
+		handler.ServeHTTP(recorder, r)
+		assert.Equal(t, http.StatusSeeOther, recorder.Code)
+		assert.Equal(t, "", recorder.Result().Cookies()[0].Value)
+	})
+
+	t.Run("when logout is not a post", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/logout", nil)
+		recorder := httptest.NewRecorder()
+
+		handler.ServeHTTP(recorder, r)
+		assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+		assert.Empty(t, recorder.Result().Cookies())
+	})
+}
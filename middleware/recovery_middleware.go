@@ -18,10 +18,31 @@ func (rl *RecoveryLogger) Println(recovered ...interface{}) {
 }
 
 // Recovery is a recovery middleware.
+// http.ErrAbortHandler is panicked again, so the server aborts the response as the handler intended
+// instead of finishing it with a status that was already sent.
 func Recovery(logger *logrus.Logger, handler http.Handler) http.Handler {
 	rl := &RecoveryLogger{Logger: logger}
-	return handlers.RecoveryHandler(
+	recovery := handlers.RecoveryHandler(
 		handlers.PrintRecoveryStack(true),
 		handlers.RecoveryLogger(rl),
-	)(handler)
+	)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		aborted := false
+		recovery(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					if recovered != http.ErrAbortHandler {
+						panic(recovered)
+					}
+					aborted = true
+				}
+			}()
+			handler.ServeHTTP(w, r)
+		})).ServeHTTP(w, r)
+
+		if aborted {
+			panic(http.ErrAbortHandler)
+		}
+	})
 }
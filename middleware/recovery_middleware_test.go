@@ -0,0 +1,35 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ijalalfrz/sirclo-weight-test/middleware"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecovery(t *testing.T) {
+	t.Run("when handler panics", func(t *testing.T) {
+		handler := middleware.Recovery(logrus.New(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("something failed")
+		}))
+		recorder := httptest.NewRecorder()
+
+		assert.NotPanics(t, func() {
+			handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+		})
+		assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	})
+
+	t.Run("when handler aborts", func(t *testing.T) {
+		handler := middleware.Recovery(logrus.New(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		}))
+
+		assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		}, "should let the server abort the response")
+	})
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/ijalalfrz/sirclo-weight-test/requestctx"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	requestIDHeader    = "X-Request-ID"
+	maxRequestIDLength = 128
+	accessLogMessage   = "HTTP request"
+)
+
+// RequestLogger is a request logging middleware.
+// It accepts the X-Request-ID of the request or assigns a new one, echoes it in the response
+// and puts a logger carrying the request id into the context of the request.
+// Once the request is served an access log with method, path, status, bytes and latency is written.
+func RequestLogger(logger *logrus.Logger, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		requestID := r.Header.Get(requestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, requestID)
+
+		entry := logger.WithField("request_id", requestID)
+		ctx := requestctx.WithRequestID(r.Context(), requestID)
+		ctx = requestctx.WithLogger(ctx, entry)
+
+		rr := NewResponseRecorder(w)
+		handler.ServeHTTP(rr, r.WithContext(ctx))
+
+		entry.WithFields(logrus.Fields{
+			"method":     r.Method,
+			"path":       r.URL.Path,
+			"status":     rr.Status(),
+			"bytes":      rr.Bytes(),
+			"latency_ms": float64(time.Since(start).Microseconds()) / 1000,
+			"remote":     r.RemoteAddr,
+		}).Info(accessLogMessage)
+	})
+}
+
+// validRequestID accepts letters, digits, dots, underscores and dashes. The id is stored with the revisions
+// and shown on their page, so markup is never accepted from the client.
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+
+	for _, c := range requestID {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '.', c == '_', c == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
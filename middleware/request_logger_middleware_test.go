@@ -0,0 +1,76 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ijalalfrz/sirclo-weight-test/middleware"
+	"github.com/ijalalfrz/sirclo-weight-test/requestctx"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestLogger(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+
+	handler := middleware.RequestLogger(logger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestctx.Logger(r.Context(), logger).Error("something failed")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("not found"))
+	}))
+
+	t.Run("when request id is not given", func(t *testing.T) {
+		hook.Reset()
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/weight", nil))
+
+		requestID := recorder.Header().Get("X-Request-ID")
+		assert.Len(t, requestID, 32, "should assign a new request id")
+		assert.Len(t, hook.AllEntries(), 2)
+
+		errorEntry := hook.AllEntries()[0]
+		assert.Equal(t, logrus.ErrorLevel, errorEntry.Level)
+		assert.Equal(t, requestID, errorEntry.Data["request_id"], "should log with the request id")
+
+		accessEntry := hook.LastEntry()
+		assert.Equal(t, requestID, accessEntry.Data["request_id"])
+		assert.Equal(t, http.MethodGet, accessEntry.Data["method"])
+		assert.Equal(t, "/weight", accessEntry.Data["path"])
+		assert.Equal(t, http.StatusNotFound, accessEntry.Data["status"])
+		assert.Equal(t, 9, accessEntry.Data["bytes"])
+		assert.Contains(t, accessEntry.Data, "latency_ms")
+	})
+
+	t.Run("when request id is given", func(t *testing.T) {
+		hook.Reset()
+		r := httptest.NewRequest(http.MethodGet, "/weight", nil)
+		r.Header.Set("X-Request-ID", "abc-123")
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, r)
+
+		assert.Equal(t, "abc-123", recorder.Header().Get("X-Request-ID"))
+		assert.Equal(t, "abc-123", hook.LastEntry().Data["request_id"])
+	})
+
+	t.Run("when request id is invalid", func(t *testing.T) {
+		hook.Reset()
+		r := httptest.NewRequest(http.MethodGet, "/weight", nil)
+		r.Header.Set("X-Request-ID", "has space")
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, r)
+
+		assert.NotEqual(t, "has space", recorder.Header().Get("X-Request-ID"))
+	})
+
+	t.Run("when request id has markup", func(t *testing.T) {
+		hook.Reset()
+		r := httptest.NewRequest(http.MethodGet, "/weight", nil)
+		r.Header.Set("X-Request-ID", `"><script>alert(1)</script>`)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, r)
+
+		assert.Len(t, recorder.Header().Get("X-Request-ID"), 32, "should assign a new request id")
+	})
+}
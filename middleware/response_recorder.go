@@ -0,0 +1,54 @@
+package middleware
+
+import "net/http"
+
+// ResponseRecorder records the status code and the size of the response written through it,
+// it is shared by the middlewares which report the outcome of a request.
+type ResponseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+// NewResponseRecorder is a constructor.
+func NewResponseRecorder(w http.ResponseWriter) *ResponseRecorder {
+	return &ResponseRecorder{ResponseWriter: w}
+}
+
+// Status returns the status code of the response, 200 when the handler did not write one.
+func (rr *ResponseRecorder) Status() int {
+	if rr.status == 0 {
+		return http.StatusOK
+	}
+	return rr.status
+}
+
+// Bytes returns the number of bytes of the body written so far.
+func (rr *ResponseRecorder) Bytes() int {
+	return rr.bytes
+}
+
+// WriteHeader records the first status code and writes it.
+func (rr *ResponseRecorder) WriteHeader(status int) {
+	if rr.status == 0 {
+		rr.status = status
+	}
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+// Write writes b to the response, an implicit 200 is recorded when no status was written.
+func (rr *ResponseRecorder) Write(b []byte) (int, error) {
+	if rr.status == 0 {
+		rr.status = http.StatusOK
+	}
+	n, err := rr.ResponseWriter.Write(b)
+	rr.bytes += n
+	return n, err
+}
+
+// Flush lets streaming handlers flush through the recorder.
+func (rr *ResponseRecorder) Flush() {
+	if flusher, ok := rr.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
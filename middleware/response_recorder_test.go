@@ -0,0 +1,41 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ijalalfrz/sirclo-weight-test/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseRecorder(t *testing.T) {
+	t.Run("when handler writes nothing", func(t *testing.T) {
+		rr := middleware.NewResponseRecorder(httptest.NewRecorder())
+
+		assert.Equal(t, http.StatusOK, rr.Status())
+		assert.Zero(t, rr.Bytes())
+	})
+
+	t.Run("when handler writes the status twice", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		rr := middleware.NewResponseRecorder(recorder)
+
+		rr.WriteHeader(http.StatusNotFound)
+		rr.WriteHeader(http.StatusInternalServerError)
+		rr.Write([]byte("not found"))
+
+		assert.Equal(t, http.StatusNotFound, rr.Status(), "should record the status which was sent")
+		assert.Equal(t, 9, rr.Bytes())
+	})
+
+	t.Run("when handler flushes", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		rr := middleware.NewResponseRecorder(recorder)
+
+		var w http.ResponseWriter = rr
+		w.(http.Flusher).Flush()
+
+		assert.True(t, recorder.Flushed, "should flush the underlying writer")
+	})
+}
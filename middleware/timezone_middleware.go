@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ijalalfrz/sirclo-weight-test/requestctx"
+)
+
+const (
+	timezoneHeader = "X-Timezone"
+	timezoneCookie = "tz"
+)
+
+// maxCachedLocations bounds the names remembered by the timezone middleware, as they are sent by the client.
+const maxCachedLocations = 1024
+
+// Timezone resolves the timezone of the user from the X-Timezone header or the tz cookie,
+// which is set by the browser on template pages. The IANA name is expected, e.g. Asia/Jakarta.
+// The given location is used when neither is present or valid.
+func Timezone(location *time.Location, handler http.Handler) http.Handler {
+	locations := &locationCache{locations: make(map[string]*time.Location)}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.Header.Get(timezoneHeader)
+		if name == "" {
+			if cookie, err := r.Cookie(timezoneCookie); err == nil {
+				name = cookie.Value
+			}
+		}
+
+		userLocation := location
+		if name != "" {
+			if loaded := locations.load(name); loaded != nil {
+				userLocation = loaded
+			}
+		}
+
+		handler.ServeHTTP(w, r.WithContext(requestctx.WithLocation(r.Context(), userLocation)))
+	})
+}
+
+// locationCache remembers the location of every name it has loaded, nil for an invalid name, so the
+// timezone database is only read once per name. It is emptied once it holds maxCachedLocations names.
+type locationCache struct {
+	mu        sync.RWMutex
+	locations map[string]*time.Location
+}
+
+// load returns the location of name, or nil when there is no such timezone.
+func (c *locationCache) load(name string) *time.Location {
+	c.mu.RLock()
+	location, ok := c.locations[name]
+	c.mu.RUnlock()
+	if ok {
+		return location
+	}
+
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		location = nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.locations) >= maxCachedLocations {
+		c.locations = make(map[string]*time.Location)
+	}
+	c.locations[name] = location
+	return location
+}
@@ -0,0 +1,61 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ijalalfrz/sirclo-weight-test/middleware"
+	"github.com/ijalalfrz/sirclo-weight-test/requestctx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimezone(t *testing.T) {
+	jakarta, _ := time.LoadLocation("Asia/Jakarta")
+
+	var location *time.Location
+	handler := middleware.Timezone(jakarta, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		location = requestctx.Location(r.Context())
+	}))
+
+	t.Run("when timezone is not given", func(t *testing.T) {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/weight", nil))
+		assert.Equal(t, "Asia/Jakarta", location.String(), "should fall back to the application timezone")
+	})
+
+	t.Run("when timezone header is given", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/weight", nil)
+		r.Header.Set("X-Timezone", "America/New_York")
+		r.AddCookie(&http.Cookie{Name: "tz", Value: "Europe/Berlin"})
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+		assert.Equal(t, "America/New_York", location.String(), "should prefer the header")
+	})
+
+	t.Run("when timezone cookie is given", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/weight", nil)
+		r.AddCookie(&http.Cookie{Name: "tz", Value: "Europe/Berlin"})
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+		assert.Equal(t, "Europe/Berlin", location.String())
+	})
+
+	t.Run("when timezone is invalid", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/weight", nil)
+		r.Header.Set("X-Timezone", "Mars/Olympus")
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+		assert.Equal(t, "Asia/Jakarta", location.String(), "should fall back to the application timezone")
+
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+		assert.Equal(t, "Asia/Jakarta", location.String(), "should fall back again")
+	})
+
+	t.Run("when timezone is given again", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/weight", nil)
+		r.Header.Set("X-Timezone", "America/New_York")
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+		loaded := location
+
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+		assert.Same(t, loaded, location, "should reuse the loaded location")
+	})
+}
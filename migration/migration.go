@@ -0,0 +1,205 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ijalalfrz/sirclo-weight-test/mongodb"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	collectionName     = "schema_migrations"
+	lockCollectionName = "schema_migrations_lock"
+	applyingMessage    = "Applying migration %d: %s"
+	appliedMessage     = "Schema is up to date at version %d"
+	concurrentMessage  = "Migration %d has been recorded by another instance"
+	waitingLockMessage = "Waiting for another instance to finish the migrations"
+)
+
+// collection of migration lock setting
+const (
+	lockID            = "lock"
+	lockLease         = time.Minute
+	lockRetryInterval = time.Second
+)
+
+// Migration is a versioned change of the database schema.
+// Up must be safe to run again when it fails halfway, the version is only recorded once it succeeds.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, db mongodb.Database) error
+}
+
+// AppliedMigration is a record of the applied migration in schema_migrations collection.
+type AppliedMigration struct {
+	Version     int    `bson:"_id"`
+	Description string `bson:"description"`
+	AppliedAt   int64  `bson:"appliedAt"`
+}
+
+// Migrator is a concrete struct of migration runner.
+type Migrator struct {
+	logger     *logrus.Logger
+	db         mongodb.Database
+	col        mongodb.Collection
+	lockCol    mongodb.Collection
+	migrations []Migration
+}
+
+// NewMigrator is a constructor. Migrations are sorted by version.
+func NewMigrator(logger *logrus.Logger, db mongodb.Database, migrations ...Migration) *Migrator {
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+
+	return &Migrator{
+		logger:     logger,
+		db:         db,
+		col:        db.Collection(collectionName),
+		lockCol:    db.Collection(lockCollectionName),
+		migrations: sorted,
+	}
+}
+
+// Applied returns the versions which have been applied.
+func (m *Migrator) Applied(ctx context.Context) (versions map[int]bool, err error) {
+	cursor, err := m.col.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return
+	}
+	defer cursor.Close(ctx)
+
+	versions = make(map[int]bool)
+	for cursor.Next(ctx) {
+		applied := AppliedMigration{}
+		if err = cursor.Decode(&applied); err != nil {
+			return
+		}
+		versions[applied.Version] = true
+	}
+
+	return
+}
+
+// Up applies every pending migration in order of version and records each of them once it succeeds.
+// It stops at the first failing migration. The migrations are run under a lease, so instances which start
+// together wait for each other instead of running the same migration twice.
+func (m *Migrator) Up(ctx context.Context) (applied []int, err error) {
+	release, err := m.lock(ctx)
+	if err != nil {
+		err = fmt.Errorf("lock migrations: %w", err)
+		return
+	}
+	defer release()
+
+	versions, err := m.Applied(ctx)
+	if err != nil {
+		return
+	}
+
+	current := 0
+	for _, migration := range m.migrations {
+		if versions[migration.Version] {
+			current = migration.Version
+			continue
+		}
+
+		m.logger.Info(fmt.Sprintf(applyingMessage, migration.Version, migration.Description))
+		if err = migration.Up(ctx, m.db); err != nil {
+			err = fmt.Errorf("migration %d: %w", migration.Version, err)
+			return
+		}
+
+		_, err = m.col.InsertOne(ctx, AppliedMigration{
+			Version:     migration.Version,
+			Description: migration.Description,
+			AppliedAt:   time.Now().UnixNano(),
+		})
+		if mongo.IsDuplicateKeyError(err) {
+			// the lease of this instance expired while the migration ran and another instance recorded it.
+			m.logger.Warn(fmt.Sprintf(concurrentMessage, migration.Version))
+			err = nil
+		}
+		if err != nil {
+			err = fmt.Errorf("record migration %d: %w", migration.Version, err)
+			return
+		}
+
+		applied = append(applied, migration.Version)
+		current = migration.Version
+	}
+
+	m.logger.Info(fmt.Sprintf(appliedMessage, current))
+	return
+}
+
+// lock takes the lease of the migrations, waiting while another instance holds it. The lease is a document
+// of schema_migrations_lock which is renewed while the migrations run, and which expires on its own
+// when the instance dies before releasing it.
+func (m *Migrator) lock(ctx context.Context) (release func(), err error) {
+	holder := primitive.NewObjectID().Hex()
+	for {
+		now := time.Now()
+		// an existing lease which has not expired does not match, so the upsert fails with a duplicate key.
+		_, err = m.lockCol.UpdateOne(ctx,
+			bson.M{"_id": lockID, "expiresAt": bson.M{"$lt": now.UnixNano()}},
+			bson.M{"$set": bson.M{"holder": holder, "expiresAt": now.Add(lockLease).UnixNano()}},
+			options.Update().SetUpsert(true),
+		)
+		if err == nil {
+			break
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return
+		}
+
+		m.logger.Info(waitingLockMessage)
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+			return
+		case <-time.After(lockRetryInterval):
+		}
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(lockLease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				_, renewErr := m.lockCol.UpdateOne(ctx,
+					bson.M{"_id": lockID, "holder": holder},
+					bson.M{"$set": bson.M{"expiresAt": time.Now().Add(lockLease).UnixNano()}},
+				)
+				if renewErr != nil {
+					m.logger.Error(renewErr)
+				}
+			}
+		}
+	}()
+
+	release = func() {
+		close(stop)
+		<-done
+		if _, err := m.lockCol.DeleteOne(context.Background(), bson.M{"_id": lockID, "holder": holder}); err != nil {
+			m.logger.Error(err)
+		}
+	}
+	return
+}
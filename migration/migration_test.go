@@ -0,0 +1,188 @@
+package migration_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ijalalfrz/sirclo-weight-test/migration"
+	"github.com/ijalalfrz/sirclo-weight-test/mongodb"
+	"github.com/ijalalfrz/sirclo-weight-test/mongodb/mocks"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func appliedCursor(versions ...int) *mocks.Cursor {
+	cursorMock := new(mocks.Cursor)
+	for _, version := range versions {
+		v := version
+		cursorMock.On("Next", mock.Anything).Return(true).Once()
+		cursorMock.On("Decode", mock.AnythingOfType("*migration.AppliedMigration")).Return(nil).Run(func(args mock.Arguments) {
+			args.Get(0).(*migration.AppliedMigration).Version = v
+		}).Once()
+	}
+	cursorMock.On("Next", mock.Anything).Return(false).Once()
+	cursorMock.On("Close", mock.Anything).Return(nil)
+	return cursorMock
+}
+
+var duplicateKeyErr = mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000, Message: "E11000 duplicate key error"}}}
+
+// lockCollection is the collection of the lease which is free, or held by another instance for the given attempts.
+func lockCollection(held int) *mocks.Collection {
+	lockCol := new(mocks.Collection)
+	acquire := mock.MatchedBy(func(filter bson.M) bool {
+		_, ok := filter["expiresAt"]
+		return filter["_id"] == "lock" && ok
+	})
+	if held > 0 {
+		lockCol.On("UpdateOne", mock.Anything, acquire, mock.Anything, mock.Anything).Return(nil, duplicateKeyErr).Times(held)
+	}
+	lockCol.On("UpdateOne", mock.Anything, acquire, mock.Anything, mock.Anything).Return(&mongo.UpdateResult{UpsertedCount: 1}, nil).Once()
+	lockCol.On("DeleteOne", mock.Anything, mock.MatchedBy(func(filter bson.M) bool {
+		return filter["_id"] == "lock" && filter["holder"] != ""
+	})).Return(&mongo.DeleteResult{DeletedCount: 1}, nil).Once()
+	return lockCol
+}
+
+func TestMigrator_Up_Success(t *testing.T) {
+	col := new(mocks.Collection)
+	lockCol := lockCollection(0)
+	db := new(mocks.Database)
+	db.On("Collection", "schema_migrations").Return(col)
+	db.On("Collection", "schema_migrations_lock").Return(lockCol)
+	col.On("Find", mock.Anything, mock.Anything, mock.Anything).Return(appliedCursor(1), nil)
+	col.On("InsertOne", mock.Anything, mock.MatchedBy(func(applied migration.AppliedMigration) bool {
+		return applied.Version == 3 && applied.AppliedAt > 0
+	})).Return(&mongo.InsertOneResult{}, nil)
+	col.On("InsertOne", mock.Anything, mock.MatchedBy(func(applied migration.AppliedMigration) bool {
+		return applied.Version == 2 && applied.AppliedAt > 0
+	})).Return(&mongo.InsertOneResult{}, nil)
+
+	var ran []int
+	up := func(version int) func(ctx context.Context, db mongodb.Database) error {
+		return func(ctx context.Context, db mongodb.Database) error {
+			ran = append(ran, version)
+			return nil
+		}
+	}
+
+	migrator := migration.NewMigrator(logrus.New(), db,
+		migration.Migration{Version: 3, Description: "third", Up: up(3)},
+		migration.Migration{Version: 1, Description: "first", Up: up(1)},
+		migration.Migration{Version: 2, Description: "second", Up: up(2)},
+	)
+
+	applied, err := migrator.Up(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, []int{2, 3}, applied, "should apply pending migrations in order")
+	assert.Equal(t, []int{2, 3}, ran, "should not run applied migrations")
+	lockCol.AssertExpectations(t)
+	db.AssertExpectations(t)
+}
+
+func TestMigrator_Up_Success_WaitsForLock(t *testing.T) {
+	col := new(mocks.Collection)
+	lockCol := lockCollection(1)
+	db := new(mocks.Database)
+	db.On("Collection", "schema_migrations").Return(col)
+	db.On("Collection", "schema_migrations_lock").Return(lockCol)
+	col.On("Find", mock.Anything, mock.Anything, mock.Anything).Return(appliedCursor(1), nil)
+
+	migrator := migration.NewMigrator(logrus.New(), db,
+		migration.Migration{Version: 1, Description: "first", Up: func(ctx context.Context, db mongodb.Database) error {
+			t.Fatal("should not run the migration applied by the instance which held the lock")
+			return nil
+		}},
+	)
+
+	applied, err := migrator.Up(context.TODO())
+	assert.NoError(t, err)
+	assert.Empty(t, applied)
+	lockCol.AssertExpectations(t)
+	col.AssertExpectations(t)
+}
+
+func TestMigrator_Up_Success_RecordedConcurrently(t *testing.T) {
+	col := new(mocks.Collection)
+	lockCol := lockCollection(0)
+	db := new(mocks.Database)
+	db.On("Collection", "schema_migrations").Return(col)
+	db.On("Collection", "schema_migrations_lock").Return(lockCol)
+	col.On("Find", mock.Anything, mock.Anything, mock.Anything).Return(appliedCursor(), nil)
+	col.On("InsertOne", mock.Anything, mock.Anything).Return(nil, duplicateKeyErr)
+
+	migrator := migration.NewMigrator(logrus.New(), db,
+		migration.Migration{Version: 1, Description: "first", Up: func(ctx context.Context, db mongodb.Database) error {
+			return nil
+		}},
+	)
+
+	applied, err := migrator.Up(context.TODO())
+	assert.NoError(t, err, "should treat the version recorded by another instance as applied")
+	assert.Equal(t, []int{1}, applied)
+	lockCol.AssertExpectations(t)
+	col.AssertExpectations(t)
+}
+
+func TestMigrator_Up_Error_Lock(t *testing.T) {
+	col := new(mocks.Collection)
+	lockCol := new(mocks.Collection)
+	db := new(mocks.Database)
+	db.On("Collection", "schema_migrations").Return(col)
+	db.On("Collection", "schema_migrations_lock").Return(lockCol)
+	lockCol.On("UpdateOne", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, mongo.ErrClientDisconnected)
+
+	migrator := migration.NewMigrator(logrus.New(), db)
+
+	_, err := migrator.Up(context.TODO())
+	assert.ErrorIs(t, err, mongo.ErrClientDisconnected)
+	col.AssertNotCalled(t, "Find", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestMigrator_Up_Error_Migration(t *testing.T) {
+	col := new(mocks.Collection)
+	lockCol := lockCollection(0)
+	db := new(mocks.Database)
+	db.On("Collection", "schema_migrations").Return(col)
+	db.On("Collection", "schema_migrations_lock").Return(lockCol)
+	col.On("Find", mock.Anything, mock.Anything, mock.Anything).Return(appliedCursor(), nil)
+
+	failure := errors.New("duplicate key")
+	migrator := migration.NewMigrator(logrus.New(), db,
+		migration.Migration{Version: 1, Description: "first", Up: func(ctx context.Context, db mongodb.Database) error {
+			return failure
+		}},
+		migration.Migration{Version: 2, Description: "second", Up: func(ctx context.Context, db mongodb.Database) error {
+			t.Fatal("should stop at the failing migration")
+			return nil
+		}},
+	)
+
+	applied, err := migrator.Up(context.TODO())
+	assert.ErrorIs(t, err, failure)
+	assert.Empty(t, applied)
+	col.AssertNotCalled(t, "InsertOne", mock.Anything, mock.Anything)
+	lockCol.AssertExpectations(t)
+	col.AssertExpectations(t)
+	db.AssertExpectations(t)
+}
+
+func TestMigrator_Up_Error_Applied(t *testing.T) {
+	col := new(mocks.Collection)
+	lockCol := lockCollection(0)
+	db := new(mocks.Database)
+	db.On("Collection", "schema_migrations").Return(col)
+	db.On("Collection", "schema_migrations_lock").Return(lockCol)
+	col.On("Find", mock.Anything, mock.Anything, mock.Anything).Return(nil, mongo.ErrClientDisconnected)
+
+	migrator := migration.NewMigrator(logrus.New(), db)
+
+	_, err := migrator.Up(context.TODO())
+	assert.Equal(t, mongo.ErrClientDisconnected, err)
+	lockCol.AssertExpectations(t)
+	col.AssertExpectations(t)
+}
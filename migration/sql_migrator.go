@@ -0,0 +1,176 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ijalalfrz/sirclo-weight-test/sqldb"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	createTableQuery = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version INTEGER PRIMARY KEY,
+	description TEXT NOT NULL,
+	applied_at BIGINT NOT NULL
+)`
+	selectAppliedQuery  = "SELECT version FROM schema_migrations ORDER BY version"
+	insertAppliedQuery  = "INSERT INTO schema_migrations (version, description, applied_at) VALUES (?, ?, ?)"
+	advisoryLockQuery   = "SELECT pg_advisory_lock($1)"
+	advisoryUnlockQuery = "SELECT pg_advisory_unlock($1)"
+)
+
+// advisoryLockKey is the key of the postgres advisory lock of the migrations, it is arbitrary
+// but must be the same for every instance of the service.
+const advisoryLockKey int64 = 7309421865
+
+// SQLMigration is a versioned change of a sql database schema.
+// Its statements are run in one transaction together with the record of the version,
+// so a failing migration leaves nothing behind.
+type SQLMigration struct {
+	Version     int
+	Description string
+	Statements  []string
+}
+
+// SQLMigrator is a concrete struct of migration runner of a sql database.
+type SQLMigrator struct {
+	logger     *logrus.Logger
+	db         *sqldb.DB
+	migrations []SQLMigration
+}
+
+// NewSQLMigrator is a constructor. Migrations are sorted by version.
+func NewSQLMigrator(logger *logrus.Logger, db *sqldb.DB, migrations ...SQLMigration) *SQLMigrator {
+	sorted := make([]SQLMigration, len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+	return &SQLMigrator{
+		logger:     logger,
+		db:         db,
+		migrations: sorted,
+	}
+}
+
+// Applied returns the versions which have been applied.
+func (m *SQLMigrator) Applied(ctx context.Context) (versions map[int]bool, err error) {
+	if _, err = m.db.ExecContext(ctx, createTableQuery); err != nil {
+		return
+	}
+
+	rows, err := m.db.QueryContext(ctx, selectAppliedQuery)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	versions = make(map[int]bool)
+	for rows.Next() {
+		var version int
+		if err = rows.Scan(&version); err != nil {
+			return
+		}
+		versions[version] = true
+	}
+
+	err = rows.Err()
+	return
+}
+
+// Up applies every pending migration in order of version. It stops at the first failing migration.
+// On postgres the migrations are run under an advisory lock, so instances which start together
+// wait for each other instead of running the same migration twice. SQLite is a single writer already.
+func (m *SQLMigrator) Up(ctx context.Context) (applied []int, err error) {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		err = fmt.Errorf("lock migrations: %w", err)
+		return
+	}
+	defer unlock()
+
+	versions, err := m.Applied(ctx)
+	if err != nil {
+		return
+	}
+
+	current := 0
+	for _, migration := range m.migrations {
+		if versions[migration.Version] {
+			current = migration.Version
+			continue
+		}
+
+		m.logger.Info(fmt.Sprintf(applyingMessage, migration.Version, migration.Description))
+		if err = m.apply(ctx, migration); sqldb.IsUniqueViolation(err) {
+			// another instance which does not take the lock recorded the version first.
+			m.logger.Warn(fmt.Sprintf(concurrentMessage, migration.Version))
+			err = nil
+			current = migration.Version
+			continue
+		}
+		if err != nil {
+			err = fmt.Errorf("migration %d: %w", migration.Version, err)
+			return
+		}
+
+		applied = append(applied, migration.Version)
+		current = migration.Version
+	}
+
+	m.logger.Info(fmt.Sprintf(appliedMessage, current))
+	return
+}
+
+// lock takes the session level advisory lock of postgres on a dedicated connection, the lock is released
+// along with the connection. It is a no-op on the other drivers.
+func (m *SQLMigrator) lock(ctx context.Context) (unlock func(), err error) {
+	unlock = func() {}
+	if m.db.Driver != sqldb.DriverPostgres {
+		return
+	}
+
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return
+	}
+	if _, err = conn.ExecContext(ctx, advisoryLockQuery, advisoryLockKey); err != nil {
+		conn.Close()
+		return
+	}
+
+	unlock = func() {
+		if _, err := conn.ExecContext(context.Background(), advisoryUnlockQuery, advisoryLockKey); err != nil {
+			m.logger.Error(err)
+		}
+		conn.Close()
+	}
+	return
+}
+
+func (m *SQLMigrator) apply(ctx context.Context, migration SQLMigration) (err error) {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	for _, statement := range migration.Statements {
+		if _, err = tx.ExecContext(ctx, statement); err != nil {
+			return
+		}
+	}
+	if _, err = tx.ExecContext(ctx, m.db.Rebind(insertAppliedQuery), migration.Version, migration.Description, time.Now().UnixNano()); err != nil {
+		return
+	}
+
+	err = tx.Commit()
+	return
+}
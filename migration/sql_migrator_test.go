@@ -0,0 +1,59 @@
+package migration_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/ijalalfrz/sirclo-weight-test/migration"
+	"github.com/ijalalfrz/sirclo-weight-test/sqldb"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openSQLite(t *testing.T) *sqldb.DB {
+	db, err := sqldb.Open(sqldb.DriverSQLite, filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		db.Close()
+	})
+	return db
+}
+
+func TestSQLMigrator_Up_Success(t *testing.T) {
+	db := openSQLite(t)
+	migrations := []migration.SQLMigration{
+		{Version: 2, Description: "second", Statements: []string{"ALTER TABLE test ADD COLUMN name TEXT"}},
+		{Version: 1, Description: "first", Statements: []string{"CREATE TABLE test (id INTEGER PRIMARY KEY)"}},
+	}
+
+	applied, err := migration.NewSQLMigrator(logrus.New(), db, migrations...).Up(context.TODO())
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, applied)
+
+	applied, err = migration.NewSQLMigrator(logrus.New(), db, migrations...).Up(context.TODO())
+	require.NoError(t, err)
+	assert.Empty(t, applied, "should not run applied migrations")
+}
+
+func TestSQLMigrator_Up_Error_Migration(t *testing.T) {
+	db := openSQLite(t)
+	migrator := migration.NewSQLMigrator(logrus.New(), db,
+		migration.SQLMigration{Version: 1, Description: "first", Statements: []string{
+			"CREATE TABLE test (id INTEGER PRIMARY KEY)",
+			"CREATE TABLE test (id INTEGER PRIMARY KEY)",
+		}},
+		migration.SQLMigration{Version: 2, Description: "second", Statements: []string{"CREATE TABLE other (id INTEGER PRIMARY KEY)"}},
+	)
+
+	applied, err := migrator.Up(context.TODO())
+	assert.Error(t, err, "should be error")
+	assert.Empty(t, applied)
+
+	versions, err := migrator.Applied(context.TODO())
+	require.NoError(t, err)
+	assert.Empty(t, versions, "should not record the failing migration")
+	_, err = db.Exec("SELECT * FROM test")
+	assert.Error(t, err, "should roll back the statements of the failing migration")
+}
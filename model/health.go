@@ -0,0 +1,15 @@
+package model
+
+// HealthResponse is a model of readiness response.
+type HealthResponse struct {
+	State        string             `json:"state"`
+	Dependencies []DependencyStatus `json:"dependencies"`
+}
+
+// DependencyStatus is a model of health status of a dependency.
+type DependencyStatus struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
@@ -0,0 +1,34 @@
+package model
+
+// WebhookPayload is a model for webhook subscription http request.
+// Events filters the event types which are delivered, none means every event.
+// Secret is generated when it is empty.
+type WebhookPayload struct {
+	URL    string   `json:"url" validate:"required,url"`
+	Secret string   `json:"secret,omitempty"`
+	Events []string `json:"events,omitempty"`
+}
+
+// WebhookResponse is a model for a single webhook subscription.
+// Secret is only returned when the subscription is created.
+type WebhookResponse struct {
+	ID        string   `json:"id"`
+	URL       string   `json:"url"`
+	Secret    string   `json:"secret,omitempty"`
+	Events    []string `json:"events"`
+	CreatedAt string   `json:"createdAt"`
+}
+
+// WebhookDeliveryResponse is a model for a single delivery of a webhook subscription.
+// NextAttemptAt is only set while the delivery is pending and DeliveredAt once it is delivered.
+type WebhookDeliveryResponse struct {
+	ID            string `json:"id"`
+	Event         string `json:"event"`
+	Status        string `json:"status"`
+	Attempts      int    `json:"attempts"`
+	ResponseCode  int    `json:"responseCode,omitempty"`
+	LastError     string `json:"lastError,omitempty"`
+	CreatedAt     string `json:"createdAt"`
+	NextAttemptAt string `json:"nextAttemptAt,omitempty"`
+	DeliveredAt   string `json:"deliveredAt,omitempty"`
+}
@@ -1,10 +1,14 @@
 package model
 
-// WeightPayload is a model for weight http request
+// WeightPayload is a model for weight http request.
+// The calendar date is given either as Day of 2006-01-02 layout or as unix nano Date.
+// Version is the expected version of the weight on an update, zero means the update is unconditional.
 type WeightPayload struct {
-	Date int64 `json:"date" validate:"required"`
-	Max  int   `json:"max" validate:"required"`
-	Min  int   `json:"min" validate:"required"`
+	Date    int64  `json:"date" validate:"required"`
+	Day     string `json:"day,omitempty"`
+	Version int64  `json:"version,omitempty"`
+	Max     int    `json:"max" validate:"required"`
+	Min     int    `json:"min" validate:"required"`
 }
 
 type WeightResponse struct {
@@ -14,10 +18,101 @@ type WeightResponse struct {
 	AverageDiff float32               `json:"averageDiff"`
 }
 
+// WeightExportResponse is a model for a single exported weight
+type WeightExportResponse struct {
+	Date       int64  `json:"date"`
+	DateString string `json:"dateString"`
+	Max        int    `json:"max"`
+	Min        int    `json:"min"`
+	Diff       int    `json:"diff"`
+}
+
 type WeighDetailResponse struct {
 	Date       int64  `json:"date"`
-	DateString string `json:"-"`
+	DateString string `json:"day"`
 	Max        int    `json:"max"`
 	Min        int    `json:"min"`
 	Diff       int    `json:"diff"`
+	Version    int64  `json:"version"`
+}
+
+// WeightRevisionResponse is a model for a single revision of weight history.
+// Before is null for an insert and After is null for a delete.
+type WeightRevisionResponse struct {
+	ID        string               `json:"id"`
+	Action    string               `json:"action"`
+	Actor     string               `json:"actor,omitempty"`
+	RequestID string               `json:"requestId,omitempty"`
+	CreatedAt string               `json:"createdAt"`
+	Before    *WeighDetailResponse `json:"before"`
+	After     *WeighDetailResponse `json:"after"`
+}
+
+// WeightFilter is a model for filtering, sorting and paginating weight list
+type WeightFilter struct {
+	Owner  string
+	From   int64
+	To     int64
+	Page   int64
+	Limit  int64
+	SortBy string
+	Sort   int
+}
+
+// WeightMeta is a model for pagination meta of weight list
+type WeightMeta struct {
+	Page      int64 `json:"page"`
+	Limit     int64 `json:"limit"`
+	TotalData int64 `json:"totalData"`
+	TotalPage int64 `json:"totalPage"`
+}
+
+// StatisticFilter is a model for filtering and grouping weight statistics
+type StatisticFilter struct {
+	Owner   string
+	From    int64
+	To      int64
+	GroupBy string
+}
+
+// StatisticResponse is a model for weight statistics of a single period
+type StatisticResponse struct {
+	Period string           `json:"period"`
+	Count  int64            `json:"count"`
+	Max    StatisticSummary `json:"max"`
+	Min    StatisticSummary `json:"min"`
+	Diff   StatisticSummary `json:"diff"`
+}
+
+// StatisticSummary is a model for summary of a single weight field
+type StatisticSummary struct {
+	Min    int     `json:"min"`
+	Max    int     `json:"max"`
+	Mean   float64 `json:"mean"`
+	Median float64 `json:"median"`
+	StdDev float64 `json:"stdDev"`
+}
+
+// WeightImportRow is a model for a single parsed row of weight import
+type WeightImportRow struct {
+	Row     int
+	Payload WeightPayload
+	Error   string
+}
+
+// ImportReport is a model for result of weight import
+type ImportReport struct {
+	Inserted int               `json:"inserted"`
+	Updated  int               `json:"updated"`
+	Skipped  int               `json:"skipped"`
+	Invalid  int               `json:"invalid"`
+	Rows     []ImportRowReport `json:"rows"`
+}
+
+// ImportRowReport is a model for result of a single row of weight import
+type ImportRowReport struct {
+	Row    int    `json:"row"`
+	Date   int64  `json:"date"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
 }
@@ -8,6 +8,7 @@ import (
 	mock "github.com/stretchr/testify/mock"
 	mongodb "github.com/ijalalfrz/sirclo-weight-test/mongodb"
 	options "go.mongodb.org/mongo-driver/mongo/options"
+	readpref "go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
 // Client is an autogenerated mock type for the Client type
@@ -65,3 +66,31 @@ func (_m *Client) Disconnect(ctx context.Context) error {
 
 	return r0
 }
+
+// Ping provides a mock function with given fields: ctx, rp
+func (_m *Client) Ping(ctx context.Context, rp *readpref.ReadPref) error {
+	ret := _m.Called(ctx, rp)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *readpref.ReadPref) error); ok {
+		r0 = rf(ctx, rp)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Transaction provides a mock function with given fields: ctx, fn
+func (_m *Client) Transaction(ctx context.Context, fn func(context.Context) error) error {
+	ret := _m.Called(ctx, fn)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, func(context.Context) error) error); ok {
+		r0 = rf(ctx, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -18,6 +18,36 @@ type Collection struct {
 	mock.Mock
 }
 
+// Aggregate provides a mock function with given fields: ctx, pipeline, opts
+func (_m *Collection) Aggregate(ctx context.Context, pipeline interface{}, opts ...*options.AggregateOptions) (mongodb.Cursor, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, pipeline)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 mongodb.Cursor
+	if rf, ok := ret.Get(0).(func(context.Context, interface{}, ...*options.AggregateOptions) mongodb.Cursor); ok {
+		r0 = rf(ctx, pipeline, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(mongodb.Cursor)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, interface{}, ...*options.AggregateOptions) error); ok {
+		r1 = rf(ctx, pipeline, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // BulkWrite provides a mock function with given fields: ctx, models, opts
 func (_m *Collection) BulkWrite(ctx context.Context, models []mongo.WriteModel, opts ...*options.BulkWriteOptions) (*mongo.BulkWriteResult, error) {
 	_va := make([]interface{}, len(opts))
@@ -189,6 +219,22 @@ func (_m *Collection) FindOne(ctx context.Context, filter interface{}, opts ...*
 	return r0
 }
 
+// Indexes provides a mock function with given fields:
+func (_m *Collection) Indexes() mongodb.IndexView {
+	ret := _m.Called()
+
+	var r0 mongodb.IndexView
+	if rf, ok := ret.Get(0).(func() mongodb.IndexView); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(mongodb.IndexView)
+		}
+	}
+
+	return r0
+}
+
 // InsertMany provides a mock function with given fields: ctx, documents, opts
 func (_m *Collection) InsertMany(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error) {
 	_va := make([]interface{}, len(opts))
@@ -308,3 +354,33 @@ func (_m *Collection) UpdateOne(ctx context.Context, filter interface{}, update
 
 	return r0, r1
 }
+
+// Watch provides a mock function with given fields: ctx, pipeline, opts
+func (_m *Collection) Watch(ctx context.Context, pipeline interface{}, opts ...*options.ChangeStreamOptions) (mongodb.ChangeStream, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, pipeline)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 mongodb.ChangeStream
+	if rf, ok := ret.Get(0).(func(context.Context, interface{}, ...*options.ChangeStreamOptions) mongodb.ChangeStream); ok {
+		r0 = rf(ctx, pipeline, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(mongodb.ChangeStream)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, interface{}, ...*options.ChangeStreamOptions) error); ok {
+		r1 = rf(ctx, pipeline, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
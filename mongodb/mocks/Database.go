@@ -3,6 +3,8 @@
 package mocks
 
 import (
+	context "context"
+
 	mock "github.com/stretchr/testify/mock"
 	mongodb "github.com/ijalalfrz/sirclo-weight-test/mongodb"
 	options "go.mongodb.org/mongo-driver/mongo/options"
@@ -35,3 +37,26 @@ func (_m *Database) Collection(name string, opts ...*options.CollectionOptions)
 
 	return r0
 }
+
+// RunCommand provides a mock function with given fields: ctx, runCommand, opts
+func (_m *Database) RunCommand(ctx context.Context, runCommand interface{}, opts ...*options.RunCmdOptions) mongodb.SingleResult {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, runCommand)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 mongodb.SingleResult
+	if rf, ok := ret.Get(0).(func(context.Context, interface{}, ...*options.RunCmdOptions) mongodb.SingleResult); ok {
+		r0 = rf(ctx, runCommand, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(mongodb.SingleResult)
+		}
+	}
+
+	return r0
+}
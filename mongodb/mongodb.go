@@ -3,8 +3,10 @@ package mongodb
 import (
 	"context"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
 // Client is a collection of behavior of mongodb client.
@@ -12,11 +14,14 @@ type Client interface {
 	Connect(ctx context.Context) (err error)
 	Database(name string, opts ...*options.DatabaseOptions) (db Database)
 	Disconnect(ctx context.Context) (err error)
+	Ping(ctx context.Context, rp *readpref.ReadPref) (err error)
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) (err error)
 }
 
 // Database is a collection of behavior of mongodb database.
 type Database interface {
 	Collection(name string, opts ...*options.CollectionOptions) (col Collection)
+	RunCommand(ctx context.Context, runCommand interface{}, opts ...*options.RunCmdOptions) (result SingleResult)
 }
 
 // Collection is a collection of behavior of mongodb client.
@@ -31,6 +36,17 @@ type Collection interface {
 	UpdateMany(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (result *mongo.UpdateResult, err error)
 	UpdateOne(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (result *mongo.UpdateResult, err error)
 	BulkWrite(ctx context.Context, models []mongo.WriteModel, opts ...*options.BulkWriteOptions) (result *mongo.BulkWriteResult, err error)
+	Aggregate(ctx context.Context, pipeline interface{}, opts ...*options.AggregateOptions) (cursor Cursor, err error)
+	Indexes() (view IndexView)
+	Watch(ctx context.Context, pipeline interface{}, opts ...*options.ChangeStreamOptions) (stream ChangeStream, err error)
+}
+
+// IndexView is a collection of behavior of mongodb index view.
+type IndexView interface {
+	List(ctx context.Context, opts ...*options.ListIndexesOptions) (cursor Cursor, err error)
+	CreateOne(ctx context.Context, model mongo.IndexModel, opts ...*options.CreateIndexesOptions) (name string, err error)
+	CreateMany(ctx context.Context, models []mongo.IndexModel, opts ...*options.CreateIndexesOptions) (names []string, err error)
+	DropOne(ctx context.Context, name string, opts ...*options.DropIndexesOptions) (err error)
 }
 
 // SingleResult is a collectioin of function of mongodb single result.
@@ -46,3 +62,12 @@ type Cursor interface {
 	Decode(val interface{}) error
 	Close(ctx context.Context) error
 }
+
+// ChangeStream is a collection of function of mongodb change stream.
+type ChangeStream interface {
+	Next(ctx context.Context) bool
+	Decode(val interface{}) error
+	Err() error
+	ResumeToken() bson.Raw
+	Close(ctx context.Context) error
+}
@@ -3,8 +3,10 @@ package mongodb
 import (
 	"context"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
 // ClientAdapter is a concrete struct of mongodb client adapter.
@@ -48,3 +50,45 @@ func (c *ClientAdapter) Disconnect(ctx context.Context) (err error) {
 	err = c.c.Disconnect(ctx)
 	return
 }
+
+// Ping sends a ping command to verify that the client can connect to the deployment.
+//
+// The rp parameter is used to determine which server is selected for the operation.
+// If it is nil, the client's read preference is used.
+func (c *ClientAdapter) Ping(ctx context.Context, rp *readpref.ReadPref) (err error) {
+	err = c.c.Ping(ctx, rp)
+	return
+}
+
+// Transaction runs fn in a multi-document transaction which is committed when fn returns no error and aborted otherwise.
+// The session is carried by the context given to fn, every operation which receives it is part of the transaction.
+// fn may be called again when the transaction fails with a transient error. Transactions need a replica set or mongos.
+// A nested call joins the transaction of the session carried by ctx instead of starting another one.
+func (c *ClientAdapter) Transaction(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	err = c.c.UseSession(ctx, func(sc mongo.SessionContext) error {
+		_, err := sc.WithTransaction(sc, func(sc mongo.SessionContext) (interface{}, error) {
+			return nil, fn(sc)
+		})
+		return err
+	})
+	return
+}
+
+// SupportsTransactions reports whether the deployment of db runs multi-document transactions, which only
+// a replica set member or a mongos does. A standalone server rejects every transaction.
+func SupportsTransactions(ctx context.Context, db Database) (supported bool, err error) {
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	if err = db.RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		return
+	}
+
+	supported = hello.SetName != "" || hello.Msg == "isdbgrid"
+	return
+}
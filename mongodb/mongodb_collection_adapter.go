@@ -160,3 +160,40 @@ func (col *CollectionAdapter) BulkWrite(ctx context.Context, models []mongo.Writ
 	result, err = col.col.BulkWrite(ctx, models, opts...)
 	return
 }
+
+// Aggregate executes an aggregate command against the collection and returns a cursor over the resulting documents.
+//
+// The pipeline parameter must be an array of documents, each representing an aggregation stage. The pipeline cannot
+// be nil but can be empty. The stage documents must all be non-nil. For a pipeline of bson.D documents, the
+// mongo.Pipeline type can be used. See
+// https://docs.mongodb.com/manual/reference/operator/aggregation-pipeline/#db-collection-aggregate-stages for a list of
+// valid stages in aggregations.
+//
+// The opts parameter can be used to specify options for the operation (see the options.AggregateOptions documentation.)
+//
+// For more information about the command, see https://docs.mongodb.com/manual/reference/command/aggregate/.
+func (col *CollectionAdapter) Aggregate(ctx context.Context, pipeline interface{}, opts ...*options.AggregateOptions) (cursor Cursor, err error) {
+	cursor, err = col.col.Aggregate(ctx, pipeline, opts...)
+	return
+}
+
+// Indexes returns an IndexView instance that can be used to perform operations on the indexes for the collection.
+func (col *CollectionAdapter) Indexes() (view IndexView) {
+	view = &IndexViewAdapter{iv: col.col.Indexes()}
+	return
+}
+
+// Watch returns a change stream for all changes on the collection, it requires a replica set or a sharded cluster.
+//
+// The pipeline parameter filters and modifies the change events, the opts parameter can be used to resume the stream
+// or to look up the full document of an update (see the options.ChangeStreamOptions documentation).
+//
+// For more information about change streams, see https://docs.mongodb.com/manual/changeStreams/.
+func (col *CollectionAdapter) Watch(ctx context.Context, pipeline interface{}, opts ...*options.ChangeStreamOptions) (stream ChangeStream, err error) {
+	cs, err := col.col.Watch(ctx, pipeline, opts...)
+	if err != nil {
+		return
+	}
+	stream = cs
+	return
+}
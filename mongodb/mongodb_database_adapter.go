@@ -1,6 +1,8 @@
 package mongodb
 
 import (
+	"context"
+
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -16,3 +18,9 @@ func (db *DatabaseAdapter) Collection(name string, opts ...*options.CollectionOp
 	col = &CollectionAdapter{mongoCollection}
 	return
 }
+
+// RunCommand executes the given command against the database.
+func (db *DatabaseAdapter) RunCommand(ctx context.Context, runCommand interface{}, opts ...*options.RunCmdOptions) (result SingleResult) {
+	result = db.db.RunCommand(ctx, runCommand, opts...)
+	return
+}
@@ -0,0 +1,176 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// collection of operation outcome
+const (
+	outcomeSuccess = "success"
+	outcomeError   = "error"
+)
+
+// MetricsDatabase is a decorator of Database whose collections record the latency of every operation.
+type MetricsDatabase struct {
+	db       Database
+	duration *prometheus.HistogramVec
+}
+
+// NewMetricsDatabase is a constructor.
+// The duration histogram must be labeled by collection, operation and outcome.
+func NewMetricsDatabase(db Database, duration *prometheus.HistogramVec) Database {
+	return &MetricsDatabase{
+		db:       db,
+		duration: duration,
+	}
+}
+
+// Collection gets a handle for an instrumented collection with the given name.
+func (db *MetricsDatabase) Collection(name string, opts ...*options.CollectionOptions) (col Collection) {
+	col = NewMetricsCollection(db.db.Collection(name, opts...), name, db.duration)
+	return
+}
+
+// RunCommand executes the given command against the database, commands are not instrumented.
+func (db *MetricsDatabase) RunCommand(ctx context.Context, runCommand interface{}, opts ...*options.RunCmdOptions) (result SingleResult) {
+	result = db.db.RunCommand(ctx, runCommand, opts...)
+	return
+}
+
+// MetricsCollection is a decorator of Collection which records the latency of every operation.
+type MetricsCollection struct {
+	col      Collection
+	name     string
+	duration *prometheus.HistogramVec
+}
+
+// NewMetricsCollection is a constructor.
+// The duration histogram must be labeled by collection, operation and outcome.
+func NewMetricsCollection(col Collection, name string, duration *prometheus.HistogramVec) Collection {
+	return &MetricsCollection{
+		col:      col,
+		name:     name,
+		duration: duration,
+	}
+}
+
+// FindOne executes a find command and returns a SingleResult for one document in the collection.
+// ErrNoDocuments is not counted as an error outcome.
+func (col *MetricsCollection) FindOne(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) (result SingleResult) {
+	start := time.Now()
+	result = col.col.FindOne(ctx, filter, opts...)
+
+	err := result.Err()
+	if err == mongo.ErrNoDocuments {
+		err = nil
+	}
+	col.observe("FindOne", start, err)
+	return
+}
+
+// Find executes a find command and returns a Cursor over the matching documents in the collection.
+func (col *MetricsCollection) Find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (cursor Cursor, err error) {
+	start := time.Now()
+	cursor, err = col.col.Find(ctx, filter, opts...)
+	col.observe("Find", start, err)
+	return
+}
+
+// InsertOne executes an insert command to insert a single document into the collection.
+func (col *MetricsCollection) InsertOne(ctx context.Context, document interface{}, opts ...*options.InsertOneOptions) (result *mongo.InsertOneResult, err error) {
+	start := time.Now()
+	result, err = col.col.InsertOne(ctx, document, opts...)
+	col.observe("InsertOne", start, err)
+	return
+}
+
+// InsertMany executes an insert command to insert multiple documents into the collection.
+func (col *MetricsCollection) InsertMany(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (result *mongo.InsertManyResult, err error) {
+	start := time.Now()
+	result, err = col.col.InsertMany(ctx, documents, opts...)
+	col.observe("InsertMany", start, err)
+	return
+}
+
+// CountDocuments returns the number of documents in the collection.
+func (col *MetricsCollection) CountDocuments(ctx context.Context, filter interface{}, opts ...*options.CountOptions) (counted int64, err error) {
+	start := time.Now()
+	counted, err = col.col.CountDocuments(ctx, filter, opts...)
+	col.observe("CountDocuments", start, err)
+	return
+}
+
+// DeleteOne executes a delete command to delete at most one document from the collection.
+func (col *MetricsCollection) DeleteOne(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (result *mongo.DeleteResult, err error) {
+	start := time.Now()
+	result, err = col.col.DeleteOne(ctx, filter, opts...)
+	col.observe("DeleteOne", start, err)
+	return
+}
+
+// DeleteMany executes a delete command to delete documents from the collection.
+func (col *MetricsCollection) DeleteMany(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (result *mongo.DeleteResult, err error) {
+	start := time.Now()
+	result, err = col.col.DeleteMany(ctx, filter, opts...)
+	col.observe("DeleteMany", start, err)
+	return
+}
+
+// UpdateMany executes an update command to update documents in the collection.
+func (col *MetricsCollection) UpdateMany(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (result *mongo.UpdateResult, err error) {
+	start := time.Now()
+	result, err = col.col.UpdateMany(ctx, filter, update, opts...)
+	col.observe("UpdateMany", start, err)
+	return
+}
+
+// UpdateOne executes an update command to update at most one document in the collection.
+func (col *MetricsCollection) UpdateOne(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (result *mongo.UpdateResult, err error) {
+	start := time.Now()
+	result, err = col.col.UpdateOne(ctx, filter, update, opts...)
+	col.observe("UpdateOne", start, err)
+	return
+}
+
+// BulkWrite performs a bulk write operation.
+func (col *MetricsCollection) BulkWrite(ctx context.Context, models []mongo.WriteModel, opts ...*options.BulkWriteOptions) (result *mongo.BulkWriteResult, err error) {
+	start := time.Now()
+	result, err = col.col.BulkWrite(ctx, models, opts...)
+	col.observe("BulkWrite", start, err)
+	return
+}
+
+// Aggregate executes an aggregate command against the collection and returns a cursor over the resulting documents.
+func (col *MetricsCollection) Aggregate(ctx context.Context, pipeline interface{}, opts ...*options.AggregateOptions) (cursor Cursor, err error) {
+	start := time.Now()
+	cursor, err = col.col.Aggregate(ctx, pipeline, opts...)
+	col.observe("Aggregate", start, err)
+	return
+}
+
+// Indexes returns the index view of the decorated collection, index operations are not recorded.
+func (col *MetricsCollection) Indexes() (view IndexView) {
+	view = col.col.Indexes()
+	return
+}
+
+// Watch opens a change stream on the decorated collection, only opening the stream is recorded.
+func (col *MetricsCollection) Watch(ctx context.Context, pipeline interface{}, opts ...*options.ChangeStreamOptions) (stream ChangeStream, err error) {
+	start := time.Now()
+	stream, err = col.col.Watch(ctx, pipeline, opts...)
+	col.observe("Watch", start, err)
+	return
+}
+
+func (col *MetricsCollection) observe(operation string, start time.Time, err error) {
+	outcome := outcomeSuccess
+	if err != nil {
+		outcome = outcomeError
+	}
+	col.duration.WithLabelValues(col.name, operation, outcome).Observe(time.Since(start).Seconds())
+}
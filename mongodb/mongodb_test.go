@@ -2,11 +2,21 @@ package mongodb_test
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/ijalalfrz/sirclo-weight-test/mongodb"
+	"github.com/ijalalfrz/sirclo-weight-test/mongodb/mocks"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 var (
@@ -28,6 +38,59 @@ func TestClientAdapter_Disconnect(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestClientAdapter_Ping(t *testing.T) {
+	c, _ := mongo.NewClient(options.Client().ApplyURI("mongodb://localhost:1").SetServerSelectionTimeout(time.Millisecond * 10))
+	err := mongodb.NewClientAdapter(c).Ping(context.TODO(), nil)
+	assert.Error(t, err)
+}
+
+func TestClientAdapter_Transaction(t *testing.T) {
+	c, _ := mongo.NewClient(options.Client().ApplyURI("mongodb://localhost:1").SetServerSelectionTimeout(time.Millisecond * 10))
+	require.NoError(t, c.Connect(context.TODO()))
+	defer c.Disconnect(context.TODO())
+
+	expected := errors.New("unexpected")
+	err := mongodb.NewClientAdapter(c).Transaction(context.TODO(), func(ctx context.Context) error {
+		assert.NotNil(t, mongo.SessionFromContext(ctx), "should carry the session")
+		return expected
+	})
+	assert.Equal(t, expected, err, "should return the error of fn")
+}
+
+func TestSupportsTransactions(t *testing.T) {
+	hello := func(reply bson.M) *mocks.SingleResult {
+		result := new(mocks.SingleResult)
+		result.On("Decode", mock.Anything).Return(func(v interface{}) error {
+			raw, _ := bson.Marshal(reply)
+			return bson.Unmarshal(raw, v)
+		})
+		return result
+	}
+
+	for name, tc := range map[string]struct {
+		reply    bson.M
+		expected bool
+	}{
+		"replica set": {reply: bson.M{"isWritablePrimary": true, "setName": "rs0"}, expected: true},
+		"mongos":      {reply: bson.M{"isWritablePrimary": true, "msg": "isdbgrid"}, expected: true},
+		"standalone":  {reply: bson.M{"isWritablePrimary": true}, expected: false},
+	} {
+		db := new(mocks.Database)
+		db.On("RunCommand", mock.Anything, bson.D{{Key: "hello", Value: 1}}).Return(hello(tc.reply))
+
+		supported, err := mongodb.SupportsTransactions(context.TODO(), db)
+		assert.NoError(t, err, name)
+		assert.Equal(t, tc.expected, supported, name)
+	}
+
+	failed := new(mocks.SingleResult)
+	failed.On("Decode", mock.Anything).Return(mongo.ErrClientDisconnected)
+	db := new(mocks.Database)
+	db.On("RunCommand", mock.Anything, mock.Anything).Return(failed)
+	_, err := mongodb.SupportsTransactions(context.TODO(), db)
+	assert.Equal(t, mongo.ErrClientDisconnected, err, "should return the error of the command")
+}
+
 func TestClientAdapter_Database(t *testing.T) {
 	err := client.Connect(context.TODO())
 	assert.NoError(t, err)
@@ -114,3 +177,69 @@ func TestCollectionAdapter_BulkWrite(t *testing.T) {
 	assert.Error(t, err)
 	assert.NotNil(t, result)
 }
+
+func TestCollectionAdapter_Aggregate(t *testing.T) {
+	pipeline := make([]interface{}, 0)
+
+	cursor, err := client.Database("test-db").Collection("test-collection").Aggregate(context.TODO(), pipeline)
+	assert.Error(t, err)
+	assert.Nil(t, cursor)
+}
+
+func TestCollectionAdapter_Indexes(t *testing.T) {
+	indexes := client.Database("test-db").Collection("test-collection").Indexes()
+	assert.NotNil(t, indexes)
+}
+
+func TestIndexViewAdapter_CreateOne(t *testing.T) {
+	model := mongo.IndexModel{Keys: map[string]interface{}{"date": 1}}
+
+	name, err := client.Database("test-db").Collection("test-collection").Indexes().CreateOne(context.TODO(), model)
+	assert.Error(t, err)
+	assert.Empty(t, name)
+}
+
+func TestIndexViewAdapter_CreateMany(t *testing.T) {
+	models := []mongo.IndexModel{{Keys: map[string]interface{}{"date": 1}}}
+
+	names, err := client.Database("test-db").Collection("test-collection").Indexes().CreateMany(context.TODO(), models)
+	assert.Error(t, err)
+	assert.Nil(t, names)
+}
+
+func TestIndexViewAdapter_List(t *testing.T) {
+	cursor, err := client.Database("test-db").Collection("test-collection").Indexes().List(context.TODO())
+	assert.Error(t, err)
+	assert.Nil(t, cursor)
+}
+
+func TestIndexViewAdapter_DropOne(t *testing.T) {
+	err := client.Database("test-db").Collection("test-collection").Indexes().DropOne(context.TODO(), "date_1")
+	assert.Error(t, err)
+}
+
+func TestMetricsCollection(t *testing.T) {
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "duration"}, []string{"collection", "operation", "outcome"})
+
+	colMock := new(mocks.Collection)
+	colMock.On("InsertOne", mock.Anything, mock.Anything).Return(&mongo.InsertOneResult{}, nil)
+	colMock.On("CountDocuments", mock.Anything, mock.Anything).Return(int64(0), mongo.ErrClientDisconnected)
+
+	dbMock := new(mocks.Database)
+	dbMock.On("Collection", "weight").Return(colMock)
+
+	col := mongodb.NewMetricsDatabase(dbMock, duration).Collection("weight")
+
+	_, err := col.InsertOne(context.TODO(), map[string]interface{}{})
+	assert.NoError(t, err)
+	_, err = col.CountDocuments(context.TODO(), map[string]interface{}{})
+	assert.Equal(t, mongo.ErrClientDisconnected, err, "should return the error of the decorated collection")
+
+	assert.Equal(t, 2, testutil.CollectAndCount(duration))
+
+	observed := &dto.Metric{}
+	duration.WithLabelValues("weight", "CountDocuments", "error").(prometheus.Histogram).Write(observed)
+	assert.Equal(t, uint64(1), observed.GetHistogram().GetSampleCount())
+	colMock.AssertExpectations(t)
+	dbMock.AssertExpectations(t)
+}
@@ -0,0 +1,94 @@
+package openapi
+
+import (
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// collection of documentation path
+const (
+	Path           = "/openapi.json"
+	DocsPath       = "/docs"
+	StyleSheetPath = DocsPath + "/swagger-ui.css"
+	ScriptPath     = DocsPath + "/swagger-ui-bundle.js"
+)
+
+// Paths are the paths of the documentation, they are public like the document itself.
+var Paths = []string{Path, DocsPath, StyleSheetPath, ScriptPath}
+
+// swaggerAssets are the files of the swagger-ui-dist package 4.15.5 which the page needs,
+// they are served by the service so the page does not depend on a CDN.
+//
+//go:embed swagger-ui/swagger-ui.css swagger-ui/swagger-ui-bundle.js
+var swaggerAssets embed.FS
+
+// swaggerUI is the page of Swagger UI.
+const swaggerUI = `<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="utf-8">
+    <title>Weight Service API</title>
+    <link rel="stylesheet" href="` + StyleSheetPath + `">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="` + ScriptPath + `"></script>
+<script>
+    window.ui = SwaggerUIBundle({url: "` + Path + `", dom_id: "#swagger-ui"});
+</script>
+</body>
+</html>
+`
+
+// HTTPHandler is a concrete struct of openapi http handler.
+type HTTPHandler struct {
+	Logger   *logrus.Logger
+	Document []byte
+	Assets   http.Handler
+}
+
+// NewOpenAPIHTTPHandler registers the document, its Swagger UI and the assets of the ui to the router,
+// the document is encoded once.
+func NewOpenAPIHTTPHandler(logger *logrus.Logger, router *mux.Router) {
+	document, err := json.Marshal(Spec())
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	assets, err := fs.Sub(swaggerAssets, "swagger-ui")
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	handler := &HTTPHandler{
+		Logger:   logger,
+		Document: document,
+		Assets:   http.StripPrefix(DocsPath, http.FileServer(http.FS(assets))),
+	}
+	router.HandleFunc(Path, handler.OpenAPI).Methods(http.MethodGet)
+	router.HandleFunc(DocsPath, handler.SwaggerUI).Methods(http.MethodGet)
+	router.HandleFunc(StyleSheetPath, handler.Asset).Methods(http.MethodGet)
+	router.HandleFunc(ScriptPath, handler.Asset).Methods(http.MethodGet)
+}
+
+// OpenAPI responds the OpenAPI document.
+func (handler HTTPHandler) OpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(handler.Document)
+}
+
+// SwaggerUI responds the Swagger UI page of the document.
+func (handler HTTPHandler) SwaggerUI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(swaggerUI))
+}
+
+// Asset responds the embedded file of the Swagger UI page.
+func (handler HTTPHandler) Asset(w http.ResponseWriter, r *http.Request) {
+	handler.Assets.ServeHTTP(w, r)
+}
@@ -0,0 +1,167 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/ijalalfrz/sirclo-weight-test/lifecycle"
+	"github.com/ijalalfrz/sirclo-weight-test/metrics"
+	"github.com/ijalalfrz/sirclo-weight-test/middleware"
+	"github.com/ijalalfrz/sirclo-weight-test/model"
+	"github.com/ijalalfrz/sirclo-weight-test/openapi"
+	"github.com/ijalalfrz/sirclo-weight-test/router"
+	webhookmocks "github.com/ijalalfrz/sirclo-weight-test/webhook/mocks"
+	"github.com/ijalalfrz/sirclo-weight-test/weight"
+	weightmocks "github.com/ijalalfrz/sirclo-weight-test/weight/mocks"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var pathVariable = regexp.MustCompile(`{([^}:]+)}`)
+
+// newRouter returns the router of the application with the authentication enabled, as main builds it.
+func newRouter() *mux.Router {
+	logger := logrus.New()
+	r, _ := router.New(router.Property{
+		Logger:         logger,
+		Validator:      validator.New(),
+		Metrics:        metrics.NewMetrics(),
+		State:          func() lifecycle.State { return lifecycle.StateReady },
+		WeightUsecase:  new(weightmocks.Usecase),
+		WebhookUsecase: new(webhookmocks.Usecase),
+		Broadcaster:    weight.NewBroadcaster(0),
+		Auth:           &middleware.AuthProperty{Logger: logger, Secret: "s3cret"},
+	})
+	return r
+}
+
+func TestSpec_Success_CoversRoutes(t *testing.T) {
+	spec := openapi.Spec()
+
+	routes := 0
+	err := newRouter().Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return err
+		}
+
+		for _, method := range methods {
+			routes++
+			operations, ok := spec.Paths[path]
+			if assert.True(t, ok, "should describe the path %s", path) {
+				assert.NotNil(t, operations[strings.ToLower(method)], "should describe %s %s", method, path)
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.NotZero(t, routes)
+}
+
+func TestSpec_Success_PathParameters(t *testing.T) {
+	for path, operations := range openapi.Spec().Paths {
+		for method, operation := range operations {
+			declared := make(map[string]bool)
+			for _, parameter := range operation.Parameters {
+				if parameter.In == "path" {
+					declared[parameter.Name] = true
+				}
+			}
+
+			variables := pathVariable.FindAllStringSubmatch(path, -1)
+			for _, variable := range variables {
+				assert.True(t, declared[variable[1]], "should declare the path parameter %s of %s %s", variable[1], method, path)
+			}
+			assert.Len(t, declared, len(variables), "should only declare the path parameters of %s %s", method, path)
+			assert.NotEmpty(t, operation.Responses, "should describe the responses of %s %s", method, path)
+		}
+	}
+}
+
+func TestSpec_Success_References(t *testing.T) {
+	spec := openapi.Spec()
+	document, err := json.Marshal(spec)
+	require.NoError(t, err)
+
+	refs := regexp.MustCompile(`"\$ref":"#/components/schemas/([^"]+)"`).FindAllStringSubmatch(string(document), -1)
+	assert.NotEmpty(t, refs)
+	for _, ref := range refs {
+		assert.Contains(t, spec.Components.Schemas, ref[1], "should resolve the reference")
+	}
+}
+
+func TestSchemaOf_Success(t *testing.T) {
+	schemas := make(map[string]*openapi.Schema)
+
+	schema := openapi.SchemaOf([]model.WeightRevisionResponse{}, schemas)
+
+	assert.Equal(t, "array", schema.Type)
+	assert.Equal(t, "#/components/schemas/WeightRevisionResponse", schema.Items.Ref)
+	revision := schemas["WeightRevisionResponse"]
+	if assert.NotNil(t, revision) {
+		assert.True(t, revision.Properties["before"].Nullable, "should be nullable for a pointer")
+		assert.Equal(t, "string", revision.Properties["requestId"].Type, "should be named by the json tag")
+	}
+	assert.Contains(t, schemas, "WeighDetailResponse", "should add the nested structs")
+
+	openapi.SchemaOf(model.WebhookPayload{}, schemas)
+	assert.Equal(t, []string{"url"}, schemas["WebhookPayload"].Required, "should require the fields which are validated as required")
+}
+
+func TestHTTPHandler_OpenAPI_Success(t *testing.T) {
+	router := newRouter()
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, openapi.Path, nil))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	var document map[string]interface{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &document))
+	assert.Equal(t, "3.0.3", document["openapi"])
+}
+
+func TestHTTPHandler_SwaggerUI_Success(t *testing.T) {
+	router := newRouter()
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, openapi.DocsPath, nil))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `url: "/openapi.json"`)
+	assert.NotContains(t, recorder.Body.String(), "https://", "should not load the assets from elsewhere")
+}
+
+func TestHTTPHandler_Asset_Success(t *testing.T) {
+	router := newRouter()
+
+	for path, contentType := range map[string]string{
+		openapi.StyleSheetPath: "text/css",
+		openapi.ScriptPath:     "text/javascript",
+	} {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+
+		assert.Equal(t, http.StatusOK, recorder.Code, path)
+		assert.Contains(t, recorder.Header().Get("Content-Type"), contentType, path)
+		assert.NotZero(t, recorder.Body.Len(), path)
+	}
+}
+
+func TestHTTPHandler_Asset_Error_NotFound(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	newRouter().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, openapi.DocsPath+"/LICENSE", nil))
+
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
@@ -0,0 +1,90 @@
+package openapi
+
+import (
+	"reflect"
+	"strings"
+)
+
+const componentsPath = "#/components/schemas/"
+
+// Ref returns a reference to the component schema of name.
+func Ref(name string) *Schema {
+	return &Schema{Ref: componentsPath + name}
+}
+
+// SchemaOf returns the schema of the json encoding of v. The named structs are added to schemas
+// and referenced, a field is required when its validate tag is required.
+func SchemaOf(v interface{}, schemas map[string]*Schema) *Schema {
+	return schemaOf(reflect.TypeOf(v), schemas)
+}
+
+func schemaOf(t reflect.Type, schemas map[string]*Schema) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return &Schema{AllOf: []*Schema{schemaOf(t.Elem(), schemas)}, Nullable: true}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: schemaOf(t.Elem(), schemas)}
+	case reflect.Map:
+		return &Schema{Type: "object"}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return structSchema(t, schemas)
+		}
+		if _, ok := schemas[t.Name()]; !ok {
+			// the placeholder stops the recursion of a self referencing struct.
+			schemas[t.Name()] = &Schema{}
+			*schemas[t.Name()] = *structSchema(t, schemas)
+		}
+		return Ref(t.Name())
+	}
+
+	// an interface is any value.
+	return &Schema{}
+}
+
+func structSchema(t reflect.Type, schemas map[string]*Schema) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := field.Name
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			if tag == "-" {
+				continue
+			}
+			if tagName := strings.Split(tag, ",")[0]; tagName != "" {
+				name = tagName
+			}
+		}
+
+		schema.Properties[name] = schemaOf(field.Type, schemas)
+		for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+			if rule == "required" {
+				schema.Required = append(schema.Required, name)
+			}
+		}
+	}
+	return schema
+}
@@ -0,0 +1,419 @@
+package openapi
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ijalalfrz/sirclo-weight-test/model"
+	"github.com/ijalalfrz/sirclo-weight-test/response"
+)
+
+// collection of tag
+const (
+	tagWeight  = "weight"
+	tagWebhook = "webhook"
+	tagHealth  = "health"
+	tagPage    = "page"
+	tagAuth    = "auth"
+	tagDocs    = "docs"
+)
+
+// collection of media type
+const (
+	mediaJSON       = "application/json"
+	mediaHTML       = "text/html"
+	mediaCSV        = "text/csv"
+	mediaXLSX       = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	mediaForm       = "application/x-www-form-urlencoded"
+	mediaMultipart  = "multipart/form-data"
+	mediaStream     = "text/event-stream"
+	mediaText       = "text/plain"
+	mediaCSS        = "text/css"
+	mediaJavaScript = "text/javascript"
+)
+
+// collection of security scheme
+const (
+	securityBearer  = "bearerAuth"
+	securitySession = "sessionCookie"
+)
+
+// statuses are the values of the status of the envelope, see response.
+var statuses = []string{
+	response.StatOK,
+	response.StatCreated,
+	response.StatNotFound,
+	response.StatUnexpectedError,
+	response.StatInsufficientPoint,
+	response.StatusInvalidPayload,
+	response.StatUnauthorized,
+	response.StatAlreadyExist,
+	response.StatBadRequest,
+	response.StatUnavailable,
+	response.StatVersionConflict,
+	response.StatPreconditionFailed,
+}
+
+// Spec returns the OpenAPI document of every route of the service.
+func Spec() Document {
+	schemas := make(map[string]*Schema)
+	schemas["Response"] = &Schema{
+		Type:        "object",
+		Description: "Envelope of every json response. success is false for an error, then data is usually null.",
+		Properties: map[string]*Schema{
+			"success": {Type: "boolean"},
+			"data":    {Description: "Payload of the response, its schema depends on the operation."},
+			"message": {Type: "string"},
+			"status":  {Type: "string", Enum: statuses},
+			"code":    {Type: "integer", Format: "int32", Description: "HTTP status code of the response."},
+			"meta":    {Description: "Pagination of a list, omitted when it is not set."},
+		},
+		Required: []string{"success", "data", "message", "status", "code"},
+	}
+
+	payload := SchemaOf(model.WeightPayload{}, schemas)
+	weightPayload := schemas["WeightPayload"]
+	weightPayload.Description = "Either day or date is required. On an update the date of the path is used and version is the expected version, zero means unconditional."
+	weightPayload.Required = []string{"max", "min"}
+	weightPayload.Properties["date"].Description = "Unix nano of the date in the timezone of the user."
+	weightPayload.Properties["day"].Example = "2022-07-01"
+	weightPayload.Properties["max"].Example = 51
+	weightPayload.Properties["min"].Example = 50
+
+	b := builder{schemas: schemas}
+	paths := map[string]PathItem{
+		"/": {
+			"get": b.public(tagHealth, "Reports whether the application is serving", b.json(http.StatusOK, nil), b.json(http.StatusServiceUnavailable, nil)),
+		},
+		"/metrics": {
+			"get": b.public(tagHealth, "Prometheus metrics", file("Metrics in the Prometheus text format.", content(mediaText, &Schema{Type: "string"}))),
+		},
+		"/healthz": {
+			"get": b.public(tagHealth, "Reports that the process is alive", b.json(http.StatusOK, nil)),
+		},
+		"/readyz": {
+			"get": b.public(tagHealth, "Reports the status and latency of each dependency",
+				b.json(http.StatusOK, model.HealthResponse{}), b.json(http.StatusServiceUnavailable, model.HealthResponse{})),
+		},
+		Path: {
+			"get": b.public(tagDocs, "This document", file("OpenAPI 3 document.", content(mediaJSON, &Schema{Type: "object"}))),
+		},
+		DocsPath: {
+			"get": b.public(tagDocs, "Swagger UI of this document", html()),
+		},
+		StyleSheetPath: {
+			"get": b.public(tagDocs, "Style sheet of the Swagger UI", file("swagger-ui.css of swagger-ui-dist.", content(mediaCSS, &Schema{Type: "string"}))),
+		},
+		ScriptPath: {
+			"get": b.public(tagDocs, "Script of the Swagger UI", file("swagger-ui-bundle.js of swagger-ui-dist.", content(mediaJavaScript, &Schema{Type: "string"}))),
+		},
+		"/login": {
+			"get":  b.public(tagAuth, "Login page", html()),
+			"post": b.public(tagAuth, "Signs in, sets the session cookie and redirects to next", redirect(), html()).with(formBody("username", "password", "next")),
+		},
+		"/logout": {
+			"post": b.public(tagAuth, "Signs out and redirects to the login page", redirect()),
+		},
+
+		"/api/v1/weights": {
+			"get": b.op(tagWeight, "Lists the weights with their averages",
+				b.jsonMeta(http.StatusOK, model.WeightResponse{}, model.WeightMeta{}), b.json(http.StatusBadRequest, nil)).
+				with(queryDate("from"), queryDate("to"), queryInt("page", "Page, starting at 1."), queryInt("limit", "Page size, 30 by default and 100 at most."),
+					queryEnum("sortBy", "Sorted field, date by default.", "date", "max", "min", "diff"), queryEnum("sort", "Sort order, desc by default.", "asc", "desc")),
+			"post": b.op(tagWeight, "Inserts the weight of a date",
+				b.json(http.StatusCreated, nil), b.json(http.StatusBadRequest, nil), b.json(http.StatusConflict, nil)).
+				with(jsonBody(payload)),
+		},
+		"/api/v1/weights/statistics": {
+			"get": b.op(tagWeight, "Summarizes the weights by period",
+				b.json(http.StatusOK, []model.StatisticResponse{}), b.json(http.StatusBadRequest, nil), b.json(http.StatusNotFound, nil)).
+				with(queryDate("from"), queryDate("to"), queryEnum("groupBy", "Period, week by default.", "week", "month", "year")),
+		},
+		"/api/v1/weights/import": {
+			"post": b.op(tagWeight, "Imports the weights of a csv of date,max,min rows",
+				b.json(http.StatusOK, model.ImportReport{}), b.json(http.StatusBadRequest, nil)).
+				with(queryEnum("mode", "overwrite updates the existing dates, they are skipped otherwise.", "overwrite"), csvBody()),
+		},
+		"/api/v1/weights/{date}": {
+			"get": b.op(tagWeight, "Finds the weight of a date",
+				b.json(http.StatusOK, model.WeighDetailResponse{}).withHeader("ETag", "Version of the weight, for If-Match."),
+				b.json(http.StatusBadRequest, nil), b.json(http.StatusNotFound, nil)).
+				with(pathDate()),
+			"put": b.op(tagWeight, "Updates the weight of a date, or creates it with mode=upsert",
+				b.json(http.StatusOK, nil), b.json(http.StatusCreated, nil), b.json(http.StatusBadRequest, nil), b.json(http.StatusNotFound, nil),
+				b.json(http.StatusConflict, nil), b.json(http.StatusPreconditionFailed, nil)).
+				with(pathDate(), queryEnum("mode", "upsert creates a missing date.", "upsert"),
+					header("If-Match", "ETag of the weight, * matches any version. The weight must exist, it is never created and 412 is responded otherwise. It takes precedence over the version of the body."), jsonBody(payload)),
+			"delete": b.op(tagWeight, "Deletes the weight of a date",
+				b.json(http.StatusOK, nil), b.json(http.StatusBadRequest, nil), b.json(http.StatusNotFound, nil)).
+				with(pathDate()),
+		},
+		"/api/v1/weights/{date}/history": {
+			"get": b.op(tagWeight, "Lists the revisions of the weight of a date, the newest first",
+				b.json(http.StatusOK, []model.WeightRevisionResponse{}), b.json(http.StatusBadRequest, nil)).
+				with(pathDate()),
+		},
+		"/api/v1/weights/{date}/history/{revision}/restore": {
+			"post": b.op(tagWeight, "Restores the weight of a date as it was after the revision, or before a delete",
+				b.json(http.StatusOK, nil), b.json(http.StatusBadRequest, nil), b.json(http.StatusNotFound, nil)).
+				with(pathDate(), path("revision", "Id of the revision.")),
+		},
+
+		"/api/v1/webhooks": {
+			"get": b.op(tagWebhook, "Lists the webhook subscriptions",
+				b.json(http.StatusOK, []model.WebhookResponse{})),
+			"post": b.op(tagWebhook, "Subscribes a url to the weight events, the secret is only returned here",
+				b.json(http.StatusCreated, model.WebhookResponse{}), b.json(http.StatusBadRequest, nil)).
+				with(jsonBody(SchemaOf(model.WebhookPayload{}, schemas))),
+		},
+		"/api/v1/webhooks/{id}": {
+			"get": b.op(tagWebhook, "Finds a webhook subscription",
+				b.json(http.StatusOK, model.WebhookResponse{}), b.json(http.StatusNotFound, nil)).
+				with(path("id", "Id of the subscription.")),
+			"delete": b.op(tagWebhook, "Deletes a webhook subscription",
+				b.json(http.StatusOK, nil), b.json(http.StatusNotFound, nil)).
+				with(path("id", "Id of the subscription.")),
+		},
+		"/api/v1/webhooks/{id}/deliveries": {
+			"get": b.op(tagWebhook, "Lists the latest deliveries of a subscription, the newest first",
+				b.json(http.StatusOK, []model.WebhookDeliveryResponse{}), b.json(http.StatusNotFound, nil)).
+				with(path("id", "Id of the subscription.")),
+		},
+		"/api/v1/webhooks/{id}/deliveries/{delivery}/redeliver": {
+			"post": b.op(tagWebhook, "Schedules a delivery again",
+				b.json(http.StatusOK, nil), b.json(http.StatusNotFound, nil)).
+				with(path("id", "Id of the subscription."), path("delivery", "Id of the delivery.")),
+		},
+
+		"/weight": {
+			"get":  b.op(tagPage, "Weight list page", html()).with(queryDate("from"), queryDate("to"), queryInt("page", ""), queryInt("limit", ""), query("sortBy", ""), query("sort", "")),
+			"post": b.op(tagPage, "Inserts the weight of the form, or upserts it with mode=upsert, and redirects to the form", redirect(), html()).with(formBody("date", "max", "min", "mode")),
+		},
+		"/weight/add": {
+			"get": b.op(tagPage, "Insert form page", html()),
+		},
+		"/weight/import": {
+			"get":  b.op(tagPage, "Import form page", html()),
+			"post": b.op(tagPage, "Imports the csv of the form and shows the report", html()).with(multipartBody("file", "mode")),
+		},
+		"/weight/export": {
+			"get": b.op(tagPage, "Exports the weights as a file",
+				file("Attachment of the weights in the format.", map[string]MediaType{
+					mediaCSV:  {Schema: &Schema{Type: "string"}},
+					mediaXLSX: {Schema: &Schema{Type: "string", Format: "binary"}},
+					mediaJSON: {Schema: &Schema{Type: "array", Items: SchemaOf(model.WeightExportResponse{}, schemas)}},
+				}), b.json(http.StatusBadRequest, nil)).
+				with(queryEnum("format", "File format, csv by default.", "csv", "xlsx", "json"), queryDate("from"), queryDate("to"), query("sortBy", ""), query("sort", "")),
+		},
+		"/weight/stream": {
+			"get": b.op(tagPage, "Server-sent events of the weights of the user",
+				file("Stream of weight.created, weight.updated and weight.deleted events whose data is the WeighDetailResponse. It ends after 45 seconds and the client reconnects.",
+					content(mediaStream, &Schema{Type: "string"})), b.json(http.StatusServiceUnavailable, nil)),
+		},
+		"/weight/{date}": {
+			"get":  b.op(tagPage, "Weight detail page", html()).with(pathDate()),
+			"post": b.op(tagPage, "Updates the weight of the form, or upserts it with mode=upsert, and redirects to the list", redirect(), html()).with(pathDate(), formBody("max", "min", "version", "mode")),
+		},
+		"/weight/{date}/update": {
+			"get": b.op(tagPage, "Update form page", html()).with(pathDate()),
+		},
+		"/weight/{date}/delete": {
+			"post": b.op(tagPage, "Deletes the weight and redirects to the list", redirect(), html()).with(pathDate()),
+		},
+		"/weight/{date}/history": {
+			"get": b.op(tagPage, "Weight history page", html()).with(pathDate()),
+		},
+		"/weight/{date}/history/{revision}/restore": {
+			"post": b.op(tagPage, "Restores the revision and redirects to the history", redirect(), html()).with(pathDate(), path("revision", "Id of the revision.")),
+		},
+		"/webhook": {
+			"get":  b.op(tagPage, "Webhook list page", html()),
+			"post": b.op(tagPage, "Subscribes the url of the form and shows its secret once", html()).with(formBody("url", "secret", "events")),
+		},
+		"/webhook/{id}/delete": {
+			"post": b.op(tagPage, "Deletes the subscription and redirects to the list", redirect(), html()).with(path("id", "Id of the subscription.")),
+		},
+		"/webhook/{id}/deliveries": {
+			"get": b.op(tagPage, "Delivery log page", html()).with(path("id", "Id of the subscription.")),
+		},
+		"/webhook/{id}/deliveries/{delivery}/redeliver": {
+			"post": b.op(tagPage, "Schedules the delivery again and redirects to the log", redirect(), html()).
+				with(path("id", "Id of the subscription."), path("delivery", "Id of the delivery.")),
+		},
+	}
+
+	return Document{
+		OpenAPI: "3.0.3",
+		Info: Info{
+			Title:       "Weight Service",
+			Description: "Daily max and min weight of each user. Every json response is wrapped in the Response envelope.",
+			Version:     "1.0.0",
+		},
+		Tags: []Tag{
+			{Name: tagWeight, Description: "Weight api"},
+			{Name: tagWebhook, Description: "Webhook subscriptions of the weight events"},
+			{Name: tagPage, Description: "Html pages, they use the session cookie"},
+			{Name: tagAuth, Description: "Session of the pages"},
+			{Name: tagHealth, Description: "Probes and metrics"},
+			{Name: tagDocs, Description: "This documentation"},
+		},
+		Paths: paths,
+		Components: Components{
+			Schemas: schemas,
+			SecuritySchemes: map[string]SecurityScheme{
+				securityBearer:  {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+				securitySession: {Type: "apiKey", In: "cookie", Name: "session"},
+			},
+		},
+		Security: []map[string][]string{{securityBearer: {}}, {securitySession: {}}},
+	}
+}
+
+// builder builds the operations, the schemas of their data are added to schemas.
+type builder struct {
+	schemas map[string]*Schema
+}
+
+// reply is a response of an operation with its status code.
+type reply struct {
+	code     int
+	response Response
+}
+
+// op is an operation which requires authentication when it is enabled.
+func (b builder) op(tag string, summary string, replies ...reply) *Operation {
+	operation := &Operation{
+		Tags:      []string{tag},
+		Summary:   summary,
+		Responses: make(map[string]Response),
+	}
+	for _, r := range replies {
+		operation.Responses[strconv.Itoa(r.code)] = r.response
+	}
+	return operation
+}
+
+// public is an operation which does not require authentication.
+func (b builder) public(tag string, summary string, replies ...reply) *Operation {
+	operation := b.op(tag, summary, replies...)
+	operation.Security = &[]map[string][]string{}
+	return operation
+}
+
+// json is a response of the envelope whose data is the schema of data, nil data is null.
+func (b builder) json(code int, data interface{}) reply {
+	return b.jsonMeta(code, data, nil)
+}
+
+// jsonMeta is a response of the envelope whose data and meta are the schemas of data and meta.
+func (b builder) jsonMeta(code int, data interface{}, meta interface{}) reply {
+	properties := make(map[string]*Schema)
+	if data != nil {
+		properties["data"] = SchemaOf(data, b.schemas)
+	}
+	if meta != nil {
+		properties["meta"] = SchemaOf(meta, b.schemas)
+	}
+
+	schema := Ref("Response")
+	if len(properties) > 0 {
+		schema = &Schema{AllOf: []*Schema{schema, {Type: "object", Properties: properties}}}
+	}
+	return reply{code: code, response: Response{
+		Description: http.StatusText(code),
+		Content:     content(mediaJSON, schema),
+	}}
+}
+
+func (r reply) withHeader(name string, description string) reply {
+	r.response.Headers = map[string]Header{name: {Description: description, Schema: &Schema{Type: "string"}}}
+	return r
+}
+
+// with appends the parameters and the request body of the operation.
+func (operation *Operation) with(parts ...interface{}) *Operation {
+	for _, part := range parts {
+		switch p := part.(type) {
+		case Parameter:
+			operation.Parameters = append(operation.Parameters, p)
+		case *RequestBody:
+			operation.RequestBody = p
+		}
+	}
+	return operation
+}
+
+func html() reply {
+	return reply{code: http.StatusOK, response: Response{Description: "Html page.", Content: content(mediaHTML, &Schema{Type: "string"})}}
+}
+
+func redirect() reply {
+	return reply{code: http.StatusSeeOther, response: Response{Description: "Redirect to the next page.", Headers: map[string]Header{"Location": {Schema: &Schema{Type: "string"}}}}}
+}
+
+func file(description string, contents map[string]MediaType) reply {
+	return reply{code: http.StatusOK, response: Response{Description: description, Content: contents}}
+}
+
+func content(mediaType string, schema *Schema) map[string]MediaType {
+	return map[string]MediaType{mediaType: {Schema: schema}}
+}
+
+func path(name string, description string) Parameter {
+	return Parameter{Name: name, In: "path", Description: description, Required: true, Schema: &Schema{Type: "string"}}
+}
+
+func pathDate() Parameter {
+	return Parameter{Name: "date", In: "path", Description: "Date as 2006-01-02, or unix nano in the timezone of the user.", Required: true, Schema: &Schema{Type: "string", Example: "2022-07-01"}}
+}
+
+func query(name string, description string) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: &Schema{Type: "string"}}
+}
+
+func queryDate(name string) Parameter {
+	return Parameter{Name: name, In: "query", Description: "Inclusive date as 2006-01-02.", Schema: &Schema{Type: "string", Format: "date"}}
+}
+
+func queryInt(name string, description string) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: &Schema{Type: "integer", Format: "int64"}}
+}
+
+func queryEnum(name string, description string, values ...string) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: &Schema{Type: "string", Enum: values}}
+}
+
+func header(name string, description string) Parameter {
+	return Parameter{Name: name, In: "header", Description: description, Schema: &Schema{Type: "string"}}
+}
+
+func jsonBody(schema *Schema) *RequestBody {
+	return &RequestBody{Required: true, Content: content(mediaJSON, schema)}
+}
+
+func csvBody() *RequestBody {
+	return &RequestBody{Required: true, Content: map[string]MediaType{
+		mediaCSV:       {Schema: &Schema{Type: "string", Example: "date,max,min\n2022-07-01,51,50"}},
+		mediaMultipart: {Schema: formSchema("file")},
+	}}
+}
+
+func formBody(fields ...string) *RequestBody {
+	return &RequestBody{Required: true, Content: content(mediaForm, formSchema(fields...))}
+}
+
+func multipartBody(fields ...string) *RequestBody {
+	return &RequestBody{Required: true, Content: content(mediaMultipart, formSchema(fields...))}
+}
+
+func formSchema(fields ...string) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for _, field := range fields {
+		schema.Properties[field] = &Schema{Type: "string"}
+		if strings.EqualFold(field, "file") {
+			schema.Properties[field].Format = "binary"
+		}
+	}
+	return schema
+}
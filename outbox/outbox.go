@@ -0,0 +1,55 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/ijalalfrz/sirclo-weight-test/entity"
+)
+
+// collection of outbox storage name
+const (
+	CollectionName = "outbox"
+	TableName      = "outbox"
+)
+
+// Repository is collection of behaviour of the outbox storage.
+// FindPending returns the oldest events which are due at now, of the keys which have no event
+// waiting for a retry after now, so a blocked key never fills the batch of the others.
+type Repository interface {
+	InsertMany(ctx context.Context, events []entity.Event) (err error)
+	FindPending(ctx context.Context, now int64, limit int64) (events []entity.Event, err error)
+	DeleteOne(ctx context.Context, id string) (err error)
+	MarkFailed(ctx context.Context, id string, attempts int, nextAttemptAt int64, lastError string) (err error)
+}
+
+// Publisher is collection of behaviour of a message broker.
+// Publish returns once the broker has acknowledged the event, an event may be published more than once.
+type Publisher interface {
+	Publish(ctx context.Context, event entity.Event) (err error)
+}
+
+// Transactor is collection of behaviour of a storage which can write atomically. Every write given the context
+// of fn is committed when fn returns no error and discarded otherwise.
+type Transactor interface {
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) (err error)
+}
+
+// Message is the envelope of a published event, Data is the payload of the event.
+type Message struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// NewMessage is a constructor.
+func NewMessage(event entity.Event) Message {
+	return Message{
+		ID:         event.ID,
+		Type:       event.Type,
+		OccurredAt: time.Unix(0, event.CreatedAt).UTC(),
+		Data:       json.RawMessage(event.Payload),
+	}
+}
@@ -0,0 +1,76 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/ijalalfrz/sirclo-weight-test/entity"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl/plain"
+)
+
+// collection of kafka message header
+const (
+	headerEventID   = "event-id"
+	headerEventType = "event-type"
+)
+
+// KafkaProperty is a property of KafkaPublisher. SASL/PLAIN is used when Username is set.
+type KafkaProperty struct {
+	Brokers  []string
+	Topic    string
+	Username string
+	Password string
+	Timeout  time.Duration
+}
+
+// KafkaPublisher is a Publisher which writes every event to a Kafka topic as a JSON Message.
+// The key of the event is the key of the message, so the events of a key land on the same partition in order.
+type KafkaPublisher struct {
+	writer *kafka.Writer
+}
+
+// NewKafkaPublisher is a constructor. A message is acknowledged once every in-sync replica has it.
+func NewKafkaPublisher(property KafkaProperty) *KafkaPublisher {
+	dialer := &kafka.Dialer{Timeout: property.Timeout, DualStack: true}
+	if property.Username != "" {
+		dialer.SASLMechanism = plain.Mechanism{Username: property.Username, Password: property.Password}
+	}
+
+	writer := kafka.NewWriter(kafka.WriterConfig{
+		Brokers:  property.Brokers,
+		Topic:    property.Topic,
+		Dialer:   dialer,
+		Balancer: &kafka.Hash{},
+		// the relay retries and orders the events, the writer sends each of them on its own.
+		MaxAttempts:  1,
+		BatchSize:    1,
+		RequiredAcks: -1,
+		WriteTimeout: property.Timeout,
+	})
+	return &KafkaPublisher{writer}
+}
+
+func (p *KafkaPublisher) Publish(ctx context.Context, event entity.Event) (err error) {
+	value, err := json.Marshal(NewMessage(event))
+	if err != nil {
+		return
+	}
+
+	err = p.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(event.Key),
+		Value: value,
+		Headers: []kafka.Header{
+			{Key: headerEventID, Value: []byte(event.ID)},
+			{Key: headerEventType, Value: []byte(event.Type)},
+		},
+		Time: time.Unix(0, event.CreatedAt),
+	})
+	return
+}
+
+// Close flushes and closes the connections of the writer.
+func (p *KafkaPublisher) Close(ctx context.Context) (err error) {
+	return p.writer.Close()
+}
@@ -0,0 +1,46 @@
+package outbox_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ijalalfrz/sirclo-weight-test/outbox"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestKafkaPublisher_Publish_Success runs against the brokers of KAFKA_TEST_BROKERS, a topic which is created automatically is needed.
+func TestKafkaPublisher_Publish_Success(t *testing.T) {
+	brokers := os.Getenv("KAFKA_TEST_BROKERS")
+	if brokers == "" {
+		t.Skip("KAFKA_TEST_BROKERS is not set")
+	}
+	topic := "weight-events-test-" + time.Now().Format("20060102150405")
+
+	publisher := outbox.NewKafkaPublisher(outbox.KafkaProperty{
+		Brokers: strings.Split(brokers, ","),
+		Topic:   topic,
+		Timeout: time.Second * 10,
+	})
+	defer publisher.Close(context.TODO())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+	require.NoError(t, publisher.Publish(ctx, event("a", "john", 1656633600000000000)))
+
+	reader := kafka.NewReader(kafka.ReaderConfig{Brokers: strings.Split(brokers, ","), Topic: topic})
+	defer reader.Close()
+	message, err := reader.ReadMessage(ctx)
+	require.NoError(t, err)
+
+	var value outbox.Message
+	assert.NoError(t, json.Unmarshal(message.Value, &value))
+	assert.Equal(t, "john", string(message.Key))
+	assert.Equal(t, "a", value.ID)
+	assert.Equal(t, "weight.created", value.Type)
+}
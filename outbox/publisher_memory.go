@@ -0,0 +1,50 @@
+package outbox
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ijalalfrz/sirclo-weight-test/entity"
+)
+
+// MemoryBroker is a thread-safe Publisher which keeps the published events in memory, for tests and demos.
+type MemoryBroker struct {
+	mu     sync.Mutex
+	events []entity.Event
+	err    error
+}
+
+// NewMemoryBroker is a constructor.
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{}
+}
+
+// Publish records event, or returns the error set by SetError.
+func (b *MemoryBroker) Publish(ctx context.Context, event entity.Event) (err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.err != nil {
+		return b.err
+	}
+	b.events = append(b.events, event)
+	return
+}
+
+// SetError makes every following Publish fail with err until it is set to nil, as an unavailable broker.
+func (b *MemoryBroker) SetError(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.err = err
+}
+
+// Events returns the published events in the order they were published.
+func (b *MemoryBroker) Events() []entity.Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	events := make([]entity.Event, len(b.events))
+	copy(events, b.events)
+	return events
+}
@@ -0,0 +1,168 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// collection of relay default
+const (
+	defaultInterval   = time.Second
+	defaultBatchSize  = 100
+	defaultMaxBackoff = time.Minute * 5
+)
+
+const (
+	publishFailedMessage = "Publishing %s event %s failed at attempt %d, it is retried in %s: %v"
+	relayStoppedMessage  = "Outbox relay is stopped."
+)
+
+// RelayProperty is a property of Relay. Zero values are replaced by the defaults.
+type RelayProperty struct {
+	Logger     *logrus.Logger
+	Repository Repository
+	Publisher  Publisher
+	Interval   time.Duration
+	BatchSize  int64
+	MaxBackoff time.Duration
+}
+
+// Relay publishes the events of the outbox in background, at least once and in order per key.
+// A failed event is retried with an exponential backoff starting at Interval and capped at MaxBackoff.
+// The later events of its key wait for it, the events of the other keys are not held back.
+type Relay struct {
+	logger     *logrus.Logger
+	repository Repository
+	publisher  Publisher
+	interval   time.Duration
+	batchSize  int64
+	maxBackoff time.Duration
+	ctx        context.Context
+	cancel     context.CancelFunc
+	stop       chan struct{}
+	done       chan struct{}
+}
+
+// NewRelay is a constructor.
+func NewRelay(property RelayProperty) *Relay {
+	if property.Interval <= 0 {
+		property.Interval = defaultInterval
+	}
+	if property.BatchSize <= 0 {
+		property.BatchSize = defaultBatchSize
+	}
+	if property.MaxBackoff <= 0 {
+		property.MaxBackoff = defaultMaxBackoff
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Relay{
+		logger:     property.Logger,
+		repository: property.Repository,
+		publisher:  property.Publisher,
+		interval:   property.Interval,
+		batchSize:  property.BatchSize,
+		maxBackoff: property.MaxBackoff,
+		ctx:        ctx,
+		cancel:     cancel,
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+// Start polls the outbox every interval in background until Close is called.
+// Do not call this in goroutine.
+func (r *Relay) Start() {
+	go func() {
+		defer close(r.done)
+
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		for {
+			// a full batch means more events are waiting, they are published without waiting for the next tick.
+			published, err := r.PublishPending(r.ctx)
+			if err == nil && int64(published) == r.batchSize {
+				select {
+				case <-r.stop:
+					return
+				default:
+					continue
+				}
+			}
+
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Close stops polling and waits for the batch in flight until ctx is done, then it is canceled.
+// The events of a canceled batch stay in the outbox and are published on the next start.
+func (r *Relay) Close(ctx context.Context) (err error) {
+	close(r.stop)
+	select {
+	case <-r.done:
+	case <-ctx.Done():
+		r.cancel()
+		<-r.done
+		err = ctx.Err()
+	}
+	r.cancel()
+
+	r.logger.Info(relayStoppedMessage)
+	return
+}
+
+// PublishPending publishes a batch of the events of the outbox and removes the published ones.
+// An event is published again when it can not be removed, so the consumers must tolerate duplicates.
+func (r *Relay) PublishPending(ctx context.Context) (published int, err error) {
+	now := time.Now().UnixNano()
+	events, err := r.repository.FindPending(ctx, now, r.batchSize)
+	if err != nil {
+		return
+	}
+
+	// the keys waiting for a retry are left out by the query, the ones failing in this batch are held here.
+	blocked := make(map[string]bool)
+	for _, event := range events {
+		if blocked[event.Key] {
+			continue
+		}
+
+		if errPublish := r.publisher.Publish(ctx, event); errPublish != nil {
+			blocked[event.Key] = true
+			attempts := event.Attempts + 1
+			backoff := r.backoff(attempts)
+			r.logger.Warn(fmt.Sprintf(publishFailedMessage, event.Type, event.ID, attempts, backoff, errPublish))
+			if err = r.repository.MarkFailed(ctx, event.ID, attempts, now+int64(backoff), errPublish.Error()); err != nil {
+				return
+			}
+			continue
+		}
+
+		if err = r.repository.DeleteOne(ctx, event.ID); err != nil {
+			return
+		}
+		published++
+	}
+
+	return
+}
+
+// backoff returns the delay before the given attempt, it doubles at every attempt.
+func (r *Relay) backoff(attempts int) time.Duration {
+	backoff := r.interval
+	for i := 1; i < attempts && backoff < r.maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > r.maxBackoff {
+		backoff = r.maxBackoff
+	}
+	return backoff
+}
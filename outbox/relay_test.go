@@ -0,0 +1,130 @@
+package outbox_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/ijalalfrz/sirclo-weight-test/entity"
+	"github.com/ijalalfrz/sirclo-weight-test/outbox"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRelay(repo outbox.Repository, broker *outbox.MemoryBroker) *outbox.Relay {
+	return outbox.NewRelay(outbox.RelayProperty{
+		Logger:     logrus.New(),
+		Repository: repo,
+		Publisher:  broker,
+		Interval:   time.Millisecond * 10,
+		BatchSize:  10,
+		MaxBackoff: time.Hour,
+	})
+}
+
+func eventIDs(events []entity.Event) (ids []string) {
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+	return
+}
+
+func TestRelay_PublishPending_Success(t *testing.T) {
+	repo := outbox.NewMemoryRepository()
+	broker := outbox.NewMemoryBroker()
+	require.NoError(t, repo.InsertMany(context.TODO(), []entity.Event{event("a", "john", 1), event("b", "jane", 2), event("c", "john", 3)}))
+
+	published, err := newRelay(repo, broker).PublishPending(context.TODO())
+	assert.NoError(t, err, "should be no error")
+	assert.Equal(t, 3, published)
+	assert.Equal(t, []string{"a", "b", "c"}, eventIDs(broker.Events()))
+
+	pending, _ := repo.FindPending(context.TODO(), math.MaxInt64, 10)
+	assert.Empty(t, pending, "should remove the published events")
+}
+
+func TestRelay_PublishPending_Error_Retried(t *testing.T) {
+	repo := outbox.NewMemoryRepository()
+	broker := outbox.NewMemoryBroker()
+	relay := newRelay(repo, broker)
+	require.NoError(t, repo.InsertMany(context.TODO(), []entity.Event{event("a", "john", 1)}))
+
+	broker.SetError(errors.New("broker unavailable"))
+	published, err := relay.PublishPending(context.TODO())
+	assert.NoError(t, err, "should be no error")
+	assert.Zero(t, published)
+
+	pending, _ := repo.FindPending(context.TODO(), math.MaxInt64, 10)
+	if assert.Len(t, pending, 1, "should keep the event") {
+		assert.Equal(t, 1, pending[0].Attempts)
+		assert.Equal(t, "broker unavailable", pending[0].LastError)
+		assert.Greater(t, pending[0].NextAttemptAt, time.Now().UnixNano())
+	}
+
+	broker.SetError(nil)
+	published, _ = relay.PublishPending(context.TODO())
+	assert.Zero(t, published, "should wait for the backoff")
+
+	require.NoError(t, repo.MarkFailed(context.TODO(), "a", 1, time.Now().UnixNano(), "broker unavailable"))
+	published, _ = relay.PublishPending(context.TODO())
+	assert.Equal(t, 1, published, "should retry once the backoff is over")
+	assert.Equal(t, []string{"a"}, eventIDs(broker.Events()))
+}
+
+func TestRelay_PublishPending_Success_OrderedByKey(t *testing.T) {
+	repo := outbox.NewMemoryRepository()
+	broker := outbox.NewMemoryBroker()
+	future := time.Now().Add(time.Hour).UnixNano()
+	require.NoError(t, repo.InsertMany(context.TODO(), []entity.Event{event("a", "john", 1), event("b", "jane", 2), event("c", "john", 3)}))
+	require.NoError(t, repo.MarkFailed(context.TODO(), "a", 1, future, "broker unavailable"))
+
+	published, err := newRelay(repo, broker).PublishPending(context.TODO())
+	assert.NoError(t, err, "should be no error")
+	assert.Equal(t, 1, published)
+	assert.Equal(t, []string{"b"}, eventIDs(broker.Events()), "should hold the later events of a failed key only")
+}
+
+func TestRelay_PublishPending_Success_BlockedKeyDoesNotFillBatch(t *testing.T) {
+	repo := outbox.NewMemoryRepository()
+	broker := outbox.NewMemoryBroker()
+	for i := 0; i < 20; i++ {
+		require.NoError(t, repo.InsertMany(context.TODO(), []entity.Event{event(fmt.Sprintf("john-%d", i), "john", int64(i))}))
+	}
+	require.NoError(t, repo.InsertMany(context.TODO(), []entity.Event{event("jane-1", "jane", 100)}))
+	require.NoError(t, repo.MarkFailed(context.TODO(), "john-0", 1, time.Now().Add(time.Hour).UnixNano(), "broker unavailable"))
+
+	published, err := newRelay(repo, broker).PublishPending(context.TODO())
+	assert.NoError(t, err, "should be no error")
+	assert.Equal(t, 1, published)
+	assert.Equal(t, []string{"jane-1"}, eventIDs(broker.Events()), "should reach the events behind a blocked key")
+}
+
+func TestRelay_Start_Success(t *testing.T) {
+	repo := outbox.NewMemoryRepository()
+	broker := outbox.NewMemoryBroker()
+	relay := newRelay(repo, broker)
+
+	relay.Start()
+	for i := 0; i < 25; i++ {
+		require.NoError(t, repo.InsertMany(context.TODO(), []entity.Event{event(string(rune('a'+i)), "john", int64(i))}))
+	}
+
+	assert.Eventually(t, func() bool {
+		return len(broker.Events()) == 25
+	}, time.Second, time.Millisecond*10, "should publish every event")
+	assert.NoError(t, relay.Close(context.TODO()))
+	assert.Equal(t, "a", broker.Events()[0].ID)
+}
+
+func TestNewMessage(t *testing.T) {
+	message := outbox.NewMessage(event("a", "john", 1656633600000000000))
+
+	content, err := json.Marshal(message)
+	assert.NoError(t, err, "should be no error")
+	assert.JSONEq(t, `{"id":"a","type":"weight.created","occurred_at":"2022-07-01T00:00:00Z","data":{"max":3}}`, string(content))
+}
@@ -0,0 +1,138 @@
+package outbox
+
+import (
+	"context"
+
+	"github.com/ijalalfrz/sirclo-weight-test/entity"
+	"github.com/ijalalfrz/sirclo-weight-test/exception"
+	"github.com/ijalalfrz/sirclo-weight-test/mongodb"
+	"github.com/ijalalfrz/sirclo-weight-test/requestctx"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type outboxRepository struct {
+	logger *logrus.Logger
+	col    mongodb.Collection
+}
+
+// NewOutboxRepository is a constructor of a Repository on outbox collection.
+// The events are inserted in the transaction carried by the context, if any.
+func NewOutboxRepository(logger *logrus.Logger, db mongodb.Database) Repository {
+	col := db.Collection(CollectionName)
+	return &outboxRepository{logger, col}
+}
+
+func (r outboxRepository) InsertMany(ctx context.Context, events []entity.Event) (err error) {
+	if len(events) < 1 {
+		return
+	}
+
+	documents := make([]interface{}, len(events))
+	for i, event := range events {
+		documents[i] = event
+	}
+
+	if _, err = r.col.InsertMany(ctx, documents); err != nil {
+		r.log(ctx).Error(err)
+		err = exception.ErrInternalServer
+		return
+	}
+	return
+}
+
+// FindPending returns the oldest due events first. The keys waiting for a retry are looked up first,
+// there is at most one such event per key as the later ones wait for it.
+func (r outboxRepository) FindPending(ctx context.Context, now int64, limit int64) (events []entity.Event, err error) {
+	blocked, err := r.blockedKeys(ctx, now)
+	if err != nil {
+		return
+	}
+
+	filter := bson.M{
+		"nextAttemptAt": bson.M{"$lte": now},
+		"key":           bson.M{"$nin": blocked},
+	}
+	opt := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(limit)
+
+	cursor, err := r.col.Find(ctx, filter, opt)
+	if err != nil {
+		r.log(ctx).Error(err)
+		err = exception.ErrInternalServer
+		return
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		event := entity.Event{}
+		if err = cursor.Decode(&event); err != nil {
+			r.log(ctx).Error(err)
+			err = exception.ErrInternalServer
+			return
+		}
+
+		events = append(events, event)
+	}
+
+	return
+}
+
+// blockedKeys returns the keys of the events waiting for a retry after now.
+func (r outboxRepository) blockedKeys(ctx context.Context, now int64) (keys []string, err error) {
+	keys = []string{}
+	opt := options.Find().SetProjection(bson.M{"key": 1})
+
+	cursor, err := r.col.Find(ctx, bson.M{"nextAttemptAt": bson.M{"$gt": now}}, opt)
+	if err != nil {
+		r.log(ctx).Error(err)
+		err = exception.ErrInternalServer
+		return
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		event := entity.Event{}
+		if err = cursor.Decode(&event); err != nil {
+			r.log(ctx).Error(err)
+			err = exception.ErrInternalServer
+			return
+		}
+
+		keys = append(keys, event.Key)
+	}
+
+	return
+}
+
+func (r outboxRepository) DeleteOne(ctx context.Context, id string) (err error) {
+	if _, err = r.col.DeleteOne(ctx, bson.M{"_id": id}); err != nil {
+		r.log(ctx).Error(err)
+		err = exception.ErrInternalServer
+		return
+	}
+	return
+}
+
+func (r outboxRepository) MarkFailed(ctx context.Context, id string, attempts int, nextAttemptAt int64, lastError string) (err error) {
+	update := bson.M{
+		"$set": bson.M{
+			"attempts":      attempts,
+			"nextAttemptAt": nextAttemptAt,
+			"lastError":     lastError,
+		},
+	}
+
+	if _, err = r.col.UpdateOne(ctx, bson.M{"_id": id}, update); err != nil {
+		r.log(ctx).Error(err)
+		err = exception.ErrInternalServer
+		return
+	}
+	return
+}
+
+func (r outboxRepository) log(ctx context.Context) *logrus.Entry {
+	return requestctx.Logger(ctx, r.logger)
+}
@@ -0,0 +1,87 @@
+package outbox
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/ijalalfrz/sirclo-weight-test/entity"
+)
+
+// memoryRepository is a thread-safe Repository which keeps the events in memory,
+// it serves the storage drivers without an outbox collection.
+type memoryRepository struct {
+	mu     sync.Mutex
+	events []entity.Event
+}
+
+// NewMemoryRepository is a constructor.
+func NewMemoryRepository() Repository {
+	return &memoryRepository{}
+}
+
+func (r *memoryRepository) InsertMany(ctx context.Context, events []entity.Event) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, events...)
+	return
+}
+
+// FindPending returns the oldest due events first.
+func (r *memoryRepository) FindPending(ctx context.Context, now int64, limit int64) (events []entity.Event, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	blocked := make(map[string]bool)
+	for _, event := range r.events {
+		if event.NextAttemptAt > now {
+			blocked[event.Key] = true
+		}
+	}
+
+	events = make([]entity.Event, 0, len(r.events))
+	for _, event := range r.events {
+		if !blocked[event.Key] {
+			events = append(events, event)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].CreatedAt != events[j].CreatedAt {
+			return events[i].CreatedAt < events[j].CreatedAt
+		}
+		return events[i].ID < events[j].ID
+	})
+	if limit > 0 && limit < int64(len(events)) {
+		events = events[:limit]
+	}
+	return
+}
+
+func (r *memoryRepository) DeleteOne(ctx context.Context, id string) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, event := range r.events {
+		if event.ID == id {
+			r.events = append(r.events[:i], r.events[i+1:]...)
+			return
+		}
+	}
+	return
+}
+
+func (r *memoryRepository) MarkFailed(ctx context.Context, id string, attempts int, nextAttemptAt int64, lastError string) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, event := range r.events {
+		if event.ID == id {
+			r.events[i].Attempts = attempts
+			r.events[i].NextAttemptAt = nextAttemptAt
+			r.events[i].LastError = lastError
+			return
+		}
+	}
+	return
+}
@@ -0,0 +1,117 @@
+package outbox
+
+import (
+	"context"
+
+	"github.com/ijalalfrz/sirclo-weight-test/entity"
+	"github.com/ijalalfrz/sirclo-weight-test/exception"
+	"github.com/ijalalfrz/sirclo-weight-test/requestctx"
+	"github.com/ijalalfrz/sirclo-weight-test/sqldb"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	eventColumns      = "id, type, event_key, payload, created_at, attempts, next_attempt_at, last_error"
+	insertEventQuery  = "INSERT INTO " + TableName + " (" + eventColumns + ") VALUES (?, ?, ?, ?, ?, ?, ?, ?)"
+	selectEventsQuery = "SELECT " + eventColumns + " FROM " + TableName + " WHERE next_attempt_at <= ? AND event_key NOT IN (" + blockedKeysQuery + ") ORDER BY created_at, id LIMIT ?"
+	blockedKeysQuery  = "SELECT event_key FROM " + TableName + " WHERE next_attempt_at > ?"
+	deleteEventQuery  = "DELETE FROM " + TableName + " WHERE id = ?"
+	markFailedQuery   = "UPDATE " + TableName + " SET attempts = ?, next_attempt_at = ?, last_error = ? WHERE id = ?"
+	createTableQuery  = "CREATE TABLE " + TableName + ` (
+	id TEXT PRIMARY KEY,
+	type TEXT NOT NULL,
+	event_key TEXT NOT NULL,
+	payload TEXT NOT NULL,
+	created_at BIGINT NOT NULL,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	next_attempt_at BIGINT NOT NULL DEFAULT 0,
+	last_error TEXT NOT NULL DEFAULT ''
+)`
+	createIndexQuery = "CREATE INDEX " + TableName + "_created_at_id_idx ON " + TableName + " (created_at, id)"
+)
+
+type sqlRepository struct {
+	logger *logrus.Logger
+	db     *sqldb.DB
+}
+
+// NewSQLRepository is a constructor of a Repository on outbox table of a PostgreSQL or SQLite database.
+// The events are inserted in the transaction carried by the context, if any.
+func NewSQLRepository(logger *logrus.Logger, db *sqldb.DB) Repository {
+	return &sqlRepository{logger, db}
+}
+
+// SQLStatements returns the statements which create outbox table, for the schema migrations of the service.
+func SQLStatements() []string {
+	return []string{createTableQuery, createIndexQuery}
+}
+
+func (r sqlRepository) InsertMany(ctx context.Context, events []entity.Event) (err error) {
+	for _, event := range events {
+		_, err = r.db.Executor(ctx).ExecContext(ctx, r.db.Rebind(insertEventQuery),
+			event.ID, event.Type, event.Key, string(event.Payload), event.CreatedAt, event.Attempts, event.NextAttemptAt, event.LastError)
+		if err != nil {
+			r.log(ctx).Error(err)
+			err = exception.ErrInternalServer
+			return
+		}
+	}
+	return
+}
+
+// FindPending returns the oldest due events first.
+func (r sqlRepository) FindPending(ctx context.Context, now int64, limit int64) (events []entity.Event, err error) {
+	rows, err := r.db.Executor(ctx).QueryContext(ctx, r.db.Rebind(selectEventsQuery), now, now, limit)
+	if err != nil {
+		r.log(ctx).Error(err)
+		err = exception.ErrInternalServer
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			event   entity.Event
+			payload string
+		)
+		if err = rows.Scan(&event.ID, &event.Type, &event.Key, &payload, &event.CreatedAt, &event.Attempts, &event.NextAttemptAt, &event.LastError); err != nil {
+			r.log(ctx).Error(err)
+			err = exception.ErrInternalServer
+			return
+		}
+
+		event.Payload = []byte(payload)
+		events = append(events, event)
+	}
+
+	if err = rows.Err(); err != nil {
+		r.log(ctx).Error(err)
+		err = exception.ErrInternalServer
+		return
+	}
+
+	return
+}
+
+func (r sqlRepository) DeleteOne(ctx context.Context, id string) (err error) {
+	if _, err = r.db.Executor(ctx).ExecContext(ctx, r.db.Rebind(deleteEventQuery), id); err != nil {
+		r.log(ctx).Error(err)
+		err = exception.ErrInternalServer
+		return
+	}
+	return
+}
+
+func (r sqlRepository) MarkFailed(ctx context.Context, id string, attempts int, nextAttemptAt int64, lastError string) (err error) {
+	_, err = r.db.Executor(ctx).ExecContext(ctx, r.db.Rebind(markFailedQuery), attempts, nextAttemptAt, lastError, id)
+	if err != nil {
+		r.log(ctx).Error(err)
+		err = exception.ErrInternalServer
+		return
+	}
+	return
+}
+
+func (r sqlRepository) log(ctx context.Context) *logrus.Entry {
+	return requestctx.Logger(ctx, r.logger)
+}
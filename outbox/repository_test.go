@@ -0,0 +1,211 @@
+package outbox_test
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/ijalalfrz/sirclo-weight-test/entity"
+	"github.com/ijalalfrz/sirclo-weight-test/exception"
+	"github.com/ijalalfrz/sirclo-weight-test/mongodb/mocks"
+	"github.com/ijalalfrz/sirclo-weight-test/outbox"
+	"github.com/ijalalfrz/sirclo-weight-test/sqldb"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func newSQLiteRepository(t *testing.T) (*sqldb.DB, outbox.Repository) {
+	db, err := sqldb.Open(sqldb.DriverSQLite, filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		db.Close()
+	})
+	for _, statement := range outbox.SQLStatements() {
+		_, err = db.Exec(statement)
+		require.NoError(t, err)
+	}
+	return db, outbox.NewSQLRepository(logrus.New(), db)
+}
+
+func event(id string, key string, createdAt int64) entity.Event {
+	return entity.Event{ID: id, Type: "weight.created", Key: key, Payload: []byte(`{"max":3}`), CreatedAt: createdAt}
+}
+
+// testRepositoryConformance runs the behaviour every outbox storage must have against the repository of newRepository.
+func testRepositoryConformance(t *testing.T, newRepository func(t *testing.T) outbox.Repository) {
+	t.Run("FindPending returns the oldest events first", func(t *testing.T) {
+		repo := newRepository(t)
+		require.NoError(t, repo.InsertMany(context.TODO(), []entity.Event{event("b", "john", 2), event("a", "jane", 1)}))
+		require.NoError(t, repo.InsertMany(context.TODO(), []entity.Event{event("c", "john", 3)}))
+
+		events, err := repo.FindPending(context.TODO(), math.MaxInt64, 2)
+		assert.NoError(t, err, "should be no error")
+		if assert.Len(t, events, 2) {
+			assert.Equal(t, event("a", "jane", 1), events[0])
+			assert.Equal(t, "b", events[1].ID)
+		}
+	})
+
+	t.Run("FindPending leaves out the keys waiting for a retry", func(t *testing.T) {
+		repo := newRepository(t)
+		var events []entity.Event
+		for i := int64(1); i <= 3; i++ {
+			events = append(events, event(fmt.Sprintf("john-%d", i), "john", i))
+		}
+		require.NoError(t, repo.InsertMany(context.TODO(), append(events, event("jane-1", "jane", 4), event("joe-1", "joe", 5))))
+		require.NoError(t, repo.MarkFailed(context.TODO(), "john-1", 1, 100, "broker unavailable"))
+		require.NoError(t, repo.MarkFailed(context.TODO(), "joe-1", 1, 50, "broker unavailable"))
+
+		pending, err := repo.FindPending(context.TODO(), 50, 2)
+		assert.NoError(t, err, "should be no error")
+		var ids []string
+		for _, event := range pending {
+			ids = append(ids, event.ID)
+		}
+		assert.Equal(t, []string{"jane-1", "joe-1"}, ids, "should not fill the batch with the events of a blocked key")
+	})
+
+	t.Run("DeleteOne removes the published event", func(t *testing.T) {
+		repo := newRepository(t)
+		require.NoError(t, repo.InsertMany(context.TODO(), []entity.Event{event("a", "john", 1), event("b", "john", 2)}))
+
+		assert.NoError(t, repo.DeleteOne(context.TODO(), "a"))
+		events, err := repo.FindPending(context.TODO(), math.MaxInt64, 10)
+		assert.NoError(t, err, "should be no error")
+		if assert.Len(t, events, 1) {
+			assert.Equal(t, "b", events[0].ID)
+		}
+	})
+
+	t.Run("MarkFailed records the attempt", func(t *testing.T) {
+		repo := newRepository(t)
+		require.NoError(t, repo.InsertMany(context.TODO(), []entity.Event{event("a", "john", 1)}))
+
+		assert.NoError(t, repo.MarkFailed(context.TODO(), "a", 2, 100, "broker unavailable"))
+		events, err := repo.FindPending(context.TODO(), math.MaxInt64, 10)
+		assert.NoError(t, err, "should be no error")
+		if assert.Len(t, events, 1) {
+			assert.Equal(t, 2, events[0].Attempts)
+			assert.Equal(t, int64(100), events[0].NextAttemptAt)
+			assert.Equal(t, "broker unavailable", events[0].LastError)
+		}
+	})
+}
+
+func TestMemoryRepository_Conformance(t *testing.T) {
+	testRepositoryConformance(t, func(t *testing.T) outbox.Repository {
+		return outbox.NewMemoryRepository()
+	})
+}
+
+func TestSQLRepository_Conformance_SQLite(t *testing.T) {
+	testRepositoryConformance(t, func(t *testing.T) outbox.Repository {
+		_, repo := newSQLiteRepository(t)
+		return repo
+	})
+}
+
+func TestSQLRepository_InsertMany_Success_Transaction(t *testing.T) {
+	db, repo := newSQLiteRepository(t)
+
+	err := db.Transaction(context.TODO(), func(ctx context.Context) error {
+		if err := repo.InsertMany(ctx, []entity.Event{event("a", "john", 1)}); err != nil {
+			return err
+		}
+		return exception.ErrConflict
+	})
+	assert.Equal(t, exception.ErrConflict, err)
+
+	events, err := repo.FindPending(context.TODO(), math.MaxInt64, 10)
+	assert.NoError(t, err, "should be no error")
+	assert.Empty(t, events, "should roll back the events with the transaction")
+}
+
+func TestSQLRepository_InsertMany_Error_Duplicate(t *testing.T) {
+	_, repo := newSQLiteRepository(t)
+	require.NoError(t, repo.InsertMany(context.TODO(), []entity.Event{event("a", "john", 1)}))
+
+	err := repo.InsertMany(context.TODO(), []entity.Event{event("a", "john", 1)})
+	assert.Equal(t, exception.ErrInternalServer, err, "should be internal server error")
+}
+
+func TestOutboxRepository_InsertMany_Success(t *testing.T) {
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+
+	col.On("InsertMany", mock.Anything, []interface{}{event("a", "john", 1), event("b", "john", 2)}).Return(nil, nil)
+	db.On("Collection", "outbox").Return(col)
+
+	repo := outbox.NewOutboxRepository(logrus.New(), db)
+
+	err := repo.InsertMany(context.TODO(), []entity.Event{event("a", "john", 1), event("b", "john", 2)})
+	assert.NoError(t, err, "should be no error")
+	assert.NoError(t, repo.InsertMany(context.TODO(), nil), "should not write an empty batch")
+	col.AssertNumberOfCalls(t, "InsertMany", 1)
+}
+
+func TestOutboxRepository_InsertMany_Error_Unexpected(t *testing.T) {
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+
+	col.On("InsertMany", mock.Anything, mock.Anything).Return(nil, mongo.ErrClientDisconnected)
+	db.On("Collection", "outbox").Return(col)
+
+	repo := outbox.NewOutboxRepository(logrus.New(), db)
+
+	err := repo.InsertMany(context.TODO(), []entity.Event{event("a", "john", 1)})
+	assert.Equal(t, exception.ErrInternalServer, err, "should be internal server error")
+}
+
+func TestOutboxRepository_FindPending_Success(t *testing.T) {
+	cursorMock := new(mocks.Cursor)
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+
+	cursorMock.On("Next", mock.Anything).Return(true).Once()
+	cursorMock.On("Next", mock.Anything).Return(false).Once()
+	cursorMock.On("Decode", mock.AnythingOfType("*entity.Event")).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(0).(*entity.Event) = event("a", "john", 1)
+	})
+	cursorMock.On("Close", mock.Anything).Return(nil)
+	blockedCursor := new(mocks.Cursor)
+	blockedCursor.On("Next", mock.Anything).Return(true).Once()
+	blockedCursor.On("Next", mock.Anything).Return(false).Once()
+	blockedCursor.On("Decode", mock.AnythingOfType("*entity.Event")).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(0).(*entity.Event) = entity.Event{Key: "jane"}
+	})
+	blockedCursor.On("Close", mock.Anything).Return(nil)
+	col.On("Find", mock.Anything, bson.M{"nextAttemptAt": bson.M{"$gt": int64(100)}}, mock.Anything).Return(blockedCursor, nil)
+	col.On("Find", mock.Anything, bson.M{"nextAttemptAt": bson.M{"$lte": int64(100)}, "key": bson.M{"$nin": []string{"jane"}}}, mock.Anything).Return(cursorMock, nil)
+	db.On("Collection", "outbox").Return(col)
+
+	repo := outbox.NewOutboxRepository(logrus.New(), db)
+
+	events, err := repo.FindPending(context.TODO(), 100, 10)
+	assert.NoError(t, err, "should be no error")
+	assert.Equal(t, []entity.Event{event("a", "john", 1)}, events)
+	cursorMock.AssertExpectations(t)
+	col.AssertExpectations(t)
+}
+
+func TestOutboxRepository_MarkFailed_Success(t *testing.T) {
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+
+	update := bson.M{"$set": bson.M{"attempts": 2, "nextAttemptAt": int64(100), "lastError": "broker unavailable"}}
+	col.On("UpdateOne", mock.Anything, bson.M{"_id": "a"}, update).Return(&mongo.UpdateResult{MatchedCount: 1}, nil)
+	col.On("DeleteOne", mock.Anything, bson.M{"_id": "a"}).Return(&mongo.DeleteResult{DeletedCount: 1}, nil)
+	db.On("Collection", "outbox").Return(col)
+
+	repo := outbox.NewOutboxRepository(logrus.New(), db)
+
+	assert.NoError(t, repo.MarkFailed(context.TODO(), "a", 2, 100, "broker unavailable"))
+	assert.NoError(t, repo.DeleteOne(context.TODO(), "a"))
+	col.AssertExpectations(t)
+}
@@ -0,0 +1,68 @@
+// Package requestctx carries the values of a request, e.g. its owner, logger and timezone, through a context,
+// so the domain and storage packages can read them without depending on the http middleware.
+package requestctx
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type contextKey string
+
+const (
+	ownerContextKey     contextKey = "owner"
+	loggerContextKey    contextKey = "logger"
+	requestIDContextKey contextKey = "requestID"
+	locationContextKey  contextKey = "location"
+)
+
+// WithOwner returns a copy of ctx which carries the owner of the request.
+func WithOwner(ctx context.Context, owner string) context.Context {
+	return context.WithValue(ctx, ownerContextKey, owner)
+}
+
+// Owner returns the owner of the request, or an empty string when the request is anonymous.
+func Owner(ctx context.Context) string {
+	owner, _ := ctx.Value(ownerContextKey).(string)
+	return owner
+}
+
+// WithLogger returns a copy of ctx which carries the request scoped logger.
+func WithLogger(ctx context.Context, entry *logrus.Entry) context.Context {
+	return context.WithValue(ctx, loggerContextKey, entry)
+}
+
+// Logger returns the request scoped logger,
+// or an entry of the given logger when ctx does not carry one.
+func Logger(ctx context.Context, logger *logrus.Logger) *logrus.Entry {
+	if entry, ok := ctx.Value(loggerContextKey).(*logrus.Entry); ok {
+		return entry
+	}
+	return logrus.NewEntry(logger)
+}
+
+// WithRequestID returns a copy of ctx which carries the id of the request.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey, requestID)
+}
+
+// RequestID returns the id of the request, or an empty string when it is not set.
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDContextKey).(string)
+	return requestID
+}
+
+// WithLocation returns a copy of ctx which carries the timezone of the user.
+func WithLocation(ctx context.Context, location *time.Location) context.Context {
+	return context.WithValue(ctx, locationContextKey, location)
+}
+
+// Location returns the timezone of the user, or UTC when ctx does not carry one.
+func Location(ctx context.Context) *time.Location {
+	if location, ok := ctx.Value(locationContextKey).(*time.Location); ok {
+		return location
+	}
+	return time.UTC
+}
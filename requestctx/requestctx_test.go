@@ -0,0 +1,26 @@
+package requestctx_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ijalalfrz/sirclo-weight-test/requestctx"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogger_Fallback(t *testing.T) {
+	logger := logrus.New()
+	entry := requestctx.Logger(context.TODO(), logger)
+	assert.Equal(t, logger, entry.Logger)
+}
+
+func TestLocation_Fallback(t *testing.T) {
+	assert.Equal(t, time.UTC, requestctx.Location(context.TODO()))
+}
+
+func TestOwner(t *testing.T) {
+	assert.Empty(t, requestctx.Owner(context.TODO()), "should be anonymous")
+	assert.Equal(t, "john", requestctx.Owner(requestctx.WithOwner(context.TODO(), "john")))
+}
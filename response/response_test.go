@@ -49,6 +49,18 @@ func TestSuccessResponse(t *testing.T) {
 		assert.Equal(t, response.StatCreated, resp.Status())
 		assert.Equal(t, "Created", resp.Message())
 	})
+
+	t.Run("when meta is set", func(t *testing.T) {
+		meta := map[string]int{"page": 1}
+		resp := response.NewSuccessResponseWithMeta(
+			nil, response.StatOK, "OK", meta,
+		)
+
+		assert.NotNil(t, resp)
+		assert.Nil(t, resp.Error())
+		assert.Equal(t, http.StatusOK, resp.HTTPStatusCode())
+		assert.Equal(t, meta, resp.Meta())
+	})
 }
 
 func TestRESTResponse(t *testing.T) {
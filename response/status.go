@@ -2,13 +2,16 @@ package response
 
 // Collection of status.
 const (
-	StatOK                string = "OK"
-	StatCreated           string = "CREATED"
-	StatNotFound          string = "NOT_FOUND"
-	StatUnexpectedError   string = "UNEXPECTED_ERROR"
-	StatInsufficientPoint string = "INSUFFICIENT_POINT"
-	StatusInvalidPayload  string = "INVALID_PAYLOAD"
-	StatUnauthorized      string = "UNAUTHORIZED"
-	StatAlreadyExist      string = "ALREADY_EXIST"
-	StatBadRequest        string = "BAD_REQUEST"
+	StatOK                 string = "OK"
+	StatCreated            string = "CREATED"
+	StatNotFound           string = "NOT_FOUND"
+	StatUnexpectedError    string = "UNEXPECTED_ERROR"
+	StatInsufficientPoint  string = "INSUFFICIENT_POINT"
+	StatusInvalidPayload   string = "INVALID_PAYLOAD"
+	StatUnauthorized       string = "UNAUTHORIZED"
+	StatAlreadyExist       string = "ALREADY_EXIST"
+	StatBadRequest         string = "BAD_REQUEST"
+	StatUnavailable        string = "SERVICE_UNAVAILABLE"
+	StatVersionConflict    string = "VERSION_CONFLICT"
+	StatPreconditionFailed string = "PRECONDITION_FAILED"
 )
@@ -31,6 +31,13 @@ func NewSuccessResponse(data interface{}, status string, message string) Respons
 	}
 }
 
+// NewSuccessResponseWithMeta is a constructor of success response which carries meta.
+func NewSuccessResponseWithMeta(data interface{}, status string, message string, meta interface{}) Response {
+	resp := NewSuccessResponse(data, status, message).(SuccessResponse)
+	resp.meta = meta
+	return resp
+}
+
 // Data returns data.
 func (r SuccessResponse) Data() interface{} {
 	return r.data
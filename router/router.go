@@ -0,0 +1,76 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/ijalalfrz/sirclo-weight-test/exception"
+	"github.com/ijalalfrz/sirclo-weight-test/health"
+	"github.com/ijalalfrz/sirclo-weight-test/lifecycle"
+	"github.com/ijalalfrz/sirclo-weight-test/metrics"
+	"github.com/ijalalfrz/sirclo-weight-test/middleware"
+	"github.com/ijalalfrz/sirclo-weight-test/openapi"
+	"github.com/ijalalfrz/sirclo-weight-test/response"
+	"github.com/ijalalfrz/sirclo-weight-test/tracing"
+	"github.com/ijalalfrz/sirclo-weight-test/webhook"
+	"github.com/ijalalfrz/sirclo-weight-test/weight"
+	"github.com/sirupsen/logrus"
+)
+
+// collection of index message
+const (
+	indexMessage    = "Application is running properly"
+	drainingMessage = "Application is not ready to serve"
+)
+
+// PublicPaths are the paths which are served without authentication.
+var PublicPaths = append(append([]string{"/", metrics.Path}, health.Paths...), openapi.Paths...)
+
+// Property is a property of router. Auth is nil when the authentication is disabled.
+type Property struct {
+	Logger         *logrus.Logger
+	Validator      *validator.Validate
+	Metrics        *metrics.Metrics
+	State          func() lifecycle.State
+	Checks         []health.Check
+	WeightUsecase  weight.Usecase
+	WebhookUsecase webhook.Usecase
+	Broadcaster    *weight.Broadcaster
+	Auth           *middleware.AuthProperty
+}
+
+// New registers every route of the service to a router. The authenticator of the login and logout pages
+// is returned for the auth middleware, it is nil when the authentication is disabled.
+func New(property Property) (router *mux.Router, authenticator *middleware.Authenticator) {
+	router = mux.NewRouter()
+	router.Use(property.Metrics.Route, tracing.Route)
+	router.HandleFunc("/", index(property.State)).Methods(http.MethodGet)
+	router.Handle(metrics.Path, property.Metrics.Handler()).Methods(http.MethodGet)
+
+	weight.NewWeightHTTPHandler(property.Logger, property.Validator, router, property.WeightUsecase, property.Broadcaster)
+	webhook.NewWebhookHTTPHandler(property.Logger, property.Validator, router, property.WebhookUsecase)
+	health.NewHealthHTTPHandler(property.Logger, router, property.State, property.Checks...)
+	openapi.NewOpenAPIHTTPHandler(property.Logger, router)
+
+	if property.Auth != nil {
+		auth := *property.Auth
+		auth.PublicPaths = PublicPaths
+		authenticator = middleware.NewAuthenticator(auth, router)
+	}
+	return
+}
+
+// index reports whether the application is serving, it is not while it drains.
+func index(state func() lifecycle.State) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if state() != lifecycle.StateReady {
+			resp := response.NewErrorResponse(exception.ErrServiceUnavailable, http.StatusServiceUnavailable, state(), response.StatUnavailable, drainingMessage)
+			response.JSON(w, resp)
+			return
+		}
+
+		resp := response.NewSuccessResponse(nil, response.StatOK, indexMessage)
+		response.JSON(w, resp)
+	}
+}
@@ -0,0 +1,68 @@
+package router_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/ijalalfrz/sirclo-weight-test/lifecycle"
+	"github.com/ijalalfrz/sirclo-weight-test/metrics"
+	"github.com/ijalalfrz/sirclo-weight-test/middleware"
+	"github.com/ijalalfrz/sirclo-weight-test/openapi"
+	"github.com/ijalalfrz/sirclo-weight-test/router"
+	webhookmocks "github.com/ijalalfrz/sirclo-weight-test/webhook/mocks"
+	"github.com/ijalalfrz/sirclo-weight-test/weight"
+	weightmocks "github.com/ijalalfrz/sirclo-weight-test/weight/mocks"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func property(state lifecycle.State) router.Property {
+	return router.Property{
+		Logger:         logrus.New(),
+		Validator:      validator.New(),
+		Metrics:        metrics.NewMetrics(),
+		State:          func() lifecycle.State { return state },
+		WeightUsecase:  new(weightmocks.Usecase),
+		WebhookUsecase: new(webhookmocks.Usecase),
+		Broadcaster:    weight.NewBroadcaster(0),
+	}
+}
+
+func TestNew_Success_Index(t *testing.T) {
+	r, authenticator := router.New(property(lifecycle.StateReady))
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Nil(t, authenticator, "should not authenticate without auth")
+}
+
+func TestNew_Error_IndexDraining(t *testing.T) {
+	r, _ := router.New(property(lifecycle.StateDraining))
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+}
+
+func TestNew_Success_PublicPaths(t *testing.T) {
+	p := property(lifecycle.StateReady)
+	p.Auth = &middleware.AuthProperty{Logger: p.Logger, Secret: "s3cret"}
+	r, authenticator := router.New(p)
+	handler := middleware.Auth(authenticator, r)
+
+	for _, path := range []string{"/", metrics.Path, openapi.DocsPath, openapi.ScriptPath} {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+
+		assert.Equal(t, http.StatusOK, recorder.Code, "should serve %s without authentication", path)
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/weights", nil))
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
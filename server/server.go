@@ -2,7 +2,9 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"time"
 
@@ -18,6 +20,7 @@ const (
 type Server struct {
 	logger     *logrus.Logger
 	httpServer *http.Server
+	errs       chan error
 }
 
 // NewServer is a constructor.
@@ -33,20 +36,44 @@ func NewServer(logger *logrus.Logger, handler http.Handler, port string) *Server
 	return &Server{
 		logger:     logger,
 		httpServer: httpServer,
+		errs:       make(chan error, 1),
 	}
 }
 
-// Start will start the server.
+// Start binds the listener and serves the incoming request in background.
+// An error is returned immediately when the address can not be bound,
+// any later failure of the listener is sent to Errors.
 // Do not call this in goroutine.
-func (s *Server) Start() {
+func (s *Server) Start() (err error) {
+	listener, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		return
+	}
+
 	go func() {
-		s.logger.Info(fmt.Sprintf(startingMessage, s.httpServer.Addr))
-		s.httpServer.ListenAndServe()
+		s.logger.Info(fmt.Sprintf(startingMessage, listener.Addr()))
+		if err := s.httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.errs <- err
+		}
+		close(s.errs)
 	}()
+
+	return
 }
 
-// Close will block all the incomming request and subsequently shutdown the server.
-func (s *Server) Close() {
-	s.httpServer.Shutdown(context.Background())
+// Errors returns a channel which receives the error of the listener.
+// The channel is closed once the server stops serving.
+func (s *Server) Errors() <-chan error {
+	return s.errs
+}
+
+// Close will block all the incomming request and wait for the active ones until ctx is done,
+// subsequently shutdown the server.
+func (s *Server) Close(ctx context.Context) (err error) {
+	if err = s.httpServer.Shutdown(ctx); err != nil {
+		return
+	}
+
 	s.logger.Info(shutdownMessage)
+	return
 }
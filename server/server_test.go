@@ -1,19 +1,52 @@
 package server_test
 
 import (
+	"context"
 	"net/http"
 	"testing"
 	"time"
 
 	"github.com/ijalalfrz/sirclo-weight-test/server"
 	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestServer(t *testing.T) {
 	httpHandler := http.NewServeMux()
 
 	srv := server.NewServer(logrus.New(), httpHandler, "9091")
-	srv.Start()
+	assert.NoError(t, srv.Start())
 	time.Sleep(time.Second * 1)
-	srv.Close()
+	assert.NoError(t, srv.Close(context.Background()))
+
+	_, ok := <-srv.Errors()
+	assert.False(t, ok, "errors channel should be closed")
+}
+
+func TestServer_Error_AddressInUse(t *testing.T) {
+	httpHandler := http.NewServeMux()
+
+	srv := server.NewServer(logrus.New(), httpHandler, "9092")
+	assert.NoError(t, srv.Start())
+	defer srv.Close(context.Background())
+
+	clash := server.NewServer(logrus.New(), httpHandler, "9092")
+	assert.Error(t, clash.Start(), "should fail to bind")
+}
+
+func TestServer_Close_Error_Timeout(t *testing.T) {
+	started := make(chan struct{})
+	httpHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(time.Second * 1)
+	})
+
+	srv := server.NewServer(logrus.New(), httpHandler, "9093")
+	assert.NoError(t, srv.Start())
+	go http.Get("http://localhost:9093/")
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	assert.ErrorIs(t, srv.Close(ctx), context.DeadlineExceeded)
 }
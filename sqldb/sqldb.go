@@ -0,0 +1,132 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+)
+
+// collection of supported driver
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite3"
+)
+
+// postgresUniqueViolation is the SQLSTATE of unique_violation.
+const postgresUniqueViolation = "23505"
+
+// Executor is the behaviour shared by sql.DB and sql.Tx to run a query.
+type Executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+type txContextKey struct{}
+
+// DB is a concrete struct of sql database which knows the dialect of its driver.
+type DB struct {
+	*sql.DB
+	Driver string
+}
+
+// Open opens the database of dsn with the given driver and verifies the connection.
+// SQLite is limited to a single connection, as it only allows one writer at a time.
+func Open(driver, dsn string) (db *DB, err error) {
+	if driver != DriverPostgres && driver != DriverSQLite {
+		err = fmt.Errorf("unsupported sql driver %q", driver)
+		return
+	}
+
+	sqlDB, err := sql.Open(driver, dsn)
+	if err != nil {
+		return
+	}
+	if driver == DriverSQLite {
+		sqlDB.SetMaxOpenConns(1)
+	}
+	if err = sqlDB.Ping(); err != nil {
+		sqlDB.Close()
+		return
+	}
+
+	db = &DB{DB: sqlDB, Driver: driver}
+	return
+}
+
+// Rebind replaces the `?` placeholders of query with the placeholders of the driver,
+// so a query is written once for every dialect.
+func (db *DB) Rebind(query string) string {
+	if db.Driver != DriverPostgres {
+		return query
+	}
+
+	var rebound strings.Builder
+	position := 0
+	for _, char := range query {
+		if char != '?' {
+			rebound.WriteRune(char)
+			continue
+		}
+		position++
+		rebound.WriteString("$" + strconv.Itoa(position))
+	}
+	return rebound.String()
+}
+
+// Transaction runs fn in a transaction which is committed when fn returns no error and rolled back otherwise.
+// The transaction is carried by the context given to fn, a nested call joins it instead of starting another one.
+// The error of fn is returned as is.
+func (db *DB) Transaction(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if _, ok := ctx.Value(txContextKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err = fn(context.WithValue(ctx, txContextKey{}, tx)); err != nil {
+		tx.Rollback()
+		return
+	}
+	err = tx.Commit()
+	return
+}
+
+// Executor returns the transaction carried by ctx, or the database when there is none.
+// Every query of a repository must go through it, as SQLite has a single connection
+// which is held by the transaction.
+func (db *DB) Executor(ctx context.Context) Executor {
+	if tx, ok := ctx.Value(txContextKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return db.DB
+}
+
+// IsUniqueViolation tells whether err is caused by a unique constraint.
+func IsUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == postgresUniqueViolation
+	}
+
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique || sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package sqldb_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/ijalalfrz/sirclo-weight-test/sqldb"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpen_Success_SQLite(t *testing.T) {
+	db, err := sqldb.Open(sqldb.DriverSQLite, filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("CREATE TABLE test (id INTEGER PRIMARY KEY, name TEXT UNIQUE)")
+	require.NoError(t, err)
+	_, err = db.Exec(db.Rebind("INSERT INTO test (name) VALUES (?)"), "john")
+	require.NoError(t, err)
+
+	_, err = db.Exec(db.Rebind("INSERT INTO test (name) VALUES (?)"), "john")
+	assert.True(t, sqldb.IsUniqueViolation(err), "should be unique violation")
+}
+
+func TestOpen_Error_UnsupportedDriver(t *testing.T) {
+	_, err := sqldb.Open("mysql", "")
+	assert.Error(t, err, "should be error")
+}
+
+func TestTransaction_Success(t *testing.T) {
+	db, err := sqldb.Open(sqldb.DriverSQLite, filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec("CREATE TABLE test (id INTEGER PRIMARY KEY, name TEXT)")
+	require.NoError(t, err)
+
+	err = db.Transaction(context.TODO(), func(ctx context.Context) error {
+		if _, err := db.Executor(ctx).ExecContext(ctx, "INSERT INTO test (name) VALUES ('john')"); err != nil {
+			return err
+		}
+		// a nested transaction joins the outer one, it would wait for the only connection otherwise.
+		return db.Transaction(ctx, func(ctx context.Context) (err error) {
+			_, err = db.Executor(ctx).ExecContext(ctx, "INSERT INTO test (name) VALUES ('jane')")
+			return
+		})
+	})
+	assert.NoError(t, err, "should be no error")
+
+	var total int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM test").Scan(&total))
+	assert.Equal(t, 2, total)
+}
+
+func TestTransaction_Error_RolledBack(t *testing.T) {
+	db, err := sqldb.Open(sqldb.DriverSQLite, filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec("CREATE TABLE test (id INTEGER PRIMARY KEY, name TEXT)")
+	require.NoError(t, err)
+
+	expected := errors.New("unexpected")
+	err = db.Transaction(context.TODO(), func(ctx context.Context) error {
+		if _, err := db.Executor(ctx).ExecContext(ctx, "INSERT INTO test (name) VALUES ('john')"); err != nil {
+			return err
+		}
+		return expected
+	})
+	assert.Equal(t, expected, err, "should return the error of fn")
+
+	var total int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM test").Scan(&total))
+	assert.Zero(t, total, "should roll back the writes of fn")
+}
+
+func TestRebind(t *testing.T) {
+	postgres := &sqldb.DB{Driver: sqldb.DriverPostgres}
+	assert.Equal(t, "SELECT * FROM weight WHERE owner = $1 AND date >= $2", postgres.Rebind("SELECT * FROM weight WHERE owner = ? AND date >= ?"))
+
+	sqlite := &sqldb.DB{Driver: sqldb.DriverSQLite}
+	assert.Equal(t, "SELECT * FROM weight WHERE owner = ?", sqlite.Rebind("SELECT * FROM weight WHERE owner = ?"))
+}
+
+func TestIsUniqueViolation(t *testing.T) {
+	assert.True(t, sqldb.IsUniqueViolation(&pq.Error{Code: "23505"}))
+	assert.False(t, sqldb.IsUniqueViolation(&pq.Error{Code: "23503"}))
+	assert.False(t, sqldb.IsUniqueViolation(errors.New("unexpected")))
+}
@@ -0,0 +1,65 @@
+package tracing
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/ijalalfrz/sirclo-weight-test/middleware"
+	"github.com/ijalalfrz/sirclo-weight-test/requestctx"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// HTTP is a tracing middleware.
+// It continues the trace of the W3C traceparent header or starts a new one, and starts a server span
+// for the request. The trace id is added to the request scoped logger so logs can be joined with traces.
+func HTTP(logger *logrus.Logger, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := Tracer().Start(ctx, fmt.Sprintf("HTTP %s", r.Method),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(semconv.HTTPServerAttributesFromHTTPRequest("", "", r)...),
+		)
+		defer span.End()
+
+		if span.SpanContext().IsValid() {
+			entry := requestctx.Logger(ctx, logger).WithField("trace_id", span.SpanContext().TraceID().String())
+			ctx = requestctx.WithLogger(ctx, entry)
+		}
+
+		rr := middleware.NewResponseRecorder(w)
+		handler.ServeHTTP(rr, r.WithContext(ctx))
+
+		span.SetAttributes(semconv.HTTPStatusCodeKey.Int(rr.Status()))
+		span.SetStatus(semconv.SpanStatusFromHTTPStatusCodeAndSpanKind(rr.Status(), trace.SpanKindServer))
+	})
+}
+
+// Route names the server span after the matched route template.
+// It must be attached with mux.Router.Use so the matched route is known.
+func Route(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				span := trace.SpanFromContext(r.Context())
+				span.SetName(fmt.Sprintf("%s %s", r.Method, template))
+				span.SetAttributes(semconv.HTTPRouteKey.String(template))
+			}
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// RecordError records err on the span and marks the span as failed.
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,113 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName is the name of the tracer of the service.
+const InstrumentationName = "github.com/ijalalfrz/sirclo-weight-test"
+
+// collection of exporter
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+)
+
+// Property is a property of tracer provider.
+type Property struct {
+	ServiceName string
+	Exporter    string
+	// Endpoint is the host:port of the OTLP/HTTP collector,
+	// when it is empty the OTEL_EXPORTER_OTLP_* environment variables are used.
+	Endpoint string
+	Insecure bool
+	// FilePath is the file which spans are appended to when the exporter is file.
+	FilePath string
+}
+
+// Provider is a concrete struct of the tracer provider of the service.
+type Provider struct {
+	tp     *sdktrace.TracerProvider
+	closer io.Closer
+}
+
+// NewProvider builds the tracer provider of the exporter and installs it as the global tracer provider
+// along with the W3C trace-context and baggage propagator.
+// When the exporter is none or empty only the propagator is installed, spans are not recorded.
+func NewProvider(ctx context.Context, property Property) (provider *Provider, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	provider = &Provider{}
+
+	var exporter sdktrace.SpanExporter
+	switch property.Exporter {
+	case "", ExporterNone:
+		return
+	case ExporterOTLP:
+		opts := []otlptracehttp.Option{}
+		if property.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(property.Endpoint))
+		}
+		if property.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterFile:
+		var f *os.File
+		f, err = os.OpenFile(property.FilePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return
+		}
+		provider.closer = f
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(f))
+	default:
+		err = fmt.Errorf("unknown tracing exporter '%s'", property.Exporter)
+	}
+	if err != nil {
+		return
+	}
+
+	provider.tp = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceNameKey.String(property.ServiceName),
+		)),
+	)
+	otel.SetTracerProvider(provider.tp)
+	return
+}
+
+// Tracer returns the tracer of the service from the global tracer provider.
+func Tracer() trace.Tracer {
+	return otel.Tracer(InstrumentationName)
+}
+
+// Close flushes the pending spans and releases the exporter.
+func (p *Provider) Close(ctx context.Context) (err error) {
+	if p.tp != nil {
+		err = p.tp.Shutdown(ctx)
+	}
+	if p.closer != nil {
+		if closeErr := p.closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return
+}
@@ -0,0 +1,76 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/ijalalfrz/sirclo-weight-test/requestctx"
+	"github.com/ijalalfrz/sirclo-weight-test/tracing"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNewProvider(t *testing.T) {
+	t.Run("when exporter is none", func(t *testing.T) {
+		provider, err := tracing.NewProvider(context.TODO(), tracing.Property{Exporter: tracing.ExporterNone})
+		assert.NoError(t, err)
+		assert.NoError(t, provider.Close(context.TODO()))
+	})
+
+	t.Run("when exporter is file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "traces.json")
+		provider, err := tracing.NewProvider(context.TODO(), tracing.Property{ServiceName: "test-service", Exporter: tracing.ExporterFile, FilePath: path})
+		assert.NoError(t, err)
+
+		_, span := tracing.Tracer().Start(context.TODO(), "test-span")
+		span.End()
+		assert.NoError(t, provider.Close(context.TODO()))
+
+		content, err := os.ReadFile(path)
+		assert.NoError(t, err)
+		assert.Contains(t, string(content), "test-span")
+	})
+
+	t.Run("when exporter is unknown", func(t *testing.T) {
+		_, err := tracing.NewProvider(context.TODO(), tracing.Property{Exporter: "zipkin"})
+		assert.Error(t, err)
+	})
+}
+
+func TestHTTP(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	tracing.NewProvider(context.TODO(), tracing.Property{})
+
+	var traceID string
+	var loggedTraceID interface{}
+	router := mux.NewRouter()
+	router.Use(tracing.Route)
+	router.HandleFunc("/weight/{date}", func(w http.ResponseWriter, r *http.Request) {
+		traceID = trace.SpanFromContext(r.Context()).SpanContext().TraceID().String()
+		loggedTraceID = requestctx.Logger(r.Context(), logrus.New()).Data["trace_id"]
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/weight/2022-07-01", nil)
+	r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	tracing.HTTP(logrus.New(), router).ServeHTTP(httptest.NewRecorder(), r)
+
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", traceID, "should continue the incoming trace")
+	assert.Equal(t, traceID, loggedTraceID, "should add trace id to the request logger")
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "GET /weight/{date}", spans[0].Name(), "should be named after the route template")
+	assert.Equal(t, trace.SpanKindServer, spans[0].SpanKind())
+	assert.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+}
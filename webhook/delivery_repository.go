@@ -0,0 +1,143 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/ijalalfrz/sirclo-weight-test/entity"
+	"github.com/ijalalfrz/sirclo-weight-test/exception"
+	"github.com/ijalalfrz/sirclo-weight-test/mongodb"
+	"github.com/ijalalfrz/sirclo-weight-test/requestctx"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type deliveryRepository struct {
+	logger *logrus.Logger
+	col    mongodb.Collection
+}
+
+// NewDeliveryRepository is a constructor of a DeliveryRepository on webhook_delivery collection.
+func NewDeliveryRepository(logger *logrus.Logger, db mongodb.Database) DeliveryRepository {
+	col := db.Collection(DeliveryCollectionName)
+	return &deliveryRepository{logger, col}
+}
+
+func (r deliveryRepository) InsertMany(ctx context.Context, deliveries []entity.WebhookDelivery) (err error) {
+	if len(deliveries) < 1 {
+		return
+	}
+
+	documents := make([]interface{}, len(deliveries))
+	for i, delivery := range deliveries {
+		documents[i] = delivery
+	}
+
+	if _, err = r.col.InsertMany(ctx, documents); err != nil {
+		r.log(ctx).Error(err)
+		err = exception.ErrInternalServer
+		return
+	}
+	return
+}
+
+// FindDue returns the pending deliveries whose next attempt is due at now, the longest waiting first.
+func (r deliveryRepository) FindDue(ctx context.Context, now int64, limit int64) (deliveries []entity.WebhookDelivery, err error) {
+	filter := bson.M{
+		"status":        StatusPending,
+		"nextAttemptAt": bson.M{"$lte": now},
+	}
+	opt := options.Find().
+		SetSort(bson.D{{Key: "nextAttemptAt", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(limit)
+
+	return r.find(ctx, filter, opt)
+}
+
+// FindMany returns the deliveries of a subscription of owner, the newest first.
+func (r deliveryRepository) FindMany(ctx context.Context, owner string, subscriptionID string, limit int64) (deliveries []entity.WebhookDelivery, err error) {
+	filter := bson.M{
+		"owner":          ownerFilter(owner),
+		"subscriptionId": subscriptionID,
+	}
+	opt := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(limit)
+
+	return r.find(ctx, filter, opt)
+}
+
+func (r deliveryRepository) FindOne(ctx context.Context, owner string, id string) (delivery entity.WebhookDelivery, err error) {
+	filter := bson.M{
+		"_id":   id,
+		"owner": ownerFilter(owner),
+	}
+
+	if err = r.col.FindOne(ctx, filter).Decode(&delivery); err != nil {
+		if err != mongo.ErrNoDocuments {
+			r.log(ctx).Error(err)
+			err = exception.ErrInternalServer
+			return
+		}
+		err = exception.ErrNotFound
+		return
+	}
+
+	return
+}
+
+// UpdateOne replaces the state of the delivery, its payload and subscription are left as is.
+func (r deliveryRepository) UpdateOne(ctx context.Context, delivery entity.WebhookDelivery) (err error) {
+	update := bson.M{
+		"$set": bson.M{
+			"status":        delivery.Status,
+			"attempts":      delivery.Attempts,
+			"nextAttemptAt": delivery.NextAttemptAt,
+			"responseCode":  delivery.ResponseCode,
+			"lastError":     delivery.LastError,
+			"deliveredAt":   delivery.DeliveredAt,
+		},
+	}
+
+	updatedResult, err := r.col.UpdateOne(ctx, bson.M{"_id": delivery.ID}, update)
+	if err != nil {
+		r.log(ctx).Error(err)
+		err = exception.ErrInternalServer
+		return
+	}
+
+	if updatedResult.MatchedCount < 1 {
+		err = exception.ErrNotFound
+		return
+	}
+
+	return
+}
+
+func (r deliveryRepository) find(ctx context.Context, filter bson.M, opt *options.FindOptions) (deliveries []entity.WebhookDelivery, err error) {
+	cursor, err := r.col.Find(ctx, filter, opt)
+	if err != nil {
+		r.log(ctx).Error(err)
+		err = exception.ErrInternalServer
+		return
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		delivery := entity.WebhookDelivery{}
+		if err = cursor.Decode(&delivery); err != nil {
+			r.log(ctx).Error(err)
+			err = exception.ErrInternalServer
+			return
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	return
+}
+
+func (r deliveryRepository) log(ctx context.Context) *logrus.Entry {
+	return requestctx.Logger(ctx, r.logger)
+}
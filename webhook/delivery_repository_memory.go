@@ -0,0 +1,107 @@
+package webhook
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/ijalalfrz/sirclo-weight-test/entity"
+	"github.com/ijalalfrz/sirclo-weight-test/exception"
+)
+
+// memoryDeliveryRepository is a thread-safe DeliveryRepository which keeps the deliveries in memory,
+// it serves the storage drivers without a webhook_delivery collection.
+type memoryDeliveryRepository struct {
+	mu         sync.RWMutex
+	deliveries []entity.WebhookDelivery
+}
+
+// NewMemoryDeliveryRepository is a constructor.
+func NewMemoryDeliveryRepository() DeliveryRepository {
+	return &memoryDeliveryRepository{}
+}
+
+func (r *memoryDeliveryRepository) InsertMany(ctx context.Context, deliveries []entity.WebhookDelivery) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.deliveries = append(r.deliveries, deliveries...)
+	return
+}
+
+// FindDue returns the pending deliveries whose next attempt is due at now, the longest waiting first.
+func (r *memoryDeliveryRepository) FindDue(ctx context.Context, now int64, limit int64) (deliveries []entity.WebhookDelivery, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, delivery := range r.deliveries {
+		if delivery.Status == StatusPending && delivery.NextAttemptAt <= now {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	sort.SliceStable(deliveries, func(i, j int) bool {
+		if deliveries[i].NextAttemptAt != deliveries[j].NextAttemptAt {
+			return deliveries[i].NextAttemptAt < deliveries[j].NextAttemptAt
+		}
+		return deliveries[i].ID < deliveries[j].ID
+	})
+	if limit > 0 && limit < int64(len(deliveries)) {
+		deliveries = deliveries[:limit]
+	}
+	return
+}
+
+// FindMany returns the deliveries of a subscription of owner, the newest first.
+func (r *memoryDeliveryRepository) FindMany(ctx context.Context, owner string, subscriptionID string, limit int64) (deliveries []entity.WebhookDelivery, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, delivery := range r.deliveries {
+		if delivery.Owner == owner && delivery.SubscriptionID == subscriptionID {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	sort.SliceStable(deliveries, func(i, j int) bool {
+		if deliveries[i].CreatedAt != deliveries[j].CreatedAt {
+			return deliveries[i].CreatedAt > deliveries[j].CreatedAt
+		}
+		return deliveries[i].ID > deliveries[j].ID
+	})
+	if limit > 0 && limit < int64(len(deliveries)) {
+		deliveries = deliveries[:limit]
+	}
+	return
+}
+
+func (r *memoryDeliveryRepository) FindOne(ctx context.Context, owner string, id string) (delivery entity.WebhookDelivery, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, stored := range r.deliveries {
+		if stored.ID == id && stored.Owner == owner {
+			return stored, nil
+		}
+	}
+	err = exception.ErrNotFound
+	return
+}
+
+// UpdateOne replaces the state of the delivery, its payload and subscription are left as is.
+func (r *memoryDeliveryRepository) UpdateOne(ctx context.Context, delivery entity.WebhookDelivery) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, stored := range r.deliveries {
+		if stored.ID == delivery.ID {
+			r.deliveries[i].Status = delivery.Status
+			r.deliveries[i].Attempts = delivery.Attempts
+			r.deliveries[i].NextAttemptAt = delivery.NextAttemptAt
+			r.deliveries[i].ResponseCode = delivery.ResponseCode
+			r.deliveries[i].LastError = delivery.LastError
+			r.deliveries[i].DeliveredAt = delivery.DeliveredAt
+			return
+		}
+	}
+	err = exception.ErrNotFound
+	return
+}
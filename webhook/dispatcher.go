@@ -0,0 +1,318 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/ijalalfrz/sirclo-weight-test/entity"
+	"github.com/ijalalfrz/sirclo-weight-test/exception"
+	"github.com/ijalalfrz/sirclo-weight-test/outbox"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// collection of dispatcher default
+const (
+	defaultInterval    = time.Second
+	defaultBatchSize   = 100
+	defaultMaxAttempts = 8
+	defaultMaxBackoff  = time.Hour
+	defaultTimeout     = time.Second * 10
+)
+
+const (
+	maxResponseSize = 4 << 10
+)
+
+const (
+	deliveryFailedMessage    = "Delivering %s webhook %s failed at attempt %d, it is retried in %s: %v"
+	deliveryDeadMessage      = "Delivering %s webhook %s failed at attempt %d, it is dead: %v"
+	subscriptionGoneMessage  = "subscription is deleted"
+	unexpectedStatusMessage  = "unexpected status %d"
+	dispatcherStoppedMessage = "Webhook dispatcher is stopped."
+)
+
+// DispatcherProperty is a property of Dispatcher. Zero values are replaced by the defaults.
+type DispatcherProperty struct {
+	Logger      *logrus.Logger
+	Repository  Repository
+	Deliveries  DeliveryRepository
+	Client      *http.Client
+	Interval    time.Duration
+	BatchSize   int64
+	MaxAttempts int
+	MaxBackoff  time.Duration
+	Timeout     time.Duration
+}
+
+// Dispatcher records a delivery of every event for each subscription of its owner, and sends the due ones
+// in background, at least once. A delivery is sent as a signed POST of the event envelope and is delivered once
+// the subscriber answers with a 2xx status. A failed delivery is retried with an exponential backoff starting at
+// Interval and capped at MaxBackoff, it is dead after MaxAttempts attempts.
+type Dispatcher struct {
+	logger      *logrus.Logger
+	repository  Repository
+	deliveries  DeliveryRepository
+	client      *http.Client
+	interval    time.Duration
+	batchSize   int64
+	maxAttempts int
+	maxBackoff  time.Duration
+	ctx         context.Context
+	cancel      context.CancelFunc
+	stop        chan struct{}
+	done        chan struct{}
+}
+
+// NewDispatcher is a constructor.
+func NewDispatcher(property DispatcherProperty) *Dispatcher {
+	if property.Interval <= 0 {
+		property.Interval = defaultInterval
+	}
+	if property.BatchSize <= 0 {
+		property.BatchSize = defaultBatchSize
+	}
+	if property.MaxAttempts <= 0 {
+		property.MaxAttempts = defaultMaxAttempts
+	}
+	if property.MaxBackoff <= 0 {
+		property.MaxBackoff = defaultMaxBackoff
+	}
+	if property.Timeout <= 0 {
+		property.Timeout = defaultTimeout
+	}
+	if property.Client == nil {
+		property.Client = NewClient(property.Timeout)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Dispatcher{
+		logger:      property.Logger,
+		repository:  property.Repository,
+		deliveries:  property.Deliveries,
+		client:      property.Client,
+		interval:    property.Interval,
+		batchSize:   property.BatchSize,
+		maxAttempts: property.MaxAttempts,
+		maxBackoff:  property.MaxBackoff,
+		ctx:         ctx,
+		cancel:      cancel,
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+// Notify records a pending delivery of the event for every subscription of owner which accepts eventType.
+// data is the payload of the event, every delivery of the event carries the same envelope id.
+func (d *Dispatcher) Notify(ctx context.Context, eventType string, owner string, data interface{}) (err error) {
+	subscriptions, err := d.repository.FindMany(ctx, owner)
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	var body []byte
+	var deliveries []entity.WebhookDelivery
+	for _, subscription := range subscriptions {
+		if !accepts(subscription, eventType) {
+			continue
+		}
+
+		if body == nil {
+			if body, err = envelope(primitive.NewObjectID().Hex(), eventType, now, data); err != nil {
+				d.logger.Error(err)
+				err = exception.ErrInternalServer
+				return
+			}
+		}
+
+		deliveries = append(deliveries, entity.WebhookDelivery{
+			ID:             primitive.NewObjectID().Hex(),
+			SubscriptionID: subscription.ID,
+			Owner:          owner,
+			Event:          eventType,
+			Payload:        body,
+			Status:         StatusPending,
+			NextAttemptAt:  now.UnixNano(),
+			CreatedAt:      now.UnixNano(),
+		})
+	}
+
+	return d.deliveries.InsertMany(ctx, deliveries)
+}
+
+// Start polls the due deliveries every interval in background until Close is called.
+// Do not call this in goroutine.
+func (d *Dispatcher) Start() {
+	go func() {
+		defer close(d.done)
+
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
+		for {
+			// a full batch means more deliveries are due, they are sent without waiting for the next tick.
+			sent, err := d.DeliverDue(d.ctx)
+			if err == nil && int64(sent) == d.batchSize {
+				select {
+				case <-d.stop:
+					return
+				default:
+					continue
+				}
+			}
+
+			select {
+			case <-d.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Close stops polling and waits for the batch in flight until ctx is done, then it is canceled.
+// The deliveries of a canceled batch stay pending and are sent on the next start.
+func (d *Dispatcher) Close(ctx context.Context) (err error) {
+	close(d.stop)
+	select {
+	case <-d.done:
+	case <-ctx.Done():
+		d.cancel()
+		<-d.done
+		err = ctx.Err()
+	}
+	d.cancel()
+
+	d.logger.Info(dispatcherStoppedMessage)
+	return
+}
+
+// DeliverDue sends a batch of the due deliveries and records the outcome of every attempt.
+// sent counts the attempts, the failed ones included.
+func (d *Dispatcher) DeliverDue(ctx context.Context) (sent int, err error) {
+	deliveries, err := d.deliveries.FindDue(ctx, time.Now().UnixNano(), d.batchSize)
+	if err != nil {
+		return
+	}
+
+	for _, delivery := range deliveries {
+		if ctx.Err() != nil {
+			return
+		}
+		if err = d.deliver(ctx, delivery); err != nil {
+			return
+		}
+		sent++
+	}
+
+	return
+}
+
+// deliver makes one attempt of delivery. A delivery whose subscription is deleted is dead without an attempt.
+func (d *Dispatcher) deliver(ctx context.Context, delivery entity.WebhookDelivery) (err error) {
+	subscription, err := d.repository.FindOne(ctx, delivery.Owner, delivery.SubscriptionID)
+	if err == exception.ErrNotFound {
+		delivery.Status = StatusDead
+		delivery.LastError = subscriptionGoneMessage
+		return d.deliveries.UpdateOne(ctx, delivery)
+	}
+	if err != nil {
+		return
+	}
+
+	delivery.Attempts++
+	delivery.ResponseCode, err = d.send(ctx, subscription, delivery)
+	now := time.Now()
+	if err == nil {
+		delivery.Status = StatusDelivered
+		delivery.LastError = ""
+		delivery.DeliveredAt = now.UnixNano()
+		return d.deliveries.UpdateOne(ctx, delivery)
+	}
+	if ctx.Err() != nil {
+		// the attempt was canceled by Close, it is not counted.
+		return ctx.Err()
+	}
+
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= d.maxAttempts {
+		delivery.Status = StatusDead
+		d.logger.Warn(fmt.Sprintf(deliveryDeadMessage, delivery.Event, delivery.ID, delivery.Attempts, err))
+	} else {
+		backoff := d.backoff(delivery.Attempts)
+		delivery.NextAttemptAt = now.Add(backoff).UnixNano()
+		d.logger.Warn(fmt.Sprintf(deliveryFailedMessage, delivery.Event, delivery.ID, delivery.Attempts, backoff, err))
+	}
+	return d.deliveries.UpdateOne(ctx, delivery)
+}
+
+// send posts the payload of delivery to the subscription, it fails on a transport error or a non 2xx status.
+func (d *Dispatcher) send(ctx context.Context, subscription entity.WebhookSubscription, delivery entity.WebhookDelivery) (code int, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(subscription.Secret, delivery.Payload))
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(DeliveryHeader, delivery.ID)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, maxResponseSize))
+
+	code = resp.StatusCode
+	if code < http.StatusOK || code >= http.StatusMultipleChoices {
+		err = fmt.Errorf(unexpectedStatusMessage, code)
+	}
+	return
+}
+
+// backoff returns the delay after the given attempt, it doubles at every attempt.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	backoff := d.interval
+	for i := 1; i < attempts && backoff < d.maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > d.maxBackoff {
+		backoff = d.maxBackoff
+	}
+	return backoff
+}
+
+// accepts reports whether subscription is subscribed to eventType, an empty filter accepts every event.
+func accepts(subscription entity.WebhookSubscription, eventType string) bool {
+	if len(subscription.Events) < 1 {
+		return true
+	}
+	for _, event := range subscription.Events {
+		if event == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// envelope returns the body of a delivery, the same envelope as the events published by the outbox.
+func envelope(id string, eventType string, occurredAt time.Time, data interface{}) (body []byte, err error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+
+	return json.Marshal(outbox.Message{
+		ID:         id,
+		Type:       eventType,
+		OccurredAt: occurredAt.UTC(),
+		Data:       json.RawMessage(payload),
+	})
+}
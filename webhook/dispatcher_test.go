@@ -0,0 +1,240 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ijalalfrz/sirclo-weight-test/entity"
+	"github.com/ijalalfrz/sirclo-weight-test/outbox"
+	"github.com/ijalalfrz/sirclo-weight-test/webhook"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// subscriber is a webhook receiver which answers with the given statuses in turn, the last one repeatedly.
+type subscriber struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (s *subscriber) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	body, _ := ioutil.ReadAll(r.Body)
+	s.requests = append(s.requests, r)
+	s.bodies = append(s.bodies, body)
+
+	status := s.statuses[0]
+	if len(s.statuses) > 1 {
+		s.statuses = s.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func newDispatcher(t *testing.T, statuses ...int) (*webhook.Dispatcher, webhook.Repository, webhook.DeliveryRepository, *subscriber, string) {
+	receiver := &subscriber{statuses: statuses}
+	server := httptest.NewServer(receiver)
+	t.Cleanup(server.Close)
+
+	subscriptions := webhook.NewMemoryRepository()
+	deliveries := webhook.NewMemoryDeliveryRepository()
+	dispatcher := webhook.NewDispatcher(webhook.DispatcherProperty{
+		Logger:      logrus.New(),
+		Repository:  subscriptions,
+		Deliveries:  deliveries,
+		Client:      server.Client(),
+		Interval:    time.Millisecond,
+		MaxAttempts: 3,
+		MaxBackoff:  time.Millisecond * 2,
+	})
+	return dispatcher, subscriptions, deliveries, receiver, server.URL
+}
+
+// deliverAll sends the due deliveries until none is left or the deadline passes.
+func deliverAll(t *testing.T, dispatcher *webhook.Dispatcher, deliveries webhook.DeliveryRepository) {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		_, err := dispatcher.DeliverDue(context.TODO())
+		require.NoError(t, err)
+
+		pending, err := deliveries.FindDue(context.TODO(), time.Now().Add(time.Hour).UnixNano(), 0)
+		require.NoError(t, err)
+		if len(pending) == 0 {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestDispatcher_Notify_Success_Signed(t *testing.T) {
+	dispatcher, subscriptions, deliveries, receiver, url := newDispatcher(t, http.StatusNoContent)
+	require.NoError(t, subscriptions.InsertOne(context.TODO(), entity.WebhookSubscription{ID: "a", Owner: "john", URL: url, Secret: "s3cret"}))
+
+	require.NoError(t, dispatcher.Notify(context.TODO(), "weight.created", "john", map[string]int{"max": 3}))
+	sent, err := dispatcher.DeliverDue(context.TODO())
+	assert.NoError(t, err, "should be no error")
+	assert.Equal(t, 1, sent)
+
+	if assert.Len(t, receiver.requests, 1) {
+		request, body := receiver.requests[0], receiver.bodies[0]
+		assert.Equal(t, http.MethodPost, request.Method)
+		assert.Equal(t, "application/json", request.Header.Get("Content-Type"))
+		assert.Equal(t, webhook.Sign("s3cret", body), request.Header.Get(webhook.SignatureHeader), "should sign the body with the secret")
+		assert.Equal(t, "weight.created", request.Header.Get(webhook.EventHeader))
+
+		var message outbox.Message
+		require.NoError(t, json.Unmarshal(body, &message))
+		assert.NotEmpty(t, message.ID)
+		assert.Equal(t, "weight.created", message.Type)
+		assert.JSONEq(t, `{"max":3}`, string(message.Data))
+
+		logged, err := deliveries.FindMany(context.TODO(), "john", "a", 10)
+		require.NoError(t, err)
+		if assert.Len(t, logged, 1) {
+			assert.Equal(t, logged[0].ID, request.Header.Get(webhook.DeliveryHeader))
+			assert.Equal(t, webhook.StatusDelivered, logged[0].Status)
+			assert.Equal(t, 1, logged[0].Attempts)
+			assert.Equal(t, http.StatusNoContent, logged[0].ResponseCode)
+			assert.NotZero(t, logged[0].DeliveredAt)
+		}
+	}
+}
+
+func TestDispatcher_Notify_Success_EventFilter(t *testing.T) {
+	dispatcher, subscriptions, deliveries, _, url := newDispatcher(t, http.StatusOK)
+	require.NoError(t, subscriptions.InsertOne(context.TODO(), entity.WebhookSubscription{ID: "all", Owner: "john", URL: url}))
+	require.NoError(t, subscriptions.InsertOne(context.TODO(), entity.WebhookSubscription{ID: "updated", Owner: "john", URL: url, Events: []string{"weight.updated"}}))
+	require.NoError(t, subscriptions.InsertOne(context.TODO(), entity.WebhookSubscription{ID: "jane", Owner: "jane", URL: url}))
+
+	require.NoError(t, dispatcher.Notify(context.TODO(), "weight.created", "john", nil))
+
+	for id, expected := range map[string]int{"all": 1, "updated": 0, "jane": 0} {
+		owner := "john"
+		if id == "jane" {
+			owner = "jane"
+		}
+		logged, err := deliveries.FindMany(context.TODO(), owner, id, 10)
+		assert.NoError(t, err)
+		assert.Len(t, logged, expected, "should only deliver to the subscriptions of the owner which accept the event: "+id)
+	}
+}
+
+func TestDispatcher_DeliverDue_Success_Retried(t *testing.T) {
+	dispatcher, subscriptions, deliveries, receiver, url := newDispatcher(t, http.StatusInternalServerError, http.StatusOK)
+	require.NoError(t, subscriptions.InsertOne(context.TODO(), entity.WebhookSubscription{ID: "a", URL: url}))
+	require.NoError(t, dispatcher.Notify(context.TODO(), "weight.updated", "", nil))
+
+	_, err := dispatcher.DeliverDue(context.TODO())
+	require.NoError(t, err)
+	logged, err := deliveries.FindMany(context.TODO(), "", "a", 10)
+	require.NoError(t, err)
+	if assert.Len(t, logged, 1) {
+		assert.Equal(t, webhook.StatusPending, logged[0].Status, "should retry a failed delivery")
+		assert.Equal(t, "unexpected status 500", logged[0].LastError)
+		assert.Greater(t, logged[0].NextAttemptAt, logged[0].CreatedAt, "should wait before the next attempt")
+	}
+
+	deliverAll(t, dispatcher, deliveries)
+	logged, err = deliveries.FindMany(context.TODO(), "", "a", 10)
+	require.NoError(t, err)
+	if assert.Len(t, logged, 1) {
+		assert.Equal(t, webhook.StatusDelivered, logged[0].Status)
+		assert.Equal(t, 2, logged[0].Attempts)
+		assert.Empty(t, logged[0].LastError)
+	}
+	assert.Len(t, receiver.requests, 2)
+}
+
+func TestDispatcher_DeliverDue_Error_Dead(t *testing.T) {
+	dispatcher, subscriptions, deliveries, receiver, url := newDispatcher(t, http.StatusBadGateway)
+	require.NoError(t, subscriptions.InsertOne(context.TODO(), entity.WebhookSubscription{ID: "a", URL: url}))
+	require.NoError(t, dispatcher.Notify(context.TODO(), "weight.updated", "", nil))
+
+	deliverAll(t, dispatcher, deliveries)
+
+	logged, err := deliveries.FindMany(context.TODO(), "", "a", 10)
+	require.NoError(t, err)
+	if assert.Len(t, logged, 1) {
+		assert.Equal(t, webhook.StatusDead, logged[0].Status, "should give up after the maximum attempts")
+		assert.Equal(t, 3, logged[0].Attempts)
+		assert.Equal(t, http.StatusBadGateway, logged[0].ResponseCode)
+	}
+	assert.Len(t, receiver.requests, 3)
+}
+
+func TestDispatcher_DeliverDue_Error_SubscriptionDeleted(t *testing.T) {
+	dispatcher, subscriptions, deliveries, receiver, url := newDispatcher(t, http.StatusOK)
+	require.NoError(t, subscriptions.InsertOne(context.TODO(), entity.WebhookSubscription{ID: "a", URL: url}))
+	require.NoError(t, dispatcher.Notify(context.TODO(), "weight.updated", "", nil))
+	require.NoError(t, subscriptions.DeleteOne(context.TODO(), "", "a"))
+
+	_, err := dispatcher.DeliverDue(context.TODO())
+	require.NoError(t, err)
+
+	logged, err := deliveries.FindMany(context.TODO(), "", "a", 10)
+	require.NoError(t, err)
+	if assert.Len(t, logged, 1) {
+		assert.Equal(t, webhook.StatusDead, logged[0].Status)
+		assert.Equal(t, 0, logged[0].Attempts)
+	}
+	assert.Empty(t, receiver.requests, "should not deliver to a deleted subscription")
+}
+
+func TestDispatcher_DeliverDue_Error_PrivateAddress(t *testing.T) {
+	receiver := &subscriber{statuses: []int{http.StatusOK}}
+	server := httptest.NewServer(receiver)
+	t.Cleanup(server.Close)
+
+	subscriptions := webhook.NewMemoryRepository()
+	deliveries := webhook.NewMemoryDeliveryRepository()
+	dispatcher := webhook.NewDispatcher(webhook.DispatcherProperty{
+		Logger:     logrus.New(),
+		Repository: subscriptions,
+		Deliveries: deliveries,
+	})
+	require.NoError(t, subscriptions.InsertOne(context.TODO(), entity.WebhookSubscription{ID: "a", URL: server.URL}))
+	require.NoError(t, subscriptions.InsertOne(context.TODO(), entity.WebhookSubscription{ID: "b", URL: "http://169.254.169.254/latest/meta-data/"}))
+	require.NoError(t, dispatcher.Notify(context.TODO(), "weight.created", "", nil))
+
+	_, err := dispatcher.DeliverDue(context.TODO())
+	require.NoError(t, err)
+
+	for _, id := range []string{"a", "b"} {
+		logged, err := deliveries.FindMany(context.TODO(), "", id, 10)
+		require.NoError(t, err)
+		if assert.Len(t, logged, 1) {
+			assert.Equal(t, 1, logged[0].Attempts)
+			assert.Contains(t, logged[0].LastError, "is not public", "should refuse to dial a private address")
+		}
+	}
+	assert.Empty(t, receiver.requests, "should not reach the loopback subscriber")
+}
+
+func TestDispatcher_Start_Success(t *testing.T) {
+	dispatcher, subscriptions, deliveries, _, url := newDispatcher(t, http.StatusOK)
+	require.NoError(t, subscriptions.InsertOne(context.TODO(), entity.WebhookSubscription{ID: "a", URL: url}))
+	require.NoError(t, dispatcher.Notify(context.TODO(), "weight.created", "", nil))
+
+	dispatcher.Start()
+	assert.Eventually(t, func() bool {
+		logged, _ := deliveries.FindMany(context.TODO(), "", "a", 10)
+		return len(logged) == 1 && logged[0].Status == webhook.StatusDelivered
+	}, time.Second, time.Millisecond*5)
+	assert.NoError(t, dispatcher.Close(context.TODO()))
+}
+
+func TestSign(t *testing.T) {
+	// the signature of RFC 4231 test case 2.
+	assert.Equal(t, "sha256=5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843",
+		webhook.Sign("Jefe", []byte("what do ya want for nothing?")))
+}
@@ -0,0 +1,66 @@
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/ijalalfrz/sirclo-weight-test/exception"
+	"github.com/ijalalfrz/sirclo-weight-test/model"
+	"github.com/ijalalfrz/sirclo-weight-test/response"
+)
+
+const (
+	apiBasePath = "/api/v1/webhooks"
+)
+
+// collection of api message
+const (
+	invalidPayloadErrMessage = "Invalid request payload"
+)
+
+func (handler HTTPHandler) APIFindMany(w http.ResponseWriter, r *http.Request) {
+	resp := handler.Usecase.FindMany(r.Context())
+	response.JSON(w, resp)
+}
+
+func (handler HTTPHandler) APIInsertOne(w http.ResponseWriter, r *http.Request) {
+	payload := model.WebhookPayload{}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		response.JSON(w, handler.invalidPayloadResponse(invalidPayloadErrMessage))
+		return
+	}
+
+	if err := handler.validateRequest(payload); err != nil {
+		response.JSON(w, handler.invalidPayloadResponse(err.Error()))
+		return
+	}
+
+	resp := handler.Usecase.InsertOne(r.Context(), payload)
+	response.JSON(w, resp)
+}
+
+func (handler HTTPHandler) APIFindOne(w http.ResponseWriter, r *http.Request) {
+	resp := handler.Usecase.FindOne(r.Context(), mux.Vars(r)["id"])
+	response.JSON(w, resp)
+}
+
+func (handler HTTPHandler) APIDeleteOne(w http.ResponseWriter, r *http.Request) {
+	resp := handler.Usecase.DeleteOne(r.Context(), mux.Vars(r)["id"])
+	response.JSON(w, resp)
+}
+
+func (handler HTTPHandler) APIDeliveries(w http.ResponseWriter, r *http.Request) {
+	resp := handler.Usecase.Deliveries(r.Context(), mux.Vars(r)["id"])
+	response.JSON(w, resp)
+}
+
+func (handler HTTPHandler) APIRedeliver(w http.ResponseWriter, r *http.Request) {
+	pathVariables := mux.Vars(r)
+	resp := handler.Usecase.Redeliver(r.Context(), pathVariables["id"], pathVariables["delivery"])
+	response.JSON(w, resp)
+}
+
+func (handler HTTPHandler) invalidPayloadResponse(message string) response.Response {
+	return response.NewErrorResponse(exception.ErrBadRequest, http.StatusBadRequest, nil, response.StatusInvalidPayload, message)
+}
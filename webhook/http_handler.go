@@ -0,0 +1,177 @@
+package webhook
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/ijalalfrz/sirclo-weight-test/model"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	basePath = "/webhook"
+)
+
+// HTTPHandler is a concrete struct of webhook http handler.
+// The pages are rendered with html/template since the URLs and the errors of the subscribers are shown as is.
+type HTTPHandler struct {
+	Logger       *logrus.Logger
+	Validate     *validator.Validate
+	Usecase      Usecase
+	TemplatePath string
+}
+
+func NewWebhookHTTPHandler(logger *logrus.Logger, validate *validator.Validate, router *mux.Router, usecase Usecase) {
+	handler := &HTTPHandler{
+		Logger:       logger,
+		Validate:     validate,
+		Usecase:      usecase,
+		TemplatePath: "./webhook/template/",
+	}
+	router.HandleFunc(basePath, handler.Index).Methods(http.MethodGet)
+	router.HandleFunc(basePath+"/{id}/deliveries", handler.Deliveries).Methods(http.MethodGet)
+
+	router.HandleFunc(basePath, handler.AddWebhook).Methods(http.MethodPost)
+	router.HandleFunc(basePath+"/{id}/delete", handler.DeleteWebhook).Methods(http.MethodPost)
+	router.HandleFunc(basePath+"/{id}/deliveries/{delivery}/redeliver", handler.Redeliver).Methods(http.MethodPost)
+
+	router.HandleFunc(apiBasePath, handler.APIFindMany).Methods(http.MethodGet)
+	router.HandleFunc(apiBasePath, handler.APIInsertOne).Methods(http.MethodPost)
+	router.HandleFunc(apiBasePath+"/{id}", handler.APIFindOne).Methods(http.MethodGet)
+	router.HandleFunc(apiBasePath+"/{id}", handler.APIDeleteOne).Methods(http.MethodDelete)
+	router.HandleFunc(apiBasePath+"/{id}/deliveries", handler.APIDeliveries).Methods(http.MethodGet)
+	router.HandleFunc(apiBasePath+"/{id}/deliveries/{delivery}/redeliver", handler.APIRedeliver).Methods(http.MethodPost)
+}
+
+func (handler HTTPHandler) Index(w http.ResponseWriter, r *http.Request) {
+	handler.renderIndex(w, r, nil)
+}
+
+// renderIndex renders the list of webhooks, created is the webhook just created with its secret, or nil.
+func (handler HTTPHandler) renderIndex(w http.ResponseWriter, r *http.Request, created interface{}) {
+	resp := handler.Usecase.FindMany(r.Context())
+
+	errMessage := r.Header.Get("error")
+	if errMessage == "" && resp.Error() != nil {
+		errMessage = resp.Message()
+	}
+	data := map[string]interface{}{
+		"Error":   errMessage,
+		"Data":    resp.Data(),
+		"Created": created,
+	}
+
+	tmpl := template.Must(template.ParseFiles(fmt.Sprintf("%s%s", handler.TemplatePath, "index.html")))
+
+	tmpl.Execute(w, data)
+	return
+}
+
+// AddWebhook subscribes the URL of the form and shows the list again with the secret of the new subscription,
+// since the secret is not shown anymore afterwards.
+func (handler HTTPHandler) AddWebhook(w http.ResponseWriter, r *http.Request) {
+	// the events are a comma separated list, an empty list subscribes to every event.
+	var events []string
+	for _, event := range strings.Split(r.FormValue("events"), ",") {
+		if event = strings.TrimSpace(event); event != "" {
+			events = append(events, event)
+		}
+	}
+	payload := model.WebhookPayload{
+		URL:    strings.TrimSpace(r.FormValue("url")),
+		Secret: strings.TrimSpace(r.FormValue("secret")),
+		Events: events,
+	}
+
+	if err := handler.validateRequest(payload); err != nil {
+		r.Header.Set("error", err.Error())
+		handler.Index(w, r)
+		return
+	}
+
+	resp := handler.Usecase.InsertOne(r.Context(), payload)
+	if resp.Error() != nil {
+		r.Header.Set("error", resp.Message())
+		handler.Index(w, r)
+		return
+	}
+
+	handler.renderIndex(w, r, resp.Data())
+	return
+}
+
+func (handler HTTPHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	resp := handler.Usecase.DeleteOne(r.Context(), mux.Vars(r)["id"])
+	if resp.Error() == nil {
+		http.Redirect(w, r, basePath, http.StatusSeeOther)
+	} else {
+		r.Header.Set("error", resp.Message())
+		handler.Index(w, r)
+		return
+	}
+	return
+}
+
+// Deliveries is the delivery log of a webhook.
+func (handler HTTPHandler) Deliveries(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	webhookResp := handler.Usecase.FindOne(r.Context(), id)
+	if webhookResp.Error() != nil {
+		r.Header.Set("error", webhookResp.Message())
+		handler.Index(w, r)
+		return
+	}
+	resp := handler.Usecase.Deliveries(r.Context(), id)
+
+	errMessage := r.Header.Get("error")
+	if errMessage == "" && resp.Error() != nil {
+		errMessage = resp.Message()
+	}
+	data := map[string]interface{}{
+		"Error":   errMessage,
+		"Webhook": webhookResp.Data(),
+		"Data":    resp.Data(),
+	}
+
+	tmpl := template.Must(template.ParseFiles(fmt.Sprintf("%s%s", handler.TemplatePath, "deliveries.html")))
+
+	tmpl.Execute(w, data)
+	return
+}
+
+func (handler HTTPHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	pathVariables := mux.Vars(r)
+	resp := handler.Usecase.Redeliver(r.Context(), pathVariables["id"], pathVariables["delivery"])
+	if resp.Error() == nil {
+		http.Redirect(w, r, fmt.Sprintf("%s/%s/deliveries", basePath, url.PathEscape(pathVariables["id"])), http.StatusSeeOther)
+	} else {
+		r.Header.Set("error", resp.Message())
+		handler.Deliveries(w, r)
+		return
+	}
+	return
+}
+
+// validateRequest checks the payload, the URL must be an absolute http or https URL.
+func (handler HTTPHandler) validateRequest(payload model.WebhookPayload) (err error) {
+	err = handler.Validate.Struct(payload)
+	if err == nil {
+		target, _ := url.Parse(payload.URL)
+		if target == nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+			err = fmt.Errorf("URL must be an absolute http or https URL")
+			return
+		}
+		return
+	}
+
+	errorFields := err.(validator.ValidationErrors)
+	errorField := errorFields[0]
+	err = fmt.Errorf("Invalid '%s' with value '%v'", errorField.Field(), errorField.Value())
+
+	return
+}
@@ -0,0 +1,132 @@
+package webhook_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/ijalalfrz/sirclo-weight-test/exception"
+	"github.com/ijalalfrz/sirclo-weight-test/model"
+	"github.com/ijalalfrz/sirclo-weight-test/response"
+	"github.com/ijalalfrz/sirclo-weight-test/webhook"
+	"github.com/ijalalfrz/sirclo-weight-test/webhook/mocks"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newHandler(usecase webhook.Usecase) (webhook.HTTPHandler, *mux.Router) {
+	hh := webhook.HTTPHandler{
+		Logger:       logrus.New(),
+		Validate:     validator.New(),
+		Usecase:      usecase,
+		TemplatePath: "./template/",
+	}
+	router := mux.NewRouter()
+	webhook.NewWebhookHTTPHandler(hh.Logger, hh.Validate, router, usecase)
+	return hh, router
+}
+
+func TestHttpHandler_APIInsertOne_Success(t *testing.T) {
+	usecase := new(mocks.Usecase)
+	_, router := newHandler(usecase)
+
+	payload := model.WebhookPayload{URL: "https://example.com/hook", Events: []string{"weight.created"}}
+	usecase.On("InsertOne", mock.Anything, payload).
+		Return(response.NewSuccessResponse(model.WebhookResponse{ID: "a", Secret: "s3cret"}, response.StatCreated, "created"))
+
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/webhooks", strings.NewReader(`{"url":"https://example.com/hook","events":["weight.created"]}`))
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, r)
+
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"secret":"s3cret"`)
+	usecase.AssertExpectations(t)
+}
+
+func TestHttpHandler_APIInsertOne_Error_InvalidURL(t *testing.T) {
+	usecase := new(mocks.Usecase)
+	_, router := newHandler(usecase)
+
+	for _, body := range []string{`{"url":""}`, `{"url":"ftp://example.com/hook"}`, `{"url":`} {
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/webhooks", strings.NewReader(body))
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, r)
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, body)
+	}
+	usecase.AssertNotCalled(t, "InsertOne", mock.Anything, mock.Anything)
+}
+
+func TestHttpHandler_APIDeliveries_Error_NotFound(t *testing.T) {
+	usecase := new(mocks.Usecase)
+	_, router := newHandler(usecase)
+
+	usecase.On("Deliveries", mock.Anything, "a").
+		Return(response.NewErrorResponse(exception.ErrNotFound, http.StatusNotFound, nil, response.StatNotFound, "Webhook not found"))
+
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/webhooks/a/deliveries", nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, r)
+
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	usecase.AssertExpectations(t)
+}
+
+func TestHttpHandler_APIRedeliver_Success(t *testing.T) {
+	usecase := new(mocks.Usecase)
+	_, router := newHandler(usecase)
+
+	usecase.On("Redeliver", mock.Anything, "a", "b").Return(response.NewSuccessResponse(nil, response.StatOK, "scheduled"))
+
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/webhooks/a/deliveries/b/redeliver", nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, r)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	usecase.AssertExpectations(t)
+}
+
+func TestHttpHandler_AddWebhook_Success_ShowsSecret(t *testing.T) {
+	usecase := new(mocks.Usecase)
+	hh, _ := newHandler(usecase)
+
+	usecase.On("InsertOne", mock.Anything, model.WebhookPayload{URL: "https://example.com/hook", Events: []string{"weight.created", "weight.updated"}}).
+		Return(response.NewSuccessResponse(model.WebhookResponse{ID: "a", URL: "https://example.com/hook", Secret: "s3cret"}, response.StatCreated, "created"))
+	usecase.On("FindMany", mock.Anything).Return(response.NewSuccessResponse([]model.WebhookResponse{{ID: "a", URL: "https://example.com/hook"}}, response.StatOK, "list"))
+
+	form := url.Values{"url": {"https://example.com/hook"}, "events": {"weight.created, weight.updated"}}
+	r := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	recorder := httptest.NewRecorder()
+	http.HandlerFunc(hh.AddWebhook).ServeHTTP(recorder, r)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "s3cret", "should show the secret once")
+	usecase.AssertExpectations(t)
+}
+
+func TestHttpHandler_Deliveries_Success_Escaped(t *testing.T) {
+	usecase := new(mocks.Usecase)
+	hh, _ := newHandler(usecase)
+
+	usecase.On("FindOne", mock.Anything, "a").Return(response.NewSuccessResponse(model.WebhookResponse{ID: "a", URL: "https://example.com/hook"}, response.StatOK, "detail"))
+	usecase.On("Deliveries", mock.Anything, "a").Return(response.NewSuccessResponse([]model.WebhookDeliveryResponse{
+		{ID: "b", Event: "weight.created", Status: webhook.StatusDead, Attempts: 8, LastError: "<script>alert(1)</script>"},
+	}, response.StatOK, "list"))
+
+	r := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/webhook/a/deliveries", nil), map[string]string{"id": "a"})
+	recorder := httptest.NewRecorder()
+	http.HandlerFunc(hh.Deliveries).ServeHTTP(recorder, r)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	body := recorder.Body.Bytes()
+	assert.False(t, bytes.Contains(body, []byte("<script>alert(1)</script>")), "should escape the error of the subscriber")
+	assert.Contains(t, string(body), "/webhook/a/deliveries/b/redeliver", "should offer to redeliver a dead delivery")
+	usecase.AssertExpectations(t)
+}
@@ -0,0 +1,104 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/ijalalfrz/sirclo-weight-test/entity"
+	"github.com/ijalalfrz/sirclo-weight-test/exception"
+	"github.com/ijalalfrz/sirclo-weight-test/mongodb"
+	"github.com/ijalalfrz/sirclo-weight-test/requestctx"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type webhookRepository struct {
+	logger *logrus.Logger
+	col    mongodb.Collection
+}
+
+// NewWebhookRepository is a constructor of a Repository on webhook collection.
+func NewWebhookRepository(logger *logrus.Logger, db mongodb.Database) Repository {
+	col := db.Collection(CollectionName)
+	return &webhookRepository{logger, col}
+}
+
+func (r webhookRepository) InsertOne(ctx context.Context, subscription entity.WebhookSubscription) (err error) {
+	if _, err = r.col.InsertOne(ctx, subscription); err != nil {
+		r.log(ctx).Error(err)
+		err = exception.ErrInternalServer
+		return
+	}
+	return
+}
+
+// FindMany returns the subscriptions of owner, the oldest first.
+func (r webhookRepository) FindMany(ctx context.Context, owner string) (subscriptions []entity.WebhookSubscription, err error) {
+	opt := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := r.col.Find(ctx, bson.M{"owner": ownerFilter(owner)}, opt)
+	if err != nil {
+		r.log(ctx).Error(err)
+		err = exception.ErrInternalServer
+		return
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		subscription := entity.WebhookSubscription{}
+		if err = cursor.Decode(&subscription); err != nil {
+			r.log(ctx).Error(err)
+			err = exception.ErrInternalServer
+			return
+		}
+
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return
+}
+
+func (r webhookRepository) FindOne(ctx context.Context, owner string, id string) (subscription entity.WebhookSubscription, err error) {
+	filter := bson.M{
+		"_id":   id,
+		"owner": ownerFilter(owner),
+	}
+
+	if err = r.col.FindOne(ctx, filter).Decode(&subscription); err != nil {
+		if err != mongo.ErrNoDocuments {
+			r.log(ctx).Error(err)
+			err = exception.ErrInternalServer
+			return
+		}
+		err = exception.ErrNotFound
+		return
+	}
+
+	return
+}
+
+func (r webhookRepository) DeleteOne(ctx context.Context, owner string, id string) (err error) {
+	filter := bson.M{
+		"_id":   id,
+		"owner": ownerFilter(owner),
+	}
+
+	deletedResult, err := r.col.DeleteOne(ctx, filter)
+	if err != nil {
+		r.log(ctx).Error(err)
+		err = exception.ErrInternalServer
+		return
+	}
+
+	if deletedResult.DeletedCount < 1 {
+		err = exception.ErrNotFound
+		return
+	}
+
+	return
+}
+
+func (r webhookRepository) log(ctx context.Context) *logrus.Entry {
+	return requestctx.Logger(ctx, r.logger)
+}
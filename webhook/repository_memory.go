@@ -0,0 +1,81 @@
+package webhook
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/ijalalfrz/sirclo-weight-test/entity"
+	"github.com/ijalalfrz/sirclo-weight-test/exception"
+)
+
+// memoryRepository is a thread-safe Repository which keeps the subscriptions in memory,
+// it serves the storage drivers without a webhook collection.
+type memoryRepository struct {
+	mu            sync.RWMutex
+	subscriptions []entity.WebhookSubscription
+}
+
+// NewMemoryRepository is a constructor.
+func NewMemoryRepository() Repository {
+	return &memoryRepository{}
+}
+
+func (r *memoryRepository) InsertOne(ctx context.Context, subscription entity.WebhookSubscription) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, stored := range r.subscriptions {
+		if stored.ID == subscription.ID {
+			return exception.ErrConflict
+		}
+	}
+	r.subscriptions = append(r.subscriptions, subscription)
+	return
+}
+
+// FindMany returns the subscriptions of owner, the oldest first.
+func (r *memoryRepository) FindMany(ctx context.Context, owner string) (subscriptions []entity.WebhookSubscription, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, subscription := range r.subscriptions {
+		if subscription.Owner == owner {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+	sort.SliceStable(subscriptions, func(i, j int) bool {
+		if subscriptions[i].CreatedAt != subscriptions[j].CreatedAt {
+			return subscriptions[i].CreatedAt < subscriptions[j].CreatedAt
+		}
+		return subscriptions[i].ID < subscriptions[j].ID
+	})
+	return
+}
+
+func (r *memoryRepository) FindOne(ctx context.Context, owner string, id string) (subscription entity.WebhookSubscription, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, stored := range r.subscriptions {
+		if stored.ID == id && stored.Owner == owner {
+			return stored, nil
+		}
+	}
+	err = exception.ErrNotFound
+	return
+}
+
+func (r *memoryRepository) DeleteOne(ctx context.Context, owner string, id string) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, stored := range r.subscriptions {
+		if stored.ID == id && stored.Owner == owner {
+			r.subscriptions = append(r.subscriptions[:i], r.subscriptions[i+1:]...)
+			return
+		}
+	}
+	err = exception.ErrNotFound
+	return
+}
@@ -0,0 +1,234 @@
+package webhook_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ijalalfrz/sirclo-weight-test/entity"
+	"github.com/ijalalfrz/sirclo-weight-test/exception"
+	"github.com/ijalalfrz/sirclo-weight-test/mongodb"
+	"github.com/ijalalfrz/sirclo-weight-test/mongodb/mocks"
+	"github.com/ijalalfrz/sirclo-weight-test/webhook"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func subscription(id string, owner string, createdAt int64) entity.WebhookSubscription {
+	return entity.WebhookSubscription{ID: id, Owner: owner, URL: "http://localhost/" + id, Secret: "s3cret", CreatedAt: createdAt}
+}
+
+func delivery(id string, subscriptionID string, createdAt int64) entity.WebhookDelivery {
+	return entity.WebhookDelivery{
+		ID:             id,
+		SubscriptionID: subscriptionID,
+		Owner:          "john",
+		Event:          "weight.created",
+		Payload:        []byte(`{"max":3}`),
+		Status:         webhook.StatusPending,
+		NextAttemptAt:  createdAt,
+		CreatedAt:      createdAt,
+	}
+}
+
+// testRepositoryConformance runs the behaviour every webhook storage must have against the repositories of newRepositories.
+func testRepositoryConformance(t *testing.T, newRepositories func(t *testing.T) (webhook.Repository, webhook.DeliveryRepository)) {
+	t.Run("FindMany returns the subscriptions of the owner, the oldest first", func(t *testing.T) {
+		repo, _ := newRepositories(t)
+		require.NoError(t, repo.InsertOne(context.TODO(), subscription("b", "john", 2)))
+		require.NoError(t, repo.InsertOne(context.TODO(), subscription("a", "john", 1)))
+		require.NoError(t, repo.InsertOne(context.TODO(), subscription("c", "jane", 3)))
+		require.NoError(t, repo.InsertOne(context.TODO(), subscription("d", "", 4)))
+
+		subscriptions, err := repo.FindMany(context.TODO(), "john")
+		assert.NoError(t, err, "should be no error")
+		if assert.Len(t, subscriptions, 2) {
+			assert.Equal(t, subscription("a", "john", 1), subscriptions[0])
+			assert.Equal(t, "b", subscriptions[1].ID)
+		}
+
+		subscriptions, err = repo.FindMany(context.TODO(), "")
+		assert.NoError(t, err, "should be no error")
+		assert.Len(t, subscriptions, 1, "should only return the subscriptions without owner to anonymous")
+	})
+
+	t.Run("FindOne and DeleteOne are scoped to the owner", func(t *testing.T) {
+		repo, _ := newRepositories(t)
+		require.NoError(t, repo.InsertOne(context.TODO(), subscription("a", "john", 1)))
+
+		_, err := repo.FindOne(context.TODO(), "jane", "a")
+		assert.Equal(t, exception.ErrNotFound, err, "should be not found error")
+		assert.Equal(t, exception.ErrNotFound, repo.DeleteOne(context.TODO(), "jane", "a"), "should be not found error")
+
+		found, err := repo.FindOne(context.TODO(), "john", "a")
+		assert.NoError(t, err, "should be no error")
+		assert.Equal(t, subscription("a", "john", 1), found)
+
+		assert.NoError(t, repo.DeleteOne(context.TODO(), "john", "a"))
+		_, err = repo.FindOne(context.TODO(), "john", "a")
+		assert.Equal(t, exception.ErrNotFound, err, "should be not found error")
+	})
+
+	t.Run("FindDue returns the due pending deliveries, the longest waiting first", func(t *testing.T) {
+		_, deliveries := newRepositories(t)
+		dead := delivery("d", "a", 1)
+		dead.Status = webhook.StatusDead
+		require.NoError(t, deliveries.InsertMany(context.TODO(), []entity.WebhookDelivery{delivery("b", "a", 2), delivery("a", "a", 1), delivery("c", "a", 10), dead}))
+
+		due, err := deliveries.FindDue(context.TODO(), 5, 10)
+		assert.NoError(t, err, "should be no error")
+		if assert.Len(t, due, 2) {
+			assert.Equal(t, delivery("a", "a", 1), due[0])
+			assert.Equal(t, "b", due[1].ID)
+		}
+
+		due, err = deliveries.FindDue(context.TODO(), 5, 1)
+		assert.NoError(t, err, "should be no error")
+		assert.Len(t, due, 1, "should be limited")
+	})
+
+	t.Run("FindMany returns the deliveries of the subscription, the newest first", func(t *testing.T) {
+		_, deliveries := newRepositories(t)
+		require.NoError(t, deliveries.InsertMany(context.TODO(), []entity.WebhookDelivery{delivery("a", "x", 1), delivery("b", "x", 2), delivery("c", "y", 3)}))
+
+		logged, err := deliveries.FindMany(context.TODO(), "john", "x", 10)
+		assert.NoError(t, err, "should be no error")
+		if assert.Len(t, logged, 2) {
+			assert.Equal(t, "b", logged[0].ID)
+			assert.Equal(t, "a", logged[1].ID)
+		}
+
+		logged, err = deliveries.FindMany(context.TODO(), "jane", "x", 10)
+		assert.NoError(t, err, "should be no error")
+		assert.Empty(t, logged, "should be scoped to the owner")
+	})
+
+	t.Run("UpdateOne records the attempt", func(t *testing.T) {
+		_, deliveries := newRepositories(t)
+		require.NoError(t, deliveries.InsertMany(context.TODO(), []entity.WebhookDelivery{delivery("a", "x", 1)}))
+
+		updated := delivery("a", "x", 1)
+		updated.Status = webhook.StatusDelivered
+		updated.Attempts = 2
+		updated.ResponseCode = 204
+		updated.DeliveredAt = 7
+		assert.NoError(t, deliveries.UpdateOne(context.TODO(), updated))
+
+		found, err := deliveries.FindOne(context.TODO(), "john", "a")
+		assert.NoError(t, err, "should be no error")
+		assert.Equal(t, updated, found)
+
+		_, err = deliveries.FindOne(context.TODO(), "jane", "a")
+		assert.Equal(t, exception.ErrNotFound, err, "should be not found error")
+		assert.Equal(t, exception.ErrNotFound, deliveries.UpdateOne(context.TODO(), delivery("b", "x", 1)), "should be not found error")
+	})
+}
+
+func TestMemoryRepository_Conformance(t *testing.T) {
+	testRepositoryConformance(t, func(t *testing.T) (webhook.Repository, webhook.DeliveryRepository) {
+		return webhook.NewMemoryRepository(), webhook.NewMemoryDeliveryRepository()
+	})
+}
+
+// TestWebhookRepository_Conformance runs against the MongoDB of MONGODB_TEST_URL, a database is created for every case.
+func TestWebhookRepository_Conformance(t *testing.T) {
+	uri := os.Getenv("MONGODB_TEST_URL")
+	if uri == "" {
+		t.Skip("MONGODB_TEST_URL is not set")
+	}
+
+	client, err := mongo.Connect(context.TODO(), options.Client().ApplyURI(uri))
+	require.NoError(t, err)
+	defer client.Disconnect(context.TODO())
+
+	testRepositoryConformance(t, func(t *testing.T) (webhook.Repository, webhook.DeliveryRepository) {
+		name := fmt.Sprintf("webhook-conformance-%d", time.Now().UnixNano())
+		t.Cleanup(func() {
+			client.Database(name).Drop(context.TODO())
+		})
+
+		db := mongodb.NewClientAdapter(client).Database(name)
+		return webhook.NewWebhookRepository(logrus.New(), db), webhook.NewDeliveryRepository(logrus.New(), db)
+	})
+}
+
+func TestWebhookRepository_FindOne_Error_Unexpected(t *testing.T) {
+	singleResult := new(mocks.SingleResult)
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+
+	singleResult.On("Decode", mock.Anything).Return(mongo.ErrClientDisconnected)
+	col.On("FindOne", mock.Anything, bson.M{"_id": "a", "owner": "john"}).Return(singleResult)
+	db.On("Collection", "webhook").Return(col)
+
+	repo := webhook.NewWebhookRepository(logrus.New(), db)
+
+	_, err := repo.FindOne(context.TODO(), "john", "a")
+	assert.Equal(t, exception.ErrInternalServer, err, "should be internal server error")
+	col.AssertExpectations(t)
+}
+
+func TestWebhookRepository_DeleteOne_Error_NotFound(t *testing.T) {
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+
+	col.On("DeleteOne", mock.Anything, bson.M{"_id": "a", "owner": nil}).Return(&mongo.DeleteResult{DeletedCount: 0}, nil)
+	db.On("Collection", "webhook").Return(col)
+
+	repo := webhook.NewWebhookRepository(logrus.New(), db)
+
+	err := repo.DeleteOne(context.TODO(), "", "a")
+	assert.Equal(t, exception.ErrNotFound, err, "should be not found error")
+	col.AssertExpectations(t)
+}
+
+func TestDeliveryRepository_InsertMany_Error_Unexpected(t *testing.T) {
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+
+	col.On("InsertMany", mock.Anything, mock.Anything).Return(nil, mongo.ErrClientDisconnected)
+	db.On("Collection", "webhook_delivery").Return(col)
+
+	repo := webhook.NewDeliveryRepository(logrus.New(), db)
+
+	assert.NoError(t, repo.InsertMany(context.TODO(), nil), "should not write an empty batch")
+	err := repo.InsertMany(context.TODO(), []entity.WebhookDelivery{delivery("a", "x", 1)})
+	assert.Equal(t, exception.ErrInternalServer, err, "should be internal server error")
+	col.AssertNumberOfCalls(t, "InsertMany", 1)
+}
+
+func TestDeliveryRepository_FindDue_Error_Unexpected(t *testing.T) {
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+
+	col.On("Find", mock.Anything, bson.M{"status": "PENDING", "nextAttemptAt": bson.M{"$lte": int64(5)}}, mock.Anything).
+		Return(nil, mongo.ErrClientDisconnected)
+	db.On("Collection", "webhook_delivery").Return(col)
+
+	repo := webhook.NewDeliveryRepository(logrus.New(), db)
+
+	_, err := repo.FindDue(context.TODO(), 5, 10)
+	assert.Equal(t, exception.ErrInternalServer, err, "should be internal server error")
+	col.AssertExpectations(t)
+}
+
+func TestDeliveryRepository_UpdateOne_Error_NotFound(t *testing.T) {
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+
+	col.On("UpdateOne", mock.Anything, bson.M{"_id": "a"}, mock.Anything).Return(&mongo.UpdateResult{MatchedCount: 0}, nil)
+	db.On("Collection", "webhook_delivery").Return(col)
+
+	repo := webhook.NewDeliveryRepository(logrus.New(), db)
+
+	err := repo.UpdateOne(context.TODO(), delivery("a", "x", 1))
+	assert.Equal(t, exception.ErrNotFound, err, "should be not found error")
+	col.AssertExpectations(t)
+}
@@ -0,0 +1,282 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ijalalfrz/sirclo-weight-test/entity"
+	"github.com/ijalalfrz/sirclo-weight-test/exception"
+	"github.com/ijalalfrz/sirclo-weight-test/model"
+	"github.com/ijalalfrz/sirclo-weight-test/requestctx"
+	"github.com/ijalalfrz/sirclo-weight-test/response"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// collection of message
+const (
+	insertOneUnexpectedErrMessage = "Unexpected error while inserting webhook"
+	insertOneSuccessMessage       = "Webhook has been successfully inserted"
+	deleteOneUnexpectedErrMessage = "Unexpected error while deleting webhook"
+	deleteOneSuccessMessage       = "Webhook has been successfully deleted"
+	webhookNotFoundErrMessage     = "Webhook not found"
+	webhookSuccessMessage         = "List of webhook"
+	webhookUnexpectedErrMessage   = "Unexpected error while geting webhook data"
+	unknownEventErrMessage        = "Unknown event "
+	invalidURLErrMessage          = "Webhook url must be an absolute http or https url"
+	deliverySuccessMessage        = "List of webhook delivery"
+	deliveryUnexpectedErrMessage  = "Unexpected error while geting webhook delivery"
+	deliveryNotFoundErrMessage    = "Webhook delivery not found"
+	redeliverUnexpectedErrMessage = "Unexpected error while redelivering webhook"
+	redeliverSuccessMessage       = "Webhook delivery has been scheduled again"
+	anonymousWriteErrMessage      = "Sign in to change webhook"
+)
+
+const (
+	secretSize        = 32
+	deliveryPageLimit = 100
+)
+
+// Usecase is collection of behaviour usecase
+type Usecase interface {
+	InsertOne(ctx context.Context, payload model.WebhookPayload) (resp response.Response)
+	FindMany(ctx context.Context) (resp response.Response)
+	FindOne(ctx context.Context, id string) (resp response.Response)
+	DeleteOne(ctx context.Context, id string) (resp response.Response)
+	Deliveries(ctx context.Context, id string) (resp response.Response)
+	Redeliver(ctx context.Context, id string, deliveryID string) (resp response.Response)
+}
+
+// UsecaseProperty is a property of Usecase. Events are the event types a subscription can filter on,
+// RequireOwner rejects the writes of anonymous callers, it is set when authentication is enabled.
+type UsecaseProperty struct {
+	Logger       *logrus.Logger
+	Repository   Repository
+	Deliveries   DeliveryRepository
+	Events       []string
+	RequireOwner bool
+}
+
+type webhookUsecase struct {
+	logger       *logrus.Logger
+	repository   Repository
+	deliveries   DeliveryRepository
+	events       map[string]bool
+	requireOwner bool
+}
+
+// NewWebhookUsecase is constructor
+func NewWebhookUsecase(property UsecaseProperty) Usecase {
+	events := make(map[string]bool)
+	for _, event := range property.Events {
+		events[event] = true
+	}
+
+	return &webhookUsecase{
+		logger:       property.Logger,
+		repository:   property.Repository,
+		deliveries:   property.Deliveries,
+		events:       events,
+		requireOwner: property.RequireOwner,
+	}
+}
+
+// InsertOne subscribes the URL of the payload to the events of the owner.
+// The secret is generated when the payload has none, it is only returned by this response.
+func (u webhookUsecase) InsertOne(ctx context.Context, payload model.WebhookPayload) (resp response.Response) {
+	if resp = u.authorizeWrite(ctx); resp != nil {
+		return
+	}
+
+	if !deliverable(payload.URL) {
+		return response.NewErrorResponse(exception.ErrBadRequest, http.StatusBadRequest, nil, response.StatusInvalidPayload, invalidURLErrMessage)
+	}
+
+	for _, event := range payload.Events {
+		if !u.events[event] {
+			return response.NewErrorResponse(exception.ErrBadRequest, http.StatusBadRequest, nil, response.StatusInvalidPayload, unknownEventErrMessage+event)
+		}
+	}
+
+	secret := payload.Secret
+	if secret == "" {
+		key := make([]byte, secretSize)
+		if _, err := rand.Read(key); err != nil {
+			u.log(ctx).Error(err)
+			return response.NewErrorResponse(err, http.StatusInternalServerError, nil, response.StatUnexpectedError, insertOneUnexpectedErrMessage)
+		}
+		secret = hex.EncodeToString(key)
+	}
+
+	subscription := entity.WebhookSubscription{
+		ID:        primitive.NewObjectID().Hex(),
+		Owner:     requestctx.Owner(ctx),
+		URL:       payload.URL,
+		Secret:    secret,
+		Events:    payload.Events,
+		CreatedAt: time.Now().UnixNano(),
+	}
+	if err := u.repository.InsertOne(ctx, subscription); err != nil {
+		u.log(ctx).Error(err)
+		return response.NewErrorResponse(err, http.StatusInternalServerError, nil, response.StatUnexpectedError, insertOneUnexpectedErrMessage)
+	}
+
+	webhookResponse := u.subscriptionResponse(ctx, subscription)
+	webhookResponse.Secret = secret
+	return response.NewSuccessResponse(webhookResponse, response.StatCreated, insertOneSuccessMessage)
+}
+
+func (u webhookUsecase) FindMany(ctx context.Context) (resp response.Response) {
+	subscriptions, err := u.repository.FindMany(ctx, requestctx.Owner(ctx))
+	if err != nil {
+		u.log(ctx).Error(err)
+		return response.NewErrorResponse(err, http.StatusInternalServerError, nil, response.StatUnexpectedError, webhookUnexpectedErrMessage)
+	}
+
+	webhookResponse := []model.WebhookResponse{}
+	for _, subscription := range subscriptions {
+		webhookResponse = append(webhookResponse, u.subscriptionResponse(ctx, subscription))
+	}
+	return response.NewSuccessResponse(webhookResponse, response.StatOK, webhookSuccessMessage)
+}
+
+func (u webhookUsecase) FindOne(ctx context.Context, id string) (resp response.Response) {
+	subscription, err := u.repository.FindOne(ctx, requestctx.Owner(ctx), id)
+	if err != nil {
+		return u.notFoundOrUnexpected(ctx, err, webhookNotFoundErrMessage, webhookUnexpectedErrMessage)
+	}
+
+	return response.NewSuccessResponse(u.subscriptionResponse(ctx, subscription), response.StatOK, webhookSuccessMessage)
+}
+
+// DeleteOne unsubscribes the webhook, its pending deliveries are dead when they are due.
+func (u webhookUsecase) DeleteOne(ctx context.Context, id string) (resp response.Response) {
+	if resp = u.authorizeWrite(ctx); resp != nil {
+		return
+	}
+
+	if err := u.repository.DeleteOne(ctx, requestctx.Owner(ctx), id); err != nil {
+		return u.notFoundOrUnexpected(ctx, err, webhookNotFoundErrMessage, deleteOneUnexpectedErrMessage)
+	}
+
+	return response.NewSuccessResponse(nil, response.StatOK, deleteOneSuccessMessage)
+}
+
+// Deliveries returns the latest deliveries of the webhook, the newest first.
+// The times are formatted in the timezone of the user.
+func (u webhookUsecase) Deliveries(ctx context.Context, id string) (resp response.Response) {
+	owner := requestctx.Owner(ctx)
+	if _, err := u.repository.FindOne(ctx, owner, id); err != nil {
+		return u.notFoundOrUnexpected(ctx, err, webhookNotFoundErrMessage, deliveryUnexpectedErrMessage)
+	}
+
+	deliveries, err := u.deliveries.FindMany(ctx, owner, id, deliveryPageLimit)
+	if err != nil {
+		u.log(ctx).Error(err)
+		return response.NewErrorResponse(err, http.StatusInternalServerError, nil, response.StatUnexpectedError, deliveryUnexpectedErrMessage)
+	}
+
+	location := requestctx.Location(ctx)
+	deliveryResponse := []model.WebhookDeliveryResponse{}
+	for _, delivery := range deliveries {
+		detail := model.WebhookDeliveryResponse{
+			ID:           delivery.ID,
+			Event:        delivery.Event,
+			Status:       delivery.Status,
+			Attempts:     delivery.Attempts,
+			ResponseCode: delivery.ResponseCode,
+			LastError:    delivery.LastError,
+			CreatedAt:    formatTime(delivery.CreatedAt, location),
+		}
+		if delivery.Status == StatusPending {
+			detail.NextAttemptAt = formatTime(delivery.NextAttemptAt, location)
+		}
+		if delivery.DeliveredAt != 0 {
+			detail.DeliveredAt = formatTime(delivery.DeliveredAt, location)
+		}
+		deliveryResponse = append(deliveryResponse, detail)
+	}
+	return response.NewSuccessResponse(deliveryResponse, response.StatOK, deliverySuccessMessage)
+}
+
+// Redeliver schedules the delivery of the webhook again right away, with a fresh count of attempts.
+// It is meant for the dead deliveries once the subscriber is fixed.
+func (u webhookUsecase) Redeliver(ctx context.Context, id string, deliveryID string) (resp response.Response) {
+	if resp = u.authorizeWrite(ctx); resp != nil {
+		return
+	}
+
+	delivery, err := u.deliveries.FindOne(ctx, requestctx.Owner(ctx), deliveryID)
+	if err != nil {
+		return u.notFoundOrUnexpected(ctx, err, deliveryNotFoundErrMessage, redeliverUnexpectedErrMessage)
+	}
+	if delivery.SubscriptionID != id {
+		return response.NewErrorResponse(exception.ErrNotFound, http.StatusNotFound, nil, response.StatNotFound, deliveryNotFoundErrMessage)
+	}
+
+	delivery.Status = StatusPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = time.Now().UnixNano()
+	delivery.LastError = ""
+	delivery.DeliveredAt = 0
+	if err = u.deliveries.UpdateOne(ctx, delivery); err != nil {
+		return u.notFoundOrUnexpected(ctx, err, deliveryNotFoundErrMessage, redeliverUnexpectedErrMessage)
+	}
+
+	return response.NewSuccessResponse(nil, response.StatOK, redeliverSuccessMessage)
+}
+
+// authorizeWrite rejects the write of an anonymous caller when every subscription must have an owner,
+// otherwise anonymous callers would share the subscriptions without owner.
+func (u webhookUsecase) authorizeWrite(ctx context.Context) (resp response.Response) {
+	if u.requireOwner && requestctx.Owner(ctx) == "" {
+		return response.NewErrorResponse(exception.ErrUnauthorized, http.StatusUnauthorized, nil, response.StatUnauthorized, anonymousWriteErrMessage)
+	}
+	return nil
+}
+
+// log returns the request scoped logger of ctx.
+func (u webhookUsecase) log(ctx context.Context) *logrus.Entry {
+	return requestctx.Logger(ctx, u.logger)
+}
+
+func (u webhookUsecase) notFoundOrUnexpected(ctx context.Context, err error, notFoundMessage string, unexpectedMessage string) response.Response {
+	u.log(ctx).Error(err)
+	if err != exception.ErrNotFound {
+		return response.NewErrorResponse(err, http.StatusInternalServerError, nil, response.StatUnexpectedError, unexpectedMessage)
+	}
+
+	return response.NewErrorResponse(exception.ErrNotFound, http.StatusNotFound, nil, response.StatNotFound, notFoundMessage)
+}
+
+func (u webhookUsecase) subscriptionResponse(ctx context.Context, subscription entity.WebhookSubscription) model.WebhookResponse {
+	events := subscription.Events
+	if events == nil {
+		events = []string{}
+	}
+
+	return model.WebhookResponse{
+		ID:        subscription.ID,
+		URL:       subscription.URL,
+		Events:    events,
+		CreatedAt: formatTime(subscription.CreatedAt, requestctx.Location(ctx)),
+	}
+}
+
+func formatTime(timestamp int64, location *time.Location) string {
+	return time.Unix(0, timestamp).In(location).Format(time.RFC3339)
+}
+
+// deliverable reports whether rawURL can be delivered to, an absolute http or https url.
+// The address it resolves to is checked by the client of the dispatcher on every delivery.
+func deliverable(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Hostname() != ""
+}
@@ -0,0 +1,183 @@
+package webhook_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ijalalfrz/sirclo-weight-test/entity"
+	"github.com/ijalalfrz/sirclo-weight-test/exception"
+	"github.com/ijalalfrz/sirclo-weight-test/model"
+	"github.com/ijalalfrz/sirclo-weight-test/requestctx"
+	"github.com/ijalalfrz/sirclo-weight-test/webhook"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newUsecase() (webhook.Usecase, webhook.Repository, webhook.DeliveryRepository) {
+	subscriptions := webhook.NewMemoryRepository()
+	deliveries := webhook.NewMemoryDeliveryRepository()
+	usecase := webhook.NewWebhookUsecase(webhook.UsecaseProperty{
+		Logger:     logrus.New(),
+		Repository: subscriptions,
+		Deliveries: deliveries,
+		Events:     []string{"weight.created", "weight.updated"},
+	})
+	return usecase, subscriptions, deliveries
+}
+
+func TestUsecaseInsertOne_Success_GeneratedSecret(t *testing.T) {
+	usecase, subscriptions, _ := newUsecase()
+	ctx := requestctx.WithOwner(context.TODO(), "john")
+
+	result := usecase.InsertOne(ctx, model.WebhookPayload{URL: "http://localhost/hook", Events: []string{"weight.created"}})
+
+	assert.Nil(t, result.Error(), "should be no error")
+	assert.Equal(t, http.StatusCreated, result.HTTPStatusCode())
+	created := result.Data().(model.WebhookResponse)
+	assert.Len(t, created.Secret, 64, "should generate a secret")
+
+	stored, err := subscriptions.FindOne(context.TODO(), "john", created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, created.Secret, stored.Secret)
+	assert.Equal(t, []string{"weight.created"}, stored.Events)
+
+	listed := usecase.FindMany(ctx).Data().([]model.WebhookResponse)
+	if assert.Len(t, listed, 1) {
+		assert.Empty(t, listed[0].Secret, "should not show the secret again")
+	}
+}
+
+func TestUsecaseInsertOne_Success_GivenSecret(t *testing.T) {
+	usecase, _, _ := newUsecase()
+
+	result := usecase.InsertOne(context.TODO(), model.WebhookPayload{URL: "http://localhost/hook", Secret: "s3cret"})
+
+	assert.Nil(t, result.Error(), "should be no error")
+	created := result.Data().(model.WebhookResponse)
+	assert.Equal(t, "s3cret", created.Secret)
+	assert.Equal(t, []string{}, created.Events, "should subscribe to every event")
+}
+
+func TestUsecaseInsertOne_Error_UnknownEvent(t *testing.T) {
+	usecase, _, _ := newUsecase()
+
+	result := usecase.InsertOne(context.TODO(), model.WebhookPayload{URL: "http://localhost/hook", Events: []string{"weight.deleted"}})
+
+	assert.Equal(t, exception.ErrBadRequest, result.Error(), "should be bad request error")
+	assert.Equal(t, "Unknown event weight.deleted", result.Message())
+}
+
+func TestUsecaseInsertOne_Error_InvalidURL(t *testing.T) {
+	usecase, subscriptions, _ := newUsecase()
+
+	for _, url := range []string{"file:///etc/passwd", "gopher://example.com/hook", "ftp://example.com/hook", "https:///hook", "/hook"} {
+		result := usecase.InsertOne(context.TODO(), model.WebhookPayload{URL: url})
+
+		assert.Equal(t, exception.ErrBadRequest, result.Error(), "should be bad request error for %s", url)
+		assert.Equal(t, http.StatusBadRequest, result.HTTPStatusCode())
+	}
+	stored, err := subscriptions.FindMany(context.TODO(), "")
+	require.NoError(t, err)
+	assert.Empty(t, stored, "should not subscribe")
+}
+
+func TestUsecaseInsertOne_Error_Anonymous(t *testing.T) {
+	subscriptions := webhook.NewMemoryRepository()
+	usecase := webhook.NewWebhookUsecase(webhook.UsecaseProperty{
+		Logger:       logrus.New(),
+		Repository:   subscriptions,
+		Deliveries:   webhook.NewMemoryDeliveryRepository(),
+		RequireOwner: true,
+	})
+
+	result := usecase.InsertOne(context.TODO(), model.WebhookPayload{URL: "http://localhost/hook"})
+
+	assert.Equal(t, exception.ErrUnauthorized, result.Error())
+	assert.Equal(t, http.StatusUnauthorized, result.HTTPStatusCode())
+	stored, err := subscriptions.FindMany(context.TODO(), "")
+	require.NoError(t, err)
+	assert.Empty(t, stored, "should not subscribe")
+}
+
+func TestUsecaseFindOne_Error_NotFound(t *testing.T) {
+	usecase, subscriptions, _ := newUsecase()
+	require.NoError(t, subscriptions.InsertOne(context.TODO(), subscription("a", "john", 1)))
+
+	result := usecase.FindOne(requestctx.WithOwner(context.TODO(), "jane"), "a")
+
+	assert.Equal(t, exception.ErrNotFound, result.Error(), "should be not found error")
+}
+
+func TestUsecaseDeleteOne_Success(t *testing.T) {
+	usecase, subscriptions, _ := newUsecase()
+	require.NoError(t, subscriptions.InsertOne(context.TODO(), subscription("a", "john", 1)))
+	ctx := requestctx.WithOwner(context.TODO(), "john")
+
+	assert.Nil(t, usecase.DeleteOne(ctx, "a").Error(), "should be no error")
+	assert.Equal(t, exception.ErrNotFound, usecase.DeleteOne(ctx, "a").Error(), "should be not found error")
+}
+
+func TestUsecaseDeliveries_Success(t *testing.T) {
+	jakarta, _ := time.LoadLocation("Asia/Jakarta")
+	usecase, subscriptions, deliveries := newUsecase()
+	require.NoError(t, subscriptions.InsertOne(context.TODO(), subscription("x", "john", 1)))
+	delivered := delivery("a", "x", time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC).UnixNano())
+	delivered.Status = webhook.StatusDelivered
+	delivered.DeliveredAt = delivered.CreatedAt
+	require.NoError(t, deliveries.InsertMany(context.TODO(), []entity.WebhookDelivery{delivered, delivery("b", "x", delivered.CreatedAt+1)}))
+
+	ctx := requestctx.WithOwner(context.TODO(), "john")
+	ctx = requestctx.WithLocation(ctx, jakarta)
+	result := usecase.Deliveries(ctx, "x")
+
+	assert.Nil(t, result.Error(), "should be no error")
+	logged := result.Data().([]model.WebhookDeliveryResponse)
+	if assert.Len(t, logged, 2) {
+		assert.Equal(t, "b", logged[0].ID)
+		assert.NotEmpty(t, logged[0].NextAttemptAt, "should show the next attempt of a pending delivery")
+		assert.Equal(t, "2022-07-01T07:00:00+07:00", logged[1].CreatedAt)
+		assert.Equal(t, "2022-07-01T07:00:00+07:00", logged[1].DeliveredAt)
+		assert.Empty(t, logged[1].NextAttemptAt)
+	}
+}
+
+func TestUsecaseDeliveries_Error_NotFound(t *testing.T) {
+	usecase, _, _ := newUsecase()
+
+	result := usecase.Deliveries(context.TODO(), "x")
+
+	assert.Equal(t, exception.ErrNotFound, result.Error(), "should be not found error")
+	assert.Equal(t, "Webhook not found", result.Message())
+}
+
+func TestUsecaseRedeliver_Success(t *testing.T) {
+	usecase, _, deliveries := newUsecase()
+	dead := delivery("a", "x", 1)
+	dead.Status = webhook.StatusDead
+	dead.Attempts = 8
+	dead.LastError = "unexpected status 500"
+	require.NoError(t, deliveries.InsertMany(context.TODO(), []entity.WebhookDelivery{dead}))
+	ctx := requestctx.WithOwner(context.TODO(), "john")
+
+	result := usecase.Redeliver(ctx, "x", "a")
+
+	assert.Nil(t, result.Error(), "should be no error")
+	due, err := deliveries.FindDue(context.TODO(), time.Now().UnixNano(), 10)
+	require.NoError(t, err)
+	if assert.Len(t, due, 1, "should be due again") {
+		assert.Equal(t, 0, due[0].Attempts)
+		assert.Empty(t, due[0].LastError)
+	}
+}
+
+func TestUsecaseRedeliver_Error_NotFound(t *testing.T) {
+	usecase, _, deliveries := newUsecase()
+	require.NoError(t, deliveries.InsertMany(context.TODO(), []entity.WebhookDelivery{delivery("a", "x", 1)}))
+	ctx := requestctx.WithOwner(context.TODO(), "john")
+
+	assert.Equal(t, exception.ErrNotFound, usecase.Redeliver(ctx, "y", "a").Error(), "should be scoped to the webhook")
+	assert.Equal(t, exception.ErrNotFound, usecase.Redeliver(context.TODO(), "x", "a").Error(), "should be scoped to the owner")
+}
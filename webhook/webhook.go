@@ -0,0 +1,67 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/ijalalfrz/sirclo-weight-test/entity"
+)
+
+// collection of webhook storage name
+const (
+	CollectionName         = "webhook"
+	DeliveryCollectionName = "webhook_delivery"
+)
+
+// collection of delivery status
+const (
+	StatusPending   = "PENDING"
+	StatusDelivered = "DELIVERED"
+	StatusDead      = "DEAD"
+)
+
+// collection of delivery header
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+)
+
+const (
+	signaturePrefix = "sha256="
+)
+
+// Repository is collection of behaviour of the webhook subscription storage.
+type Repository interface {
+	InsertOne(ctx context.Context, subscription entity.WebhookSubscription) (err error)
+	FindMany(ctx context.Context, owner string) (subscriptions []entity.WebhookSubscription, err error)
+	FindOne(ctx context.Context, owner string, id string) (subscription entity.WebhookSubscription, err error)
+	DeleteOne(ctx context.Context, owner string, id string) (err error)
+}
+
+// DeliveryRepository is collection of behaviour of the webhook delivery storage.
+type DeliveryRepository interface {
+	InsertMany(ctx context.Context, deliveries []entity.WebhookDelivery) (err error)
+	FindDue(ctx context.Context, now int64, limit int64) (deliveries []entity.WebhookDelivery, err error)
+	FindMany(ctx context.Context, owner string, subscriptionID string, limit int64) (deliveries []entity.WebhookDelivery, err error)
+	FindOne(ctx context.Context, owner string, id string) (delivery entity.WebhookDelivery, err error)
+	UpdateOne(ctx context.Context, delivery entity.WebhookDelivery) (err error)
+}
+
+// Sign returns the signature of body with secret as it is sent in SignatureHeader,
+// the hex HMAC-SHA256 of body prefixed by sha256=.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// ownerFilter matches documents of the given owner. Anonymous requests only see documents without owner.
+func ownerFilter(owner string) interface{} {
+	if owner == "" {
+		return nil
+	}
+	return owner
+}
@@ -0,0 +1,125 @@
+package weight
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// collection of broadcaster default
+const (
+	defaultStreamBuffer = 16
+)
+
+// ErrBroadcasterClosed is returned by Subscribe after the broadcaster is closed.
+var ErrBroadcasterClosed = errors.New("broadcaster is closed")
+
+// StreamEvent is a weight event passed to the live streams.
+type StreamEvent struct {
+	Type string
+	Data interface{}
+}
+
+// Notifiers passes every event to each of its notifiers, the first error is returned after all are notified.
+type Notifiers []Notifier
+
+// Notify passes the event to each notifier.
+func (n Notifiers) Notify(ctx context.Context, eventType string, owner string, data interface{}) (err error) {
+	for _, notifier := range n {
+		if errNotify := notifier.Notify(ctx, eventType, owner, data); errNotify != nil && err == nil {
+			err = errNotify
+		}
+	}
+	return
+}
+
+// Broadcaster fans the weight events out to the live streams of their owner, in process.
+// Publishing never waits for a stream: a stream which falls Buffer events behind is closed,
+// its client reconnects and reads the weights again.
+type Broadcaster struct {
+	mu          sync.Mutex
+	buffer      int
+	subscribers map[string]map[chan StreamEvent]struct{}
+	closed      bool
+}
+
+// NewBroadcaster is a constructor, buffer is the number of events a stream may fall behind.
+func NewBroadcaster(buffer int) *Broadcaster {
+	if buffer <= 0 {
+		buffer = defaultStreamBuffer
+	}
+
+	return &Broadcaster{
+		buffer:      buffer,
+		subscribers: make(map[string]map[chan StreamEvent]struct{}),
+	}
+}
+
+// Subscribe opens a stream of the events of owner. The channel is closed by cancel,
+// when the stream falls behind or when the broadcaster is closed.
+func (b *Broadcaster) Subscribe(owner string) (events <-chan StreamEvent, cancel func(), err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		err = ErrBroadcasterClosed
+		return
+	}
+
+	ch := make(chan StreamEvent, b.buffer)
+	if b.subscribers[owner] == nil {
+		b.subscribers[owner] = make(map[chan StreamEvent]struct{})
+	}
+	b.subscribers[owner][ch] = struct{}{}
+
+	events = ch
+	cancel = func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.remove(owner, ch)
+	}
+	return
+}
+
+// Notify publishes the event to the streams of owner.
+func (b *Broadcaster) Notify(ctx context.Context, eventType string, owner string, data interface{}) (err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	event := StreamEvent{Type: eventType, Data: data}
+	for ch := range b.subscribers[owner] {
+		select {
+		case ch <- event:
+		default:
+			b.remove(owner, ch)
+		}
+	}
+	return
+}
+
+// Close ends every stream and rejects the new ones, the clients reconnect to another instance or after the restart.
+func (b *Broadcaster) Close(ctx context.Context) (err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for owner, subscribers := range b.subscribers {
+		for ch := range subscribers {
+			b.remove(owner, ch)
+		}
+	}
+	return
+}
+
+// remove closes the stream once, the caller holds the lock.
+func (b *Broadcaster) remove(owner string, ch chan StreamEvent) {
+	if _, ok := b.subscribers[owner][ch]; !ok {
+		return
+	}
+
+	delete(b.subscribers[owner], ch)
+	if len(b.subscribers[owner]) == 0 {
+		delete(b.subscribers, owner)
+	}
+	close(ch)
+}
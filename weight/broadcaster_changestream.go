@@ -0,0 +1,196 @@
+package weight
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ijalalfrz/sirclo-weight-test/entity"
+	"github.com/ijalalfrz/sirclo-weight-test/mongodb"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// collection of change stream feed default
+const (
+	defaultWatchBackoff    = time.Second
+	defaultWatchMaxBackoff = time.Minute
+)
+
+// collection of mongodb error of a change stream which can not be resumed
+const (
+	changeStreamFatalCode       = 280
+	changeStreamHistoryLostCode = 286
+	resumableErrorLabel         = "ResumableChangeStreamError"
+)
+
+const (
+	watchFailedMessage  = "Watching the weight changes failed, it is retried in %s: %v"
+	watchStoppedMessage = "Weight change stream is stopped."
+	decodeFailedMessage = "Weight change can not be decoded, it is skipped: %v"
+)
+
+// ChangeStreamProperty is a property of ChangeStreamFeed. Zero values are replaced by the defaults.
+type ChangeStreamProperty struct {
+	Logger      *logrus.Logger
+	Database    mongodb.Database
+	Broadcaster *Broadcaster
+	MaxBackoff  time.Duration
+}
+
+// weightChange is a change event of the weight collection.
+type weightChange struct {
+	OperationType            string         `bson:"operationType"`
+	FullDocument             *entity.Weight `bson:"fullDocument"`
+	FullDocumentBeforeChange *entity.Weight `bson:"fullDocumentBeforeChange"`
+}
+
+// ChangeStreamFeed passes the inserts, updates and deletes of the weight collection to the broadcaster,
+// so the streams of every instance see the writes of the others. It needs a replica set, and the pre-images
+// of MongoDB 6.0 for the deletes, whose event only carries the id.
+// A failed stream is resumed after a backoff doubling up to MaxBackoff.
+type ChangeStreamFeed struct {
+	logger      *logrus.Logger
+	collection  mongodb.Collection
+	broadcaster *Broadcaster
+	maxBackoff  time.Duration
+	resumeToken bson.Raw
+	ctx         context.Context
+	cancel      context.CancelFunc
+	done        chan struct{}
+}
+
+// NewChangeStreamFeed is a constructor.
+func NewChangeStreamFeed(property ChangeStreamProperty) *ChangeStreamFeed {
+	if property.MaxBackoff <= 0 {
+		property.MaxBackoff = defaultWatchMaxBackoff
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ChangeStreamFeed{
+		logger:      property.Logger,
+		collection:  property.Database.Collection(collectionName),
+		broadcaster: property.Broadcaster,
+		maxBackoff:  property.MaxBackoff,
+		ctx:         ctx,
+		cancel:      cancel,
+		done:        make(chan struct{}),
+	}
+}
+
+// Start watches the weight collection in background until Close is called.
+// Do not call this in goroutine.
+func (f *ChangeStreamFeed) Start() {
+	go func() {
+		defer close(f.done)
+
+		backoff := defaultWatchBackoff
+		for {
+			err := f.Watch(f.ctx)
+			if f.ctx.Err() != nil {
+				return
+			}
+			if err == nil {
+				backoff = defaultWatchBackoff
+				continue
+			}
+
+			f.logger.Warn(fmt.Sprintf(watchFailedMessage, backoff, err))
+			select {
+			case <-f.ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			if backoff *= 2; backoff > f.maxBackoff {
+				backoff = f.maxBackoff
+			}
+		}
+	}()
+}
+
+// Close stops watching, the changes until then have already been broadcast.
+func (f *ChangeStreamFeed) Close(ctx context.Context) (err error) {
+	f.cancel()
+	select {
+	case <-f.done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	f.logger.Info(watchStoppedMessage)
+	return
+}
+
+// Watch broadcasts the changes until the stream fails or ctx is done, it resumes after the last broadcast change.
+// A stream which can not be resumed anymore is opened again from now on, any other failure keeps the position,
+// so the changes written while the server is unreachable are not missed. A change which can not be decoded is
+// skipped, as resuming before it would fail on it again.
+func (f *ChangeStreamFeed) Watch(ctx context.Context) (err error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"operationType": bson.M{"$in": []string{"insert", "update", "replace", "delete"}}}}},
+	}
+	opts := options.ChangeStream().
+		SetFullDocument(options.UpdateLookup).
+		SetFullDocumentBeforeChange(options.WhenAvailable)
+	if f.resumeToken != nil {
+		opts.SetResumeAfter(f.resumeToken)
+	}
+
+	stream, err := f.collection.Watch(ctx, pipeline, opts)
+	if err != nil {
+		if !resumable(err) {
+			f.resumeToken = nil
+		}
+		return
+	}
+	defer stream.Close(context.Background())
+
+	for stream.Next(ctx) {
+		f.resumeToken = stream.ResumeToken()
+		var change weightChange
+		if errDecode := stream.Decode(&change); errDecode != nil {
+			f.logger.Warn(fmt.Sprintf(decodeFailedMessage, errDecode))
+			continue
+		}
+
+		if change.OperationType == "delete" {
+			// without the pre-image the owner of the deleted weight is unknown.
+			if change.FullDocumentBeforeChange != nil {
+				f.broadcaster.Notify(ctx, eventWeightDeleted, change.FullDocumentBeforeChange.Owner, revisionSnapshot(change.FullDocumentBeforeChange))
+			}
+			continue
+		}
+
+		// the weight is deleted before the update is looked up.
+		if change.FullDocument == nil {
+			continue
+		}
+
+		eventType := eventWeightUpdated
+		if change.OperationType == "insert" {
+			eventType = eventWeightCreated
+		}
+		f.broadcaster.Notify(ctx, eventType, change.FullDocument.Owner, revisionSnapshot(change.FullDocument))
+	}
+
+	err = stream.Err()
+	return
+}
+
+// resumable reports whether the stream can still be resumed after err. The server rejects a resume token which
+// has fallen off the oplog, or labels the errors after which the stream may be resumed, anything else like
+// a network failure says nothing about the token.
+func resumable(err error) bool {
+	cmdErr, ok := err.(mongo.CommandError)
+	if !ok {
+		return true
+	}
+
+	switch cmdErr.Code {
+	case changeStreamFatalCode, changeStreamHistoryLostCode:
+		return false
+	}
+	return cmdErr.HasErrorLabel(resumableErrorLabel)
+}
@@ -0,0 +1,155 @@
+package weight_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ijalalfrz/sirclo-weight-test/entity"
+	"github.com/ijalalfrz/sirclo-weight-test/model"
+	"github.com/ijalalfrz/sirclo-weight-test/mongodb/mocks"
+	"github.com/ijalalfrz/sirclo-weight-test/weight"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// changes returns a stream mock which yields the given change events in turn.
+func changes(events ...bson.M) *mocks.ChangeStream {
+	stream := new(mocks.ChangeStream)
+	for _, event := range events {
+		event := event
+		stream.On("Next", mock.Anything).Return(true).Once()
+		stream.On("Decode", mock.Anything).Return(func(val interface{}) error {
+			raw, _ := bson.Marshal(event)
+			return bson.Unmarshal(raw, val)
+		}).Once()
+		stream.On("ResumeToken").Return(bson.Raw(nil)).Once()
+	}
+	stream.On("Next", mock.Anything).Return(false)
+	stream.On("Err").Return(nil)
+	stream.On("Close", mock.Anything).Return(nil)
+	return stream
+}
+
+func TestChangeStreamFeed_Watch_Success(t *testing.T) {
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+	stream := changes(
+		bson.M{"operationType": "insert", "fullDocument": entity.Weight{Owner: "john", Date: 1, Day: "1970-01-01", Max: 3, Min: 1, Diff: 2, Version: 1}},
+		bson.M{"operationType": "update", "fullDocument": nil},
+		bson.M{"operationType": "replace", "fullDocument": entity.Weight{Owner: "jane", Date: 1, Max: 5, Min: 1, Diff: 4, Version: 2}},
+		bson.M{"operationType": "delete"},
+		bson.M{"operationType": "delete", "fullDocumentBeforeChange": entity.Weight{Owner: "john", Date: 1, Max: 3, Min: 1, Diff: 2, Version: 1}},
+	)
+	col.On("Watch", mock.Anything, mock.Anything, mock.MatchedBy(func(opts *options.ChangeStreamOptions) bool {
+		return *opts.FullDocument == options.UpdateLookup && *opts.FullDocumentBeforeChange == options.WhenAvailable
+	})).Return(stream, nil)
+	db.On("Collection", "weight").Return(col)
+
+	broadcaster := weight.NewBroadcaster(0)
+	john, cancel, err := broadcaster.Subscribe("john")
+	require.NoError(t, err)
+	defer cancel()
+	jane, cancelJane, err := broadcaster.Subscribe("jane")
+	require.NoError(t, err)
+	defer cancelJane()
+
+	feed := weight.NewChangeStreamFeed(weight.ChangeStreamProperty{Logger: logrus.New(), Database: db, Broadcaster: broadcaster})
+
+	assert.NoError(t, feed.Watch(context.TODO()), "should be no error")
+	assert.Equal(t, weight.StreamEvent{
+		Type: "weight.created",
+		Data: &model.WeighDetailResponse{Date: 1, DateString: "1970-01-01", Max: 3, Min: 1, Diff: 2, Version: 1},
+	}, <-john)
+	if assert.Len(t, john, 1, "should skip the delete without pre-image") {
+		deleted := <-john
+		assert.Equal(t, "weight.deleted", deleted.Type)
+		assert.Equal(t, int64(1), deleted.Data.(*model.WeighDetailResponse).Version, "should carry the deleted weight")
+	}
+	if assert.Len(t, jane, 1, "should skip the change of a deleted weight") {
+		assert.Equal(t, "weight.updated", (<-jane).Type)
+	}
+	stream.AssertCalled(t, "Close", mock.Anything)
+}
+
+func TestChangeStreamFeed_Watch_Success_SkipUndecodable(t *testing.T) {
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+	token, err := bson.Marshal(bson.M{"_data": "token"})
+	require.NoError(t, err)
+	stream := new(mocks.ChangeStream)
+	stream.On("Next", mock.Anything).Return(true).Once()
+	stream.On("Decode", mock.Anything).Return(errors.New("cannot decode string into an integer type")).Once()
+	stream.On("ResumeToken").Return(bson.Raw(token)).Once()
+	stream.On("Next", mock.Anything).Return(false)
+	stream.On("Err").Return(errors.New("connection reset"))
+	stream.On("Close", mock.Anything).Return(nil)
+	col.On("Watch", mock.Anything, mock.Anything, mock.MatchedBy(func(opts *options.ChangeStreamOptions) bool {
+		return opts.ResumeAfter == nil
+	})).Return(stream, nil).Once()
+	col.On("Watch", mock.Anything, mock.Anything, mock.MatchedBy(func(opts *options.ChangeStreamOptions) bool {
+		return opts.ResumeAfter != nil
+	})).Return(changes(), nil).Once()
+	db.On("Collection", "weight").Return(col)
+
+	feed := weight.NewChangeStreamFeed(weight.ChangeStreamProperty{Logger: logrus.New(), Database: db, Broadcaster: weight.NewBroadcaster(0)})
+
+	assert.Error(t, feed.Watch(context.TODO()), "should be the error of the stream, not of the change")
+	assert.NoError(t, feed.Watch(context.TODO()), "should resume after the undecodable change")
+	stream.AssertExpectations(t)
+	col.AssertExpectations(t)
+}
+
+func TestChangeStreamFeed_Watch_Error_NoReplicaSet(t *testing.T) {
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+	col.On("Watch", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, mongo.CommandError{Code: 40573, Message: "The $changeStream stage is only supported on replica sets"})
+	db.On("Collection", "weight").Return(col)
+
+	feed := weight.NewChangeStreamFeed(weight.ChangeStreamProperty{Logger: logrus.New(), Database: db, Broadcaster: weight.NewBroadcaster(0)})
+
+	assert.Error(t, feed.Watch(context.TODO()), "should be error")
+}
+
+func TestChangeStreamFeed_Watch_Error_Resume(t *testing.T) {
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+	token, err := bson.Marshal(bson.M{"_data": "token"})
+	require.NoError(t, err)
+	stream := new(mocks.ChangeStream)
+	stream.On("Next", mock.Anything).Return(true).Once()
+	stream.On("Decode", mock.Anything).Return(nil).Once()
+	stream.On("ResumeToken").Return(bson.Raw(token)).Once()
+	stream.On("Next", mock.Anything).Return(false)
+	stream.On("Err").Return(errors.New("connection reset"))
+	stream.On("Close", mock.Anything).Return(nil)
+
+	resumed := func(opts *options.ChangeStreamOptions) bool {
+		return opts.ResumeAfter != nil
+	}
+	col.On("Watch", mock.Anything, mock.Anything, mock.MatchedBy(func(opts *options.ChangeStreamOptions) bool {
+		return !resumed(opts)
+	})).Return(stream, nil).Once()
+	col.On("Watch", mock.Anything, mock.Anything, mock.MatchedBy(resumed)).
+		Return(nil, errors.New("server selection timeout")).Once()
+	col.On("Watch", mock.Anything, mock.Anything, mock.MatchedBy(resumed)).
+		Return(nil, mongo.CommandError{Code: 286, Message: "resume point may no longer be in the oplog"}).Once()
+	col.On("Watch", mock.Anything, mock.Anything, mock.MatchedBy(func(opts *options.ChangeStreamOptions) bool {
+		return !resumed(opts)
+	})).Return(nil, errors.New("server selection timeout")).Once()
+	db.On("Collection", "weight").Return(col)
+
+	feed := weight.NewChangeStreamFeed(weight.ChangeStreamProperty{Logger: logrus.New(), Database: db, Broadcaster: weight.NewBroadcaster(0)})
+
+	assert.Error(t, feed.Watch(context.TODO()), "should be the error of the stream")
+	assert.Error(t, feed.Watch(context.TODO()), "should keep the resume token when the server is unreachable")
+	assert.Error(t, feed.Watch(context.TODO()), "should drop the resume token when the history is lost")
+	assert.Error(t, feed.Watch(context.TODO()), "should open the stream from now on")
+	col.AssertExpectations(t)
+}
@@ -0,0 +1,68 @@
+package weight_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ijalalfrz/sirclo-weight-test/weight"
+	"github.com/ijalalfrz/sirclo-weight-test/weight/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBroadcaster_Notify_Success_ScopedToOwner(t *testing.T) {
+	broadcaster := weight.NewBroadcaster(0)
+	john, cancel, err := broadcaster.Subscribe("john")
+	require.NoError(t, err)
+	defer cancel()
+	jane, cancelJane, err := broadcaster.Subscribe("jane")
+	require.NoError(t, err)
+	defer cancelJane()
+
+	assert.NoError(t, broadcaster.Notify(context.TODO(), "weight.created", "john", 1))
+
+	assert.Equal(t, weight.StreamEvent{Type: "weight.created", Data: 1}, <-john)
+	assert.Empty(t, jane, "should only stream the events of the owner")
+}
+
+func TestBroadcaster_Notify_Success_SlowSubscriberClosed(t *testing.T) {
+	broadcaster := weight.NewBroadcaster(1)
+	slow, cancel, err := broadcaster.Subscribe("john")
+	require.NoError(t, err)
+
+	assert.NoError(t, broadcaster.Notify(context.TODO(), "weight.created", "john", 1))
+	assert.NoError(t, broadcaster.Notify(context.TODO(), "weight.updated", "john", 1), "should not wait for a slow subscriber")
+
+	<-slow
+	_, ok := <-slow
+	assert.False(t, ok, "should close the stream which falls behind")
+	cancel()
+}
+
+func TestBroadcaster_Close_Success(t *testing.T) {
+	broadcaster := weight.NewBroadcaster(0)
+	events, cancel, err := broadcaster.Subscribe("john")
+	require.NoError(t, err)
+
+	assert.NoError(t, broadcaster.Close(context.TODO()))
+
+	_, ok := <-events
+	assert.False(t, ok, "should end the open streams")
+	cancel()
+
+	_, _, err = broadcaster.Subscribe("john")
+	assert.Equal(t, weight.ErrBroadcasterClosed, err, "should reject the new streams")
+}
+
+func TestNotifiers_Notify_Error(t *testing.T) {
+	failing := new(mocks.Notifier)
+	next := new(mocks.Notifier)
+	failing.On("Notify", context.TODO(), "weight.created", "john", 1).Return(errors.New("unexpected"))
+	next.On("Notify", context.TODO(), "weight.created", "john", 1).Return(nil)
+
+	err := weight.Notifiers{failing, next}.Notify(context.TODO(), "weight.created", "john", 1)
+
+	assert.EqualError(t, err, "unexpected")
+	next.AssertExpectations(t)
+}
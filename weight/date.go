@@ -0,0 +1,47 @@
+package weight
+
+import (
+	"strconv"
+	"time"
+)
+
+const (
+	dateLayout = "2006-01-02"
+)
+
+// A weight is keyed by its calendar date. The canonical key of a calendar date is the unix nano
+// of its midnight in UTC, so the same date has the same key whatever timezone the user is in.
+
+// calendarDate returns the canonical key of the calendar date t falls on in its own location.
+func calendarDate(t time.Time) int64 {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC).UnixNano()
+}
+
+// canonicalDate resolves a unix nano timestamp to the canonical key of the calendar date it falls on in loc.
+// The calendar date only depends on loc, a UTC midnight is the previous day for a user west of UTC,
+// so a client which already has the calendar date must send it as 2006-01-02 instead.
+func canonicalDate(timestamp int64, loc *time.Location) int64 {
+	return calendarDate(time.Unix(0, timestamp).In(loc))
+}
+
+// parseDate parses a calendar date of 2006-01-02 layout, or a unix nano timestamp which is resolved in loc.
+func parseDate(value string, loc *time.Location) (date int64, err error) {
+	t, err := time.Parse(dateLayout, value)
+	if err == nil {
+		date = t.UnixNano()
+		return
+	}
+
+	timestamp, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return
+	}
+	date = canonicalDate(timestamp, loc)
+	return
+}
+
+// formatDate formats the canonical key as a calendar date.
+func formatDate(date int64) string {
+	return time.Unix(0, date).UTC().Format(dateLayout)
+}
@@ -0,0 +1,206 @@
+package weight
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/ijalalfrz/sirclo-weight-test/model"
+)
+
+// collection of export format
+const (
+	exportFormatCSV  = "csv"
+	exportFormatJSON = "json"
+	exportFormatXLSX = "xlsx"
+)
+
+var exportColumns = []string{"date", "dateString", "max", "min", "diff"}
+
+// exportWriter writes exported weights one by one into the underlying writer.
+type exportWriter interface {
+	ContentType() string
+	Begin() error
+	Write(weight model.WeightExportResponse) error
+	End() error
+}
+
+func newExportWriter(format string, w io.Writer) (exportWriter, error) {
+	switch format {
+	case "", exportFormatCSV:
+		return &csvExportWriter{w: csv.NewWriter(w)}, nil
+	case exportFormatJSON:
+		return &jsonExportWriter{w: w}, nil
+	case exportFormatXLSX:
+		return &xlsxExportWriter{zw: zip.NewWriter(w)}, nil
+	default:
+		return nil, fmt.Errorf("Invalid 'format' with value '%s'", format)
+	}
+}
+
+type csvExportWriter struct {
+	w *csv.Writer
+}
+
+func (e *csvExportWriter) ContentType() string {
+	return "text/csv"
+}
+
+func (e *csvExportWriter) Begin() error {
+	return e.w.Write(exportColumns)
+}
+
+func (e *csvExportWriter) Write(weight model.WeightExportResponse) error {
+	return e.w.Write([]string{
+		strconv.FormatInt(weight.Date, 10),
+		weight.DateString,
+		strconv.Itoa(weight.Max),
+		strconv.Itoa(weight.Min),
+		strconv.Itoa(weight.Diff),
+	})
+}
+
+func (e *csvExportWriter) End() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// jsonExportWriter writes a json array element by element instead of encoding the whole slice at once.
+type jsonExportWriter struct {
+	w       io.Writer
+	written bool
+}
+
+func (e *jsonExportWriter) ContentType() string {
+	return "application/json"
+}
+
+func (e *jsonExportWriter) Begin() error {
+	_, err := io.WriteString(e.w, "[")
+	return err
+}
+
+func (e *jsonExportWriter) Write(weight model.WeightExportResponse) error {
+	if e.written {
+		if _, err := io.WriteString(e.w, ","); err != nil {
+			return err
+		}
+	}
+	e.written = true
+
+	b, err := json.Marshal(weight)
+	if err != nil {
+		return err
+	}
+	_, err = e.w.Write(b)
+	return err
+}
+
+func (e *jsonExportWriter) End() error {
+	_, err := io.WriteString(e.w, "]\n")
+	return err
+}
+
+// xlsxExportWriter writes a minimal SpreadsheetML package with a single sheet.
+// The static parts are written first so the rows of the sheet can be streamed as the last zip entry.
+type xlsxExportWriter struct {
+	zw    *zip.Writer
+	sheet io.Writer
+}
+
+var xlsxStaticParts = []struct {
+	name    string
+	content string
+}{
+	{
+		name:    "[Content_Types].xml",
+		content: `<?xml version="1.0" encoding="UTF-8" standalone="yes"?><Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`,
+	},
+	{
+		name:    "_rels/.rels",
+		content: `<?xml version="1.0" encoding="UTF-8" standalone="yes"?><Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`,
+	},
+	{
+		name:    "xl/workbook.xml",
+		content: `<?xml version="1.0" encoding="UTF-8" standalone="yes"?><workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Weight" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+	},
+	{
+		name:    "xl/_rels/workbook.xml.rels",
+		content: `<?xml version="1.0" encoding="UTF-8" standalone="yes"?><Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`,
+	},
+}
+
+func (e *xlsxExportWriter) ContentType() string {
+	return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+}
+
+func (e *xlsxExportWriter) Begin() (err error) {
+	for _, part := range xlsxStaticParts {
+		f, err := e.zw.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err = io.WriteString(f, part.content); err != nil {
+			return err
+		}
+	}
+
+	e.sheet, err = e.zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return
+	}
+
+	_, err = io.WriteString(e.sheet, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?><worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	if err != nil {
+		return
+	}
+
+	header := make([]interface{}, len(exportColumns))
+	for i, column := range exportColumns {
+		header[i] = column
+	}
+	return e.writeRow(header...)
+}
+
+func (e *xlsxExportWriter) Write(weight model.WeightExportResponse) error {
+	return e.writeRow(weight.Date, weight.DateString, weight.Max, weight.Min, weight.Diff)
+}
+
+func (e *xlsxExportWriter) End() error {
+	if _, err := io.WriteString(e.sheet, `</sheetData></worksheet>`); err != nil {
+		return err
+	}
+	return e.zw.Close()
+}
+
+func (e *xlsxExportWriter) writeRow(cells ...interface{}) error {
+	if _, err := io.WriteString(e.sheet, "<row>"); err != nil {
+		return err
+	}
+
+	for _, cell := range cells {
+		var err error
+		switch v := cell.(type) {
+		case string:
+			if _, err = io.WriteString(e.sheet, `<c t="inlineStr"><is><t>`); err != nil {
+				return err
+			}
+			if err = xml.EscapeText(e.sheet, []byte(v)); err != nil {
+				return err
+			}
+			_, err = io.WriteString(e.sheet, `</t></is></c>`)
+		default:
+			_, err = fmt.Fprintf(e.sheet, `<c><v>%d</v></c>`, v)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(e.sheet, "</row>")
+	return err
+}
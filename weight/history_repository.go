@@ -0,0 +1,100 @@
+package weight
+
+import (
+	"context"
+
+	"github.com/ijalalfrz/sirclo-weight-test/entity"
+	"github.com/ijalalfrz/sirclo-weight-test/exception"
+	"github.com/ijalalfrz/sirclo-weight-test/mongodb"
+	"github.com/ijalalfrz/sirclo-weight-test/requestctx"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// HistoryRepository is collection of behaviour weightHistoryRepository
+type HistoryRepository interface {
+	InsertOne(ctx context.Context, revision entity.WeightRevision) (err error)
+	FindMany(ctx context.Context, owner string, key int64) (bunchOfRevision []entity.WeightRevision, err error)
+	FindOne(ctx context.Context, owner string, key int64, id string) (revision entity.WeightRevision, err error)
+}
+
+type weightHistoryRepository struct {
+	logger *logrus.Logger
+	col    mongodb.Collection
+}
+
+// NewWeightHistoryRepository is a constructor.
+func NewWeightHistoryRepository(logger *logrus.Logger, db mongodb.Database) HistoryRepository {
+	col := db.Collection(historyCollectionName)
+	return &weightHistoryRepository{logger, col}
+}
+
+func (r weightHistoryRepository) InsertOne(ctx context.Context, revision entity.WeightRevision) (err error) {
+	_, err = r.col.InsertOne(ctx, revision)
+	if err != nil {
+		r.log(ctx).Error(err)
+		err = exception.ErrInternalServer
+		return
+	}
+	return
+}
+
+// FindMany returns the revisions of the weight of the key, the newest first.
+func (r weightHistoryRepository) FindMany(ctx context.Context, owner string, key int64) (bunchOfRevision []entity.WeightRevision, err error) {
+	filter := bson.M{
+		"owner": ownerFilter(owner),
+		"date":  key,
+	}
+	opt := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+
+	cursor, err := r.col.Find(ctx, filter, opt)
+	if err != nil {
+		r.log(ctx).Error(err)
+		err = exception.ErrInternalServer
+		return
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		revision := entity.WeightRevision{}
+		if err = cursor.Decode(&revision); err != nil {
+			r.log(ctx).Error(err)
+			err = exception.ErrInternalServer
+			return
+		}
+
+		bunchOfRevision = append(bunchOfRevision, revision)
+	}
+
+	if len(bunchOfRevision) < 1 {
+		err = exception.ErrNotFound
+		return
+	}
+
+	return
+}
+func (r weightHistoryRepository) FindOne(ctx context.Context, owner string, key int64, id string) (revision entity.WeightRevision, err error) {
+	filter := bson.M{
+		"_id":   id,
+		"owner": ownerFilter(owner),
+		"date":  key,
+	}
+
+	if err = r.col.FindOne(ctx, filter).Decode(&revision); err != nil {
+		if err != mongo.ErrNoDocuments {
+			r.log(ctx).Error(err)
+			err = exception.ErrInternalServer
+			return
+		}
+		err = exception.ErrNotFound
+		return
+	}
+
+	return
+}
+
+func (r weightHistoryRepository) log(ctx context.Context) *logrus.Entry {
+	return requestctx.Logger(ctx, r.logger)
+}
@@ -0,0 +1,81 @@
+package weight
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+
+	"github.com/ijalalfrz/sirclo-weight-test/entity"
+	"github.com/sirupsen/logrus"
+)
+
+// NewFileHistoryRepository is a constructor of a HistoryRepository appended to a JSON Lines file, for local development
+// with the file store. The revisions are kept in memory and each one is appended and synced before it is kept,
+// so the history survives a restart. A missing file starts an empty history.
+func NewFileHistoryRepository(logger *logrus.Logger, path string) (repository HistoryRepository, err error) {
+	bunchOfRevision, err := readHistoryFile(path)
+	if err != nil {
+		return
+	}
+
+	repository = newMemoryHistoryRepository(logger, bunchOfRevision, func(revision entity.WeightRevision) error {
+		return appendHistoryFile(path, revision)
+	})
+	return
+}
+
+// readHistoryFile reads the revisions of the file. A last line without newline is an append cut by a crash,
+// it was never acknowledged so it is truncated, otherwise the next append would be joined to it.
+func readHistoryFile(path string) (bunchOfRevision []entity.WeightRevision, err error) {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		err = nil
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	if complete := bytes.LastIndexByte(content, '\n') + 1; complete < len(content) {
+		if err = os.Truncate(path, int64(complete)); err != nil {
+			return
+		}
+		content = content[:complete]
+	}
+
+	for _, line := range bytes.Split(content, []byte("\n")) {
+		if len(line) < 1 {
+			continue
+		}
+
+		var revision entity.WeightRevision
+		if err = json.Unmarshal(line, &revision); err != nil {
+			return
+		}
+		bunchOfRevision = append(bunchOfRevision, revision)
+	}
+	return
+}
+
+func appendHistoryFile(path string, revision entity.WeightRevision) (err error) {
+	line, err := json.Marshal(revision)
+	if err != nil {
+		return
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+
+	if _, err = file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return
+	}
+	if err = file.Sync(); err != nil {
+		file.Close()
+		return
+	}
+	err = file.Close()
+	return
+}
@@ -0,0 +1,87 @@
+package weight
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/ijalalfrz/sirclo-weight-test/entity"
+	"github.com/ijalalfrz/sirclo-weight-test/exception"
+	"github.com/ijalalfrz/sirclo-weight-test/requestctx"
+	"github.com/sirupsen/logrus"
+)
+
+// memoryHistoryRepository is a thread-safe HistoryRepository which keeps the revisions in memory,
+// it serves the storage drivers without a history collection. Every revision is handed to persist
+// before it is kept, so a store on top of it can append it and reject it.
+type memoryHistoryRepository struct {
+	logger    *logrus.Logger
+	mu        sync.RWMutex
+	revisions map[memoryKey][]entity.WeightRevision
+	persist   func(revision entity.WeightRevision) error
+}
+
+// NewMemoryHistoryRepository is a constructor.
+func NewMemoryHistoryRepository() HistoryRepository {
+	return newMemoryHistoryRepository(nil, nil, nil)
+}
+
+func newMemoryHistoryRepository(logger *logrus.Logger, bunchOfRevision []entity.WeightRevision, persist func(revision entity.WeightRevision) error) *memoryHistoryRepository {
+	revisions := make(map[memoryKey][]entity.WeightRevision)
+	for _, revision := range bunchOfRevision {
+		key := memoryKey{revision.Owner, revision.Date}
+		revisions[key] = append(revisions[key], revision)
+	}
+	return &memoryHistoryRepository{logger: logger, revisions: revisions, persist: persist}
+}
+
+func (r *memoryHistoryRepository) InsertOne(ctx context.Context, revision entity.WeightRevision) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.persist != nil {
+		if err = r.persist(revision); err != nil {
+			requestctx.Logger(ctx, r.logger).Error(err)
+			err = exception.ErrInternalServer
+			return
+		}
+	}
+
+	key := memoryKey{revision.Owner, revision.Date}
+	r.revisions[key] = append(r.revisions[key], revision)
+	return
+}
+
+// FindMany returns the revisions of the weight of the key, the newest first.
+func (r *memoryHistoryRepository) FindMany(ctx context.Context, owner string, key int64) (bunchOfRevision []entity.WeightRevision, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	revisions := r.revisions[memoryKey{owner, key}]
+	if len(revisions) < 1 {
+		err = exception.ErrNotFound
+		return
+	}
+
+	bunchOfRevision = make([]entity.WeightRevision, len(revisions))
+	copy(bunchOfRevision, revisions)
+	sort.SliceStable(bunchOfRevision, func(i, j int) bool {
+		return bunchOfRevision[i].CreatedAt > bunchOfRevision[j].CreatedAt
+	})
+	return
+}
+
+func (r *memoryHistoryRepository) FindOne(ctx context.Context, owner string, key int64, id string) (revision entity.WeightRevision, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, revision = range r.revisions[memoryKey{owner, key}] {
+		if revision.ID == id {
+			return
+		}
+	}
+
+	revision = entity.WeightRevision{}
+	err = exception.ErrNotFound
+	return
+}
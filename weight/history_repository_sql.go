@@ -0,0 +1,152 @@
+package weight
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/ijalalfrz/sirclo-weight-test/entity"
+	"github.com/ijalalfrz/sirclo-weight-test/exception"
+	"github.com/ijalalfrz/sirclo-weight-test/requestctx"
+	"github.com/ijalalfrz/sirclo-weight-test/sqldb"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	revisionColumns      = "id, owner, date, action, before_weight, after_weight, actor, request_id, created_at"
+	insertRevisionQuery  = "INSERT INTO weight_history (" + revisionColumns + ") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	selectRevisionsQuery = "SELECT " + revisionColumns + " FROM weight_history WHERE owner = ? AND date = ? ORDER BY created_at DESC"
+	selectRevisionQuery  = "SELECT " + revisionColumns + " FROM weight_history WHERE id = ? AND owner = ? AND date = ?"
+)
+
+// sqlRow is the behaviour shared by sql.Row and sql.Rows to scan a row.
+type sqlRow interface {
+	Scan(dest ...interface{}) error
+}
+
+type sqlHistoryRepository struct {
+	logger *logrus.Logger
+	db     *sqldb.DB
+}
+
+// NewSQLHistoryRepository is a constructor of a HistoryRepository on the weight_history table.
+// The snapshots of a revision are stored as JSON.
+func NewSQLHistoryRepository(logger *logrus.Logger, db *sqldb.DB) HistoryRepository {
+	return &sqlHistoryRepository{logger, db}
+}
+
+func (r sqlHistoryRepository) InsertOne(ctx context.Context, revision entity.WeightRevision) (err error) {
+	before, err := marshalSnapshot(revision.Before)
+	if err != nil {
+		r.log(ctx).Error(err)
+		err = exception.ErrInternalServer
+		return
+	}
+	after, err := marshalSnapshot(revision.After)
+	if err != nil {
+		r.log(ctx).Error(err)
+		err = exception.ErrInternalServer
+		return
+	}
+
+	_, err = r.db.Executor(ctx).ExecContext(ctx, r.db.Rebind(insertRevisionQuery),
+		revision.ID, revision.Owner, revision.Date, revision.Action, before, after, revision.Actor, revision.RequestID, revision.CreatedAt)
+	if err != nil {
+		r.log(ctx).Error(err)
+		err = exception.ErrInternalServer
+		return
+	}
+	return
+}
+
+// FindMany returns the revisions of the weight of the key, the newest first.
+func (r sqlHistoryRepository) FindMany(ctx context.Context, owner string, key int64) (bunchOfRevision []entity.WeightRevision, err error) {
+	rows, err := r.db.Executor(ctx).QueryContext(ctx, r.db.Rebind(selectRevisionsQuery), owner, key)
+	if err != nil {
+		r.log(ctx).Error(err)
+		err = exception.ErrInternalServer
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var revision entity.WeightRevision
+		if revision, err = r.scan(rows); err != nil {
+			r.log(ctx).Error(err)
+			err = exception.ErrInternalServer
+			return
+		}
+
+		bunchOfRevision = append(bunchOfRevision, revision)
+	}
+
+	if err = rows.Err(); err != nil {
+		r.log(ctx).Error(err)
+		err = exception.ErrInternalServer
+		return
+	}
+
+	if len(bunchOfRevision) < 1 {
+		err = exception.ErrNotFound
+		return
+	}
+
+	return
+}
+
+func (r sqlHistoryRepository) FindOne(ctx context.Context, owner string, key int64, id string) (revision entity.WeightRevision, err error) {
+	revision, err = r.scan(r.db.Executor(ctx).QueryRowContext(ctx, r.db.Rebind(selectRevisionQuery), id, owner, key))
+	if err != nil {
+		if err != sql.ErrNoRows {
+			r.log(ctx).Error(err)
+			err = exception.ErrInternalServer
+			return
+		}
+		err = exception.ErrNotFound
+		return
+	}
+
+	return
+}
+
+func (r sqlHistoryRepository) log(ctx context.Context) *logrus.Entry {
+	return requestctx.Logger(ctx, r.logger)
+}
+
+func (r sqlHistoryRepository) scan(row sqlRow) (revision entity.WeightRevision, err error) {
+	var before, after sql.NullString
+	err = row.Scan(&revision.ID, &revision.Owner, &revision.Date, &revision.Action, &before, &after, &revision.Actor, &revision.RequestID, &revision.CreatedAt)
+	if err != nil {
+		return
+	}
+
+	if revision.Before, err = unmarshalSnapshot(before); err != nil {
+		return
+	}
+	revision.After, err = unmarshalSnapshot(after)
+	return
+}
+
+// marshalSnapshot returns the JSON of weight, a nil weight is stored as NULL.
+func marshalSnapshot(weight *entity.Weight) (snapshot sql.NullString, err error) {
+	if weight == nil {
+		return
+	}
+
+	content, err := json.Marshal(weight)
+	if err != nil {
+		return
+	}
+	snapshot = sql.NullString{String: string(content), Valid: true}
+	return
+}
+
+func unmarshalSnapshot(snapshot sql.NullString) (weight *entity.Weight, err error) {
+	if !snapshot.Valid {
+		return
+	}
+
+	weight = new(entity.Weight)
+	err = json.Unmarshal([]byte(snapshot.String), weight)
+	return
+}
@@ -0,0 +1,152 @@
+package weight_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ijalalfrz/sirclo-weight-test/entity"
+	"github.com/ijalalfrz/sirclo-weight-test/exception"
+	"github.com/ijalalfrz/sirclo-weight-test/mongodb/mocks"
+	"github.com/ijalalfrz/sirclo-weight-test/weight"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestHistoryInsertOne_Success(t *testing.T) {
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+
+	col.On("InsertOne", mock.Anything, mock.Anything).Return(nil, nil)
+	db.On("Collection", "weight_history").Return(col)
+
+	repo := weight.NewWeightHistoryRepository(logrus.New(), db)
+
+	err := repo.InsertOne(context.TODO(), entity.WeightRevision{ID: "abc", Action: "INSERT"})
+	assert.NoError(t, err, "should be no error")
+	col.AssertExpectations(t)
+	db.AssertExpectations(t)
+}
+
+func TestHistoryInsertOne_Error_Unexpected(t *testing.T) {
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+
+	col.On("InsertOne", mock.Anything, mock.Anything).Return(nil, mongo.ErrClientDisconnected)
+	db.On("Collection", "weight_history").Return(col)
+
+	repo := weight.NewWeightHistoryRepository(logrus.New(), db)
+
+	err := repo.InsertOne(context.TODO(), entity.WeightRevision{})
+	assert.Equal(t, exception.ErrInternalServer, err, "should be internal server error")
+	col.AssertExpectations(t)
+}
+
+func TestHistoryFindMany_Success(t *testing.T) {
+	cursorMock := new(mocks.Cursor)
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+
+	cursorMock.On("Next", mock.Anything).Return(true).Twice()
+	cursorMock.On("Next", mock.Anything).Return(false).Once()
+	cursorMock.On("Decode", mock.AnythingOfType("*entity.WeightRevision")).Return(nil).Run(func(args mock.Arguments) {
+		arg := args.Get(0).(*entity.WeightRevision)
+		arg.Date = 1656633600000000000
+	})
+	cursorMock.On("Close", mock.Anything).Return(nil)
+	col.On("Find", mock.Anything, bson.M{"owner": "john", "date": int64(1656633600000000000)}, mock.Anything).Return(cursorMock, nil)
+	db.On("Collection", "weight_history").Return(col)
+
+	repo := weight.NewWeightHistoryRepository(logrus.New(), db)
+
+	revisions, err := repo.FindMany(context.TODO(), "john", 1656633600000000000)
+	assert.NoError(t, err, "should be no error")
+	assert.Len(t, revisions, 2)
+	cursorMock.AssertExpectations(t)
+	col.AssertExpectations(t)
+}
+
+func TestHistoryFindMany_Error_NotFound(t *testing.T) {
+	cursorMock := new(mocks.Cursor)
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+
+	cursorMock.On("Next", mock.Anything).Return(false).Once()
+	cursorMock.On("Close", mock.Anything).Return(nil)
+	col.On("Find", mock.Anything, mock.Anything, mock.Anything).Return(cursorMock, nil)
+	db.On("Collection", "weight_history").Return(col)
+
+	repo := weight.NewWeightHistoryRepository(logrus.New(), db)
+
+	_, err := repo.FindMany(context.TODO(), "", 1)
+	assert.Equal(t, exception.ErrNotFound, err, "should be not found error")
+	col.AssertExpectations(t)
+}
+
+func TestHistoryFindMany_Error_Unexpected(t *testing.T) {
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+
+	col.On("Find", mock.Anything, mock.Anything, mock.Anything).Return(nil, mongo.ErrClientDisconnected)
+	db.On("Collection", "weight_history").Return(col)
+
+	repo := weight.NewWeightHistoryRepository(logrus.New(), db)
+
+	_, err := repo.FindMany(context.TODO(), "", 1)
+	assert.Equal(t, exception.ErrInternalServer, err, "should be internal server error")
+	col.AssertExpectations(t)
+}
+
+func TestHistoryFindOne_Success(t *testing.T) {
+	singleResultMock := new(mocks.SingleResult)
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+
+	singleResultMock.On("Decode", mock.AnythingOfType("*entity.WeightRevision")).Return(nil).Run(func(args mock.Arguments) {
+		arg := args.Get(0).(*entity.WeightRevision)
+		arg.ID = "abc"
+	})
+	col.On("FindOne", mock.Anything, bson.M{"_id": "abc", "owner": nil, "date": int64(1)}).Return(singleResultMock)
+	db.On("Collection", "weight_history").Return(col)
+
+	repo := weight.NewWeightHistoryRepository(logrus.New(), db)
+
+	revision, err := repo.FindOne(context.TODO(), "", 1, "abc")
+	assert.NoError(t, err, "should be no error")
+	assert.Equal(t, "abc", revision.ID)
+	col.AssertExpectations(t)
+}
+
+func TestHistoryFindOne_Error_NotFound(t *testing.T) {
+	singleResultMock := new(mocks.SingleResult)
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+
+	singleResultMock.On("Decode", mock.Anything).Return(mongo.ErrNoDocuments)
+	col.On("FindOne", mock.Anything, mock.Anything).Return(singleResultMock)
+	db.On("Collection", "weight_history").Return(col)
+
+	repo := weight.NewWeightHistoryRepository(logrus.New(), db)
+
+	_, err := repo.FindOne(context.TODO(), "", 1, "abc")
+	assert.Equal(t, exception.ErrNotFound, err, "should be not found error")
+	col.AssertExpectations(t)
+}
+
+func TestHistoryFindOne_Error_Unexpected(t *testing.T) {
+	singleResultMock := new(mocks.SingleResult)
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+
+	singleResultMock.On("Decode", mock.Anything).Return(mongo.ErrClientDisconnected)
+	col.On("FindOne", mock.Anything, mock.Anything).Return(singleResultMock)
+	db.On("Collection", "weight_history").Return(col)
+
+	repo := weight.NewWeightHistoryRepository(logrus.New(), db)
+
+	_, err := repo.FindOne(context.TODO(), "", 1, "abc")
+	assert.Equal(t, exception.ErrInternalServer, err, "should be internal server error")
+	col.AssertExpectations(t)
+}
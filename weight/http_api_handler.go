@@ -0,0 +1,256 @@
+package weight
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/ijalalfrz/sirclo-weight-test/exception"
+	"github.com/ijalalfrz/sirclo-weight-test/model"
+	"github.com/ijalalfrz/sirclo-weight-test/requestctx"
+	"github.com/ijalalfrz/sirclo-weight-test/response"
+)
+
+const (
+	apiBasePath = "/api/v1/weights"
+)
+
+// collection of api message
+const (
+	invalidPayloadErrMessage = "Invalid request payload"
+	invalidDateErrMessage    = "Invalid date path variable"
+	preconditionErrMessage   = "Weight does not match If-Match, reload it and try again"
+)
+
+func (handler HTTPHandler) APIFindMany(w http.ResponseWriter, r *http.Request) {
+	filter, err := handler.parseFilter(r)
+	if err != nil {
+		response.JSON(w, handler.invalidQueryResponse(err.Error()))
+		return
+	}
+
+	resp := handler.Usecase.FindMany(r.Context(), filter)
+	response.JSON(w, resp)
+}
+
+func (handler HTTPHandler) APIFindOne(w http.ResponseWriter, r *http.Request) {
+	date, err := handler.dateFromPath(r)
+	if err != nil {
+		response.JSON(w, handler.invalidDateResponse())
+		return
+	}
+
+	resp := handler.Usecase.FindOne(r.Context(), date)
+	handler.setETag(w, resp)
+	response.JSON(w, resp)
+}
+
+func (handler HTTPHandler) APIInsertOne(w http.ResponseWriter, r *http.Request) {
+	payload := model.WeightPayload{}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		response.JSON(w, handler.invalidPayloadResponse(invalidPayloadErrMessage))
+		return
+	}
+
+	if err := handler.resolvePayloadDate(r, &payload); err != nil {
+		response.JSON(w, handler.invalidPayloadResponse(err.Error()))
+		return
+	}
+
+	if err := handler.validateRequest(payload); err != nil {
+		response.JSON(w, handler.invalidPayloadResponse(err.Error()))
+		return
+	}
+
+	resp := handler.Usecase.InsertOne(r.Context(), payload)
+	response.JSON(w, resp)
+}
+
+func (handler HTTPHandler) APIUpdateOne(w http.ResponseWriter, r *http.Request) {
+	date, err := handler.dateFromPath(r)
+	if err != nil {
+		response.JSON(w, handler.invalidDateResponse())
+		return
+	}
+
+	payload := model.WeightPayload{}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		response.JSON(w, handler.invalidPayloadResponse(invalidPayloadErrMessage))
+		return
+	}
+	payload.Date = date
+
+	if err := handler.validateRequest(payload); err != nil {
+		response.JSON(w, handler.invalidPayloadResponse(err.Error()))
+		return
+	}
+
+	// If-Match takes precedence over the version of the body. Any If-Match, the wildcard included,
+	// requires the weight to exist, so a missing weight or a mismatch is responded as 412.
+	ifMatch := r.Header.Get("If-Match")
+	mustExist := ifMatch != ""
+	if mustExist {
+		version, ok := handler.versionFromETag(ifMatch)
+		if !ok {
+			response.JSON(w, handler.preconditionFailedResponse())
+			return
+		}
+		payload.Version = version
+	}
+
+	// a strict update responds not found for a missing date, mode=upsert creates it and responds 201.
+	var resp response.Response
+	if r.URL.Query().Get("mode") == modeUpsert && !mustExist {
+		resp = handler.Usecase.UpsertOne(r.Context(), date, payload)
+	} else {
+		resp = handler.Usecase.UpdateOne(r.Context(), date, payload)
+	}
+	if mustExist && (resp.Error() == exception.ErrConflict || resp.Error() == exception.ErrNotFound) {
+		resp = handler.preconditionFailedResponse()
+	}
+	response.JSON(w, resp)
+}
+
+func (handler HTTPHandler) APIDeleteOne(w http.ResponseWriter, r *http.Request) {
+	date, err := handler.dateFromPath(r)
+	if err != nil {
+		response.JSON(w, handler.invalidDateResponse())
+		return
+	}
+
+	resp := handler.Usecase.DeleteOne(r.Context(), date)
+	response.JSON(w, resp)
+}
+
+func (handler HTTPHandler) APIHistory(w http.ResponseWriter, r *http.Request) {
+	date, err := handler.dateFromPath(r)
+	if err != nil {
+		response.JSON(w, handler.invalidDateResponse())
+		return
+	}
+
+	resp := handler.Usecase.History(r.Context(), date)
+	response.JSON(w, resp)
+}
+
+func (handler HTTPHandler) APIRestore(w http.ResponseWriter, r *http.Request) {
+	date, err := handler.dateFromPath(r)
+	if err != nil {
+		response.JSON(w, handler.invalidDateResponse())
+		return
+	}
+
+	resp := handler.Usecase.Restore(r.Context(), date, mux.Vars(r)["revision"])
+	response.JSON(w, resp)
+}
+
+func (handler HTTPHandler) APIStatistics(w http.ResponseWriter, r *http.Request) {
+	filter, err := handler.parseFilter(r)
+	if err != nil {
+		response.JSON(w, handler.invalidQueryResponse(err.Error()))
+		return
+	}
+
+	statisticFilter := model.StatisticFilter{
+		From:    filter.From,
+		To:      filter.To,
+		GroupBy: r.URL.Query().Get("groupBy"),
+	}
+
+	resp := handler.Usecase.Statistics(r.Context(), statisticFilter)
+	response.JSON(w, resp)
+}
+
+func (handler HTTPHandler) APIImportMany(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+
+	var reader io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			response.JSON(w, handler.invalidPayloadResponse(invalidPayloadErrMessage))
+			return
+		}
+		defer file.Close()
+		reader = file
+	}
+
+	rows, err := handler.parseImport(reader)
+	if err != nil {
+		response.JSON(w, handler.invalidPayloadResponse(err.Error()))
+		return
+	}
+
+	resp := handler.Usecase.ImportMany(r.Context(), rows, r.URL.Query().Get("mode") == "overwrite")
+	response.JSON(w, resp)
+}
+
+// dateFromPath parses the date path variable, either 2006-01-02 or unix nano in the timezone of the user.
+func (handler HTTPHandler) dateFromPath(r *http.Request) (date int64, err error) {
+	pathVariables := mux.Vars(r)
+	date, err = parseDate(pathVariables["date"], requestctx.Location(r.Context()))
+	return
+}
+
+// resolvePayloadDate sets the canonical date of the payload from its day,
+// or from its unix nano date which is resolved in the timezone of the user.
+func (handler HTTPHandler) resolvePayloadDate(r *http.Request, payload *model.WeightPayload) (err error) {
+	location := requestctx.Location(r.Context())
+	if payload.Day != "" {
+		t, errDay := time.Parse(dateLayout, payload.Day)
+		if errDay != nil {
+			return fmt.Errorf("Invalid 'Day' with value '%s'", payload.Day)
+		}
+		payload.Date = t.UnixNano()
+		return
+	}
+
+	if payload.Date != 0 {
+		payload.Date = canonicalDate(payload.Date, location)
+	}
+	return
+}
+
+// setETag exposes the version of the weight of a detail response as ETag.
+func (handler HTTPHandler) setETag(w http.ResponseWriter, resp response.Response) {
+	if detail, ok := resp.Data().(model.WeighDetailResponse); ok && resp.Error() == nil {
+		w.Header().Set("ETag", fmt.Sprintf("\"%d\"", detail.Version))
+	}
+}
+
+// versionFromETag parses the version of an If-Match header. A wildcard matches any version and is
+// parsed as no version, the caller still requires the weight to exist.
+func (handler HTTPHandler) versionFromETag(etag string) (version int64, ok bool) {
+	etag = strings.TrimSpace(etag)
+	if etag == "*" {
+		return 0, true
+	}
+
+	etag = strings.TrimPrefix(etag, "W/")
+	version, err := strconv.ParseInt(strings.Trim(etag, "\""), 10, 64)
+	if err != nil || version < 1 {
+		return 0, false
+	}
+	return version, true
+}
+
+func (handler HTTPHandler) preconditionFailedResponse() response.Response {
+	return response.NewErrorResponse(exception.ErrConflict, http.StatusPreconditionFailed, nil, response.StatPreconditionFailed, preconditionErrMessage)
+}
+
+func (handler HTTPHandler) invalidDateResponse() response.Response {
+	return response.NewErrorResponse(exception.ErrBadRequest, http.StatusBadRequest, nil, response.StatBadRequest, invalidDateErrMessage)
+}
+
+func (handler HTTPHandler) invalidPayloadResponse(message string) response.Response {
+	return response.NewErrorResponse(exception.ErrBadRequest, http.StatusBadRequest, nil, response.StatusInvalidPayload, message)
+}
+
+func (handler HTTPHandler) invalidQueryResponse(message string) response.Response {
+	return response.NewErrorResponse(exception.ErrBadRequest, http.StatusBadRequest, nil, response.StatBadRequest, message)
+}
@@ -0,0 +1,618 @@
+package weight_test
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/ijalalfrz/sirclo-weight-test/exception"
+	"github.com/ijalalfrz/sirclo-weight-test/model"
+	"github.com/ijalalfrz/sirclo-weight-test/requestctx"
+	"github.com/ijalalfrz/sirclo-weight-test/response"
+	"github.com/ijalalfrz/sirclo-weight-test/weight"
+	"github.com/ijalalfrz/sirclo-weight-test/weight/mocks"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHttpHandler_APIFindMany_Success(t *testing.T) {
+	usecase := new(mocks.Usecase)
+
+	hh := weight.HTTPHandler{
+		Logger:   logrus.New(),
+		Validate: vld,
+		Usecase:  usecase,
+	}
+
+	successResponse := response.NewSuccessResponse(model.WeightResponse{}, response.StatOK, "success")
+	usecase.On("FindMany", mock.Anything, mock.Anything).Return(successResponse)
+
+	r := httptest.NewRequest(http.MethodGet, "/just/for/testing", nil)
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.APIFindMany)
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	usecase.AssertExpectations(t)
+}
+
+func TestHttpHandler_APIFindMany_Error_InvalidQuery(t *testing.T) {
+	usecase := new(mocks.Usecase)
+
+	hh := weight.HTTPHandler{
+		Logger:   logrus.New(),
+		Validate: vld,
+		Usecase:  usecase,
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/just/for/testing?from=yesterday", nil)
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.APIFindMany)
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	usecase.AssertExpectations(t)
+}
+
+func TestHttpHandler_APIFindOne_Success(t *testing.T) {
+	usecase := new(mocks.Usecase)
+
+	hh := weight.HTTPHandler{
+		Logger:   logrus.New(),
+		Validate: vld,
+		Usecase:  usecase,
+	}
+
+	successResponse := response.NewSuccessResponse(model.WeighDetailResponse{Date: 1}, response.StatOK, "success")
+	usecase.On("FindOne", mock.Anything, int64(1656633600000000000)).Return(successResponse)
+
+	r := httptest.NewRequest(http.MethodGet, "/just/for/testing", nil)
+	r = mux.SetURLVars(r, map[string]string{"date": "2022-07-01"})
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.APIFindOne)
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	usecase.AssertExpectations(t)
+}
+
+func TestHttpHandler_APIFindOne_Error_InvalidDate(t *testing.T) {
+	usecase := new(mocks.Usecase)
+
+	hh := weight.HTTPHandler{
+		Logger:   logrus.New(),
+		Validate: vld,
+		Usecase:  usecase,
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/just/for/testing", nil)
+	r = mux.SetURLVars(r, map[string]string{"date": "abc"})
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.APIFindOne)
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	usecase.AssertExpectations(t)
+}
+
+func TestHttpHandler_APIInsertOne_Success(t *testing.T) {
+	usecase := new(mocks.Usecase)
+
+	hh := weight.HTTPHandler{
+		Logger:   logrus.New(),
+		Validate: vld,
+		Usecase:  usecase,
+	}
+
+	successResponse := response.NewSuccessResponse(nil, response.StatCreated, "success")
+	usecase.On("InsertOne", mock.Anything, model.WeightPayload{Date: 1656633600000000000, Day: "2022-07-01", Max: 3, Min: 1}).Return(successResponse)
+
+	var bodyStr = []byte(`{"day":"2022-07-01","max":3,"min":1}`)
+	r := httptest.NewRequest(http.MethodPost, "/just/for/testing", bytes.NewReader(bodyStr))
+	r.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.APIInsertOne)
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	usecase.AssertExpectations(t)
+}
+
+func TestHttpHandler_APIInsertOne_Success_UnixNanoInTimezone(t *testing.T) {
+	usecase := new(mocks.Usecase)
+
+	hh := weight.HTTPHandler{
+		Logger:   logrus.New(),
+		Validate: vld,
+		Usecase:  usecase,
+	}
+
+	successResponse := response.NewSuccessResponse(nil, response.StatCreated, "success")
+	usecase.On("InsertOne", mock.Anything, model.WeightPayload{Date: 1656633600000000000, Max: 3, Min: 1}).Return(successResponse)
+
+	// 2022-07-01 00:00 in Jakarta is still 2022-06-30 in UTC.
+	jakarta, _ := time.LoadLocation("Asia/Jakarta")
+	bodyStr := []byte(fmt.Sprintf(`{"date":%d,"max":3,"min":1}`, time.Date(2022, 7, 1, 0, 0, 0, 0, jakarta).UnixNano()))
+	r := httptest.NewRequest(http.MethodPost, "/just/for/testing", bytes.NewReader(bodyStr))
+	r.Header.Set("Content-Type", "application/json")
+	r = r.WithContext(requestctx.WithLocation(r.Context(), jakarta))
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.APIInsertOne)
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	usecase.AssertExpectations(t)
+}
+
+func TestHttpHandler_APIInsertOne_Success_UTCMidnightInTimezone(t *testing.T) {
+	usecase := new(mocks.Usecase)
+
+	hh := weight.HTTPHandler{
+		Logger:   logrus.New(),
+		Validate: vld,
+		Usecase:  usecase,
+	}
+
+	successResponse := response.NewSuccessResponse(nil, response.StatCreated, "success")
+	usecase.On("InsertOne", mock.Anything, model.WeightPayload{Date: 1656633600000000000, Max: 3, Min: 1}).Return(successResponse)
+
+	// 2022-07-02 00:00 in UTC is still 2022-07-01 in New York, it is not taken as a calendar date.
+	newYork, _ := time.LoadLocation("America/New_York")
+	bodyStr := []byte(fmt.Sprintf(`{"date":%d,"max":3,"min":1}`, time.Date(2022, 7, 2, 0, 0, 0, 0, time.UTC).UnixNano()))
+	r := httptest.NewRequest(http.MethodPost, "/just/for/testing", bytes.NewReader(bodyStr))
+	r.Header.Set("Content-Type", "application/json")
+	r = r.WithContext(requestctx.WithLocation(r.Context(), newYork))
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.APIInsertOne)
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	usecase.AssertExpectations(t)
+}
+
+func TestHttpHandler_APIInsertOne_Error_InvalidDay(t *testing.T) {
+	usecase := new(mocks.Usecase)
+
+	hh := weight.HTTPHandler{
+		Logger:   logrus.New(),
+		Validate: vld,
+		Usecase:  usecase,
+	}
+
+	var bodyStr = []byte(`{"day":"01-07-2022","max":3,"min":1}`)
+	r := httptest.NewRequest(http.MethodPost, "/just/for/testing", bytes.NewReader(bodyStr))
+	r.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.APIInsertOne)
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	usecase.AssertNotCalled(t, "InsertOne", mock.Anything, mock.Anything)
+}
+
+func TestHttpHandler_APIInsertOne_Error_InvalidJSON(t *testing.T) {
+	usecase := new(mocks.Usecase)
+
+	hh := weight.HTTPHandler{
+		Logger:   logrus.New(),
+		Validate: vld,
+		Usecase:  usecase,
+	}
+
+	var bodyStr = []byte(`{"date":`)
+	r := httptest.NewRequest(http.MethodPost, "/just/for/testing", bytes.NewReader(bodyStr))
+	r.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.APIInsertOne)
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	usecase.AssertExpectations(t)
+}
+
+func TestHttpHandler_APIInsertOne_Error_Validation(t *testing.T) {
+	usecase := new(mocks.Usecase)
+
+	hh := weight.HTTPHandler{
+		Logger:   logrus.New(),
+		Validate: vld,
+		Usecase:  usecase,
+	}
+
+	var bodyStr = []byte(`{"date":1,"max":1,"min":4}`)
+	r := httptest.NewRequest(http.MethodPost, "/just/for/testing", bytes.NewReader(bodyStr))
+	r.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.APIInsertOne)
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	usecase.AssertExpectations(t)
+}
+
+func TestHttpHandler_APIUpdateOne_Success(t *testing.T) {
+	usecase := new(mocks.Usecase)
+
+	hh := weight.HTTPHandler{
+		Logger:   logrus.New(),
+		Validate: vld,
+		Usecase:  usecase,
+	}
+
+	successResponse := response.NewSuccessResponse(nil, response.StatOK, "success")
+	usecase.On("UpdateOne", mock.Anything, int64(1656633600000000000), model.WeightPayload{Date: 1656633600000000000, Max: 3, Min: 1}).Return(successResponse)
+
+	var bodyStr = []byte(`{"max":3,"min":1}`)
+	r := httptest.NewRequest(http.MethodPut, "/just/for/testing", bytes.NewReader(bodyStr))
+	r.Header.Set("Content-Type", "application/json")
+	r = mux.SetURLVars(r, map[string]string{"date": "2022-07-01"})
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.APIUpdateOne)
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	usecase.AssertExpectations(t)
+}
+
+func TestHttpHandler_APIUpdateOne_Success_Upsert(t *testing.T) {
+	usecase := new(mocks.Usecase)
+
+	hh := weight.HTTPHandler{
+		Logger:   logrus.New(),
+		Validate: vld,
+		Usecase:  usecase,
+	}
+
+	successResponse := response.NewSuccessResponse(nil, response.StatCreated, "created")
+	usecase.On("UpsertOne", mock.Anything, int64(1656633600000000000), model.WeightPayload{Date: 1656633600000000000, Max: 3, Min: 1}).Return(successResponse)
+
+	var bodyStr = []byte(`{"max":3,"min":1}`)
+	r := httptest.NewRequest(http.MethodPut, "/just/for/testing?mode=upsert", bytes.NewReader(bodyStr))
+	r.Header.Set("Content-Type", "application/json")
+	r = mux.SetURLVars(r, map[string]string{"date": "2022-07-01"})
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.APIUpdateOne)
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	usecase.AssertExpectations(t)
+}
+
+func TestHttpHandler_APIUpdateOne_Error_Unexpected(t *testing.T) {
+	usecase := new(mocks.Usecase)
+
+	hh := weight.HTTPHandler{
+		Logger:   logrus.New(),
+		Validate: vld,
+		Usecase:  usecase,
+	}
+
+	errorResponse := response.NewErrorResponse(exception.ErrInternalServer, http.StatusInternalServerError, nil, response.StatUnexpectedError, "fail")
+	usecase.On("UpdateOne", mock.Anything, mock.Anything, mock.Anything).Return(errorResponse)
+
+	var bodyStr = []byte(`{"max":3,"min":1}`)
+	r := httptest.NewRequest(http.MethodPut, "/just/for/testing", bytes.NewReader(bodyStr))
+	r.Header.Set("Content-Type", "application/json")
+	r = mux.SetURLVars(r, map[string]string{"date": "2022-07-01"})
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.APIUpdateOne)
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	usecase.AssertExpectations(t)
+}
+
+func TestHttpHandler_APIFindOne_Success_ETag(t *testing.T) {
+	usecase := new(mocks.Usecase)
+
+	hh := weight.HTTPHandler{
+		Logger:   logrus.New(),
+		Validate: vld,
+		Usecase:  usecase,
+	}
+
+	successResponse := response.NewSuccessResponse(model.WeighDetailResponse{Date: 1656633600000000000, Version: 3}, response.StatOK, "success")
+	usecase.On("FindOne", mock.Anything, int64(1656633600000000000)).Return(successResponse)
+
+	r := httptest.NewRequest(http.MethodGet, "/just/for/testing", nil)
+	r = mux.SetURLVars(r, map[string]string{"date": "2022-07-01"})
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.APIFindOne)
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, `"3"`, recorder.Header().Get("ETag"))
+	usecase.AssertExpectations(t)
+}
+
+func TestHttpHandler_APIUpdateOne_Success_IfMatch(t *testing.T) {
+	usecase := new(mocks.Usecase)
+
+	hh := weight.HTTPHandler{
+		Logger:   logrus.New(),
+		Validate: vld,
+		Usecase:  usecase,
+	}
+
+	successResponse := response.NewSuccessResponse(nil, response.StatOK, "success")
+	usecase.On("UpdateOne", mock.Anything, int64(1656633600000000000), model.WeightPayload{Date: 1656633600000000000, Max: 3, Min: 1, Version: 3}).Return(successResponse)
+
+	var bodyStr = []byte(`{"max":3,"min":1,"version":1}`)
+	r := httptest.NewRequest(http.MethodPut, "/just/for/testing", bytes.NewReader(bodyStr))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("If-Match", `W/"3"`)
+	r = mux.SetURLVars(r, map[string]string{"date": "2022-07-01"})
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.APIUpdateOne)
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	usecase.AssertExpectations(t)
+}
+
+func TestHttpHandler_APIUpdateOne_Error_PreconditionFailed(t *testing.T) {
+	usecase := new(mocks.Usecase)
+
+	hh := weight.HTTPHandler{
+		Logger:   logrus.New(),
+		Validate: vld,
+		Usecase:  usecase,
+	}
+
+	errorResponse := response.NewErrorResponse(exception.ErrConflict, http.StatusConflict, nil, response.StatVersionConflict, "conflict")
+	usecase.On("UpdateOne", mock.Anything, mock.Anything, mock.Anything).Return(errorResponse)
+
+	var bodyStr = []byte(`{"max":3,"min":1}`)
+	r := httptest.NewRequest(http.MethodPut, "/just/for/testing", bytes.NewReader(bodyStr))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("If-Match", `"2"`)
+	r = mux.SetURLVars(r, map[string]string{"date": "2022-07-01"})
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.APIUpdateOne)
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+	usecase.AssertExpectations(t)
+}
+
+func TestHttpHandler_APIUpdateOne_Error_WildcardIfMatchNotFound(t *testing.T) {
+	usecase := new(mocks.Usecase)
+
+	hh := weight.HTTPHandler{
+		Logger:   logrus.New(),
+		Validate: vld,
+		Usecase:  usecase,
+	}
+
+	errorResponse := response.NewErrorResponse(exception.ErrNotFound, http.StatusNotFound, nil, response.StatNotFound, "not found")
+	usecase.On("UpdateOne", mock.Anything, int64(1656633600000000000), model.WeightPayload{Date: 1656633600000000000, Max: 3, Min: 1}).Return(errorResponse)
+
+	var bodyStr = []byte(`{"max":3,"min":1}`)
+	r := httptest.NewRequest(http.MethodPut, "/just/for/testing?mode=upsert", bytes.NewReader(bodyStr))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("If-Match", "*")
+	r = mux.SetURLVars(r, map[string]string{"date": "2022-07-01"})
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.APIUpdateOne)
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+	usecase.AssertExpectations(t)
+	usecase.AssertNotCalled(t, "UpsertOne", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHttpHandler_APIUpdateOne_Error_InvalidIfMatch(t *testing.T) {
+	usecase := new(mocks.Usecase)
+
+	hh := weight.HTTPHandler{
+		Logger:   logrus.New(),
+		Validate: vld,
+		Usecase:  usecase,
+	}
+
+	var bodyStr = []byte(`{"max":3,"min":1}`)
+	r := httptest.NewRequest(http.MethodPut, "/just/for/testing", bytes.NewReader(bodyStr))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("If-Match", `"abc"`)
+	r = mux.SetURLVars(r, map[string]string{"date": "2022-07-01"})
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.APIUpdateOne)
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+	usecase.AssertNotCalled(t, "UpdateOne", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHttpHandler_APIDeleteOne_Success(t *testing.T) {
+	usecase := new(mocks.Usecase)
+
+	hh := weight.HTTPHandler{
+		Logger:   logrus.New(),
+		Validate: vld,
+		Usecase:  usecase,
+	}
+
+	successResponse := response.NewSuccessResponse(nil, response.StatOK, "success")
+	usecase.On("DeleteOne", mock.Anything, int64(1656633600000000000)).Return(successResponse)
+
+	r := httptest.NewRequest(http.MethodDelete, "/just/for/testing", nil)
+	r = mux.SetURLVars(r, map[string]string{"date": "2022-07-01"})
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.APIDeleteOne)
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	usecase.AssertExpectations(t)
+}
+
+func TestHttpHandler_APIDeleteOne_Error_NotFound(t *testing.T) {
+	usecase := new(mocks.Usecase)
+
+	hh := weight.HTTPHandler{
+		Logger:   logrus.New(),
+		Validate: vld,
+		Usecase:  usecase,
+	}
+
+	errorResponse := response.NewErrorResponse(exception.ErrNotFound, http.StatusNotFound, nil, response.StatNotFound, "fail")
+	usecase.On("DeleteOne", mock.Anything, int64(1656633600000000000)).Return(errorResponse)
+
+	r := httptest.NewRequest(http.MethodDelete, "/just/for/testing", nil)
+	r = mux.SetURLVars(r, map[string]string{"date": "2022-07-01"})
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.APIDeleteOne)
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	usecase.AssertExpectations(t)
+}
+
+func TestHttpHandler_APIStatistics_Success(t *testing.T) {
+	usecase := new(mocks.Usecase)
+
+	hh := weight.HTTPHandler{
+		Logger:   logrus.New(),
+		Validate: vld,
+		Usecase:  usecase,
+	}
+
+	expectedFilter := model.StatisticFilter{From: 1656633600000000000, GroupBy: "month"}
+	successResponse := response.NewSuccessResponse([]model.StatisticResponse{}, response.StatOK, "success")
+	usecase.On("Statistics", mock.Anything, expectedFilter).Return(successResponse)
+
+	r := httptest.NewRequest(http.MethodGet, "/just/for/testing?from=2022-07-01&groupBy=month", nil)
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.APIStatistics)
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	usecase.AssertExpectations(t)
+}
+
+func TestHttpHandler_APIStatistics_Error_InvalidQuery(t *testing.T) {
+	usecase := new(mocks.Usecase)
+
+	hh := weight.HTTPHandler{
+		Logger:   logrus.New(),
+		Validate: vld,
+		Usecase:  usecase,
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/just/for/testing?to=tomorrow", nil)
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.APIStatistics)
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	usecase.AssertExpectations(t)
+}
+
+func TestHttpHandler_APIImportMany_Success(t *testing.T) {
+	usecase := new(mocks.Usecase)
+
+	hh := weight.HTTPHandler{
+		Logger:   logrus.New(),
+		Validate: vld,
+		Usecase:  usecase,
+	}
+
+	expectedRows := []model.WeightImportRow{
+		{Row: 1, Payload: model.WeightPayload{Date: 1656633600000000000, Max: 3, Min: 1}},
+	}
+	successResponse := response.NewSuccessResponse(model.ImportReport{Inserted: 1}, response.StatOK, "success")
+	usecase.On("ImportMany", mock.Anything, expectedRows, false).Return(successResponse)
+
+	var bodyStr = []byte("2022-07-01,3,1\n")
+	r := httptest.NewRequest(http.MethodPost, "/just/for/testing", bytes.NewReader(bodyStr))
+	r.Header.Set("Content-Type", "text/csv")
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.APIImportMany)
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	usecase.AssertExpectations(t)
+}
+
+func TestHttpHandler_APIImportMany_Success_Header(t *testing.T) {
+	usecase := new(mocks.Usecase)
+
+	hh := weight.HTTPHandler{
+		Logger:   logrus.New(),
+		Validate: vld,
+		Usecase:  usecase,
+	}
+
+	expectedRows := []model.WeightImportRow{
+		{Row: 2, Payload: model.WeightPayload{Date: 1656633600000000000, Max: 3, Min: 1}},
+	}
+	usecase.On("ImportMany", mock.Anything, expectedRows, false).Return(response.NewSuccessResponse(model.ImportReport{Inserted: 1}, response.StatOK, "success"))
+
+	var bodyStr = []byte("Date, Max, Min\n2022-07-01,3,1\n")
+	r := httptest.NewRequest(http.MethodPost, "/just/for/testing", bytes.NewReader(bodyStr))
+	r.Header.Set("Content-Type", "text/csv")
+	recorder := httptest.NewRecorder()
+	http.HandlerFunc(hh.APIImportMany).ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	usecase.AssertExpectations(t)
+}
+
+func TestHttpHandler_APIImportMany_Error_InvalidCSV(t *testing.T) {
+	usecase := new(mocks.Usecase)
+
+	hh := weight.HTTPHandler{
+		Logger:   logrus.New(),
+		Validate: vld,
+		Usecase:  usecase,
+	}
+
+	var bodyStr = []byte("2022-07-01,\"3,1\n")
+	r := httptest.NewRequest(http.MethodPost, "/just/for/testing", bytes.NewReader(bodyStr))
+	r.Header.Set("Content-Type", "text/csv")
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.APIImportMany)
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	usecase.AssertExpectations(t)
+}
+
+func TestHttpHandler_APIHistory_Success(t *testing.T) {
+	usecase := new(mocks.Usecase)
+
+	hh := weight.HTTPHandler{
+		Logger:   logrus.New(),
+		Validate: vld,
+		Usecase:  usecase,
+	}
+
+	successResponse := response.NewSuccessResponse([]model.WeightRevisionResponse{{ID: "abc"}}, response.StatOK, "success")
+	usecase.On("History", mock.Anything, int64(1656633600000000000)).Return(successResponse)
+
+	r := httptest.NewRequest(http.MethodGet, "/just/for/testing", nil)
+	r = mux.SetURLVars(r, map[string]string{"date": "2022-07-01"})
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.APIHistory)
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	usecase.AssertExpectations(t)
+}
+
+func TestHttpHandler_APIHistory_Error_InvalidDate(t *testing.T) {
+	usecase := new(mocks.Usecase)
+
+	hh := weight.HTTPHandler{
+		Logger:   logrus.New(),
+		Validate: vld,
+		Usecase:  usecase,
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/just/for/testing", nil)
+	r = mux.SetURLVars(r, map[string]string{"date": "yesterday"})
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.APIHistory)
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestHttpHandler_APIRestore_Success(t *testing.T) {
+	usecase := new(mocks.Usecase)
+
+	hh := weight.HTTPHandler{
+		Logger:   logrus.New(),
+		Validate: vld,
+		Usecase:  usecase,
+	}
+
+	successResponse := response.NewSuccessResponse(nil, response.StatOK, "success")
+	usecase.On("Restore", mock.Anything, int64(1656633600000000000), "abc").Return(successResponse)
+
+	r := httptest.NewRequest(http.MethodPost, "/just/for/testing", nil)
+	r = mux.SetURLVars(r, map[string]string{"date": "2022-07-01", "revision": "abc"})
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.APIRestore)
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	usecase.AssertExpectations(t)
+}
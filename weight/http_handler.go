@@ -1,52 +1,88 @@
 package weight
 
 import (
+	"encoding/csv"
 	"fmt"
+	"html/template"
+	"io"
 	"net/http"
 	"strconv"
-	"text/template"
+	"strings"
 	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
+	"github.com/ijalalfrz/sirclo-weight-test/exception"
 	"github.com/ijalalfrz/sirclo-weight-test/model"
+	"github.com/ijalalfrz/sirclo-weight-test/requestctx"
+	"github.com/ijalalfrz/sirclo-weight-test/response"
 	"github.com/sirupsen/logrus"
 )
 
 const (
-	basePath = "/weight"
+	basePath      = "/weight"
+	maxImportSize = 10 << 20
+	modeUpsert    = "upsert"
 )
 
+// importColumns are the columns of an imported csv, its first row may name them.
+var importColumns = []string{"date", "max", "min"}
+
 // HTTPHandler is a concrete struct of weight http handler.
 type HTTPHandler struct {
 	Logger       *logrus.Logger
 	Validate     *validator.Validate
 	Usecase      Usecase
+	Broadcaster  *Broadcaster
 	TemplatePath string
 }
 
-func NewWeightHTTPHandler(logger *logrus.Logger, validate *validator.Validate, router *mux.Router, usecase Usecase) {
+// NewWeightHTTPHandler registers the weight pages and api on router, the live stream is only served with a broadcaster.
+func NewWeightHTTPHandler(logger *logrus.Logger, validate *validator.Validate, router *mux.Router, usecase Usecase, broadcaster *Broadcaster) {
 	handler := &HTTPHandler{
 		Logger:       logger,
 		Validate:     validate,
 		Usecase:      usecase,
+		Broadcaster:  broadcaster,
 		TemplatePath: "./weight/template/",
 	}
+	if broadcaster != nil {
+		router.HandleFunc(basePath+"/stream", handler.Stream).Methods(http.MethodGet)
+	}
 	router.HandleFunc(basePath+"/add", handler.GetWeightForm).Methods(http.MethodGet)
+	router.HandleFunc(basePath+"/import", handler.GetImportForm).Methods(http.MethodGet)
+	router.HandleFunc(basePath+"/import", handler.ImportWeight).Methods(http.MethodPost)
+	router.HandleFunc(basePath+"/export", handler.ExportWeight).Methods(http.MethodGet)
 	router.HandleFunc(basePath+"/{date}/update", handler.GetUpdateWeightForm).Methods(http.MethodGet)
+	router.HandleFunc(basePath+"/{date}/history", handler.History).Methods(http.MethodGet)
 
 	router.HandleFunc(basePath, handler.Index).Methods(http.MethodGet)
 	router.HandleFunc(basePath+"/{date}", handler.Detail).Methods(http.MethodGet)
 
 	router.HandleFunc(basePath, handler.AddWeight).Methods(http.MethodPost)
 	router.HandleFunc(basePath+"/{date}", handler.UpdateWeight).Methods(http.MethodPost)
+	router.HandleFunc(basePath+"/{date}/delete", handler.DeleteWeight).Methods(http.MethodPost)
+	router.HandleFunc(basePath+"/{date}/history/{revision}/restore", handler.RestoreWeight).Methods(http.MethodPost)
+
+	router.HandleFunc(apiBasePath, handler.APIFindMany).Methods(http.MethodGet)
+	router.HandleFunc(apiBasePath+"/statistics", handler.APIStatistics).Methods(http.MethodGet)
+	router.HandleFunc(apiBasePath+"/import", handler.APIImportMany).Methods(http.MethodPost)
+	router.HandleFunc(apiBasePath+"/{date}", handler.APIFindOne).Methods(http.MethodGet)
+	router.HandleFunc(apiBasePath, handler.APIInsertOne).Methods(http.MethodPost)
+	router.HandleFunc(apiBasePath+"/{date}", handler.APIUpdateOne).Methods(http.MethodPut)
+	router.HandleFunc(apiBasePath+"/{date}", handler.APIDeleteOne).Methods(http.MethodDelete)
+	router.HandleFunc(apiBasePath+"/{date}/history", handler.APIHistory).Methods(http.MethodGet)
+	router.HandleFunc(apiBasePath+"/{date}/history/{revision}/restore", handler.APIRestore).Methods(http.MethodPost)
 
 }
 
 func (handler HTTPHandler) GetWeightForm(w http.ResponseWriter, r *http.Request) {
 
+	// the date is prefilled with today of the user, not of the server.
+	location := requestctx.Location(r.Context())
 	data := map[string]interface{}{
 		"Error": r.Header.Get("error"),
+		"Today": time.Now().In(location).Format(dateLayout),
 	}
 	tmpl := template.Must(template.ParseFiles(fmt.Sprintf("%s%s", handler.TemplatePath, "add.html")))
 
@@ -57,15 +93,10 @@ func (handler HTTPHandler) GetWeightForm(w http.ResponseWriter, r *http.Request)
 
 func (handler HTTPHandler) GetUpdateWeightForm(w http.ResponseWriter, r *http.Request) {
 
-	pathVariables := mux.Vars(r)
-	dateStr := pathVariables["date"]
-	if dateStr == "" {
-		http.Redirect(w, r, basePath, http.StatusSeeOther)
-	}
-
-	date, err := strconv.ParseInt(dateStr, 10, 64)
+	date, err := handler.dateFromPath(r)
 	if err != nil {
 		http.Redirect(w, r, basePath, http.StatusSeeOther)
+		return
 	}
 
 	resp := handler.Usecase.FindOne(r.Context(), date)
@@ -83,28 +114,56 @@ func (handler HTTPHandler) GetUpdateWeightForm(w http.ResponseWriter, r *http.Re
 }
 
 func (handler HTTPHandler) Index(w http.ResponseWriter, r *http.Request) {
-	resp := handler.Usecase.FindMany(r.Context())
+	filter, err := handler.parseFilter(r)
+	if err != nil {
+		filter = model.WeightFilter{}
+	}
+
+	resp := handler.Usecase.FindMany(r.Context(), filter)
+
+	data := map[string]interface{}{
+		"Error": resp.Message(),
+		"Data":  resp.Data(),
+		"Query": r.URL.Query(),
+	}
+	if resp.Error() == nil {
+		data["Error"] = ""
+	}
+	if meta, ok := resp.Meta().(model.WeightMeta); ok {
+		data["Meta"] = meta
+		if meta.Page > 1 {
+			data["PrevPage"] = meta.Page - 1
+		}
+		if meta.Page < meta.TotalPage {
+			data["NextPage"] = meta.Page + 1
+		}
+	}
 
 	tmpl := template.Must(template.ParseFiles(fmt.Sprintf("%s%s", handler.TemplatePath, "index.html")))
 
-	tmpl.Execute(w, resp.Data())
+	tmpl.Execute(w, data)
 	return
 
 }
 
 func (handler HTTPHandler) Detail(w http.ResponseWriter, r *http.Request) {
-	pathVariables := mux.Vars(r)
-	dateStr := pathVariables["date"]
-	if dateStr == "" {
+	date, err := handler.dateFromPath(r)
+	if err != nil {
 		http.Redirect(w, r, basePath, http.StatusSeeOther)
+		return
 	}
-	date, _ := strconv.ParseInt(dateStr, 10, 64)
 
 	resp := handler.Usecase.FindOne(r.Context(), date)
+	handler.setETag(w, resp)
+
+	data := map[string]interface{}{
+		"Error": r.Header.Get("error"),
+		"Data":  resp.Data(),
+	}
 
 	tmpl := template.Must(template.ParseFiles(fmt.Sprintf("%s%s", handler.TemplatePath, "detail.html")))
 
-	tmpl.Execute(w, resp.Data())
+	tmpl.Execute(w, data)
 	return
 
 }
@@ -113,7 +172,7 @@ func (handler HTTPHandler) AddWeight(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	dateString := r.FormValue("date")
-	dateTime, _ := time.Parse("2006-01-02", dateString)
+	dateTime, _ := time.Parse(dateLayout, dateString)
 	max, _ := strconv.Atoi(r.FormValue("max"))
 	min, _ := strconv.Atoi(r.FormValue("min"))
 	payload := model.WeightPayload{
@@ -129,7 +188,13 @@ func (handler HTTPHandler) AddWeight(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp := handler.Usecase.InsertOne(ctx, payload)
+	// the weight of the date is overwritten instead of rejected as already exist when upsert is requested.
+	var resp response.Response
+	if r.FormValue("mode") == modeUpsert {
+		resp = handler.Usecase.UpsertOne(ctx, payload.Date, payload)
+	} else {
+		resp = handler.Usecase.InsertOne(ctx, payload)
+	}
 
 	if resp.Error() == nil {
 		http.Redirect(w, r, basePath+"/add", http.StatusSeeOther)
@@ -143,28 +208,35 @@ func (handler HTTPHandler) AddWeight(w http.ResponseWriter, r *http.Request) {
 
 func (handler HTTPHandler) UpdateWeight(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	pathVariables := mux.Vars(r)
-	dateStr := pathVariables["date"]
-	if dateStr == "" {
+	date, err := handler.dateFromPath(r)
+	if err != nil {
 		http.Redirect(w, r, basePath, http.StatusSeeOther)
+		return
 	}
 	max, _ := strconv.Atoi(r.FormValue("max"))
 	min, _ := strconv.Atoi(r.FormValue("min"))
-	date, _ := strconv.ParseInt(dateStr, 10, 64)
+	// the version is kept in a hidden field of the form, so a concurrent change is not silently overwritten.
+	version, _ := strconv.ParseInt(r.FormValue("version"), 10, 64)
 	payload := model.WeightPayload{
-		Date: date,
-		Max:  max,
-		Min:  min,
+		Date:    date,
+		Max:     max,
+		Min:     min,
+		Version: version,
 	}
 
-	err := handler.validateRequest(payload)
+	err = handler.validateRequest(payload)
 	if err != nil {
 		r.Header.Set("error", err.Error())
 		handler.GetUpdateWeightForm(w, r)
 		return
 	}
 
-	resp := handler.Usecase.UpdateOne(ctx, date, payload)
+	var resp response.Response
+	if r.FormValue("mode") == modeUpsert {
+		resp = handler.Usecase.UpsertOne(ctx, date, payload)
+	} else {
+		resp = handler.Usecase.UpdateOne(ctx, date, payload)
+	}
 	if resp.Error() == nil {
 		http.Redirect(w, r, basePath, http.StatusSeeOther)
 	} else {
@@ -175,6 +247,293 @@ func (handler HTTPHandler) UpdateWeight(w http.ResponseWriter, r *http.Request)
 	return
 }
 
+func (handler HTTPHandler) DeleteWeight(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	date, err := handler.dateFromPath(r)
+	if err != nil {
+		http.Redirect(w, r, basePath, http.StatusSeeOther)
+		return
+	}
+
+	resp := handler.Usecase.DeleteOne(ctx, date)
+	if resp.Error() == nil {
+		http.Redirect(w, r, basePath, http.StatusSeeOther)
+	} else {
+		r.Header.Set("error", resp.Message())
+		handler.Detail(w, r)
+		return
+	}
+	return
+}
+
+func (handler HTTPHandler) History(w http.ResponseWriter, r *http.Request) {
+	date, err := handler.dateFromPath(r)
+	if err != nil {
+		http.Redirect(w, r, basePath, http.StatusSeeOther)
+		return
+	}
+
+	resp := handler.Usecase.History(r.Context(), date)
+
+	errMessage := r.Header.Get("error")
+	if errMessage == "" && resp.Error() != nil {
+		errMessage = resp.Message()
+	}
+	data := map[string]interface{}{
+		"Error": errMessage,
+		"Date":  formatDate(date),
+		"Data":  resp.Data(),
+	}
+
+	tmpl := template.Must(template.ParseFiles(fmt.Sprintf("%s%s", handler.TemplatePath, "history.html")))
+
+	tmpl.Execute(w, data)
+	return
+}
+
+func (handler HTTPHandler) RestoreWeight(w http.ResponseWriter, r *http.Request) {
+	date, err := handler.dateFromPath(r)
+	if err != nil {
+		http.Redirect(w, r, basePath, http.StatusSeeOther)
+		return
+	}
+
+	resp := handler.Usecase.Restore(r.Context(), date, mux.Vars(r)["revision"])
+	if resp.Error() == nil {
+		http.Redirect(w, r, fmt.Sprintf("%s/%s/history", basePath, formatDate(date)), http.StatusSeeOther)
+	} else {
+		r.Header.Set("error", resp.Message())
+		handler.History(w, r)
+		return
+	}
+	return
+}
+
+func (handler HTTPHandler) GetImportForm(w http.ResponseWriter, r *http.Request) {
+
+	data := map[string]interface{}{
+		"Error":  r.Header.Get("error"),
+		"Report": nil,
+	}
+	tmpl := template.Must(template.ParseFiles(fmt.Sprintf("%s%s", handler.TemplatePath, "import.html")))
+
+	tmpl.Execute(w, data)
+	return
+
+}
+
+func (handler HTTPHandler) ImportWeight(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		r.Header.Set("error", "File is required")
+		handler.GetImportForm(w, r)
+		return
+	}
+	defer file.Close()
+
+	rows, err := handler.parseImport(file)
+	if err != nil {
+		r.Header.Set("error", err.Error())
+		handler.GetImportForm(w, r)
+		return
+	}
+
+	resp := handler.Usecase.ImportMany(r.Context(), rows, r.FormValue("mode") == "overwrite")
+
+	data := map[string]interface{}{
+		"Error":  "",
+		"Report": resp.Data(),
+	}
+	if resp.Error() != nil {
+		data["Error"] = resp.Message()
+	}
+
+	tmpl := template.Must(template.ParseFiles(fmt.Sprintf("%s%s", handler.TemplatePath, "import.html")))
+
+	tmpl.Execute(w, data)
+	return
+}
+
+func (handler HTTPHandler) ExportWeight(w http.ResponseWriter, r *http.Request) {
+	filter, err := handler.parseFilter(r)
+	if err != nil {
+		response.JSON(w, response.NewErrorResponse(exception.ErrBadRequest, http.StatusBadRequest, nil, response.StatBadRequest, err.Error()))
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	writer, err := newExportWriter(format, w)
+	if err != nil {
+		response.JSON(w, response.NewErrorResponse(exception.ErrBadRequest, http.StatusBadRequest, nil, response.StatBadRequest, err.Error()))
+		return
+	}
+	if format == "" {
+		format = exportFormatCSV
+	}
+
+	// the response header is only written along with the first row, so an error
+	// that happens before anything is streamed can still be responded as json.
+	started := false
+	begin := func() error {
+		started = true
+		w.Header().Set("Content-Type", writer.ContentType())
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"weight.%s\"", format))
+		return writer.Begin()
+	}
+
+	resp := handler.Usecase.ExportMany(r.Context(), filter, func(weight model.WeightExportResponse) error {
+		if !started {
+			if err := begin(); err != nil {
+				return err
+			}
+		}
+		return writer.Write(weight)
+	})
+
+	if resp.Error() != nil && !started {
+		response.JSON(w, resp)
+		return
+	}
+
+	// the status is already sent once a row is streamed, so the connection is aborted instead of ending
+	// the file, otherwise the client would receive a truncated export as if it were complete.
+	if resp.Error() != nil {
+		panic(http.ErrAbortHandler)
+	}
+
+	if !started {
+		if err := begin(); err != nil {
+			requestctx.Logger(r.Context(), handler.Logger).Error(err)
+			return
+		}
+	}
+
+	if err := writer.End(); err != nil {
+		requestctx.Logger(r.Context(), handler.Logger).Error(err)
+	}
+	return
+}
+
+// parseImport reads csv rows of date,max,min and validates each of them.
+// A header row is skipped when its first column is not a date.
+func (handler HTTPHandler) parseImport(reader io.Reader) (rows []model.WeightImportRow, err error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		err = fmt.Errorf("Invalid csv file: %s", err.Error())
+		return
+	}
+
+	for index, record := range records {
+		if index == 0 && isImportHeader(record) {
+			continue
+		}
+
+		row := model.WeightImportRow{
+			Row: index + 1,
+		}
+
+		if len(record) != len(importColumns) {
+			row.Error = "Row must contain date,max,min"
+			rows = append(rows, row)
+			continue
+		}
+
+		dateTime, errDate := time.Parse(dateLayout, record[0])
+		max, errMax := strconv.Atoi(record[1])
+		min, errMin := strconv.Atoi(record[2])
+
+		row.Payload = model.WeightPayload{
+			Date: dateTime.UnixNano(),
+			Max:  max,
+			Min:  min,
+		}
+
+		switch {
+		case errDate != nil:
+			row.Payload.Date = 0
+			row.Error = fmt.Sprintf("Invalid 'Date' with value '%s'", record[0])
+		case errMax != nil:
+			row.Error = fmt.Sprintf("Invalid 'Max' with value '%s'", record[1])
+		case errMin != nil:
+			row.Error = fmt.Sprintf("Invalid 'Min' with value '%s'", record[2])
+		default:
+			if errValidate := handler.validateRequest(row.Payload); errValidate != nil {
+				row.Error = errValidate.Error()
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	return
+}
+
+// isImportHeader reports whether the record names the import columns, in any case.
+func isImportHeader(record []string) bool {
+	if len(record) != len(importColumns) {
+		return false
+	}
+	for i, column := range importColumns {
+		if !strings.EqualFold(strings.TrimSpace(record[i]), column) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseFilter reads from, to, page, limit, sortBy and sort from query string.
+func (handler HTTPHandler) parseFilter(r *http.Request) (filter model.WeightFilter, err error) {
+	query := r.URL.Query()
+
+	if from := query.Get("from"); from != "" {
+		fromTime, err := time.Parse(dateLayout, from)
+		if err != nil {
+			return filter, fmt.Errorf("Invalid 'from' with value '%s'", from)
+		}
+		filter.From = fromTime.UnixNano()
+	}
+
+	if to := query.Get("to"); to != "" {
+		toTime, err := time.Parse(dateLayout, to)
+		if err != nil {
+			return filter, fmt.Errorf("Invalid 'to' with value '%s'", to)
+		}
+		filter.To = toTime.UnixNano()
+	}
+
+	if page := query.Get("page"); page != "" {
+		filter.Page, err = strconv.ParseInt(page, 10, 64)
+		if err != nil {
+			return filter, fmt.Errorf("Invalid 'page' with value '%s'", page)
+		}
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		filter.Limit, err = strconv.ParseInt(limit, 10, 64)
+		if err != nil {
+			return filter, fmt.Errorf("Invalid 'limit' with value '%s'", limit)
+		}
+	}
+
+	filter.SortBy = query.Get("sortBy")
+
+	switch query.Get("sort") {
+	case "", "desc":
+		filter.Sort = -1
+	case "asc":
+		filter.Sort = 1
+	default:
+		return filter, fmt.Errorf("Invalid 'sort' with value '%s'", query.Get("sort"))
+	}
+
+	return
+}
+
 func (handler HTTPHandler) validateRequest(payload model.WeightPayload) (err error) {
 	err = handler.Validate.Struct(payload)
 	if err == nil {
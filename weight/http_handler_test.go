@@ -1,7 +1,13 @@
 package weight_test
 
 import (
+	"archive/zip"
+	"bufio"
 	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -10,12 +16,15 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/ijalalfrz/sirclo-weight-test/entity"
 	"github.com/ijalalfrz/sirclo-weight-test/exception"
+	"github.com/ijalalfrz/sirclo-weight-test/model"
+	"github.com/ijalalfrz/sirclo-weight-test/requestctx"
 	"github.com/ijalalfrz/sirclo-weight-test/response"
 	"github.com/ijalalfrz/sirclo-weight-test/weight"
 	"github.com/ijalalfrz/sirclo-weight-test/weight/mocks"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 var (
@@ -32,7 +41,7 @@ func TestNewWeightHTTPHandler(t *testing.T) {
 	router := &mux.Router{}
 	usecase := &mocks.Usecase{}
 
-	weight.NewWeightHTTPHandler(logger, validate, router, usecase)
+	weight.NewWeightHTTPHandler(logger, validate, router, usecase, weight.NewBroadcaster(0))
 }
 
 func TestHttpHandler_Index_Success(t *testing.T) {
@@ -53,7 +62,7 @@ func TestHttpHandler_Index_Success(t *testing.T) {
 		},
 	}
 	successResponse := response.NewSuccessResponse(data, response.StatOK, "success")
-	usecase.On("FindMany", mock.Anything).Return(successResponse)
+	usecase.On("FindMany", mock.Anything, mock.Anything).Return(successResponse)
 
 	r := httptest.NewRequest(http.MethodGet, "/just/for/testing", nil)
 	recorder := httptest.NewRecorder()
@@ -63,6 +72,57 @@ func TestHttpHandler_Index_Success(t *testing.T) {
 	usecase.AssertExpectations(t)
 }
 
+func TestHttpHandler_Index_Success_WithFilter(t *testing.T) {
+	usecase := new(mocks.Usecase)
+
+	hh := weight.HTTPHandler{
+		Logger:       logrus.New(),
+		Validate:     vld,
+		Usecase:      usecase,
+		TemplatePath: "./template/",
+	}
+	expectedFilter := model.WeightFilter{
+		From:   1656633600000000000,
+		To:     1656720000000000000,
+		Page:   2,
+		Limit:  5,
+		SortBy: "max",
+		Sort:   1,
+	}
+	meta := model.WeightMeta{Page: 2, Limit: 5, TotalData: 12, TotalPage: 3}
+	successResponse := response.NewSuccessResponseWithMeta(model.WeightResponse{}, response.StatOK, "success", meta)
+	usecase.On("FindMany", mock.Anything, expectedFilter).Return(successResponse)
+
+	r := httptest.NewRequest(http.MethodGet, "/just/for/testing?from=2022-07-01&to=2022-07-02&page=2&limit=5&sortBy=max&sort=asc", nil)
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.Index)
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	assert.Contains(t, recorder.Body.String(), "page=1")
+	assert.Contains(t, recorder.Body.String(), "page=3")
+	usecase.AssertExpectations(t)
+}
+
+func TestHttpHandler_Index_Success_EscapesQuery(t *testing.T) {
+	usecase := new(mocks.Usecase)
+
+	hh := weight.HTTPHandler{
+		Logger:       logrus.New(),
+		Validate:     vld,
+		Usecase:      usecase,
+		TemplatePath: "./template/",
+	}
+	usecase.On("FindMany", mock.Anything, mock.Anything).Return(response.NewSuccessResponse(model.WeightResponse{}, response.StatOK, "success"))
+
+	r := httptest.NewRequest(http.MethodGet, "/just/for/testing?from=%22%3E%3Cscript%3Ealert(1)%3C/script%3E", nil)
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.Index)
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.NotContains(t, recorder.Body.String(), `"><script>alert(1)`, "should escape the query")
+	assert.Contains(t, recorder.Body.String(), `&#34;&gt;&lt;script&gt;alert(1)`, "should escape the query of the form")
+}
+
 func TestHttpHandler_AddForm_Success(t *testing.T) {
 	usecase := new(mocks.Usecase)
 
@@ -125,7 +185,7 @@ func TestHttpHandler_UpdateForm_Success(t *testing.T) {
 	r := httptest.NewRequest(http.MethodGet, "/just/for/testing", nil)
 
 	vars := map[string]string{
-		"date": "1",
+		"date": "2022-07-01",
 	}
 
 	r = mux.SetURLVars(r, vars)
@@ -156,7 +216,7 @@ func TestHttpHandler_Detail_Success(t *testing.T) {
 	r := httptest.NewRequest(http.MethodGet, "/just/for/testing", nil)
 
 	vars := map[string]string{
-		"date": "1",
+		"date": "2022-07-01",
 	}
 
 	r = mux.SetURLVars(r, vars)
@@ -193,6 +253,30 @@ func TestHttpHandler_Detail_Error_NoPathVariable(t *testing.T) {
 	assert.Equal(t, recorder.Code, http.StatusSeeOther)
 }
 
+func TestHttpHandler_AddWeight_Success_Upsert(t *testing.T) {
+	usecase := new(mocks.Usecase)
+
+	hh := weight.HTTPHandler{
+		Logger:       logrus.New(),
+		Validate:     vld,
+		Usecase:      usecase,
+		TemplatePath: "./template/",
+	}
+
+	successResponse := response.NewSuccessResponse(nil, response.StatOK, "success")
+	usecase.On("UpsertOne", mock.Anything, mock.Anything, mock.Anything).Return(successResponse)
+	var bodyStr = []byte(`date=1&max=3&min=1&mode=upsert`)
+	r := httptest.NewRequest(http.MethodPost, "/just/for/testing", bytes.NewReader(bodyStr))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.AddWeight)
+
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, recorder.Code, http.StatusSeeOther)
+	usecase.AssertExpectations(t)
+}
+
 func TestHttpHandler_AddWeight_Success(t *testing.T) {
 	usecase := new(mocks.Usecase)
 
@@ -300,7 +384,7 @@ func TestHttpHandler_UpdateWeight_Success(t *testing.T) {
 	r := httptest.NewRequest(http.MethodPost, "/just/for/testing", bytes.NewReader(bodyStr))
 	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	vars := map[string]string{
-		"date": "1",
+		"date": "2022-07-01",
 	}
 	r = mux.SetURLVars(r, vars)
 
@@ -311,6 +395,31 @@ func TestHttpHandler_UpdateWeight_Success(t *testing.T) {
 	assert.Equal(t, recorder.Code, http.StatusSeeOther)
 }
 
+func TestHttpHandler_UpdateWeight_Success_Version(t *testing.T) {
+	usecase := new(mocks.Usecase)
+
+	hh := weight.HTTPHandler{
+		Logger:       logrus.New(),
+		Validate:     vld,
+		Usecase:      usecase,
+		TemplatePath: "./template/",
+	}
+
+	successResponse := response.NewSuccessResponse(nil, response.StatOK, "success")
+	usecase.On("UpdateOne", mock.Anything, int64(1656633600000000000), model.WeightPayload{Date: 1656633600000000000, Max: 3, Min: 1, Version: 2}).Return(successResponse)
+	var bodyStr = []byte(`max=3&min=1&version=2`)
+	r := httptest.NewRequest(http.MethodPost, "/just/for/testing", bytes.NewReader(bodyStr))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r = mux.SetURLVars(r, map[string]string{"date": "2022-07-01"})
+
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.UpdateWeight)
+
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, recorder.Code, http.StatusSeeOther)
+	usecase.AssertExpectations(t)
+}
+
 func TestHttpHandler_UpdateWeight_ErrorValidation(t *testing.T) {
 	usecase := new(mocks.Usecase)
 
@@ -331,7 +440,7 @@ func TestHttpHandler_UpdateWeight_ErrorValidation(t *testing.T) {
 	r := httptest.NewRequest(http.MethodPost, "/just/for/testing", nil)
 	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	vars := map[string]string{
-		"date": "1",
+		"date": "2022-07-01",
 	}
 	r = mux.SetURLVars(r, vars)
 
@@ -387,7 +496,7 @@ func TestHttpHandler_UpdateWeight_Error_Unexpected(t *testing.T) {
 	r := httptest.NewRequest(http.MethodPost, "/just/for/testing", bytes.NewReader(bodyStr))
 	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	vars := map[string]string{
-		"date": "1",
+		"date": "2022-07-01",
 	}
 	r = mux.SetURLVars(r, vars)
 
@@ -397,3 +506,489 @@ func TestHttpHandler_UpdateWeight_Error_Unexpected(t *testing.T) {
 	handler.ServeHTTP(recorder, r)
 	assert.Equal(t, recorder.Code, http.StatusOK)
 }
+
+func TestHttpHandler_DeleteWeight_Success(t *testing.T) {
+	usecase := new(mocks.Usecase)
+
+	hh := weight.HTTPHandler{
+		Logger:       logrus.New(),
+		Validate:     vld,
+		Usecase:      usecase,
+		TemplatePath: "./template/",
+	}
+
+	successResponse := response.NewSuccessResponse(nil, response.StatOK, "success")
+	usecase.On("DeleteOne", mock.Anything, int64(1656633600000000000)).Return(successResponse)
+	r := httptest.NewRequest(http.MethodPost, "/just/for/testing", nil)
+	vars := map[string]string{
+		"date": "2022-07-01",
+	}
+	r = mux.SetURLVars(r, vars)
+
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.DeleteWeight)
+
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, recorder.Code, http.StatusSeeOther)
+	usecase.AssertExpectations(t)
+}
+
+func TestHttpHandler_DeleteWeight_Error_NotFound(t *testing.T) {
+	usecase := new(mocks.Usecase)
+
+	hh := weight.HTTPHandler{
+		Logger:       logrus.New(),
+		Validate:     vld,
+		Usecase:      usecase,
+		TemplatePath: "./template/",
+	}
+
+	errorResponse := response.NewErrorResponse(exception.ErrNotFound, http.StatusNotFound, nil, response.StatNotFound, "fail")
+	usecase.On("DeleteOne", mock.Anything, mock.Anything).Return(errorResponse)
+	usecase.On("FindOne", mock.Anything, mock.Anything).Return(errorResponse)
+	r := httptest.NewRequest(http.MethodPost, "/just/for/testing", nil)
+	vars := map[string]string{
+		"date": "2022-07-01",
+	}
+	r = mux.SetURLVars(r, vars)
+
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.DeleteWeight)
+
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	usecase.AssertExpectations(t)
+}
+
+func TestHttpHandler_DeleteWeight_Error_NoPathVariable(t *testing.T) {
+	usecase := new(mocks.Usecase)
+
+	hh := weight.HTTPHandler{
+		Logger:       logrus.New(),
+		Validate:     vld,
+		Usecase:      usecase,
+		TemplatePath: "./template/",
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/just/for/testing", nil)
+
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.DeleteWeight)
+
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, recorder.Code, http.StatusSeeOther)
+	usecase.AssertExpectations(t)
+}
+
+func TestHttpHandler_ImportForm_Success(t *testing.T) {
+	usecase := new(mocks.Usecase)
+
+	hh := weight.HTTPHandler{
+		Logger:       logrus.New(),
+		Validate:     vld,
+		Usecase:      usecase,
+		TemplatePath: "./template/",
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/just/for/testing", nil)
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.GetImportForm)
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, recorder.Code, http.StatusOK)
+}
+
+func TestHttpHandler_ImportWeight_Success(t *testing.T) {
+	usecase := new(mocks.Usecase)
+
+	hh := weight.HTTPHandler{
+		Logger:       logrus.New(),
+		Validate:     vld,
+		Usecase:      usecase,
+		TemplatePath: "./template/",
+	}
+
+	expectedRows := []model.WeightImportRow{
+		{Row: 2, Payload: model.WeightPayload{Date: 1656633600000000000, Max: 3, Min: 1}},
+		{Row: 3, Payload: model.WeightPayload{Date: 1656720000000000000, Max: 1, Min: 4}, Error: "Max must be greater than min"},
+		{Row: 4, Payload: model.WeightPayload{Max: 3, Min: 1}, Error: "Invalid 'Date' with value 'yesterday'"},
+		{Row: 5, Error: "Row must contain date,max,min"},
+	}
+	report := model.ImportReport{Inserted: 1, Invalid: 3}
+	successResponse := response.NewSuccessResponse(report, response.StatOK, "success")
+	usecase.On("ImportMany", mock.Anything, expectedRows, true).Return(successResponse)
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("file", "weight.csv")
+	part.Write([]byte("date,max,min\n2022-07-01,3,1\n2022-07-02,1,4\nyesterday,3,1\n2022-07-03\n"))
+	writer.WriteField("mode", "overwrite")
+	writer.Close()
+
+	r := httptest.NewRequest(http.MethodPost, "/just/for/testing", body)
+	r.Header.Set("Content-Type", writer.FormDataContentType())
+
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.ImportWeight)
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	assert.Contains(t, recorder.Body.String(), "Ditambah: 1")
+	usecase.AssertExpectations(t)
+}
+
+func TestHttpHandler_ImportWeight_Success_ShortFirstRow(t *testing.T) {
+	usecase := new(mocks.Usecase)
+
+	hh := weight.HTTPHandler{
+		Logger:       logrus.New(),
+		Validate:     vld,
+		Usecase:      usecase,
+		TemplatePath: "./template/",
+	}
+
+	expectedRows := []model.WeightImportRow{
+		{Row: 1, Error: "Row must contain date,max,min"},
+		{Row: 2, Payload: model.WeightPayload{Date: 1656720000000000000, Max: 3, Min: 1}},
+	}
+	report := model.ImportReport{Inserted: 1, Invalid: 1}
+	usecase.On("ImportMany", mock.Anything, expectedRows, false).Return(response.NewSuccessResponse(report, response.StatOK, "success"))
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("file", "weight.csv")
+	part.Write([]byte("2022-07-01,3\n2022-07-02,3,1\n"))
+	writer.Close()
+
+	r := httptest.NewRequest(http.MethodPost, "/just/for/testing", body)
+	r.Header.Set("Content-Type", writer.FormDataContentType())
+
+	recorder := httptest.NewRecorder()
+	http.HandlerFunc(hh.ImportWeight).ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	usecase.AssertExpectations(t)
+}
+
+func TestHttpHandler_ImportWeight_Error_NoFile(t *testing.T) {
+	usecase := new(mocks.Usecase)
+
+	hh := weight.HTTPHandler{
+		Logger:       logrus.New(),
+		Validate:     vld,
+		Usecase:      usecase,
+		TemplatePath: "./template/",
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/just/for/testing", nil)
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.ImportWeight)
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	assert.Contains(t, recorder.Body.String(), "File is required")
+	usecase.AssertExpectations(t)
+}
+
+func exportHandler(usecase *mocks.Usecase) weight.HTTPHandler {
+	return weight.HTTPHandler{
+		Logger:       logrus.New(),
+		Validate:     vld,
+		Usecase:      usecase,
+		TemplatePath: "./template/",
+	}
+}
+
+func mockExportMany(usecase *mocks.Usecase, rows ...model.WeightExportResponse) {
+	successResponse := response.NewSuccessResponse(nil, response.StatOK, "success")
+	usecase.On("ExportMany", mock.Anything, mock.Anything, mock.Anything).Return(successResponse).Run(func(args mock.Arguments) {
+		fn := args.Get(2).(func(model.WeightExportResponse) error)
+		for _, row := range rows {
+			fn(row)
+		}
+	})
+}
+
+func TestHttpHandler_ExportWeight_Success_CSV(t *testing.T) {
+	usecase := new(mocks.Usecase)
+	hh := exportHandler(usecase)
+	mockExportMany(usecase, model.WeightExportResponse{Date: 1656633600000000000, DateString: "2022-07-01", Max: 3, Min: 1, Diff: 2})
+
+	r := httptest.NewRequest(http.MethodGet, "/just/for/testing?format=csv", nil)
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.ExportWeight)
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	assert.Equal(t, "text/csv", recorder.Header().Get("Content-Type"))
+	assert.Contains(t, recorder.Header().Get("Content-Disposition"), "weight.csv")
+	assert.Equal(t, "date,dateString,max,min,diff\n1656633600000000000,2022-07-01,3,1,2\n", recorder.Body.String())
+	usecase.AssertExpectations(t)
+}
+
+func TestHttpHandler_ExportWeight_Success_JSON(t *testing.T) {
+	usecase := new(mocks.Usecase)
+	hh := exportHandler(usecase)
+	mockExportMany(usecase,
+		model.WeightExportResponse{Date: 1656633600000000000, DateString: "2022-07-01", Max: 3, Min: 1, Diff: 2},
+		model.WeightExportResponse{Date: 1656720000000000000, DateString: "2022-07-02", Max: 4, Min: 1, Diff: 3},
+	)
+
+	r := httptest.NewRequest(http.MethodGet, "/just/for/testing?format=json", nil)
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.ExportWeight)
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+
+	var result []model.WeightExportResponse
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &result))
+	assert.Equal(t, 2, len(result))
+	assert.Equal(t, "2022-07-02", result[1].DateString)
+	usecase.AssertExpectations(t)
+}
+
+func TestHttpHandler_ExportWeight_Success_JSON_Empty(t *testing.T) {
+	usecase := new(mocks.Usecase)
+	hh := exportHandler(usecase)
+	mockExportMany(usecase)
+
+	r := httptest.NewRequest(http.MethodGet, "/just/for/testing?format=json", nil)
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.ExportWeight)
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	assert.Equal(t, "[]\n", recorder.Body.String())
+	usecase.AssertExpectations(t)
+}
+
+func TestHttpHandler_ExportWeight_Success_XLSX(t *testing.T) {
+	usecase := new(mocks.Usecase)
+	hh := exportHandler(usecase)
+	mockExportMany(usecase, model.WeightExportResponse{Date: 1656633600000000000, DateString: "2022-07-01", Max: 3, Min: 1, Diff: 2})
+
+	r := httptest.NewRequest(http.MethodGet, "/just/for/testing?format=xlsx", nil)
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.ExportWeight)
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, recorder.Code, http.StatusOK)
+
+	body := recorder.Body.Bytes()
+	zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	assert.NoError(t, err, "should be a valid zip")
+
+	var sheet string
+	for _, f := range zr.File {
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			rc, _ := f.Open()
+			b, _ := io.ReadAll(rc)
+			rc.Close()
+			sheet = string(b)
+		}
+	}
+	assert.Contains(t, sheet, "<t>2022-07-01</t>")
+	assert.Contains(t, sheet, "<v>1656633600000000000</v>")
+	usecase.AssertExpectations(t)
+}
+
+func TestHttpHandler_ExportWeight_Error_InvalidFormat(t *testing.T) {
+	usecase := new(mocks.Usecase)
+	hh := exportHandler(usecase)
+
+	r := httptest.NewRequest(http.MethodGet, "/just/for/testing?format=pdf", nil)
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.ExportWeight)
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, recorder.Code, http.StatusBadRequest)
+	usecase.AssertExpectations(t)
+}
+
+func TestHttpHandler_ExportWeight_Error_Unexpected(t *testing.T) {
+	usecase := new(mocks.Usecase)
+	hh := exportHandler(usecase)
+	errorResponse := response.NewErrorResponse(exception.ErrInternalServer, http.StatusInternalServerError, nil, response.StatUnexpectedError, "error")
+	usecase.On("ExportMany", mock.Anything, mock.Anything, mock.Anything).Return(errorResponse)
+
+	r := httptest.NewRequest(http.MethodGet, "/just/for/testing", nil)
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.ExportWeight)
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, recorder.Code, http.StatusInternalServerError)
+	usecase.AssertExpectations(t)
+}
+
+func TestHttpHandler_ExportWeight_Error_AfterFirstRow(t *testing.T) {
+	usecase := new(mocks.Usecase)
+	hh := exportHandler(usecase)
+	errorResponse := response.NewErrorResponse(exception.ErrInternalServer, http.StatusInternalServerError, nil, response.StatUnexpectedError, "error")
+	usecase.On("ExportMany", mock.Anything, mock.Anything, mock.Anything).Return(errorResponse).Run(func(args mock.Arguments) {
+		fn := args.Get(2).(func(model.WeightExportResponse) error)
+		fn(model.WeightExportResponse{Date: 1656633600000000000, DateString: "2022-07-01", Max: 3, Min: 1, Diff: 2})
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/just/for/testing?format=json", nil)
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.ExportWeight)
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		handler.ServeHTTP(recorder, r)
+	}, "should abort the response")
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "2022-07-01")
+	var result []model.WeightExportResponse
+	assert.Error(t, json.Unmarshal(recorder.Body.Bytes(), &result), "should not be a complete file")
+	usecase.AssertExpectations(t)
+}
+
+func TestHttpHandler_History_Success(t *testing.T) {
+	usecase := new(mocks.Usecase)
+
+	hh := weight.HTTPHandler{
+		Logger:       logrus.New(),
+		Validate:     vld,
+		Usecase:      usecase,
+		TemplatePath: "./template/",
+	}
+
+	revisions := []model.WeightRevisionResponse{
+		{ID: "abc", Action: "UPDATE", CreatedAt: "2022-07-01T10:00:00Z", Before: &model.WeighDetailResponse{Max: 2, Min: 1}, After: &model.WeighDetailResponse{Max: 3, Min: 1}},
+		{ID: "def", Action: "DELETE", CreatedAt: "2022-07-01T11:00:00Z", Before: &model.WeighDetailResponse{Max: 3, Min: 1}},
+	}
+	successResponse := response.NewSuccessResponse(revisions, response.StatOK, "success")
+	usecase.On("History", mock.Anything, int64(1656633600000000000)).Return(successResponse)
+	r := httptest.NewRequest(http.MethodGet, "/just/for/testing", nil)
+	r = mux.SetURLVars(r, map[string]string{"date": "2022-07-01"})
+
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.History)
+
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "/weight/2022-07-01/history/abc/restore")
+	assert.Contains(t, recorder.Body.String(), "DELETE")
+	usecase.AssertExpectations(t)
+}
+
+func TestHttpHandler_History_Success_Escaped(t *testing.T) {
+	usecase := new(mocks.Usecase)
+
+	hh := weight.HTTPHandler{
+		Logger:       logrus.New(),
+		Validate:     vld,
+		Usecase:      usecase,
+		TemplatePath: "./template/",
+	}
+
+	revisions := []model.WeightRevisionResponse{
+		{ID: "abc", Action: "INSERT", Actor: "<b>john</b>", RequestID: "<script>alert(1)</script>", After: &model.WeighDetailResponse{Max: 3, Min: 1}},
+	}
+	usecase.On("History", mock.Anything, int64(1656633600000000000)).Return(response.NewSuccessResponse(revisions, response.StatOK, "success"))
+	r := httptest.NewRequest(http.MethodGet, "/just/for/testing", nil)
+	r = mux.SetURLVars(r, map[string]string{"date": "2022-07-01"})
+
+	recorder := httptest.NewRecorder()
+	http.HandlerFunc(hh.History).ServeHTTP(recorder, r)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.NotContains(t, recorder.Body.String(), "<script>alert(1)", "should escape the stored request id")
+	assert.Contains(t, recorder.Body.String(), "&lt;script&gt;alert(1)&lt;/script&gt;")
+	assert.Contains(t, recorder.Body.String(), "&lt;b&gt;john&lt;/b&gt;")
+}
+
+func TestHttpHandler_RestoreWeight_Success(t *testing.T) {
+	usecase := new(mocks.Usecase)
+
+	hh := weight.HTTPHandler{
+		Logger:       logrus.New(),
+		Validate:     vld,
+		Usecase:      usecase,
+		TemplatePath: "./template/",
+	}
+
+	successResponse := response.NewSuccessResponse(nil, response.StatOK, "success")
+	usecase.On("Restore", mock.Anything, int64(1656633600000000000), "abc").Return(successResponse)
+	r := httptest.NewRequest(http.MethodPost, "/just/for/testing", nil)
+	r = mux.SetURLVars(r, map[string]string{"date": "2022-07-01", "revision": "abc"})
+
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.RestoreWeight)
+
+	handler.ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusSeeOther, recorder.Code)
+	assert.Equal(t, "/weight/2022-07-01/history", recorder.Header().Get("Location"))
+	usecase.AssertExpectations(t)
+}
+
+func TestHttpHandler_RestoreWeight_Error_NotFound(t *testing.T) {
+	usecase := new(mocks.Usecase)
+
+	hh := weight.HTTPHandler{
+		Logger:       logrus.New(),
+		Validate:     vld,
+		Usecase:      usecase,
+		TemplatePath: "./template/",
+	}
+
+	errorResponse := response.NewErrorResponse(exception.ErrNotFound, http.StatusNotFound, nil, response.StatNotFound, "Revision not found")
+	usecase.On("Restore", mock.Anything, mock.Anything, "abc").Return(errorResponse)
+	usecase.On("History", mock.Anything, mock.Anything).Return(response.NewSuccessResponse(nil, response.StatOK, "success"))
+	r := httptest.NewRequest(http.MethodPost, "/just/for/testing", nil)
+	r = mux.SetURLVars(r, map[string]string{"date": "2022-07-01", "revision": "abc"})
+
+	recorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(hh.RestoreWeight)
+
+	handler.ServeHTTP(recorder, r)
+	assert.Contains(t, recorder.Body.String(), "Revision not found")
+	usecase.AssertExpectations(t)
+}
+
+func TestHttpHandler_Stream_Success(t *testing.T) {
+	broadcaster := weight.NewBroadcaster(0)
+	hh := weight.HTTPHandler{
+		Logger:      logrus.New(),
+		Broadcaster: broadcaster,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hh.Stream(w, r.WithContext(requestctx.WithOwner(r.Context(), "john")))
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "retry: 2000\n", line, "should tell the client when to reconnect")
+	reader.ReadString('\n')
+
+	require.NoError(t, broadcaster.Notify(context.TODO(), "weight.created", "jane", &model.WeighDetailResponse{Max: 1}))
+	require.NoError(t, broadcaster.Notify(context.TODO(), "weight.created", "john", &model.WeighDetailResponse{Max: 3}))
+
+	line, err = reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "event: weight.created\n", line, "should only stream the events of the user")
+	line, err = reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Contains(t, line, `"max":3`)
+
+	require.NoError(t, broadcaster.Close(context.TODO()))
+	reader.ReadString('\n')
+	_, err = reader.ReadString('\n')
+	assert.Equal(t, io.EOF, err, "should end the stream when the broadcaster is closed")
+}
+
+func TestHttpHandler_Stream_Error_Closed(t *testing.T) {
+	broadcaster := weight.NewBroadcaster(0)
+	require.NoError(t, broadcaster.Close(context.TODO()))
+	hh := weight.HTTPHandler{
+		Logger:      logrus.New(),
+		Broadcaster: broadcaster,
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/weight/stream", nil)
+	recorder := httptest.NewRecorder()
+	http.HandlerFunc(hh.Stream).ServeHTTP(recorder, r)
+
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+}
@@ -0,0 +1,77 @@
+package weight
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ijalalfrz/sirclo-weight-test/exception"
+	"github.com/ijalalfrz/sirclo-weight-test/requestctx"
+	"github.com/ijalalfrz/sirclo-weight-test/response"
+)
+
+// collection of stream timing, a stream ends before the write timeout of the server and the client reconnects after retry.
+const (
+	streamHeartbeat = time.Second * 15
+	streamLifetime  = time.Second * 45
+	streamRetry     = time.Second * 2
+)
+
+// collection of stream message
+const (
+	streamUnsupportedErrMessage = "Streaming is not supported"
+	streamClosedErrMessage      = "Stream is closed, try again later"
+)
+
+// Stream sends the weight events of the user as server-sent events, the event name is the event type
+// and the data is the weight as it is stored. A comment is sent every heartbeat to keep the connection open.
+func (handler HTTPHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		response.JSON(w, response.NewErrorResponse(exception.ErrInternalServer, http.StatusInternalServerError, nil, response.StatUnexpectedError, streamUnsupportedErrMessage))
+		return
+	}
+
+	events, cancel, err := handler.Broadcaster.Subscribe(requestctx.Owner(r.Context()))
+	if err != nil {
+		response.JSON(w, response.NewErrorResponse(exception.ErrServiceUnavailable, http.StatusServiceUnavailable, nil, response.StatUnavailable, streamClosedErrMessage))
+		return
+	}
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetry/time.Millisecond)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	lifetime := time.NewTimer(streamLifetime)
+	defer lifetime.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-lifetime.C:
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(event.Data)
+			if err != nil {
+				requestctx.Logger(r.Context(), handler.Logger).Error(err)
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,308 @@
+package weight
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ijalalfrz/sirclo-weight-test/migration"
+	"github.com/ijalalfrz/sirclo-weight-test/mongodb"
+	"github.com/ijalalfrz/sirclo-weight-test/outbox"
+	"github.com/ijalalfrz/sirclo-weight-test/webhook"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	collectionName            = "weight"
+	quarantineCollectionName  = "weight_quarantine"
+	historyCollectionName     = "weight_history"
+	ownerDateIndexName        = "owner_1_date_1"
+	historyOwnerDateIndexName = "owner_1_date_1_createdAt_-1"
+	outboxCreatedAtIndexName  = "createdAt_1__id_1"
+	outboxRetryIndexName      = "nextAttemptAt_1"
+	webhookOwnerIndexName     = "owner_1_createdAt_1"
+	deliveryDueIndexName      = "status_1_nextAttemptAt_1"
+	deliveryLogIndexName      = "owner_1_subscriptionId_1_createdAt_-1"
+)
+
+// collection of mongodb error code of an unknown option
+const (
+	invalidOptionsCode = 72
+	unknownFieldCode   = 40415
+)
+
+const (
+	preImagesUnsupportedMessage = "Pre-images of " + collectionName + " are not supported before MongoDB 6.0, the change stream does not see the deletes: %v"
+	calendarDateConflictMessage = "Weight %v resolves to %s which already exists, it is moved to " + quarantineCollectionName
+	calendarDateConflictReason  = "calendar date already exists"
+	invalidDateMessage          = "Weight %v has no unix nano date, it is moved to " + quarantineCollectionName
+	invalidDateReason           = "invalid date"
+	duplicateMessage            = "Weight %v has the same owner and date as weight %v, it is moved to " + quarantineCollectionName
+	duplicateReason             = "duplicate owner and date"
+)
+
+// Migrations returns the schema migrations of weight collection.
+func Migrations(logger *logrus.Logger) []migration.Migration {
+	return []migration.Migration{
+		{
+			Version:     1,
+			Description: "create unique index on owner and date of weight",
+			Up: func(ctx context.Context, db mongodb.Database) (err error) {
+				if err = quarantineDuplicates(ctx, logger, db); err != nil {
+					return
+				}
+
+				// documents without owner are indexed as null owner, so the date of anonymous weights is unique too.
+				_, err = db.Collection(collectionName).Indexes().CreateOne(ctx, mongo.IndexModel{
+					Keys:    bson.D{{Key: "owner", Value: 1}, {Key: "date", Value: 1}},
+					Options: options.Index().SetName(ownerDateIndexName).SetUnique(true),
+				})
+				return
+			},
+		},
+		{
+			Version:     2,
+			Description: "store canonical calendar date of weight",
+			Up: func(ctx context.Context, db mongodb.Database) (err error) {
+				return migrateCalendarDate(ctx, logger, db)
+			},
+		},
+		{
+			Version:     3,
+			Description: "create index on owner, date and creation time of weight history",
+			Up: func(ctx context.Context, db mongodb.Database) (err error) {
+				_, err = db.Collection(historyCollectionName).Indexes().CreateOne(ctx, mongo.IndexModel{
+					Keys:    bson.D{{Key: "owner", Value: 1}, {Key: "date", Value: 1}, {Key: "createdAt", Value: -1}},
+					Options: options.Index().SetName(historyOwnerDateIndexName),
+				})
+				return
+			},
+		},
+		{
+			Version:     4,
+			Description: "set initial version of weight",
+			Up: func(ctx context.Context, db mongodb.Database) (err error) {
+				_, err = db.Collection(collectionName).UpdateMany(ctx,
+					bson.M{"version": bson.M{"$exists": false}},
+					bson.M{"$set": bson.M{"version": 1}},
+				)
+				return
+			},
+		},
+		{
+			Version:     5,
+			Description: "create index on creation time of outbox",
+			Up: func(ctx context.Context, db mongodb.Database) (err error) {
+				_, err = db.Collection(outbox.CollectionName).Indexes().CreateOne(ctx, mongo.IndexModel{
+					Keys:    bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}},
+					Options: options.Index().SetName(outboxCreatedAtIndexName),
+				})
+				return
+			},
+		},
+		{
+			Version:     6,
+			Description: "create indexes of webhook subscriptions and deliveries",
+			Up: func(ctx context.Context, db mongodb.Database) (err error) {
+				_, err = db.Collection(webhook.CollectionName).Indexes().CreateOne(ctx, mongo.IndexModel{
+					Keys:    bson.D{{Key: "owner", Value: 1}, {Key: "createdAt", Value: 1}},
+					Options: options.Index().SetName(webhookOwnerIndexName),
+				})
+				if err != nil {
+					return
+				}
+
+				_, err = db.Collection(webhook.DeliveryCollectionName).Indexes().CreateMany(ctx, []mongo.IndexModel{
+					{
+						Keys:    bson.D{{Key: "status", Value: 1}, {Key: "nextAttemptAt", Value: 1}},
+						Options: options.Index().SetName(deliveryDueIndexName),
+					},
+					{
+						Keys:    bson.D{{Key: "owner", Value: 1}, {Key: "subscriptionId", Value: 1}, {Key: "createdAt", Value: -1}},
+						Options: options.Index().SetName(deliveryLogIndexName),
+					},
+				})
+				return
+			},
+		},
+		{
+			Version:     7,
+			Description: "create index on next attempt time of outbox",
+			Up: func(ctx context.Context, db mongodb.Database) (err error) {
+				_, err = db.Collection(outbox.CollectionName).Indexes().CreateOne(ctx, mongo.IndexModel{
+					Keys:    bson.D{{Key: "nextAttemptAt", Value: 1}},
+					Options: options.Index().SetName(outboxRetryIndexName),
+				})
+				return
+			},
+		},
+		{
+			Version:     8,
+			Description: "record pre-images of weight for the change stream",
+			Up: func(ctx context.Context, db mongodb.Database) (err error) {
+				// a delete event only carries the id, the pre-image tells the change stream whose weight is deleted.
+				err = db.RunCommand(ctx, bson.D{
+					{Key: "collMod", Value: collectionName},
+					{Key: "changeStreamPreAndPostImages", Value: bson.M{"enabled": true}},
+				}).Err()
+				if cmdErr, ok := err.(mongo.CommandError); ok && (cmdErr.Code == invalidOptionsCode || cmdErr.Code == unknownFieldCode) {
+					logger.Warn(fmt.Sprintf(preImagesUnsupportedMessage, err))
+					err = nil
+				}
+				return
+			},
+		},
+	}
+}
+
+// quarantineDuplicates keeps one weight of every owner and date, the one with the highest version and then
+// the latest one, and moves the others to the quarantine collection, as the unique index can not be built
+// over them. Nothing is dropped, the operator can compare them and restore the right one.
+func quarantineDuplicates(ctx context.Context, logger *logrus.Logger, db mongodb.Database) (err error) {
+	col := db.Collection(collectionName)
+	cursor, err := col.Aggregate(ctx, []bson.M{
+		{"$sort": bson.D{{Key: "version", Value: -1}, {Key: "_id", Value: -1}}},
+		// a missing owner is indexed as null, so it is grouped as null too.
+		{"$group": bson.M{
+			"_id":   bson.M{"owner": bson.M{"$ifNull": bson.A{"$owner", nil}}, "date": "$date"},
+			"ids":   bson.M{"$push": "$_id"},
+			"count": bson.M{"$sum": 1},
+		}},
+		{"$match": bson.M{"count": bson.M{"$gt": 1}}},
+	}, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		duplicate := bson.M{}
+		if err = cursor.Decode(&duplicate); err != nil {
+			return
+		}
+
+		ids, _ := duplicate["ids"].(bson.A)
+		for _, id := range ids[1:] {
+			document := bson.M{}
+			if err = col.FindOne(ctx, bson.M{"_id": id}).Decode(&document); err != nil {
+				return fmt.Errorf("read duplicate weight %v: %w", id, err)
+			}
+			if err = quarantine(ctx, db, document, duplicateReason); err != nil {
+				return fmt.Errorf("quarantine duplicate weight %v: %w", id, err)
+			}
+			logger.Warn(fmt.Sprintf(duplicateMessage, id, ids[0]))
+		}
+	}
+
+	return
+}
+
+// quarantine moves the weight document to the quarantine collection along with the reason it was moved.
+// It can be run again for the same document, which is only removed from the weights once it is kept.
+func quarantine(ctx context.Context, db mongodb.Database, document bson.M, reason string) (err error) {
+	document["quarantineReason"] = reason
+	document["quarantinedAt"] = time.Now().UnixNano()
+	if _, err = db.Collection(quarantineCollectionName).InsertOne(ctx, document); err != nil && !mongo.IsDuplicateKeyError(err) {
+		return
+	}
+
+	_, err = db.Collection(collectionName).DeleteOne(ctx, bson.M{"_id": document["_id"]})
+	return
+}
+
+// migrateCalendarDate rewrites the unix nano date of every weight without day to the canonical calendar date.
+// The form stored the date it was given as a UTC midnight, so a legacy date is resolved in UTC whatever the
+// timezone of the service. A weight without unix nano date, or whose calendar date is already taken by another
+// weight of the same owner, is moved to the quarantine collection, so no data is lost and every weight left in
+// the collection has a calendar date.
+func migrateCalendarDate(ctx context.Context, logger *logrus.Logger, db mongodb.Database) (err error) {
+	col := db.Collection(collectionName)
+	cursor, err := col.Find(ctx, bson.M{"day": bson.M{"$exists": false}})
+	if err != nil {
+		return
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		legacy := bson.M{}
+		if err = cursor.Decode(&legacy); err != nil {
+			return
+		}
+
+		timestamp, ok := legacy["date"].(int64)
+		if !ok {
+			if err = quarantine(ctx, db, legacy, invalidDateReason); err != nil {
+				return fmt.Errorf("quarantine weight %v: %w", legacy["_id"], err)
+			}
+			logger.Warn(fmt.Sprintf(invalidDateMessage, legacy["_id"]))
+			continue
+		}
+
+		date := canonicalDate(timestamp, time.UTC)
+		update := bson.M{
+			"$set": bson.M{"date": date, "day": formatDate(date)},
+		}
+		if _, err = col.UpdateOne(ctx, bson.M{"_id": legacy["_id"]}, update); err != nil {
+			if !mongo.IsDuplicateKeyError(err) {
+				return fmt.Errorf("migrate weight %v: %w", legacy["_id"], err)
+			}
+			if err = quarantine(ctx, db, legacy, calendarDateConflictReason); err != nil {
+				return fmt.Errorf("quarantine weight %v: %w", legacy["_id"], err)
+			}
+			logger.Warn(fmt.Sprintf(calendarDateConflictMessage, legacy["_id"], formatDate(date)))
+		}
+	}
+
+	return
+}
+
+// SQLMigrations returns the schema migrations of weight table for the sql drivers.
+// The unique constraint on owner and date also serves the date range queries of an owner.
+func SQLMigrations() []migration.SQLMigration {
+	return []migration.SQLMigration{
+		{
+			Version:     1,
+			Description: "create weight table with unique owner and date",
+			Statements: []string{`CREATE TABLE weight (
+	owner TEXT NOT NULL DEFAULT '',
+	date BIGINT NOT NULL,
+	day TEXT NOT NULL,
+	max INTEGER NOT NULL,
+	min INTEGER NOT NULL,
+	diff INTEGER NOT NULL,
+	version BIGINT NOT NULL DEFAULT 1,
+	CONSTRAINT weight_owner_date_key UNIQUE (owner, date)
+)`},
+		},
+		{
+			Version:     2,
+			Description: "create weight_history table",
+			Statements: []string{`CREATE TABLE weight_history (
+	id TEXT PRIMARY KEY,
+	owner TEXT NOT NULL DEFAULT '',
+	date BIGINT NOT NULL,
+	action TEXT NOT NULL,
+	before_weight TEXT,
+	after_weight TEXT,
+	actor TEXT NOT NULL DEFAULT '',
+	request_id TEXT NOT NULL DEFAULT '',
+	created_at BIGINT NOT NULL
+)`,
+				"CREATE INDEX weight_history_owner_date_created_at_idx ON weight_history (owner, date, created_at DESC)",
+			},
+		},
+		{
+			Version:     3,
+			Description: "create outbox table",
+			Statements:  outbox.SQLStatements(),
+		},
+		{
+			Version:     4,
+			Description: "create index on next attempt time of outbox",
+			Statements:  []string{"CREATE INDEX " + outbox.TableName + "_next_attempt_at_idx ON " + outbox.TableName + " (next_attempt_at)"},
+		},
+	}
+}
@@ -7,6 +7,8 @@ import (
 
 	entity "github.com/ijalalfrz/sirclo-weight-test/entity"
 	mock "github.com/stretchr/testify/mock"
+
+	model "github.com/ijalalfrz/sirclo-weight-test/model"
 )
 
 // Repository is an autogenerated mock type for the Repository type
@@ -14,13 +16,106 @@ type Repository struct {
 	mock.Mock
 }
 
-// FindMany provides a mock function with given fields: ctx, sortBy, sort
-func (_m *Repository) FindMany(ctx context.Context, sortBy string, sort int) ([]entity.Weight, error) {
-	ret := _m.Called(ctx, sortBy, sort)
+// Averages provides a mock function with given fields: ctx, filter
+func (_m *Repository) Averages(ctx context.Context, filter model.WeightFilter) (entity.WeightAverage, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 entity.WeightAverage
+	if rf, ok := ret.Get(0).(func(context.Context, model.WeightFilter) entity.WeightAverage); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		r0 = ret.Get(0).(entity.WeightAverage)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, model.WeightFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// BulkUpsert provides a mock function with given fields: ctx, owner, bunchOfWeight, overwrite
+func (_m *Repository) BulkUpsert(ctx context.Context, owner string, bunchOfWeight []entity.Weight, overwrite bool) ([]bool, error) {
+	ret := _m.Called(ctx, owner, bunchOfWeight, overwrite)
+
+	var r0 []bool
+	if rf, ok := ret.Get(0).(func(context.Context, string, []entity.Weight, bool) []bool); ok {
+		r0 = rf(ctx, owner, bunchOfWeight, overwrite)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]bool)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, []entity.Weight, bool) error); ok {
+		r1 = rf(ctx, owner, bunchOfWeight, overwrite)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CountMany provides a mock function with given fields: ctx, filter
+func (_m *Repository) CountMany(ctx context.Context, filter model.WeightFilter) (int64, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, model.WeightFilter) int64); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, model.WeightFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteOne provides a mock function with given fields: ctx, owner, key
+func (_m *Repository) DeleteOne(ctx context.Context, owner string, key int64) error {
+	ret := _m.Called(ctx, owner, key)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) error); ok {
+		r0 = rf(ctx, owner, key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FindEach provides a mock function with given fields: ctx, filter, fn
+func (_m *Repository) FindEach(ctx context.Context, filter model.WeightFilter, fn func(entity.Weight) error) error {
+	ret := _m.Called(ctx, filter, fn)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.WeightFilter, func(entity.Weight) error) error); ok {
+		r0 = rf(ctx, filter, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FindMany provides a mock function with given fields: ctx, filter
+func (_m *Repository) FindMany(ctx context.Context, filter model.WeightFilter) ([]entity.Weight, error) {
+	ret := _m.Called(ctx, filter)
 
 	var r0 []entity.Weight
-	if rf, ok := ret.Get(0).(func(context.Context, string, int) []entity.Weight); ok {
-		r0 = rf(ctx, sortBy, sort)
+	if rf, ok := ret.Get(0).(func(context.Context, model.WeightFilter) []entity.Weight); ok {
+		r0 = rf(ctx, filter)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]entity.Weight)
@@ -28,8 +123,8 @@ func (_m *Repository) FindMany(ctx context.Context, sortBy string, sort int) ([]
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, int) error); ok {
-		r1 = rf(ctx, sortBy, sort)
+	if rf, ok := ret.Get(1).(func(context.Context, model.WeightFilter) error); ok {
+		r1 = rf(ctx, filter)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -37,20 +132,20 @@ func (_m *Repository) FindMany(ctx context.Context, sortBy string, sort int) ([]
 	return r0, r1
 }
 
-// FindOne provides a mock function with given fields: ctx, key
-func (_m *Repository) FindOne(ctx context.Context, key int64) (entity.Weight, error) {
-	ret := _m.Called(ctx, key)
+// FindOne provides a mock function with given fields: ctx, owner, key
+func (_m *Repository) FindOne(ctx context.Context, owner string, key int64) (entity.Weight, error) {
+	ret := _m.Called(ctx, owner, key)
 
 	var r0 entity.Weight
-	if rf, ok := ret.Get(0).(func(context.Context, int64) entity.Weight); ok {
-		r0 = rf(ctx, key)
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) entity.Weight); ok {
+		r0 = rf(ctx, owner, key)
 	} else {
 		r0 = ret.Get(0).(entity.Weight)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
-		r1 = rf(ctx, key)
+	if rf, ok := ret.Get(1).(func(context.Context, string, int64) error); ok {
+		r1 = rf(ctx, owner, key)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -72,13 +167,36 @@ func (_m *Repository) InsertOne(ctx context.Context, _a1 entity.Weight) error {
 	return r0
 }
 
-// UpdateOne provides a mock function with given fields: ctx, key, _a2
-func (_m *Repository) UpdateOne(ctx context.Context, key int64, _a2 entity.Weight) error {
-	ret := _m.Called(ctx, key, _a2)
+// Statistics provides a mock function with given fields: ctx, filter
+func (_m *Repository) Statistics(ctx context.Context, filter model.StatisticFilter) ([]entity.WeightStatistic, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []entity.WeightStatistic
+	if rf, ok := ret.Get(0).(func(context.Context, model.StatisticFilter) []entity.WeightStatistic); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.WeightStatistic)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, model.StatisticFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateOne provides a mock function with given fields: ctx, owner, key, _a3
+func (_m *Repository) UpdateOne(ctx context.Context, owner string, key int64, _a3 entity.Weight) error {
+	ret := _m.Called(ctx, owner, key, _a3)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64, entity.Weight) error); ok {
-		r0 = rf(ctx, key, _a2)
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, entity.Weight) error); ok {
+		r0 = rf(ctx, owner, key, _a3)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -86,6 +204,27 @@ func (_m *Repository) UpdateOne(ctx context.Context, key int64, _a2 entity.Weigh
 	return r0
 }
 
+// UpsertOne provides a mock function with given fields: ctx, owner, key, _a3
+func (_m *Repository) UpsertOne(ctx context.Context, owner string, key int64, _a3 entity.Weight) (bool, error) {
+	ret := _m.Called(ctx, owner, key, _a3)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, entity.Weight) bool); ok {
+		r0 = rf(ctx, owner, key, _a3)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, int64, entity.Weight) error); ok {
+		r1 = rf(ctx, owner, key, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 type mockConstructorTestingTNewRepository interface {
 	mock.TestingT
 	Cleanup(func())
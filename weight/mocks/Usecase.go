@@ -16,13 +16,45 @@ type Usecase struct {
 	mock.Mock
 }
 
-// FindMany provides a mock function with given fields: ctx
-func (_m *Usecase) FindMany(ctx context.Context) response.Response {
-	ret := _m.Called(ctx)
+// DeleteOne provides a mock function with given fields: ctx, key
+func (_m *Usecase) DeleteOne(ctx context.Context, key int64) response.Response {
+	ret := _m.Called(ctx, key)
+
+	var r0 response.Response
+	if rf, ok := ret.Get(0).(func(context.Context, int64) response.Response); ok {
+		r0 = rf(ctx, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(response.Response)
+		}
+	}
+
+	return r0
+}
+
+// ExportMany provides a mock function with given fields: ctx, filter, fn
+func (_m *Usecase) ExportMany(ctx context.Context, filter model.WeightFilter, fn func(model.WeightExportResponse) error) response.Response {
+	ret := _m.Called(ctx, filter, fn)
 
 	var r0 response.Response
-	if rf, ok := ret.Get(0).(func(context.Context) response.Response); ok {
-		r0 = rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, model.WeightFilter, func(model.WeightExportResponse) error) response.Response); ok {
+		r0 = rf(ctx, filter, fn)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(response.Response)
+		}
+	}
+
+	return r0
+}
+
+// FindMany provides a mock function with given fields: ctx, filter
+func (_m *Usecase) FindMany(ctx context.Context, filter model.WeightFilter) response.Response {
+	ret := _m.Called(ctx, filter)
+
+	var r0 response.Response
+	if rf, ok := ret.Get(0).(func(context.Context, model.WeightFilter) response.Response); ok {
+		r0 = rf(ctx, filter)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(response.Response)
@@ -48,6 +80,38 @@ func (_m *Usecase) FindOne(ctx context.Context, key int64) response.Response {
 	return r0
 }
 
+// History provides a mock function with given fields: ctx, key
+func (_m *Usecase) History(ctx context.Context, key int64) response.Response {
+	ret := _m.Called(ctx, key)
+
+	var r0 response.Response
+	if rf, ok := ret.Get(0).(func(context.Context, int64) response.Response); ok {
+		r0 = rf(ctx, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(response.Response)
+		}
+	}
+
+	return r0
+}
+
+// ImportMany provides a mock function with given fields: ctx, rows, overwrite
+func (_m *Usecase) ImportMany(ctx context.Context, rows []model.WeightImportRow, overwrite bool) response.Response {
+	ret := _m.Called(ctx, rows, overwrite)
+
+	var r0 response.Response
+	if rf, ok := ret.Get(0).(func(context.Context, []model.WeightImportRow, bool) response.Response); ok {
+		r0 = rf(ctx, rows, overwrite)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(response.Response)
+		}
+	}
+
+	return r0
+}
+
 // InsertOne provides a mock function with given fields: ctx, payload
 func (_m *Usecase) InsertOne(ctx context.Context, payload model.WeightPayload) response.Response {
 	ret := _m.Called(ctx, payload)
@@ -64,6 +128,38 @@ func (_m *Usecase) InsertOne(ctx context.Context, payload model.WeightPayload) r
 	return r0
 }
 
+// Restore provides a mock function with given fields: ctx, key, revisionID
+func (_m *Usecase) Restore(ctx context.Context, key int64, revisionID string) response.Response {
+	ret := _m.Called(ctx, key, revisionID)
+
+	var r0 response.Response
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) response.Response); ok {
+		r0 = rf(ctx, key, revisionID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(response.Response)
+		}
+	}
+
+	return r0
+}
+
+// Statistics provides a mock function with given fields: ctx, filter
+func (_m *Usecase) Statistics(ctx context.Context, filter model.StatisticFilter) response.Response {
+	ret := _m.Called(ctx, filter)
+
+	var r0 response.Response
+	if rf, ok := ret.Get(0).(func(context.Context, model.StatisticFilter) response.Response); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(response.Response)
+		}
+	}
+
+	return r0
+}
+
 // UpdateOne provides a mock function with given fields: ctx, key, payload
 func (_m *Usecase) UpdateOne(ctx context.Context, key int64, payload model.WeightPayload) response.Response {
 	ret := _m.Called(ctx, key, payload)
@@ -80,6 +176,22 @@ func (_m *Usecase) UpdateOne(ctx context.Context, key int64, payload model.Weigh
 	return r0
 }
 
+// UpsertOne provides a mock function with given fields: ctx, key, payload
+func (_m *Usecase) UpsertOne(ctx context.Context, key int64, payload model.WeightPayload) response.Response {
+	ret := _m.Called(ctx, key, payload)
+
+	var r0 response.Response
+	if rf, ok := ret.Get(0).(func(context.Context, int64, model.WeightPayload) response.Response); ok {
+		r0 = rf(ctx, key, payload)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(response.Response)
+		}
+	}
+
+	return r0
+}
+
 type mockConstructorTestingTNewUsecase interface {
 	mock.TestingT
 	Cleanup(func())
@@ -2,8 +2,13 @@ package weight
 
 import "github.com/sirupsen/logrus"
 
+// UsecaseProperty is a property of Usecase.
+// RequireOwner rejects the writes of anonymous callers, it is set when authentication is enabled.
 type UsecaseProperty struct {
-	ServiceName string
-	Logger      *logrus.Logger
-	Repository  Repository
+	ServiceName  string
+	Logger       *logrus.Logger
+	Repository   Repository
+	History      HistoryRepository
+	Notifier     Notifier
+	RequireOwner bool
 }
@@ -2,10 +2,13 @@ package weight
 
 import (
 	"context"
+	"time"
 
 	"github.com/ijalalfrz/sirclo-weight-test/entity"
 	"github.com/ijalalfrz/sirclo-weight-test/exception"
+	"github.com/ijalalfrz/sirclo-weight-test/model"
 	"github.com/ijalalfrz/sirclo-weight-test/mongodb"
+	"github.com/ijalalfrz/sirclo-weight-test/requestctx"
 	"github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -15,11 +18,25 @@ import (
 // Repository is collection of behaviour weightRepository
 type Repository interface {
 	InsertOne(ctx context.Context, weight entity.Weight) (err error)
-	UpdateOne(ctx context.Context, key int64, weight entity.Weight) (err error)
-	FindMany(ctx context.Context, sortBy string, sort int) (bunchOfWeight []entity.Weight, err error)
-	FindOne(ctx context.Context, key int64) (weight entity.Weight, err error)
+	UpdateOne(ctx context.Context, owner string, key int64, weight entity.Weight) (err error)
+	UpsertOne(ctx context.Context, owner string, key int64, weight entity.Weight) (created bool, err error)
+	FindMany(ctx context.Context, filter model.WeightFilter) (bunchOfWeight []entity.Weight, err error)
+	CountMany(ctx context.Context, filter model.WeightFilter) (total int64, err error)
+	FindOne(ctx context.Context, owner string, key int64) (weight entity.Weight, err error)
+	DeleteOne(ctx context.Context, owner string, key int64) (err error)
+	Statistics(ctx context.Context, filter model.StatisticFilter) (bunchOfStatistic []entity.WeightStatistic, err error)
+	Averages(ctx context.Context, filter model.WeightFilter) (average entity.WeightAverage, err error)
+	BulkUpsert(ctx context.Context, owner string, bunchOfWeight []entity.Weight, overwrite bool) (inserted []bool, err error)
+	FindEach(ctx context.Context, filter model.WeightFilter, fn func(weight entity.Weight) error) (err error)
 }
 
+// collection of statistic grouping
+const (
+	groupByWeek  = "week"
+	groupByMonth = "month"
+	groupByYear  = "year"
+)
+
 type weightRepository struct {
 	logger *logrus.Logger
 	col    mongodb.Collection
@@ -27,51 +44,70 @@ type weightRepository struct {
 
 // NewWeightRepository is a constructor.
 func NewWeightRepository(logger *logrus.Logger, db mongodb.Database) Repository {
-	col := db.Collection("weight")
+	col := db.Collection(collectionName)
 	return &weightRepository{logger, col}
 }
 
 func (r weightRepository) InsertOne(ctx context.Context, weight entity.Weight) (err error) {
+	weight.Version = 1
 	_, err = r.col.InsertOne(ctx, weight)
 	if err != nil {
-		r.logger.Error(err)
-		err = exception.ErrInternalServer
+		r.log(ctx).Error(err)
+		err = r.writeError(err)
 		return
 	}
 	return
 }
-func (r weightRepository) UpdateOne(ctx context.Context, key int64, weight entity.Weight) (err error) {
-	filter := bson.M{
-		"date": key,
-	}
 
-	updatedData := map[string]interface{}{
-		"$set": weight,
-	}
+// UpdateOne updates the weight of the key. When the version of weight is set the update only applies
+// to that version, ErrConflict is returned when the weight has been changed since.
+func (r weightRepository) UpdateOne(ctx context.Context, owner string, key int64, weight entity.Weight) (err error) {
+	filter := r.versionFilter(owner, key, weight.Version)
 
-	updatedResult, err := r.col.UpdateOne(ctx, filter, updatedData, options.Update().SetUpsert(true))
+	updatedResult, err := r.col.UpdateOne(ctx, filter, r.versionUpdate(weight))
 	if err != nil {
-		r.logger.Error(err)
-		err = exception.ErrInternalServer
+		r.log(ctx).Error(err)
+		err = r.writeError(err)
 		return
 	}
 
 	if updatedResult.MatchedCount < 1 {
-		err = exception.ErrNotFound
+		err = r.unmatchedError(ctx, owner, key, weight.Version)
 		return
 	}
 
 	return
 }
-func (r weightRepository) FindMany(ctx context.Context, sortBy string, sort int) (bunchOfWeight []entity.Weight, err error) {
-	qSort := map[string]int{
-		sortBy: sort,
+
+// UpsertOne replaces the weight of the key or creates it when it does not exist yet.
+// When the version of weight is set it behaves as a conditional UpdateOne, as a weight of an expected version must exist.
+func (r weightRepository) UpsertOne(ctx context.Context, owner string, key int64, weight entity.Weight) (created bool, err error) {
+	if weight.Version > 0 {
+		err = r.UpdateOne(ctx, owner, key, weight)
+		return
 	}
-	opt := options.Find().SetSort(qSort)
-	filter := map[interface{}]interface{}{}
-	cursor, err := r.col.Find(ctx, filter, opt)
+
+	filter := r.versionFilter(owner, key, 0)
+
+	updatedResult, err := r.col.UpdateOne(ctx, filter, r.versionUpdate(weight), options.Update().SetUpsert(true))
 	if err != nil {
-		r.logger.Error(err)
+		r.log(ctx).Error(err)
+		err = r.writeError(err)
+		return
+	}
+
+	created = updatedResult.UpsertedCount > 0
+	return
+}
+func (r weightRepository) FindMany(ctx context.Context, filter model.WeightFilter) (bunchOfWeight []entity.Weight, err error) {
+	opt := options.Find().SetSort(r.buildSort(filter))
+	if filter.Limit > 0 {
+		opt.SetSkip((filter.Page - 1) * filter.Limit).SetLimit(filter.Limit)
+	}
+
+	cursor, err := r.col.Find(ctx, r.buildFilter(filter), opt)
+	if err != nil {
+		r.log(ctx).Error(err)
 		err = exception.ErrInternalServer
 		return
 	}
@@ -93,20 +129,310 @@ func (r weightRepository) FindMany(ctx context.Context, sortBy string, sort int)
 
 	return
 }
-func (r weightRepository) FindOne(ctx context.Context, key int64) (weight entity.Weight, err error) {
+
+// FindEach iterates the cursor of the matching weights and calls fn for each of them,
+// so the caller does not need to hold the whole result set in memory. Pagination of the filter is ignored.
+func (r weightRepository) FindEach(ctx context.Context, filter model.WeightFilter, fn func(weight entity.Weight) error) (err error) {
+	opt := options.Find().SetSort(r.buildSort(filter))
+
+	cursor, err := r.col.Find(ctx, r.buildFilter(filter), opt)
+	if err != nil {
+		r.log(ctx).Error(err)
+		err = exception.ErrInternalServer
+		return
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		weight := entity.Weight{}
+		if err = cursor.Decode(&weight); err != nil {
+			r.log(ctx).Error(err)
+			err = exception.ErrInternalServer
+			return
+		}
+
+		if err = fn(weight); err != nil {
+			return
+		}
+	}
+
+	return
+}
+func (r weightRepository) CountMany(ctx context.Context, filter model.WeightFilter) (total int64, err error) {
+	total, err = r.col.CountDocuments(ctx, r.buildFilter(filter))
+	if err != nil {
+		r.log(ctx).Error(err)
+		err = exception.ErrInternalServer
+		return
+	}
+
+	return
+}
+func (r weightRepository) FindOne(ctx context.Context, owner string, key int64) (weight entity.Weight, err error) {
 	filter := bson.M{
-		"date": key,
+		"owner": ownerFilter(owner),
+		"date":  key,
 	}
 
 	if err = r.col.FindOne(ctx, filter).Decode(&weight); err != nil {
 		if err != mongo.ErrNoDocuments {
-			r.logger.Error(err)
+			r.log(ctx).Error(err)
+			err = exception.ErrInternalServer
+			return
+		}
+		err = exception.ErrNotFound
+		return
+	}
+
+	return
+}
+func (r weightRepository) DeleteOne(ctx context.Context, owner string, key int64) (err error) {
+	filter := bson.M{
+		"owner": ownerFilter(owner),
+		"date":  key,
+	}
+
+	deletedResult, err := r.col.DeleteOne(ctx, filter)
+	if err != nil {
+		r.log(ctx).Error(err)
+		err = exception.ErrInternalServer
+		return
+	}
+
+	if deletedResult.DeletedCount < 1 {
+		err = exception.ErrNotFound
+		return
+	}
+
+	return
+}
+func (r weightRepository) Statistics(ctx context.Context, filter model.StatisticFilter) (bunchOfStatistic []entity.WeightStatistic, err error) {
+	match := r.buildFilter(model.WeightFilter{Owner: filter.Owner, From: filter.From, To: filter.To})
+
+	group := bson.M{
+		"_id":   r.statisticGroupID(filter.GroupBy),
+		"count": bson.M{"$sum": 1},
+	}
+	project := bson.M{
+		"count": 1,
+	}
+	for _, field := range []string{"max", "min", "diff"} {
+		group[field+"Min"] = bson.M{"$min": "$" + field}
+		group[field+"Max"] = bson.M{"$max": "$" + field}
+		group[field+"Mean"] = bson.M{"$avg": "$" + field}
+		group[field+"StdDev"] = bson.M{"$stdDevPop": "$" + field}
+		// $median is only available from MongoDB 7.0, so values are pushed and the median is computed by the caller.
+		group[field+"Values"] = bson.M{"$push": "$" + field}
+
+		project[field] = bson.M{
+			"min":    "$" + field + "Min",
+			"max":    "$" + field + "Max",
+			"mean":   "$" + field + "Mean",
+			"stdDev": "$" + field + "StdDev",
+			"values": "$" + field + "Values",
+		}
+	}
+
+	pipeline := []bson.M{
+		{"$match": match},
+		{"$group": group},
+		{"$project": project},
+		{"$sort": bson.D{{Key: "_id.year", Value: 1}, {Key: "_id.month", Value: 1}, {Key: "_id.week", Value: 1}}},
+	}
+
+	cursor, err := r.col.Aggregate(ctx, pipeline)
+	if err != nil {
+		r.log(ctx).Error(err)
+		err = exception.ErrInternalServer
+		return
+	}
+
+	for cursor.Next(ctx) {
+		statistic := entity.WeightStatistic{}
+		if err = cursor.Decode(&statistic); err != nil {
+			r.log(ctx).Error(err)
 			err = exception.ErrInternalServer
 			return
 		}
+
+		bunchOfStatistic = append(bunchOfStatistic, statistic)
+	}
+
+	if len(bunchOfStatistic) < 1 {
 		err = exception.ErrNotFound
 		return
 	}
 
 	return
 }
+
+// Averages averages the max, min and diff of the matching weights in one group, the averages are zero
+// when no weight matches. Pagination of the filter is ignored.
+func (r weightRepository) Averages(ctx context.Context, filter model.WeightFilter) (average entity.WeightAverage, err error) {
+	pipeline := []bson.M{
+		{"$match": r.buildFilter(filter)},
+		{"$group": bson.M{
+			"_id":  nil,
+			"max":  bson.M{"$avg": "$max"},
+			"min":  bson.M{"$avg": "$min"},
+			"diff": bson.M{"$avg": "$diff"},
+		}},
+	}
+
+	cursor, err := r.col.Aggregate(ctx, pipeline)
+	if err != nil {
+		r.log(ctx).Error(err)
+		err = exception.ErrInternalServer
+		return
+	}
+	defer cursor.Close(ctx)
+
+	if cursor.Next(ctx) {
+		if err = cursor.Decode(&average); err != nil {
+			r.log(ctx).Error(err)
+			err = exception.ErrInternalServer
+			return
+		}
+	}
+
+	return
+}
+
+// BulkUpsert writes weights in one ordered bulk write. Existing dates are overwritten when overwrite is true,
+// otherwise they are left untouched. The returned slice tells which weights were newly inserted.
+func (r weightRepository) BulkUpsert(ctx context.Context, owner string, bunchOfWeight []entity.Weight, overwrite bool) (inserted []bool, err error) {
+	models := make([]mongo.WriteModel, 0, len(bunchOfWeight))
+	for _, weight := range bunchOfWeight {
+		filter := bson.M{
+			"owner": ownerFilter(owner),
+			"date":  weight.Date,
+		}
+		weight.Version = 1
+		update := bson.M{"$setOnInsert": weight}
+		if overwrite {
+			update = r.versionUpdate(weight)
+		}
+		writeModel := mongo.NewUpdateOneModel().
+			SetFilter(filter).
+			SetUpdate(update).
+			SetUpsert(true)
+		models = append(models, writeModel)
+	}
+
+	result, err := r.col.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(true))
+	if err != nil {
+		r.log(ctx).Error(err)
+		err = r.writeError(err)
+		return
+	}
+
+	inserted = make([]bool, len(bunchOfWeight))
+	for index := range result.UpsertedIDs {
+		inserted[index] = true
+	}
+
+	return
+}
+
+func (r weightRepository) log(ctx context.Context) *logrus.Entry {
+	return requestctx.Logger(ctx, r.logger)
+}
+
+// writeError maps the error of a write, a duplicate key means the weight of the date already exists.
+func (r weightRepository) writeError(err error) error {
+	if mongo.IsDuplicateKeyError(err) {
+		return exception.ErrConflict
+	}
+	return exception.ErrInternalServer
+}
+
+// versionFilter matches the weight of the key, and of the expected version when it is set.
+func (r weightRepository) versionFilter(owner string, key int64, version int64) bson.M {
+	filter := bson.M{
+		"owner": ownerFilter(owner),
+		"date":  key,
+	}
+	if version > 0 {
+		filter["version"] = version
+	}
+	return filter
+}
+
+// versionUpdate sets the fields of weight and increments its version.
+func (r weightRepository) versionUpdate(weight entity.Weight) bson.M {
+	weight.Version = 0
+	return bson.M{
+		"$set": weight,
+		"$inc": bson.M{"version": 1},
+	}
+}
+
+// unmatchedError tells why a conditional update did not match, the weight is either missing or of another version.
+func (r weightRepository) unmatchedError(ctx context.Context, owner string, key int64, version int64) error {
+	if version < 1 {
+		return exception.ErrNotFound
+	}
+
+	total, err := r.col.CountDocuments(ctx, r.versionFilter(owner, key, 0))
+	if err != nil {
+		r.log(ctx).Error(err)
+		return exception.ErrInternalServer
+	}
+	if total > 0 {
+		return exception.ErrConflict
+	}
+	return exception.ErrNotFound
+}
+func (r weightRepository) statisticGroupID(groupBy string) interface{} {
+	// date is stored as unix nano, so it is converted to milliseconds before being casted as date.
+	date := bson.M{"$toDate": bson.M{"$divide": bson.A{"$date", int64(time.Millisecond)}}}
+
+	switch groupBy {
+	case groupByWeek:
+		return bson.M{"year": bson.M{"$isoWeekYear": date}, "week": bson.M{"$isoWeek": date}}
+	case groupByMonth:
+		return bson.M{"year": bson.M{"$year": date}, "month": bson.M{"$month": date}}
+	case groupByYear:
+		return bson.M{"year": bson.M{"$year": date}}
+	default:
+		return nil
+	}
+}
+
+// buildSort sorts by the field of the filter, weights with the same value are sorted by date,
+// so a page is stable like in the other drivers.
+func (r weightRepository) buildSort(filter model.WeightFilter) bson.D {
+	sort := bson.D{
+		{Key: filter.SortBy, Value: filter.Sort},
+	}
+	if filter.SortBy != "date" {
+		sort = append(sort, bson.E{Key: "date", Value: filter.Sort})
+	}
+	return sort
+}
+
+func (r weightRepository) buildFilter(filter model.WeightFilter) bson.M {
+	query := bson.M{
+		"owner": ownerFilter(filter.Owner),
+	}
+	dateRange := bson.M{}
+	if filter.From != 0 {
+		dateRange["$gte"] = filter.From
+	}
+	if filter.To != 0 {
+		dateRange["$lte"] = filter.To
+	}
+	if len(dateRange) > 0 {
+		query["date"] = dateRange
+	}
+	return query
+}
+
+// ownerFilter matches documents of the given owner. Anonymous requests only see documents without owner,
+// which are the ones written before weights were scoped to an owner.
+func ownerFilter(owner string) interface{} {
+	if owner == "" {
+		return nil
+	}
+	return owner
+}
@@ -0,0 +1,252 @@
+package weight
+
+import (
+	"context"
+	"time"
+
+	"github.com/ijalalfrz/sirclo-weight-test/entity"
+	"github.com/ijalalfrz/sirclo-weight-test/exception"
+	"github.com/ijalalfrz/sirclo-weight-test/model"
+	"github.com/ijalalfrz/sirclo-weight-test/outbox"
+	"github.com/ijalalfrz/sirclo-weight-test/requestctx"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// collection of revision action
+const (
+	revisionActionInsert  = "INSERT"
+	revisionActionUpdate  = "UPDATE"
+	revisionActionDelete  = "DELETE"
+	revisionActionRestore = "RESTORE"
+)
+
+// revisionAttempts bounds the attempts of a write whose before snapshot is changed by a concurrent write.
+const revisionAttempts = 3
+
+type revisionActionContextKey struct{}
+
+// contextWithRevisionAction returns a copy of ctx which records the next write as the given action,
+// so a restore is not recorded as a plain update.
+func contextWithRevisionAction(ctx context.Context, action string) context.Context {
+	return context.WithValue(ctx, revisionActionContextKey{}, action)
+}
+
+// auditRepository is a decorator of Repository which records a revision with the before and after snapshot
+// of every successful write, in the same transaction as the write. A write fails when its revision can not be recorded.
+// The before snapshot is read in the transaction and an update only applies to its version, so a weight changed
+// in between is read again instead of being recorded with a stale snapshot.
+// Without a transactor a failed revision does not roll back the write, which is only fit for the in-memory storages.
+type auditRepository struct {
+	next       Repository
+	history    HistoryRepository
+	transactor outbox.Transactor
+	logger     *logrus.Logger
+}
+
+// NewAuditRepository is a constructor. transactor must be the one of the storage of next and history, or nil.
+func NewAuditRepository(next Repository, history HistoryRepository, transactor outbox.Transactor, logger *logrus.Logger) Repository {
+	return auditRepository{
+		next:       next,
+		history:    history,
+		transactor: transactor,
+		logger:     logger,
+	}
+}
+
+func (r auditRepository) InsertOne(ctx context.Context, weight entity.Weight) (err error) {
+	return transaction(ctx, r.transactor, r.logger, func(ctx context.Context) (err error) {
+		if err = r.next.InsertOne(ctx, weight); err != nil {
+			return
+		}
+
+		weight.Version = 1
+		return r.record(ctx, revisionActionInsert, weight.Owner, weight.Date, nil, &weight)
+	})
+}
+
+func (r auditRepository) UpdateOne(ctx context.Context, owner string, key int64, weight entity.Weight) (err error) {
+	return r.retry(weight.Version, func() error {
+		return transaction(ctx, r.transactor, r.logger, func(ctx context.Context) (err error) {
+			before, err := r.next.FindOne(ctx, owner, key)
+			if err != nil {
+				return
+			}
+
+			after := weight
+			if after.Version < 1 {
+				after.Version = before.Version
+			}
+			if err = r.next.UpdateOne(ctx, owner, key, after); err != nil {
+				return
+			}
+
+			after.Version = before.Version + 1
+			return r.record(ctx, revisionActionUpdate, owner, key, &before, &after)
+		})
+	})
+}
+
+// UpsertOne inserts the weight when it does not exist yet, so a weight created in between fails with ErrConflict
+// and is updated by the next attempt.
+func (r auditRepository) UpsertOne(ctx context.Context, owner string, key int64, weight entity.Weight) (created bool, err error) {
+	err = r.retry(weight.Version, func() error {
+		created = false
+		return transaction(ctx, r.transactor, r.logger, func(ctx context.Context) (err error) {
+			before, err := r.next.FindOne(ctx, owner, key)
+			if err != nil && err != exception.ErrNotFound {
+				return
+			}
+
+			after := weight
+			if err == exception.ErrNotFound {
+				// a weight of an expected version must exist.
+				if weight.Version > 0 {
+					return
+				}
+
+				after.Owner, after.Date = owner, key
+				if err = r.next.InsertOne(ctx, after); err != nil {
+					return
+				}
+
+				created = true
+				after.Version = 1
+				return r.record(ctx, revisionActionInsert, owner, key, nil, &after)
+			}
+
+			if after.Version < 1 {
+				after.Version = before.Version
+			}
+			if _, err = r.next.UpsertOne(ctx, owner, key, after); err != nil {
+				return
+			}
+
+			after.Version = before.Version + 1
+			return r.record(ctx, revisionActionUpdate, owner, key, &before, &after)
+		})
+	})
+	return
+}
+
+func (r auditRepository) FindMany(ctx context.Context, filter model.WeightFilter) (bunchOfWeight []entity.Weight, err error) {
+	return r.next.FindMany(ctx, filter)
+}
+
+func (r auditRepository) CountMany(ctx context.Context, filter model.WeightFilter) (total int64, err error) {
+	return r.next.CountMany(ctx, filter)
+}
+
+func (r auditRepository) Averages(ctx context.Context, filter model.WeightFilter) (average entity.WeightAverage, err error) {
+	return r.next.Averages(ctx, filter)
+}
+
+func (r auditRepository) FindOne(ctx context.Context, owner string, key int64) (weight entity.Weight, err error) {
+	return r.next.FindOne(ctx, owner, key)
+}
+
+func (r auditRepository) DeleteOne(ctx context.Context, owner string, key int64) (err error) {
+	return transaction(ctx, r.transactor, r.logger, func(ctx context.Context) (err error) {
+		before, err := r.next.FindOne(ctx, owner, key)
+		if err != nil {
+			return
+		}
+
+		if err = r.next.DeleteOne(ctx, owner, key); err != nil {
+			return
+		}
+
+		return r.record(ctx, revisionActionDelete, owner, key, &before, nil)
+	})
+}
+
+func (r auditRepository) Statistics(ctx context.Context, filter model.StatisticFilter) (bunchOfStatistic []entity.WeightStatistic, err error) {
+	return r.next.Statistics(ctx, filter)
+}
+
+// BulkUpsert looks up the existing weights of the batch with a single range query,
+// so an import does not cost an extra query per row.
+func (r auditRepository) BulkUpsert(ctx context.Context, owner string, bunchOfWeight []entity.Weight, overwrite bool) (inserted []bool, err error) {
+	if len(bunchOfWeight) < 1 {
+		return r.next.BulkUpsert(ctx, owner, bunchOfWeight, overwrite)
+	}
+
+	err = transaction(ctx, r.transactor, r.logger, func(ctx context.Context) (err error) {
+		existing := make(map[int64]entity.Weight)
+		if overwrite {
+			filter := model.WeightFilter{Owner: owner, From: bunchOfWeight[0].Date, To: bunchOfWeight[0].Date, SortBy: defaultSortBy, Sort: 1}
+			for _, weight := range bunchOfWeight {
+				if weight.Date < filter.From {
+					filter.From = weight.Date
+				}
+				if weight.Date > filter.To {
+					filter.To = weight.Date
+				}
+			}
+
+			bunchOfExisting, errFind := r.next.FindMany(ctx, filter)
+			if errFind != nil && errFind != exception.ErrNotFound {
+				return errFind
+			}
+			for _, weight := range bunchOfExisting {
+				existing[weight.Date] = weight
+			}
+		}
+
+		if inserted, err = r.next.BulkUpsert(ctx, owner, bunchOfWeight, overwrite); err != nil {
+			return
+		}
+
+		for i := range bunchOfWeight {
+			after := bunchOfWeight[i]
+			switch {
+			case inserted[i]:
+				after.Version = 1
+				err = r.record(ctx, revisionActionInsert, owner, after.Date, nil, &after)
+			case overwrite:
+				before := existing[after.Date]
+				after.Version = before.Version + 1
+				err = r.record(ctx, revisionActionUpdate, owner, after.Date, &before, &after)
+			}
+			if err != nil {
+				return
+			}
+		}
+		return
+	})
+	return
+}
+
+func (r auditRepository) FindEach(ctx context.Context, filter model.WeightFilter, fn func(weight entity.Weight) error) (err error) {
+	return r.next.FindEach(ctx, filter, fn)
+}
+
+// retry calls write again while a concurrent write changes the before snapshot, which fails it with ErrConflict.
+// A caller who expects a version gets the conflict as is.
+func (r auditRepository) retry(version int64, write func() error) (err error) {
+	for attempt := 1; ; attempt++ {
+		err = write()
+		if err != exception.ErrConflict || version > 0 || attempt >= revisionAttempts {
+			return
+		}
+	}
+}
+
+func (r auditRepository) record(ctx context.Context, action, owner string, key int64, before, after *entity.Weight) (err error) {
+	if override, ok := ctx.Value(revisionActionContextKey{}).(string); ok {
+		action = override
+	}
+
+	revision := entity.WeightRevision{
+		ID:        primitive.NewObjectID().Hex(),
+		Owner:     owner,
+		Date:      key,
+		Action:    action,
+		Before:    before,
+		After:     after,
+		Actor:     requestctx.Owner(ctx),
+		RequestID: requestctx.RequestID(ctx),
+		CreatedAt: time.Now().UnixNano(),
+	}
+	return r.history.InsertOne(ctx, revision)
+}
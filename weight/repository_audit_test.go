@@ -0,0 +1,265 @@
+package weight_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/ijalalfrz/sirclo-weight-test/entity"
+	"github.com/ijalalfrz/sirclo-weight-test/exception"
+	"github.com/ijalalfrz/sirclo-weight-test/model"
+	"github.com/ijalalfrz/sirclo-weight-test/requestctx"
+	"github.com/ijalalfrz/sirclo-weight-test/sqldb"
+	"github.com/ijalalfrz/sirclo-weight-test/weight"
+	"github.com/ijalalfrz/sirclo-weight-test/weight/mocks"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func auditContext() context.Context {
+	ctx := requestctx.WithOwner(context.TODO(), "john")
+	return requestctx.WithRequestID(ctx, "abc-123")
+}
+
+func TestAuditRepository_InsertOne_Success(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	historyMock := new(mocks.HistoryRepository)
+	repo := weight.NewAuditRepository(repoMock, historyMock, nil, logrus.New())
+
+	after := entity.Weight{Owner: "john", Date: 1, Max: 3, Min: 1, Diff: 2}
+	repoMock.On("InsertOne", mock.Anything, after).Return(nil)
+	historyMock.On("InsertOne", mock.Anything, mock.MatchedBy(func(revision entity.WeightRevision) bool {
+		return revision.ID != "" && revision.Action == "INSERT" && revision.Before == nil && revision.After.Max == after.Max && revision.After.Version == 1 &&
+			revision.Owner == "john" && revision.Actor == "john" && revision.RequestID == "abc-123" && revision.CreatedAt > 0
+	})).Return(nil)
+
+	err := repo.InsertOne(auditContext(), after)
+	assert.NoError(t, err, "should be no error")
+	repoMock.AssertExpectations(t)
+	historyMock.AssertExpectations(t)
+}
+
+func TestAuditRepository_InsertOne_Error_NotRecorded(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	historyMock := new(mocks.HistoryRepository)
+	repo := weight.NewAuditRepository(repoMock, historyMock, nil, logrus.New())
+
+	repoMock.On("InsertOne", mock.Anything, mock.Anything).Return(exception.ErrConflict)
+
+	err := repo.InsertOne(auditContext(), entity.Weight{})
+	assert.Equal(t, exception.ErrConflict, err)
+	historyMock.AssertNotCalled(t, "InsertOne", mock.Anything, mock.Anything)
+}
+
+func TestAuditRepository_UpdateOne_Success(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	historyMock := new(mocks.HistoryRepository)
+	repo := weight.NewAuditRepository(repoMock, historyMock, nil, logrus.New())
+
+	before := entity.Weight{Owner: "john", Date: 1, Max: 3, Min: 1, Diff: 2, Version: 2}
+	after := entity.Weight{Owner: "john", Date: 1, Max: 5, Min: 1, Diff: 4, Version: 2}
+	repoMock.On("FindOne", mock.Anything, "john", int64(1)).Return(before, nil)
+	repoMock.On("UpdateOne", mock.Anything, "john", int64(1), after).Return(nil)
+	historyMock.On("InsertOne", mock.Anything, mock.MatchedBy(func(revision entity.WeightRevision) bool {
+		return revision.Action == "UPDATE" && *revision.Before == before && revision.After.Max == after.Max && revision.After.Version == 3
+	})).Return(nil)
+
+	err := repo.UpdateOne(auditContext(), "john", 1, after)
+	assert.NoError(t, err, "should be no error")
+	repoMock.AssertExpectations(t)
+	historyMock.AssertExpectations(t)
+}
+
+func TestAuditRepository_UpdateOne_Error_NotFound(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	historyMock := new(mocks.HistoryRepository)
+	repo := weight.NewAuditRepository(repoMock, historyMock, nil, logrus.New())
+
+	repoMock.On("FindOne", mock.Anything, mock.Anything, mock.Anything).Return(entity.Weight{}, exception.ErrNotFound)
+
+	err := repo.UpdateOne(auditContext(), "john", 1, entity.Weight{})
+	assert.Equal(t, exception.ErrNotFound, err)
+	repoMock.AssertNotCalled(t, "UpdateOne", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	historyMock.AssertNotCalled(t, "InsertOne", mock.Anything, mock.Anything)
+}
+
+func TestAuditRepository_UpsertOne_Success_Created(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	historyMock := new(mocks.HistoryRepository)
+	repo := weight.NewAuditRepository(repoMock, historyMock, nil, logrus.New())
+
+	repoMock.On("FindOne", mock.Anything, mock.Anything, mock.Anything).Return(entity.Weight{}, exception.ErrNotFound)
+	repoMock.On("InsertOne", mock.Anything, entity.Weight{Owner: "john", Date: 1}).Return(nil)
+	historyMock.On("InsertOne", mock.Anything, mock.MatchedBy(func(revision entity.WeightRevision) bool {
+		return revision.Action == "INSERT" && revision.Before == nil
+	})).Return(nil)
+
+	created, err := repo.UpsertOne(auditContext(), "john", 1, entity.Weight{Date: 1})
+	assert.NoError(t, err, "should be no error")
+	assert.True(t, created)
+	historyMock.AssertExpectations(t)
+}
+
+func TestAuditRepository_UpsertOne_Success_Concurrent(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	historyMock := new(mocks.HistoryRepository)
+	repo := weight.NewAuditRepository(repoMock, historyMock, nil, logrus.New())
+
+	before := entity.Weight{Owner: "john", Date: 1, Max: 3, Min: 1, Diff: 2, Version: 1}
+	repoMock.On("FindOne", mock.Anything, "john", int64(1)).Return(entity.Weight{}, exception.ErrNotFound).Once()
+	repoMock.On("InsertOne", mock.Anything, mock.Anything).Return(exception.ErrConflict).Once()
+	repoMock.On("FindOne", mock.Anything, "john", int64(1)).Return(before, nil).Once()
+	repoMock.On("UpsertOne", mock.Anything, "john", int64(1), entity.Weight{Max: 5, Min: 1, Diff: 4, Version: 1}).Return(false, nil).Once()
+	historyMock.On("InsertOne", mock.Anything, mock.MatchedBy(func(revision entity.WeightRevision) bool {
+		return revision.Action == "UPDATE" && *revision.Before == before && revision.After.Version == 2
+	})).Return(nil).Once()
+
+	created, err := repo.UpsertOne(auditContext(), "john", 1, entity.Weight{Max: 5, Min: 1, Diff: 4})
+	assert.NoError(t, err, "should update the weight created in between")
+	assert.False(t, created)
+	repoMock.AssertExpectations(t)
+	historyMock.AssertExpectations(t)
+}
+
+func TestAuditRepository_UpdateOne_Error_Conflict(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	historyMock := new(mocks.HistoryRepository)
+	repo := weight.NewAuditRepository(repoMock, historyMock, nil, logrus.New())
+
+	repoMock.On("FindOne", mock.Anything, "john", int64(1)).Return(entity.Weight{Version: 2}, nil)
+	repoMock.On("UpdateOne", mock.Anything, "john", int64(1), entity.Weight{Version: 2}).Return(exception.ErrConflict)
+
+	err := repo.UpdateOne(auditContext(), "john", 1, entity.Weight{})
+	assert.Equal(t, exception.ErrConflict, err)
+	repoMock.AssertNumberOfCalls(t, "UpdateOne", 3)
+	historyMock.AssertNotCalled(t, "InsertOne", mock.Anything, mock.Anything)
+
+	err = repo.UpdateOne(auditContext(), "john", 1, entity.Weight{Version: 2})
+	assert.Equal(t, exception.ErrConflict, err)
+	repoMock.AssertNumberOfCalls(t, "UpdateOne", 4)
+}
+
+func TestAuditRepository_DeleteOne_Success(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	historyMock := new(mocks.HistoryRepository)
+	repo := weight.NewAuditRepository(repoMock, historyMock, nil, logrus.New())
+
+	before := entity.Weight{Owner: "john", Date: 1, Max: 3, Min: 1, Diff: 2}
+	repoMock.On("FindOne", mock.Anything, "john", int64(1)).Return(before, nil)
+	repoMock.On("DeleteOne", mock.Anything, "john", int64(1)).Return(nil)
+	historyMock.On("InsertOne", mock.Anything, mock.MatchedBy(func(revision entity.WeightRevision) bool {
+		return revision.Action == "DELETE" && *revision.Before == before && revision.After == nil
+	})).Return(nil)
+
+	err := repo.DeleteOne(auditContext(), "john", 1)
+	assert.NoError(t, err, "should be no error")
+	repoMock.AssertExpectations(t)
+	historyMock.AssertExpectations(t)
+}
+
+func TestAuditRepository_BulkUpsert_Success_Overwrite(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	historyMock := new(mocks.HistoryRepository)
+	repo := weight.NewAuditRepository(repoMock, historyMock, nil, logrus.New())
+
+	batch := []entity.Weight{
+		{Owner: "john", Date: 3, Max: 3, Min: 1, Diff: 2},
+		{Owner: "john", Date: 1, Max: 5, Min: 1, Diff: 4},
+	}
+	expectedFilter := model.WeightFilter{Owner: "john", From: 1, To: 3, SortBy: "date", Sort: 1}
+	repoMock.On("FindMany", mock.Anything, expectedFilter).Return([]entity.Weight{{Owner: "john", Date: 1, Max: 2, Min: 1, Diff: 1}}, nil)
+	repoMock.On("BulkUpsert", mock.Anything, "john", batch, true).Return([]bool{true, false}, nil)
+	historyMock.On("InsertOne", mock.Anything, mock.MatchedBy(func(revision entity.WeightRevision) bool {
+		return revision.Action == "INSERT" && revision.Date == 3
+	})).Return(nil).Once()
+	historyMock.On("InsertOne", mock.Anything, mock.MatchedBy(func(revision entity.WeightRevision) bool {
+		return revision.Action == "UPDATE" && revision.Date == 1 && revision.Before.Max == 2 && revision.After.Max == 5
+	})).Return(nil).Once()
+
+	inserted, err := repo.BulkUpsert(auditContext(), "john", batch, true)
+	assert.NoError(t, err, "should be no error")
+	assert.Equal(t, []bool{true, false}, inserted)
+	repoMock.AssertExpectations(t)
+	historyMock.AssertExpectations(t)
+}
+
+func TestAuditRepository_BulkUpsert_Success_SkipDuplicate(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	historyMock := new(mocks.HistoryRepository)
+	repo := weight.NewAuditRepository(repoMock, historyMock, nil, logrus.New())
+
+	batch := []entity.Weight{{Date: 1}, {Date: 2}}
+	repoMock.On("BulkUpsert", mock.Anything, "", batch, false).Return([]bool{false, true}, nil)
+	historyMock.On("InsertOne", mock.Anything, mock.MatchedBy(func(revision entity.WeightRevision) bool {
+		return revision.Action == "INSERT" && revision.Date == 2
+	})).Return(nil).Once()
+
+	_, err := repo.BulkUpsert(context.TODO(), "", batch, false)
+	assert.NoError(t, err, "should be no error")
+	repoMock.AssertNotCalled(t, "FindMany", mock.Anything, mock.Anything)
+	historyMock.AssertExpectations(t)
+}
+
+func TestAuditRepository_Error_History(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	historyMock := new(mocks.HistoryRepository)
+	repo := weight.NewAuditRepository(repoMock, historyMock, nil, logrus.New())
+
+	repoMock.On("InsertOne", mock.Anything, mock.Anything).Return(nil)
+	historyMock.On("InsertOne", mock.Anything, mock.Anything).Return(exception.ErrInternalServer)
+
+	err := repo.InsertOne(auditContext(), entity.Weight{})
+	assert.Equal(t, exception.ErrInternalServer, err, "should fail the write")
+}
+
+func TestAuditRepository_Error_RolledBack(t *testing.T) {
+	db := openSQL(t, sqldb.DriverSQLite, filepath.Join(t.TempDir(), "weights.db"))
+	repo := weight.NewAuditRepository(weight.NewSQLRepository(logrus.New(), db), weight.NewSQLHistoryRepository(logrus.New(), db), db, logrus.New())
+
+	require.NoError(t, repo.InsertOne(auditContext(), conformanceWeight("john", 1, 3, 1)))
+
+	_, err := db.Exec("DROP TABLE weight_history")
+	require.NoError(t, err)
+	err = repo.UpdateOne(auditContext(), "john", day(1), conformanceWeight("john", 1, 9, 1))
+	assert.Equal(t, exception.ErrInternalServer, err, "should be internal server error")
+	_, err = repo.UpsertOne(auditContext(), "john", day(2), conformanceWeight("john", 2, 9, 1))
+	assert.Equal(t, exception.ErrInternalServer, err, "should be internal server error")
+
+	stored, err := repo.FindOne(context.TODO(), "john", day(1))
+	require.NoError(t, err)
+	assert.Equal(t, 3, stored.Max, "should roll back the write when its revision can not be recorded")
+	_, err = repo.FindOne(context.TODO(), "john", day(2))
+	assert.Equal(t, exception.ErrNotFound, err, "should roll back the write when its revision can not be recorded")
+}
+
+func TestAuditRepository_Restore_RecordedAsRestore(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	historyMock := new(mocks.HistoryRepository)
+	repo := weight.NewAuditRepository(repoMock, historyMock, nil, logrus.New())
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logrus.New(),
+		Repository:  repo,
+		History:     historyMock,
+	})
+
+	before := entity.Weight{Owner: "john", Date: 1, Max: 5, Min: 1, Diff: 4}
+	historyMock.On("FindOne", mock.Anything, "john", int64(1), "abc").Return(entity.WeightRevision{
+		ID:     "abc",
+		Action: "UPDATE",
+		Before: &entity.Weight{Owner: "john", Date: 1, Max: 2, Min: 1, Diff: 1},
+		After:  &entity.Weight{Owner: "john", Date: 1, Max: 3, Min: 1, Diff: 2},
+	}, nil)
+	repoMock.On("FindOne", mock.Anything, "john", int64(1)).Return(before, nil)
+	repoMock.On("UpsertOne", mock.Anything, "john", int64(1), mock.Anything).Return(false, nil)
+	historyMock.On("InsertOne", mock.Anything, mock.MatchedBy(func(revision entity.WeightRevision) bool {
+		return revision.Action == "RESTORE" && revision.Before.Max == 5 && revision.After.Max == 3
+	})).Return(nil)
+
+	result := usecase.Restore(auditContext(), 1, "abc")
+	assert.Nil(t, result.Error(), "should be no error")
+	repoMock.AssertExpectations(t)
+	historyMock.AssertExpectations(t)
+}
@@ -0,0 +1,198 @@
+package weight
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ijalalfrz/sirclo-weight-test/cache"
+	"github.com/ijalalfrz/sirclo-weight-test/entity"
+	"github.com/ijalalfrz/sirclo-weight-test/model"
+	"github.com/ijalalfrz/sirclo-weight-test/requestctx"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+// collection of cache lookup result
+const (
+	cacheResultHit   = "hit"
+	cacheResultMiss  = "miss"
+	cacheResultError = "error"
+)
+
+const cacheKeyPrefix = "weight"
+
+// cacheRepository is a read-through decorator of Repository which caches FindOne, FindMany, CountMany and Averages.
+// The keys of an owner carry a generation which every write of the owner replaces, so a write invalidates
+// every cached read of the owner at once without listing the keys. The previous keys are left to expire.
+// The cache is bypassed while it fails, the failure is logged.
+type cacheRepository struct {
+	next     Repository
+	cache    cache.Cache
+	ttl      time.Duration
+	requests *prometheus.CounterVec
+	logger   *logrus.Logger
+}
+
+// NewCacheRepository is a constructor. The requests counter must be labeled by operation and result.
+func NewCacheRepository(next Repository, c cache.Cache, ttl time.Duration, requests *prometheus.CounterVec, logger *logrus.Logger) Repository {
+	return cacheRepository{
+		next:     next,
+		cache:    c,
+		ttl:      ttl,
+		requests: requests,
+		logger:   logger,
+	}
+}
+
+func (r cacheRepository) InsertOne(ctx context.Context, weight entity.Weight) (err error) {
+	err = r.next.InsertOne(ctx, weight)
+	r.invalidate(ctx, weight.Owner)
+	return
+}
+
+func (r cacheRepository) UpdateOne(ctx context.Context, owner string, key int64, weight entity.Weight) (err error) {
+	err = r.next.UpdateOne(ctx, owner, key, weight)
+	r.invalidate(ctx, owner)
+	return
+}
+
+func (r cacheRepository) UpsertOne(ctx context.Context, owner string, key int64, weight entity.Weight) (created bool, err error) {
+	created, err = r.next.UpsertOne(ctx, owner, key, weight)
+	r.invalidate(ctx, owner)
+	return
+}
+
+func (r cacheRepository) FindMany(ctx context.Context, filter model.WeightFilter) (bunchOfWeight []entity.Weight, err error) {
+	suffix := fmt.Sprintf("%d:%d:%d:%d:%s:%d", filter.From, filter.To, filter.Page, filter.Limit, filter.SortBy, filter.Sort)
+	err = r.readThrough(ctx, "FindMany", filter.Owner, suffix, &bunchOfWeight, func() (err error) {
+		bunchOfWeight, err = r.next.FindMany(ctx, filter)
+		return
+	})
+	return
+}
+
+func (r cacheRepository) CountMany(ctx context.Context, filter model.WeightFilter) (total int64, err error) {
+	suffix := fmt.Sprintf("%d:%d", filter.From, filter.To)
+	err = r.readThrough(ctx, "CountMany", filter.Owner, suffix, &total, func() (err error) {
+		total, err = r.next.CountMany(ctx, filter)
+		return
+	})
+	return
+}
+
+func (r cacheRepository) Averages(ctx context.Context, filter model.WeightFilter) (average entity.WeightAverage, err error) {
+	suffix := fmt.Sprintf("%d:%d", filter.From, filter.To)
+	err = r.readThrough(ctx, "Averages", filter.Owner, suffix, &average, func() (err error) {
+		average, err = r.next.Averages(ctx, filter)
+		return
+	})
+	return
+}
+
+func (r cacheRepository) FindOne(ctx context.Context, owner string, key int64) (weight entity.Weight, err error) {
+	err = r.readThrough(ctx, "FindOne", owner, strconv.FormatInt(key, 10), &weight, func() (err error) {
+		weight, err = r.next.FindOne(ctx, owner, key)
+		return
+	})
+	return
+}
+
+func (r cacheRepository) DeleteOne(ctx context.Context, owner string, key int64) (err error) {
+	err = r.next.DeleteOne(ctx, owner, key)
+	r.invalidate(ctx, owner)
+	return
+}
+
+func (r cacheRepository) Statistics(ctx context.Context, filter model.StatisticFilter) (bunchOfStatistic []entity.WeightStatistic, err error) {
+	return r.next.Statistics(ctx, filter)
+}
+
+// BulkUpsert invalidates even when it fails, as the weights before the failing one may have been written.
+func (r cacheRepository) BulkUpsert(ctx context.Context, owner string, bunchOfWeight []entity.Weight, overwrite bool) (inserted []bool, err error) {
+	inserted, err = r.next.BulkUpsert(ctx, owner, bunchOfWeight, overwrite)
+	r.invalidate(ctx, owner)
+	return
+}
+
+func (r cacheRepository) FindEach(ctx context.Context, filter model.WeightFilter, fn func(weight entity.Weight) error) (err error) {
+	return r.next.FindEach(ctx, filter, fn)
+}
+
+// readThrough decodes the cached value of the key into value, or calls load which must fill value and caches it.
+// Errors of load are not cached.
+func (r cacheRepository) readThrough(ctx context.Context, operation, owner, suffix string, value interface{}, load func() error) (err error) {
+	generation, ok := r.generation(ctx, owner)
+	if !ok {
+		r.requests.WithLabelValues(operation, cacheResultError).Inc()
+		return load()
+	}
+
+	key := r.key(owner, generation, operation, suffix)
+	cached, err := r.cache.Get(ctx, key)
+	if err == nil {
+		if err = json.Unmarshal(cached, value); err == nil {
+			r.requests.WithLabelValues(operation, cacheResultHit).Inc()
+			return
+		}
+	}
+	if err != cache.ErrMiss {
+		r.log(ctx).Error(err)
+	}
+	r.requests.WithLabelValues(operation, cacheResultMiss).Inc()
+
+	if err = load(); err != nil {
+		return
+	}
+
+	content, errMarshal := json.Marshal(value)
+	if errMarshal != nil {
+		r.log(ctx).Error(errMarshal)
+		return
+	}
+	if errSet := r.cache.Set(ctx, key, content, r.ttl); errSet != nil {
+		r.log(ctx).Error(errSet)
+	}
+	return
+}
+
+// generation returns the current generation of the keys of owner, a new one is started when there is none.
+func (r cacheRepository) generation(ctx context.Context, owner string) (generation string, ok bool) {
+	key := r.key(owner, "generation")
+	value, err := r.cache.Get(ctx, key)
+	if err == nil {
+		return string(value), true
+	}
+	if err != cache.ErrMiss {
+		r.log(ctx).Error(err)
+		return
+	}
+
+	generation = strconv.FormatInt(time.Now().UnixNano(), 10)
+	if err = r.cache.Set(ctx, key, []byte(generation), 0); err != nil {
+		r.log(ctx).Error(err)
+		return
+	}
+	return generation, true
+}
+
+// invalidate replaces the generation of the keys of owner.
+func (r cacheRepository) invalidate(ctx context.Context, owner string) {
+	generation := strconv.FormatInt(time.Now().UnixNano(), 10)
+	if err := r.cache.Set(ctx, r.key(owner, "generation"), []byte(generation), 0); err != nil {
+		r.log(ctx).Error(err)
+	}
+}
+
+// key joins the parts of a cache key, the owner is escaped so it can not be mistaken for another part.
+func (r cacheRepository) key(owner string, parts ...string) string {
+	return strings.Join(append([]string{cacheKeyPrefix, url.QueryEscape(owner)}, parts...), ":")
+}
+
+func (r cacheRepository) log(ctx context.Context) *logrus.Entry {
+	return requestctx.Logger(ctx, r.logger)
+}
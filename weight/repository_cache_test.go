@@ -0,0 +1,194 @@
+package weight_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/ijalalfrz/sirclo-weight-test/cache"
+	"github.com/ijalalfrz/sirclo-weight-test/entity"
+	"github.com/ijalalfrz/sirclo-weight-test/exception"
+	"github.com/ijalalfrz/sirclo-weight-test/model"
+	"github.com/ijalalfrz/sirclo-weight-test/weight"
+	"github.com/ijalalfrz/sirclo-weight-test/weight/mocks"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// failingCache is a cache which is always unavailable.
+type failingCache struct{}
+
+func (failingCache) Get(ctx context.Context, key string) ([]byte, error) {
+	return nil, errors.New("connection refused")
+}
+
+func (failingCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return errors.New("connection refused")
+}
+
+func cacheRequests() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(prometheus.CounterOpts{Name: "cache_requests_total"}, []string{"operation", "result"})
+}
+
+func TestCacheRepository_FindOne_Success(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	requests := cacheRequests()
+	repo := weight.NewCacheRepository(repoMock, cache.NewLRU(10), time.Minute, requests, logrus.New())
+
+	expected := entity.Weight{Owner: "john", Date: 1, Max: 3, Min: 1, Diff: 2, Version: 1}
+	repoMock.On("FindOne", mock.Anything, "john", int64(1)).Return(expected, nil).Once()
+
+	for i := 0; i < 3; i++ {
+		result, err := repo.FindOne(context.TODO(), "john", 1)
+		assert.NoError(t, err, "should be no error")
+		assert.Equal(t, expected, result)
+	}
+	repoMock.AssertNumberOfCalls(t, "FindOne", 1)
+	assert.Equal(t, float64(1), testutil.ToFloat64(requests.WithLabelValues("FindOne", "miss")))
+	assert.Equal(t, float64(2), testutil.ToFloat64(requests.WithLabelValues("FindOne", "hit")))
+}
+
+func TestCacheRepository_FindOne_Error_NotCached(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	repo := weight.NewCacheRepository(repoMock, cache.NewLRU(10), time.Minute, cacheRequests(), logrus.New())
+
+	repoMock.On("FindOne", mock.Anything, "john", int64(1)).Return(entity.Weight{}, exception.ErrNotFound)
+
+	for i := 0; i < 2; i++ {
+		_, err := repo.FindOne(context.TODO(), "john", 1)
+		assert.Equal(t, exception.ErrNotFound, err)
+	}
+	repoMock.AssertNumberOfCalls(t, "FindOne", 2)
+}
+
+func TestCacheRepository_FindMany_Success(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	requests := cacheRequests()
+	repo := weight.NewCacheRepository(repoMock, cache.NewLRU(10), time.Minute, requests, logrus.New())
+
+	first := model.WeightFilter{Owner: "john", Page: 1, Limit: 10}
+	second := model.WeightFilter{Owner: "john", Page: 2, Limit: 10}
+	repoMock.On("FindMany", mock.Anything, first).Return([]entity.Weight{{Owner: "john", Date: 1}}, nil).Once()
+	repoMock.On("FindMany", mock.Anything, second).Return([]entity.Weight{{Owner: "john", Date: 2}}, nil).Once()
+	repoMock.On("CountMany", mock.Anything, first).Return(int64(11), nil).Once()
+
+	for i := 0; i < 2; i++ {
+		result, err := repo.FindMany(context.TODO(), first)
+		assert.NoError(t, err, "should be no error")
+		assert.Equal(t, int64(1), result[0].Date)
+
+		result, err = repo.FindMany(context.TODO(), second)
+		assert.NoError(t, err, "should be no error")
+		assert.Equal(t, int64(2), result[0].Date, "should be cached by page")
+
+		total, err := repo.CountMany(context.TODO(), first)
+		assert.NoError(t, err, "should be no error")
+		assert.Equal(t, int64(11), total)
+	}
+	repoMock.AssertExpectations(t)
+	assert.Equal(t, float64(2), testutil.ToFloat64(requests.WithLabelValues("FindMany", "hit")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(requests.WithLabelValues("CountMany", "hit")))
+}
+
+func TestCacheRepository_Invalidate_Success(t *testing.T) {
+	writes := map[string]func(repoMock *mocks.Repository, repo weight.Repository) error{
+		"InsertOne": func(repoMock *mocks.Repository, repo weight.Repository) error {
+			repoMock.On("InsertOne", mock.Anything, mock.Anything).Return(nil)
+			return repo.InsertOne(context.TODO(), entity.Weight{Owner: "john", Date: 2})
+		},
+		"UpdateOne": func(repoMock *mocks.Repository, repo weight.Repository) error {
+			repoMock.On("UpdateOne", mock.Anything, "john", int64(1), mock.Anything).Return(nil)
+			return repo.UpdateOne(context.TODO(), "john", 1, entity.Weight{Owner: "john", Date: 1})
+		},
+		"UpsertOne": func(repoMock *mocks.Repository, repo weight.Repository) (err error) {
+			repoMock.On("UpsertOne", mock.Anything, "john", int64(1), mock.Anything).Return(false, nil)
+			_, err = repo.UpsertOne(context.TODO(), "john", 1, entity.Weight{Owner: "john", Date: 1})
+			return
+		},
+		"DeleteOne": func(repoMock *mocks.Repository, repo weight.Repository) error {
+			repoMock.On("DeleteOne", mock.Anything, "john", int64(1)).Return(nil)
+			return repo.DeleteOne(context.TODO(), "john", 1)
+		},
+		"BulkUpsert": func(repoMock *mocks.Repository, repo weight.Repository) (err error) {
+			repoMock.On("BulkUpsert", mock.Anything, "john", mock.Anything, true).Return([]bool{false}, nil)
+			_, err = repo.BulkUpsert(context.TODO(), "john", []entity.Weight{{Owner: "john", Date: 1}}, true)
+			return
+		},
+	}
+
+	for method, write := range writes {
+		t.Run(method, func(t *testing.T) {
+			repoMock := new(mocks.Repository)
+			repo := weight.NewCacheRepository(repoMock, cache.NewLRU(10), time.Minute, cacheRequests(), logrus.New())
+
+			repoMock.On("FindOne", mock.Anything, "john", int64(1)).Return(entity.Weight{Owner: "john", Date: 1, Max: 3}, nil).Once()
+			repoMock.On("FindOne", mock.Anything, "jane", int64(1)).Return(entity.Weight{Owner: "jane", Date: 1, Max: 4}, nil).Once()
+			repo.FindOne(context.TODO(), "john", 1)
+			repo.FindOne(context.TODO(), "jane", 1)
+
+			assert.NoError(t, write(repoMock, repo), "should be no error")
+
+			repoMock.On("FindOne", mock.Anything, "john", int64(1)).Return(entity.Weight{Owner: "john", Date: 1, Max: 5}, nil).Once()
+			result, err := repo.FindOne(context.TODO(), "john", 1)
+			assert.NoError(t, err, "should be no error")
+			assert.Equal(t, 5, result.Max, "should read the repository after a write")
+
+			result, err = repo.FindOne(context.TODO(), "jane", 1)
+			assert.NoError(t, err, "should be no error")
+			assert.Equal(t, 4, result.Max, "should keep the cache of the other owners")
+			repoMock.AssertNumberOfCalls(t, "FindOne", 3)
+		})
+	}
+}
+
+func TestCacheRepository_FindOne_Success_CacheUnavailable(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	requests := cacheRequests()
+	repo := weight.NewCacheRepository(repoMock, failingCache{}, time.Minute, requests, logrus.New())
+
+	expected := entity.Weight{Owner: "john", Date: 1, Max: 3}
+	repoMock.On("FindOne", mock.Anything, "john", int64(1)).Return(expected, nil)
+	repoMock.On("InsertOne", mock.Anything, expected).Return(nil)
+
+	result, err := repo.FindOne(context.TODO(), "john", 1)
+	assert.NoError(t, err, "should fall through to the repository")
+	assert.Equal(t, expected, result)
+	assert.NoError(t, repo.InsertOne(context.TODO(), expected), "should not fail the write")
+	assert.Equal(t, float64(1), testutil.ToFloat64(requests.WithLabelValues("FindOne", "error")))
+}
+
+func TestCacheRepository_FindOne_Success_Redis(t *testing.T) {
+	server, err := miniredis.Run()
+	require.NoError(t, err)
+	defer server.Close()
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	repoMock := new(mocks.Repository)
+	repo := weight.NewCacheRepository(repoMock, cache.NewRedis(client), time.Minute, cacheRequests(), logrus.New())
+
+	expected := entity.Weight{Owner: "john", Date: 1, Max: 3, Version: 1}
+	repoMock.On("FindOne", mock.Anything, "john", int64(1)).Return(expected, nil).Once()
+	repoMock.On("UpdateOne", mock.Anything, "john", int64(1), mock.Anything).Return(nil)
+
+	for i := 0; i < 2; i++ {
+		result, err := repo.FindOne(context.TODO(), "john", 1)
+		assert.NoError(t, err, "should be no error")
+		assert.Equal(t, expected, result)
+	}
+	repoMock.AssertNumberOfCalls(t, "FindOne", 1)
+
+	assert.NoError(t, repo.UpdateOne(context.TODO(), "john", 1, expected))
+	expected.Version = 2
+	repoMock.On("FindOne", mock.Anything, "john", int64(1)).Return(expected, nil).Once()
+	result, err := repo.FindOne(context.TODO(), "john", 1)
+	assert.NoError(t, err, "should be no error")
+	assert.Equal(t, int64(2), result.Version, "should read the repository after a write")
+}
@@ -0,0 +1,435 @@
+package weight_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ijalalfrz/sirclo-weight-test/entity"
+	"github.com/ijalalfrz/sirclo-weight-test/exception"
+	"github.com/ijalalfrz/sirclo-weight-test/migration"
+	"github.com/ijalalfrz/sirclo-weight-test/model"
+	"github.com/ijalalfrz/sirclo-weight-test/mongodb"
+	"github.com/ijalalfrz/sirclo-weight-test/sqldb"
+	"github.com/ijalalfrz/sirclo-weight-test/weight"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// day returns the calendar date key of the given day of July 2022.
+func day(d int) int64 {
+	return time.Date(2022, 7, d, 0, 0, 0, 0, time.UTC).UnixNano()
+}
+
+func conformanceWeight(owner string, d, max, min int) entity.Weight {
+	return entity.Weight{Owner: owner, Date: day(d), Day: fmt.Sprintf("2022-07-%02d", d), Max: max, Min: min, Diff: max - min}
+}
+
+// testRepositoryConformance is the behaviour every Repository must share, newRepository returns an empty repository.
+func testRepositoryConformance(t *testing.T, newRepository func(t *testing.T) weight.Repository) {
+	ctx := context.TODO()
+
+	t.Run("InsertOne and FindOne", func(t *testing.T) {
+		repo := newRepository(t)
+
+		require.NoError(t, repo.InsertOne(ctx, conformanceWeight("john", 1, 3, 1)))
+		assert.Equal(t, exception.ErrConflict, repo.InsertOne(ctx, conformanceWeight("john", 1, 5, 1)), "should be conflict error")
+		require.NoError(t, repo.InsertOne(ctx, conformanceWeight("jane", 1, 5, 1)), "should be scoped to owner")
+
+		found, err := repo.FindOne(ctx, "john", day(1))
+		require.NoError(t, err)
+		assert.Equal(t, "john", found.Owner)
+		assert.Equal(t, "2022-07-01", found.Day)
+		assert.Equal(t, 3, found.Max)
+		assert.Equal(t, int64(1), found.Version)
+
+		_, err = repo.FindOne(ctx, "john", day(2))
+		assert.Equal(t, exception.ErrNotFound, err, "should be not found error")
+	})
+
+	t.Run("UpdateOne", func(t *testing.T) {
+		repo := newRepository(t)
+		require.NoError(t, repo.InsertOne(ctx, conformanceWeight("john", 1, 3, 1)))
+
+		require.NoError(t, repo.UpdateOne(ctx, "john", day(1), conformanceWeight("john", 1, 4, 1)))
+		updated := conformanceWeight("john", 1, 5, 1)
+		updated.Version = 2
+		require.NoError(t, repo.UpdateOne(ctx, "john", day(1), updated))
+		assert.Equal(t, exception.ErrConflict, repo.UpdateOne(ctx, "john", day(1), updated), "should be version conflict")
+
+		found, err := repo.FindOne(ctx, "john", day(1))
+		require.NoError(t, err)
+		assert.Equal(t, 5, found.Max)
+		assert.Equal(t, int64(3), found.Version)
+
+		assert.Equal(t, exception.ErrNotFound, repo.UpdateOne(ctx, "john", day(2), conformanceWeight("john", 2, 3, 1)))
+		assert.Equal(t, exception.ErrNotFound, repo.UpdateOne(ctx, "john", day(2), updated), "should be not found with version")
+	})
+
+	t.Run("UpsertOne", func(t *testing.T) {
+		repo := newRepository(t)
+
+		created, err := repo.UpsertOne(ctx, "john", day(1), conformanceWeight("john", 1, 3, 1))
+		require.NoError(t, err)
+		assert.True(t, created, "should be created")
+
+		created, err = repo.UpsertOne(ctx, "john", day(1), conformanceWeight("john", 1, 4, 1))
+		require.NoError(t, err)
+		assert.False(t, created, "should be updated")
+
+		found, err := repo.FindOne(ctx, "john", day(1))
+		require.NoError(t, err)
+		assert.Equal(t, 4, found.Max)
+		assert.Equal(t, int64(2), found.Version)
+
+		stale := conformanceWeight("john", 1, 5, 1)
+		stale.Version = 1
+		_, err = repo.UpsertOne(ctx, "john", day(1), stale)
+		assert.Equal(t, exception.ErrConflict, err, "should be version conflict")
+		_, err = repo.UpsertOne(ctx, "john", day(2), stale)
+		assert.Equal(t, exception.ErrNotFound, err, "should not create a weight of an expected version")
+	})
+
+	t.Run("FindMany, CountMany and FindEach", func(t *testing.T) {
+		repo := newRepository(t)
+		for d, max := range map[int]int{1: 5, 2: 3, 3: 7, 4: 4} {
+			require.NoError(t, repo.InsertOne(ctx, conformanceWeight("john", d, max, 1)))
+		}
+		require.NoError(t, repo.InsertOne(ctx, conformanceWeight("jane", 2, 9, 1)))
+
+		bunchOfWeight, err := repo.FindMany(ctx, model.WeightFilter{Owner: "john", From: day(2), To: day(4), SortBy: "max", Sort: -1})
+		require.NoError(t, err)
+		assert.Equal(t, []int{7, 4, 3}, maxOf(bunchOfWeight))
+
+		bunchOfWeight, err = repo.FindMany(ctx, model.WeightFilter{Owner: "john", SortBy: "date", Sort: 1, Page: 2, Limit: 3})
+		require.NoError(t, err)
+		assert.Equal(t, []int{4}, maxOf(bunchOfWeight), "should be the second page")
+
+		total, err := repo.CountMany(ctx, model.WeightFilter{Owner: "john", From: day(2)})
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), total)
+
+		_, err = repo.FindMany(ctx, model.WeightFilter{Owner: "joe", SortBy: "date", Sort: 1})
+		assert.Equal(t, exception.ErrNotFound, err, "should be not found error")
+
+		var dates []int64
+		err = repo.FindEach(ctx, model.WeightFilter{Owner: "john", SortBy: "date", Sort: -1, Limit: 1}, func(weight entity.Weight) error {
+			dates = append(dates, weight.Date)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []int64{day(4), day(3), day(2), day(1)}, dates, "should ignore pagination")
+
+		errStop := fmt.Errorf("stop")
+		err = repo.FindEach(ctx, model.WeightFilter{Owner: "john", SortBy: "date", Sort: 1}, func(weight entity.Weight) error {
+			return errStop
+		})
+		assert.Equal(t, errStop, err, "should return the error of fn")
+	})
+
+	t.Run("DeleteOne", func(t *testing.T) {
+		repo := newRepository(t)
+		require.NoError(t, repo.InsertOne(ctx, conformanceWeight("john", 1, 3, 1)))
+
+		require.NoError(t, repo.DeleteOne(ctx, "john", day(1)))
+		assert.Equal(t, exception.ErrNotFound, repo.DeleteOne(ctx, "john", day(1)), "should be not found error")
+		_, err := repo.FindOne(ctx, "john", day(1))
+		assert.Equal(t, exception.ErrNotFound, err)
+	})
+
+	t.Run("BulkUpsert", func(t *testing.T) {
+		repo := newRepository(t)
+		require.NoError(t, repo.InsertOne(ctx, conformanceWeight("john", 1, 3, 1)))
+
+		inserted, err := repo.BulkUpsert(ctx, "john", []entity.Weight{conformanceWeight("", 1, 8, 1), conformanceWeight("", 2, 4, 1)}, false)
+		require.NoError(t, err)
+		assert.Equal(t, []bool{false, true}, inserted)
+		found, _ := repo.FindOne(ctx, "john", day(1))
+		assert.Equal(t, 3, found.Max, "should be left untouched")
+		found, _ = repo.FindOne(ctx, "john", day(2))
+		assert.Equal(t, int64(1), found.Version)
+
+		inserted, err = repo.BulkUpsert(ctx, "john", []entity.Weight{conformanceWeight("", 1, 8, 1), conformanceWeight("", 3, 4, 1)}, true)
+		require.NoError(t, err)
+		assert.Equal(t, []bool{false, true}, inserted)
+		found, _ = repo.FindOne(ctx, "john", day(1))
+		assert.Equal(t, 8, found.Max, "should be overwritten")
+		assert.Equal(t, int64(2), found.Version)
+	})
+
+	t.Run("Statistics", func(t *testing.T) {
+		repo := newRepository(t)
+		require.NoError(t, repo.InsertOne(ctx, conformanceWeight("john", 1, 4, 1)))
+		require.NoError(t, repo.InsertOne(ctx, conformanceWeight("john", 2, 6, 1)))
+		august := entity.Weight{Owner: "john", Date: time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC).UnixNano(), Day: "2022-08-01", Max: 3, Min: 1, Diff: 2}
+		require.NoError(t, repo.InsertOne(ctx, august))
+
+		bunchOfStatistic, err := repo.Statistics(ctx, model.StatisticFilter{Owner: "john", GroupBy: "month"})
+		require.NoError(t, err)
+		require.Len(t, bunchOfStatistic, 2)
+		assert.Equal(t, entity.WeightStatisticPeriod{Year: 2022, Month: 7}, bunchOfStatistic[0].Period)
+		assert.Equal(t, int64(2), bunchOfStatistic[0].Count)
+		assert.Equal(t, 4, bunchOfStatistic[0].Max.Min)
+		assert.Equal(t, 6, bunchOfStatistic[0].Max.Max)
+		assert.Equal(t, 5.0, bunchOfStatistic[0].Max.Mean)
+		assert.Equal(t, 1.0, bunchOfStatistic[0].Max.StdDev)
+		assert.ElementsMatch(t, []int{4, 6}, bunchOfStatistic[0].Max.Values)
+
+		_, err = repo.Statistics(ctx, model.StatisticFilter{Owner: "jane", GroupBy: "month"})
+		assert.Equal(t, exception.ErrNotFound, err, "should be not found error")
+	})
+
+	t.Run("Averages", func(t *testing.T) {
+		repo := newRepository(t)
+		require.NoError(t, repo.InsertOne(ctx, conformanceWeight("john", 1, 4, 1)))
+		require.NoError(t, repo.InsertOne(ctx, conformanceWeight("john", 2, 7, 2)))
+		require.NoError(t, repo.InsertOne(ctx, conformanceWeight("john", 3, 9, 1)))
+		require.NoError(t, repo.InsertOne(ctx, conformanceWeight("jane", 2, 9, 1)))
+
+		average, err := repo.Averages(ctx, model.WeightFilter{Owner: "john", To: day(2), Page: 2, Limit: 1})
+		require.NoError(t, err)
+		assert.Equal(t, entity.WeightAverage{Max: 5.5, Min: 1.5, Diff: 4}, average, "should average the whole filter")
+
+		average, err = repo.Averages(ctx, model.WeightFilter{Owner: "joe"})
+		require.NoError(t, err)
+		assert.Equal(t, entity.WeightAverage{}, average, "should be zero without weights")
+	})
+}
+
+func maxOf(bunchOfWeight []entity.Weight) (values []int) {
+	for _, weight := range bunchOfWeight {
+		values = append(values, weight.Max)
+	}
+	return
+}
+
+func TestMemoryRepository_Conformance(t *testing.T) {
+	testRepositoryConformance(t, func(t *testing.T) weight.Repository {
+		return weight.NewMemoryRepository(logrus.New())
+	})
+}
+
+func TestFileRepository_Conformance(t *testing.T) {
+	testRepositoryConformance(t, func(t *testing.T) weight.Repository {
+		repo, err := weight.NewFileRepository(logrus.New(), filepath.Join(t.TempDir(), "weights.json"))
+		require.NoError(t, err)
+		return repo
+	})
+}
+
+// TestWeightRepository_Conformance runs against the MongoDB of MONGODB_TEST_URL, a database is created for every case.
+func TestWeightRepository_Conformance(t *testing.T) {
+	uri := os.Getenv("MONGODB_TEST_URL")
+	if uri == "" {
+		t.Skip("MONGODB_TEST_URL is not set")
+	}
+
+	client, err := mongo.Connect(context.TODO(), options.Client().ApplyURI(uri))
+	require.NoError(t, err)
+	defer client.Disconnect(context.TODO())
+
+	testRepositoryConformance(t, func(t *testing.T) weight.Repository {
+		name := fmt.Sprintf("weight-conformance-%d", time.Now().UnixNano())
+		t.Cleanup(func() {
+			client.Database(name).Drop(context.TODO())
+		})
+
+		db := mongodb.NewClientAdapter(client).Database(name)
+		require.NoError(t, weight.Migrations(logrus.New())[0].Up(context.TODO(), db))
+		return weight.NewWeightRepository(logrus.New(), db)
+	})
+}
+
+// openSQL opens an empty database of driver with the weight schema.
+func openSQL(t *testing.T, driver, dsn string) *sqldb.DB {
+	db, err := sqldb.Open(driver, dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	_, err = migration.NewSQLMigrator(logrus.New(), db, weight.SQLMigrations()...).Up(context.TODO())
+	require.NoError(t, err)
+	return db
+}
+
+func TestSQLRepository_Conformance_SQLite(t *testing.T) {
+	testRepositoryConformance(t, func(t *testing.T) weight.Repository {
+		db := openSQL(t, sqldb.DriverSQLite, filepath.Join(t.TempDir(), "weights.db"))
+		return weight.NewSQLRepository(logrus.New(), db)
+	})
+}
+
+// TestSQLRepository_Conformance_Postgres runs against the PostgreSQL of POSTGRES_TEST_DSN, the tables are dropped after every case.
+func TestSQLRepository_Conformance_Postgres(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN is not set")
+	}
+
+	testRepositoryConformance(t, func(t *testing.T) weight.Repository {
+		db := openSQL(t, sqldb.DriverPostgres, dsn)
+		t.Cleanup(func() {
+			db.Exec("DROP TABLE weight, weight_history, outbox, schema_migrations")
+		})
+		return weight.NewSQLRepository(logrus.New(), db)
+	})
+}
+
+func TestSQLRepository_Error_Unexpected(t *testing.T) {
+	db := openSQL(t, sqldb.DriverSQLite, filepath.Join(t.TempDir(), "weights.db"))
+	repo := weight.NewSQLRepository(logrus.New(), db)
+	db.Close()
+
+	err := repo.InsertOne(context.TODO(), conformanceWeight("john", 1, 3, 1))
+	assert.Equal(t, exception.ErrInternalServer, err, "should be internal server error")
+	_, err = repo.FindOne(context.TODO(), "john", day(1))
+	assert.Equal(t, exception.ErrInternalServer, err, "should be internal server error")
+	_, err = repo.BulkUpsert(context.TODO(), "john", []entity.Weight{conformanceWeight("", 1, 3, 1)}, true)
+	assert.Equal(t, exception.ErrInternalServer, err, "should be internal server error")
+}
+
+func TestSQLHistoryRepository_Success(t *testing.T) {
+	db := openSQL(t, sqldb.DriverSQLite, filepath.Join(t.TempDir(), "weights.db"))
+	repo := weight.NewSQLHistoryRepository(logrus.New(), db)
+
+	before := conformanceWeight("john", 1, 3, 1)
+	require.NoError(t, repo.InsertOne(context.TODO(), entity.WeightRevision{ID: "a", Owner: "john", Date: day(1), Action: "INSERT", After: &before, CreatedAt: 1}))
+	require.NoError(t, repo.InsertOne(context.TODO(), entity.WeightRevision{ID: "b", Owner: "john", Date: day(1), Action: "DELETE", Before: &before, Actor: "john", RequestID: "abc-123", CreatedAt: 2}))
+
+	bunchOfRevision, err := repo.FindMany(context.TODO(), "john", day(1))
+	require.NoError(t, err)
+	require.Len(t, bunchOfRevision, 2)
+	assert.Equal(t, "b", bunchOfRevision[0].ID, "should be newest first")
+	assert.Equal(t, before, *bunchOfRevision[0].Before)
+	assert.Nil(t, bunchOfRevision[0].After)
+
+	revision, err := repo.FindOne(context.TODO(), "john", day(1), "a")
+	require.NoError(t, err)
+	assert.Nil(t, revision.Before)
+	assert.Equal(t, before, *revision.After)
+
+	_, err = repo.FindOne(context.TODO(), "jane", day(1), "a")
+	assert.Equal(t, exception.ErrNotFound, err, "should be scoped to owner")
+	_, err = repo.FindMany(context.TODO(), "jane", day(1))
+	assert.Equal(t, exception.ErrNotFound, err)
+}
+
+func TestFileRepository_Success_Reopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "weights.json")
+	repo, err := weight.NewFileRepository(logrus.New(), path)
+	require.NoError(t, err)
+	require.NoError(t, repo.InsertOne(context.TODO(), conformanceWeight("john", 1, 3, 1)))
+	require.NoError(t, repo.InsertOne(context.TODO(), conformanceWeight("john", 2, 4, 1)))
+	require.NoError(t, repo.DeleteOne(context.TODO(), "john", day(2)))
+
+	reopened, err := weight.NewFileRepository(logrus.New(), path)
+	require.NoError(t, err)
+	found, err := reopened.FindOne(context.TODO(), "john", day(1))
+	assert.NoError(t, err, "should be persisted")
+	assert.Equal(t, 3, found.Max)
+	_, err = reopened.FindOne(context.TODO(), "john", day(2))
+	assert.Equal(t, exception.ErrNotFound, err, "should persist the delete")
+}
+
+func TestFileRepository_Error_Corrupted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "weights.json")
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0600))
+
+	_, err := weight.NewFileRepository(logrus.New(), path)
+	assert.Error(t, err, "should be error")
+}
+
+func TestFileRepository_Error_Unwritable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "weights.json")
+	repo, err := weight.NewFileRepository(logrus.New(), path)
+	require.NoError(t, err)
+
+	err = repo.InsertOne(context.TODO(), conformanceWeight("john", 1, 3, 1))
+	assert.Equal(t, exception.ErrInternalServer, err, "should be internal server error")
+	_, err = repo.FindOne(context.TODO(), "john", day(1))
+	assert.Equal(t, exception.ErrNotFound, err, "should not apply a write which is not persisted")
+}
+
+func TestMemoryHistoryRepository_Success(t *testing.T) {
+	repo := weight.NewMemoryHistoryRepository()
+	require.NoError(t, repo.InsertOne(context.TODO(), entity.WeightRevision{ID: "a", Owner: "john", Date: day(1), CreatedAt: 1}))
+	require.NoError(t, repo.InsertOne(context.TODO(), entity.WeightRevision{ID: "b", Owner: "john", Date: day(1), CreatedAt: 2}))
+
+	bunchOfRevision, err := repo.FindMany(context.TODO(), "john", day(1))
+	require.NoError(t, err)
+	assert.Equal(t, "b", bunchOfRevision[0].ID, "should be newest first")
+
+	revision, err := repo.FindOne(context.TODO(), "john", day(1), "a")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), revision.CreatedAt)
+
+	_, err = repo.FindOne(context.TODO(), "jane", day(1), "a")
+	assert.Equal(t, exception.ErrNotFound, err, "should be scoped to owner")
+	_, err = repo.FindMany(context.TODO(), "jane", day(1))
+	assert.Equal(t, exception.ErrNotFound, err)
+}
+
+func TestFileHistoryRepository_Success_Reopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "weights.history.jsonl")
+	repo, err := weight.NewFileHistoryRepository(logrus.New(), path)
+	require.NoError(t, err)
+	after := conformanceWeight("john", 1, 3, 1)
+	require.NoError(t, repo.InsertOne(context.TODO(), entity.WeightRevision{ID: "a", Owner: "john", Date: day(1), Action: "INSERT", After: &after, CreatedAt: 1}))
+	require.NoError(t, repo.InsertOne(context.TODO(), entity.WeightRevision{ID: "b", Owner: "john", Date: day(1), Action: "DELETE", Before: &after, CreatedAt: 2}))
+
+	// an append cut by a crash is dropped, and does not break the next one.
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"ID":"c","Ow`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	reopened, err := weight.NewFileHistoryRepository(logrus.New(), path)
+	require.NoError(t, err)
+	require.NoError(t, reopened.InsertOne(context.TODO(), entity.WeightRevision{ID: "d", Owner: "john", Date: day(1), CreatedAt: 3}))
+	reopened, err = weight.NewFileHistoryRepository(logrus.New(), path)
+	require.NoError(t, err)
+
+	bunchOfRevision, err := reopened.FindMany(context.TODO(), "john", day(1))
+	require.NoError(t, err, "should be persisted")
+	if assert.Len(t, bunchOfRevision, 3) {
+		assert.Equal(t, []string{"d", "b", "a"}, []string{bunchOfRevision[0].ID, bunchOfRevision[1].ID, bunchOfRevision[2].ID})
+		assert.Equal(t, after, *bunchOfRevision[1].Before)
+	}
+}
+
+func TestFileHistoryRepository_Error_Unwritable(t *testing.T) {
+	repo, err := weight.NewFileHistoryRepository(logrus.New(), filepath.Join(t.TempDir(), "missing", "weights.history.jsonl"))
+	require.NoError(t, err)
+
+	err = repo.InsertOne(context.TODO(), entity.WeightRevision{ID: "a", Owner: "john", Date: day(1)})
+	assert.Equal(t, exception.ErrInternalServer, err, "should be internal server error")
+	_, err = repo.FindMany(context.TODO(), "john", day(1))
+	assert.Equal(t, exception.ErrNotFound, err, "should not keep a revision which is not persisted")
+}
+
+func TestMemoryRepository_Success_Concurrent(t *testing.T) {
+	repo := weight.NewMemoryRepository(logrus.New())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			repo.UpsertOne(context.TODO(), "john", day(1), conformanceWeight("john", 1, 3, 1))
+		}()
+	}
+	wg.Wait()
+
+	found, err := repo.FindOne(context.TODO(), "john", day(1))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(20), found.Version, "should count every write")
+}
@@ -0,0 +1,75 @@
+package weight
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/ijalalfrz/sirclo-weight-test/entity"
+	"github.com/sirupsen/logrus"
+)
+
+// NewFileRepository is a constructor of a Repository embedded in a single JSON file, for local development.
+// The weights are kept in memory and the whole file is rewritten on every write. The file is replaced
+// atomically, so a crash leaves either the old or the new content. A missing file starts an empty store.
+func NewFileRepository(logger *logrus.Logger, path string) (repository Repository, err error) {
+	bunchOfWeight, err := readWeightFile(path)
+	if err != nil {
+		return
+	}
+
+	repository = newMemoryRepository(logger, bunchOfWeight, func(bunchOfWeight []entity.Weight) error {
+		return writeWeightFile(path, bunchOfWeight)
+	})
+	return
+}
+
+func readWeightFile(path string) (bunchOfWeight []entity.Weight, err error) {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		err = nil
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(content, &bunchOfWeight)
+	return
+}
+
+func writeWeightFile(path string, bunchOfWeight []entity.Weight) (err error) {
+	// the weights are sorted so the file does not change more than the data does.
+	sort.Slice(bunchOfWeight, func(i, j int) bool {
+		if bunchOfWeight[i].Owner != bunchOfWeight[j].Owner {
+			return bunchOfWeight[i].Owner < bunchOfWeight[j].Owner
+		}
+		return bunchOfWeight[i].Date < bunchOfWeight[j].Date
+	})
+	content, err := json.MarshalIndent(bunchOfWeight, "", "  ")
+	if err != nil {
+		return
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return
+	}
+	defer os.Remove(file.Name())
+
+	if _, err = file.Write(content); err != nil {
+		file.Close()
+		return
+	}
+	if err = file.Sync(); err != nil {
+		file.Close()
+		return
+	}
+	if err = file.Close(); err != nil {
+		return
+	}
+
+	err = os.Rename(file.Name(), path)
+	return
+}
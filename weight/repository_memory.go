@@ -0,0 +1,399 @@
+package weight
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ijalalfrz/sirclo-weight-test/entity"
+	"github.com/ijalalfrz/sirclo-weight-test/exception"
+	"github.com/ijalalfrz/sirclo-weight-test/model"
+	"github.com/ijalalfrz/sirclo-weight-test/requestctx"
+	"github.com/sirupsen/logrus"
+)
+
+// memoryKey is the unique key of a weight, the same as the unique index of the weight collection.
+type memoryKey struct {
+	owner string
+	date  int64
+}
+
+// memoryRepository is a thread-safe Repository which keeps the weights in a map, for tests and demos.
+// Every write is collected as a set of changes which is handed to persist before it is applied,
+// so a store on top of it can keep its copy in sync and reject the write as a whole.
+type memoryRepository struct {
+	logger  *logrus.Logger
+	mu      sync.RWMutex
+	weights map[memoryKey]entity.Weight
+	persist func(bunchOfWeight []entity.Weight) error
+}
+
+// NewMemoryRepository is a constructor.
+func NewMemoryRepository(logger *logrus.Logger) Repository {
+	return newMemoryRepository(logger, nil, nil)
+}
+
+func newMemoryRepository(logger *logrus.Logger, bunchOfWeight []entity.Weight, persist func(bunchOfWeight []entity.Weight) error) *memoryRepository {
+	weights := make(map[memoryKey]entity.Weight, len(bunchOfWeight))
+	for _, weight := range bunchOfWeight {
+		weights[memoryKey{weight.Owner, weight.Date}] = weight
+	}
+	return &memoryRepository{logger: logger, weights: weights, persist: persist}
+}
+
+func (r *memoryRepository) InsertOne(ctx context.Context, weight entity.Weight) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := memoryKey{weight.Owner, weight.Date}
+	if _, ok := r.weights[key]; ok {
+		err = exception.ErrConflict
+		return
+	}
+
+	weight.Version = 1
+	err = r.apply(ctx, map[memoryKey]*entity.Weight{key: &weight})
+	return
+}
+
+// UpdateOne updates the weight of the key. When the version of weight is set the update only applies
+// to that version, ErrConflict is returned when the weight has been changed since.
+func (r *memoryRepository) UpdateOne(ctx context.Context, owner string, key int64, weight entity.Weight) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	err = r.update(ctx, memoryKey{owner, key}, weight)
+	return
+}
+
+// UpsertOne replaces the weight of the key or creates it when it does not exist yet.
+// When the version of weight is set it behaves as a conditional UpdateOne, as a weight of an expected version must exist.
+func (r *memoryRepository) UpsertOne(ctx context.Context, owner string, key int64, weight entity.Weight) (created bool, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	memKey := memoryKey{owner, key}
+	if _, ok := r.weights[memKey]; ok || weight.Version > 0 {
+		err = r.update(ctx, memKey, weight)
+		return
+	}
+
+	weight.Owner, weight.Date, weight.Version = owner, key, 1
+	if err = r.apply(ctx, map[memoryKey]*entity.Weight{memKey: &weight}); err != nil {
+		return
+	}
+
+	created = true
+	return
+}
+
+func (r *memoryRepository) FindMany(ctx context.Context, filter model.WeightFilter) (bunchOfWeight []entity.Weight, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	bunchOfWeight = r.match(filter)
+	if filter.Limit > 0 {
+		skip := (filter.Page - 1) * filter.Limit
+		if skip < 0 {
+			skip = 0
+		}
+		if skip > int64(len(bunchOfWeight)) {
+			skip = int64(len(bunchOfWeight))
+		}
+		end := skip + filter.Limit
+		if end > int64(len(bunchOfWeight)) {
+			end = int64(len(bunchOfWeight))
+		}
+		bunchOfWeight = bunchOfWeight[skip:end]
+	}
+
+	if len(bunchOfWeight) < 1 {
+		bunchOfWeight = nil
+		err = exception.ErrNotFound
+		return
+	}
+
+	return
+}
+
+// FindEach calls fn for each of the matching weights. Pagination of the filter is ignored.
+// The weights are copied before fn is called, so fn may write to the repository.
+func (r *memoryRepository) FindEach(ctx context.Context, filter model.WeightFilter, fn func(weight entity.Weight) error) (err error) {
+	r.mu.RLock()
+	bunchOfWeight := r.match(filter)
+	r.mu.RUnlock()
+
+	for _, weight := range bunchOfWeight {
+		if err = fn(weight); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func (r *memoryRepository) CountMany(ctx context.Context, filter model.WeightFilter) (total int64, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	total = int64(len(r.match(filter)))
+	return
+}
+
+// Averages averages the max, min and diff of the matching weights, the averages are zero when no weight matches.
+func (r *memoryRepository) Averages(ctx context.Context, filter model.WeightFilter) (average entity.WeightAverage, err error) {
+	r.mu.RLock()
+	bunchOfWeight := r.match(filter)
+	r.mu.RUnlock()
+
+	if len(bunchOfWeight) < 1 {
+		return
+	}
+	for _, weight := range bunchOfWeight {
+		average.Max += float64(weight.Max)
+		average.Min += float64(weight.Min)
+		average.Diff += float64(weight.Diff)
+	}
+	count := float64(len(bunchOfWeight))
+	average.Max /= count
+	average.Min /= count
+	average.Diff /= count
+	return
+}
+
+func (r *memoryRepository) FindOne(ctx context.Context, owner string, key int64) (weight entity.Weight, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	weight, ok := r.weights[memoryKey{owner, key}]
+	if !ok {
+		err = exception.ErrNotFound
+		return
+	}
+
+	return
+}
+
+func (r *memoryRepository) DeleteOne(ctx context.Context, owner string, key int64) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	memKey := memoryKey{owner, key}
+	if _, ok := r.weights[memKey]; !ok {
+		err = exception.ErrNotFound
+		return
+	}
+
+	err = r.apply(ctx, map[memoryKey]*entity.Weight{memKey: nil})
+	return
+}
+
+// Statistics groups the weights the same way as the aggregation of weightRepository,
+// by the ISO week, the month or the year of the date in UTC.
+func (r *memoryRepository) Statistics(ctx context.Context, filter model.StatisticFilter) (bunchOfStatistic []entity.WeightStatistic, err error) {
+	r.mu.RLock()
+	bunchOfWeight := r.match(model.WeightFilter{Owner: filter.Owner, From: filter.From, To: filter.To, SortBy: defaultSortBy, Sort: 1})
+	r.mu.RUnlock()
+
+	bunchOfStatistic = groupStatistics(bunchOfWeight, filter.GroupBy)
+	if len(bunchOfStatistic) < 1 {
+		err = exception.ErrNotFound
+		return
+	}
+
+	return
+}
+
+// BulkUpsert writes the weights in order as a whole. Existing dates are overwritten when overwrite is true,
+// otherwise they are left untouched. The returned slice tells which weights were newly inserted.
+func (r *memoryRepository) BulkUpsert(ctx context.Context, owner string, bunchOfWeight []entity.Weight, overwrite bool) (inserted []bool, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	changes := make(map[memoryKey]*entity.Weight, len(bunchOfWeight))
+	result := make([]bool, len(bunchOfWeight))
+	for i := range bunchOfWeight {
+		weight := bunchOfWeight[i]
+		key := memoryKey{owner, weight.Date}
+		existing, ok := r.weights[key]
+		if change, changed := changes[key]; changed {
+			existing, ok = *change, true
+		}
+
+		switch {
+		case !ok:
+			weight.Owner, weight.Version = owner, 1
+			result[i] = true
+		case overwrite:
+			weight.Owner, weight.Version = owner, existing.Version+1
+		default:
+			continue
+		}
+		changes[key] = &weight
+	}
+
+	if err = r.apply(ctx, changes); err != nil {
+		return
+	}
+
+	inserted = result
+	return
+}
+
+// update applies weight to the existing weight of the key, the caller must hold the write lock.
+func (r *memoryRepository) update(ctx context.Context, key memoryKey, weight entity.Weight) (err error) {
+	existing, ok := r.weights[key]
+	if !ok {
+		err = exception.ErrNotFound
+		return
+	}
+	if weight.Version > 0 && weight.Version != existing.Version {
+		err = exception.ErrConflict
+		return
+	}
+
+	weight.Owner, weight.Date, weight.Version = key.owner, key.date, existing.Version+1
+	err = r.apply(ctx, map[memoryKey]*entity.Weight{key: &weight})
+	return
+}
+
+// apply persists the weights with the changes applied and then applies them to the map, a nil change deletes the weight.
+// The caller must hold the write lock.
+func (r *memoryRepository) apply(ctx context.Context, changes map[memoryKey]*entity.Weight) (err error) {
+	if r.persist != nil {
+		bunchOfWeight := make([]entity.Weight, 0, len(r.weights)+len(changes))
+		for key, weight := range r.weights {
+			if _, changed := changes[key]; !changed {
+				bunchOfWeight = append(bunchOfWeight, weight)
+			}
+		}
+		for _, weight := range changes {
+			if weight != nil {
+				bunchOfWeight = append(bunchOfWeight, *weight)
+			}
+		}
+
+		if err = r.persist(bunchOfWeight); err != nil {
+			requestctx.Logger(ctx, r.logger).Error(err)
+			err = exception.ErrInternalServer
+			return
+		}
+	}
+
+	for key, weight := range changes {
+		if weight == nil {
+			delete(r.weights, key)
+			continue
+		}
+		r.weights[key] = *weight
+	}
+	return
+}
+
+// match returns the weights of the filter sorted by its field, the caller must hold the read lock.
+// Weights of the same value are sorted by date, so the order is stable between calls.
+func (r *memoryRepository) match(filter model.WeightFilter) (bunchOfWeight []entity.Weight) {
+	for key, weight := range r.weights {
+		if key.owner != filter.Owner {
+			continue
+		}
+		if (filter.From != 0 && key.date < filter.From) || (filter.To != 0 && key.date > filter.To) {
+			continue
+		}
+		bunchOfWeight = append(bunchOfWeight, weight)
+	}
+
+	sort.Slice(bunchOfWeight, func(i, j int) bool {
+		a, b := sortValue(bunchOfWeight[i], filter.SortBy), sortValue(bunchOfWeight[j], filter.SortBy)
+		if a == b {
+			a, b = bunchOfWeight[i].Date, bunchOfWeight[j].Date
+		}
+		if filter.Sort < 0 {
+			return a > b
+		}
+		return a < b
+	})
+	return
+}
+
+func sortValue(weight entity.Weight, field string) int64 {
+	switch field {
+	case "max":
+		return int64(weight.Max)
+	case "min":
+		return int64(weight.Min)
+	case "diff":
+		return int64(weight.Diff)
+	default:
+		return weight.Date
+	}
+}
+
+// groupStatistics computes the statistics of the weights of each period, sorted by period.
+func groupStatistics(bunchOfWeight []entity.Weight, groupBy string) (bunchOfStatistic []entity.WeightStatistic) {
+	groups := make(map[entity.WeightStatisticPeriod][]entity.Weight)
+	for _, weight := range bunchOfWeight {
+		period := statisticPeriod(weight.Date, groupBy)
+		groups[period] = append(groups[period], weight)
+	}
+
+	for period, group := range groups {
+		statistic := entity.WeightStatistic{Period: period, Count: int64(len(group))}
+		statistic.Max = statisticField(group, func(weight entity.Weight) int { return weight.Max })
+		statistic.Min = statisticField(group, func(weight entity.Weight) int { return weight.Min })
+		statistic.Diff = statisticField(group, func(weight entity.Weight) int { return weight.Diff })
+		bunchOfStatistic = append(bunchOfStatistic, statistic)
+	}
+	sort.Slice(bunchOfStatistic, func(i, j int) bool {
+		a, b := bunchOfStatistic[i].Period, bunchOfStatistic[j].Period
+		if a.Year != b.Year {
+			return a.Year < b.Year
+		}
+		if a.Month != b.Month {
+			return a.Month < b.Month
+		}
+		return a.Week < b.Week
+	})
+
+	return
+}
+
+func statisticPeriod(date int64, groupBy string) (period entity.WeightStatisticPeriod) {
+	t := time.Unix(0, date).UTC()
+	switch groupBy {
+	case groupByWeek:
+		period.Year, period.Week = t.ISOWeek()
+	case groupByMonth:
+		period.Year, period.Month = t.Year(), int(t.Month())
+	case groupByYear:
+		period.Year = t.Year()
+	}
+	return
+}
+
+// statisticField computes the same figures as the $group stage, the standard deviation is of the population.
+func statisticField(bunchOfWeight []entity.Weight, value func(weight entity.Weight) int) (field entity.WeightStatisticField) {
+	field.Values = make([]int, 0, len(bunchOfWeight))
+	sum := 0.0
+	for i, weight := range bunchOfWeight {
+		v := value(weight)
+		if i == 0 || v < field.Min {
+			field.Min = v
+		}
+		if i == 0 || v > field.Max {
+			field.Max = v
+		}
+		sum += float64(v)
+		field.Values = append(field.Values, v)
+	}
+
+	field.Mean = sum / float64(len(bunchOfWeight))
+	variance := 0.0
+	for _, v := range field.Values {
+		variance += math.Pow(float64(v)-field.Mean, 2)
+	}
+	field.StdDev = math.Sqrt(variance / float64(len(bunchOfWeight)))
+	return
+}
@@ -0,0 +1,224 @@
+package weight
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/ijalalfrz/sirclo-weight-test/entity"
+	"github.com/ijalalfrz/sirclo-weight-test/exception"
+	"github.com/ijalalfrz/sirclo-weight-test/model"
+	"github.com/ijalalfrz/sirclo-weight-test/outbox"
+	"github.com/ijalalfrz/sirclo-weight-test/requestctx"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// collection of weight event type
+const (
+	eventWeightCreated = "weight.created"
+	eventWeightUpdated = "weight.updated"
+	eventWeightDeleted = "weight.deleted"
+)
+
+// EventTypes returns the types of the weight events.
+func EventTypes() []string {
+	return []string{eventWeightCreated, eventWeightUpdated, eventWeightDeleted}
+}
+
+// outboxRepository is a decorator of Repository which inserts a weight.created, weight.updated or weight.deleted
+// event of every written weight into the outbox, in the same transaction as the write. The payload is the weight
+// as it is stored, read back in the transaction or read before a delete, and the key of the event is the owner.
+// Without a transactor the write and the events are not atomic, which is only fit for the in-memory storages.
+type outboxRepository struct {
+	next       Repository
+	outbox     outbox.Repository
+	transactor outbox.Transactor
+	logger     *logrus.Logger
+}
+
+// NewOutboxRepository is a constructor. transactor must be the one of the storage of next, or nil.
+func NewOutboxRepository(next Repository, events outbox.Repository, transactor outbox.Transactor, logger *logrus.Logger) Repository {
+	return outboxRepository{
+		next:       next,
+		outbox:     events,
+		transactor: transactor,
+		logger:     logger,
+	}
+}
+
+func (r outboxRepository) InsertOne(ctx context.Context, weight entity.Weight) (err error) {
+	return transaction(ctx, r.transactor, r.logger, func(ctx context.Context) (err error) {
+		if err = r.next.InsertOne(ctx, weight); err != nil {
+			return
+		}
+		return r.record(ctx, weight.Owner, map[int64]string{weight.Date: eventWeightCreated})
+	})
+}
+
+func (r outboxRepository) UpdateOne(ctx context.Context, owner string, key int64, weight entity.Weight) (err error) {
+	return transaction(ctx, r.transactor, r.logger, func(ctx context.Context) (err error) {
+		if err = r.next.UpdateOne(ctx, owner, key, weight); err != nil {
+			return
+		}
+		return r.record(ctx, owner, map[int64]string{key: eventWeightUpdated})
+	})
+}
+
+func (r outboxRepository) UpsertOne(ctx context.Context, owner string, key int64, weight entity.Weight) (created bool, err error) {
+	err = transaction(ctx, r.transactor, r.logger, func(ctx context.Context) (err error) {
+		if created, err = r.next.UpsertOne(ctx, owner, key, weight); err != nil {
+			return
+		}
+
+		eventType := eventWeightUpdated
+		if created {
+			eventType = eventWeightCreated
+		}
+		return r.record(ctx, owner, map[int64]string{key: eventType})
+	})
+	return
+}
+
+func (r outboxRepository) FindMany(ctx context.Context, filter model.WeightFilter) (bunchOfWeight []entity.Weight, err error) {
+	return r.next.FindMany(ctx, filter)
+}
+
+func (r outboxRepository) CountMany(ctx context.Context, filter model.WeightFilter) (total int64, err error) {
+	return r.next.CountMany(ctx, filter)
+}
+
+func (r outboxRepository) Averages(ctx context.Context, filter model.WeightFilter) (average entity.WeightAverage, err error) {
+	return r.next.Averages(ctx, filter)
+}
+
+func (r outboxRepository) FindOne(ctx context.Context, owner string, key int64) (weight entity.Weight, err error) {
+	return r.next.FindOne(ctx, owner, key)
+}
+
+func (r outboxRepository) DeleteOne(ctx context.Context, owner string, key int64) (err error) {
+	return transaction(ctx, r.transactor, r.logger, func(ctx context.Context) (err error) {
+		weight, err := r.next.FindOne(ctx, owner, key)
+		if err != nil {
+			return
+		}
+		if err = r.next.DeleteOne(ctx, owner, key); err != nil {
+			return
+		}
+
+		event, err := r.event(ctx, eventWeightDeleted, owner, weight)
+		if err != nil {
+			return
+		}
+		return r.outbox.InsertMany(ctx, []entity.Event{event})
+	})
+}
+
+func (r outboxRepository) Statistics(ctx context.Context, filter model.StatisticFilter) (bunchOfStatistic []entity.WeightStatistic, err error) {
+	return r.next.Statistics(ctx, filter)
+}
+
+// BulkUpsert records an event of every inserted weight, and of every overwritten one when overwrite is true.
+func (r outboxRepository) BulkUpsert(ctx context.Context, owner string, bunchOfWeight []entity.Weight, overwrite bool) (inserted []bool, err error) {
+	err = transaction(ctx, r.transactor, r.logger, func(ctx context.Context) (err error) {
+		if inserted, err = r.next.BulkUpsert(ctx, owner, bunchOfWeight, overwrite); err != nil {
+			return
+		}
+
+		written := make(map[int64]string)
+		for i, weight := range bunchOfWeight {
+			switch {
+			case inserted[i]:
+				written[weight.Date] = eventWeightCreated
+			case overwrite:
+				written[weight.Date] = eventWeightUpdated
+			}
+		}
+		return r.record(ctx, owner, written)
+	})
+	return
+}
+
+func (r outboxRepository) FindEach(ctx context.Context, filter model.WeightFilter, fn func(weight entity.Weight) error) (err error) {
+	return r.next.FindEach(ctx, filter, fn)
+}
+
+// transaction runs fn in a transaction of transactor, or as is when transactor is nil. The error of fn is returned as is,
+// a failure of the transaction itself is logged.
+func transaction(ctx context.Context, transactor outbox.Transactor, logger *logrus.Logger, fn func(ctx context.Context) error) (err error) {
+	if transactor == nil {
+		return fn(ctx)
+	}
+
+	var errFn error
+	err = transactor.Transaction(ctx, func(ctx context.Context) error {
+		errFn = fn(ctx)
+		return errFn
+	})
+	if err != nil && err != errFn {
+		requestctx.Logger(ctx, logger).Error(err)
+		err = exception.ErrInternalServer
+	}
+	return
+}
+
+// record inserts the event of each written date of owner into the outbox, in the order of the dates.
+func (r outboxRepository) record(ctx context.Context, owner string, written map[int64]string) (err error) {
+	if len(written) < 1 {
+		return
+	}
+
+	filter := model.WeightFilter{Owner: owner, SortBy: defaultSortBy, Sort: 1}
+	for date := range written {
+		if filter.From == 0 || date < filter.From {
+			filter.From = date
+		}
+		if date > filter.To {
+			filter.To = date
+		}
+	}
+
+	var events []entity.Event
+	createdAt := time.Now().UnixNano()
+	err = r.next.FindEach(ctx, filter, func(weight entity.Weight) error {
+		eventType, ok := written[weight.Date]
+		if !ok {
+			return nil
+		}
+
+		event, err := r.event(ctx, eventType, owner, weight)
+		if err != nil {
+			return err
+		}
+		event.CreatedAt = createdAt
+		events = append(events, event)
+		return nil
+	})
+	if err != nil {
+		return
+	}
+
+	return r.outbox.InsertMany(ctx, events)
+}
+
+// event returns the event of eventType of the weight of owner.
+func (r outboxRepository) event(ctx context.Context, eventType string, owner string, weight entity.Weight) (event entity.Event, err error) {
+	payload, err := json.Marshal(weight)
+	if err != nil {
+		r.log(ctx).Error(err)
+		return event, exception.ErrInternalServer
+	}
+
+	event = entity.Event{
+		ID:        primitive.NewObjectID().Hex(),
+		Type:      eventType,
+		Key:       owner,
+		Payload:   payload,
+		CreatedAt: time.Now().UnixNano(),
+	}
+	return
+}
+
+func (r outboxRepository) log(ctx context.Context) *logrus.Entry {
+	return requestctx.Logger(ctx, r.logger)
+}
@@ -0,0 +1,113 @@
+package weight_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/ijalalfrz/sirclo-weight-test/entity"
+	"github.com/ijalalfrz/sirclo-weight-test/exception"
+	"github.com/ijalalfrz/sirclo-weight-test/outbox"
+	"github.com/ijalalfrz/sirclo-weight-test/sqldb"
+	"github.com/ijalalfrz/sirclo-weight-test/weight"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingTransactor is a transactor whose commit always fails.
+type failingTransactor struct{}
+
+func (failingTransactor) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := fn(ctx); err != nil {
+		return err
+	}
+	return errors.New("commit failed")
+}
+
+// pendingWeights returns the type and the payload of the events of the outbox, the oldest first.
+func pendingWeights(t *testing.T, events outbox.Repository) (types []string, weights []entity.Weight) {
+	pending, err := events.FindPending(context.TODO(), math.MaxInt64, 100)
+	require.NoError(t, err)
+	for _, event := range pending {
+		var weight entity.Weight
+		require.NoError(t, json.Unmarshal(event.Payload, &weight))
+		assert.Equal(t, weight.Owner, event.Key, "should be keyed by owner")
+		types = append(types, event.Type)
+		weights = append(weights, weight)
+	}
+	return
+}
+
+func TestOutboxRepository_Success(t *testing.T) {
+	ctx := context.TODO()
+	events := outbox.NewMemoryRepository()
+	repo := weight.NewOutboxRepository(weight.NewMemoryRepository(logrus.New()), events, nil, logrus.New())
+
+	require.NoError(t, repo.InsertOne(ctx, conformanceWeight("john", 1, 3, 1)))
+	require.NoError(t, repo.UpdateOne(ctx, "john", day(1), conformanceWeight("john", 1, 5, 1)))
+	created, err := repo.UpsertOne(ctx, "john", day(2), conformanceWeight("john", 2, 4, 2))
+	require.NoError(t, err)
+	assert.True(t, created)
+	_, err = repo.BulkUpsert(ctx, "john", []entity.Weight{conformanceWeight("john", 2, 6, 2), conformanceWeight("john", 3, 7, 2)}, false)
+	require.NoError(t, err)
+	require.NoError(t, repo.DeleteOne(ctx, "john", day(1)))
+
+	types, weights := pendingWeights(t, events)
+	assert.Equal(t, []string{"weight.created", "weight.updated", "weight.created", "weight.created", "weight.deleted"}, types,
+		"should skip the weights left untouched by a bulk upsert")
+	if assert.Len(t, weights, 5) {
+		assert.Equal(t, int64(1), weights[0].Version)
+		assert.Equal(t, 5, weights[1].Max)
+		assert.Equal(t, int64(2), weights[1].Version, "should carry the stored version")
+		assert.Equal(t, day(3), weights[3].Date)
+		assert.Equal(t, 5, weights[4].Max, "should carry the deleted weight")
+	}
+}
+
+func TestOutboxRepository_Error_NotRecorded(t *testing.T) {
+	events := outbox.NewMemoryRepository()
+	repo := weight.NewOutboxRepository(weight.NewMemoryRepository(logrus.New()), events, nil, logrus.New())
+
+	err := repo.UpdateOne(context.TODO(), "john", day(1), conformanceWeight("john", 1, 5, 1))
+	assert.Equal(t, exception.ErrNotFound, err)
+	err = repo.DeleteOne(context.TODO(), "john", day(1))
+	assert.Equal(t, exception.ErrNotFound, err)
+
+	types, _ := pendingWeights(t, events)
+	assert.Empty(t, types, "should not record a failed write")
+}
+
+func TestOutboxRepository_Error_RolledBack(t *testing.T) {
+	db := openSQL(t, sqldb.DriverSQLite, filepath.Join(t.TempDir(), "weights.db"))
+	events := outbox.NewSQLRepository(logrus.New(), db)
+	repo := weight.NewOutboxRepository(weight.NewSQLRepository(logrus.New(), db), events, db, logrus.New())
+
+	require.NoError(t, repo.InsertOne(context.TODO(), conformanceWeight("john", 1, 3, 1)))
+	types, _ := pendingWeights(t, events)
+	assert.Equal(t, []string{"weight.created"}, types)
+
+	_, err := db.Exec("DROP TABLE outbox")
+	require.NoError(t, err)
+	_, err = repo.BulkUpsert(context.TODO(), "john", []entity.Weight{conformanceWeight("john", 1, 9, 1), conformanceWeight("john", 2, 9, 1)}, true)
+	assert.Equal(t, exception.ErrInternalServer, err, "should be internal server error")
+
+	stored, err := weight.NewSQLRepository(logrus.New(), db).FindOne(context.TODO(), "john", day(1))
+	require.NoError(t, err)
+	assert.Equal(t, 3, stored.Max, "should roll back the write when its events can not be recorded")
+	_, err = weight.NewSQLRepository(logrus.New(), db).FindOne(context.TODO(), "john", day(2))
+	assert.Equal(t, exception.ErrNotFound, err, "should roll back the write when its events can not be recorded")
+}
+
+func TestOutboxRepository_Error_Transaction(t *testing.T) {
+	repo := weight.NewOutboxRepository(weight.NewMemoryRepository(logrus.New()), outbox.NewMemoryRepository(), failingTransactor{}, logrus.New())
+
+	err := repo.InsertOne(context.TODO(), conformanceWeight("john", 1, 3, 1))
+	assert.Equal(t, exception.ErrInternalServer, err, "should be internal server error")
+
+	_, err = repo.UpsertOne(context.TODO(), "john", day(2), entity.Weight{Version: 3})
+	assert.Equal(t, exception.ErrNotFound, err, "should return the error of the write as is")
+}
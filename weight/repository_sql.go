@@ -0,0 +1,355 @@
+package weight
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/ijalalfrz/sirclo-weight-test/entity"
+	"github.com/ijalalfrz/sirclo-weight-test/exception"
+	"github.com/ijalalfrz/sirclo-weight-test/model"
+	"github.com/ijalalfrz/sirclo-weight-test/requestctx"
+	"github.com/ijalalfrz/sirclo-weight-test/sqldb"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	weightColumns       = "owner, date, day, max, min, diff, version"
+	insertWeightQuery   = "INSERT INTO weight (" + weightColumns + ") VALUES (?, ?, ?, ?, ?, ?, 1)"
+	upsertWeightQuery   = insertWeightQuery + " ON CONFLICT (owner, date) DO UPDATE SET day = excluded.day, max = excluded.max, min = excluded.min, diff = excluded.diff, version = weight.version + 1 RETURNING version"
+	insertMissingQuery  = insertWeightQuery + " ON CONFLICT (owner, date) DO NOTHING"
+	updateWeightQuery   = "UPDATE weight SET day = ?, max = ?, min = ?, diff = ?, version = version + 1 WHERE owner = ? AND date = ?"
+	selectWeightQuery   = "SELECT " + weightColumns + " FROM weight WHERE owner = ? AND date = ?"
+	countWeightQuery    = "SELECT COUNT(*) FROM weight WHERE owner = ? AND date = ?"
+	deleteWeightQuery   = "DELETE FROM weight WHERE owner = ? AND date = ?"
+	selectWeightsQuery  = "SELECT " + weightColumns + " FROM weight"
+	countWeightsQuery   = "SELECT COUNT(*) FROM weight"
+	averageWeightsQuery = "SELECT COALESCE(AVG(max), 0), COALESCE(AVG(min), 0), COALESCE(AVG(diff), 0) FROM weight"
+	sqlPaginationClause = " LIMIT ? OFFSET ?"
+)
+
+// sqlSortColumns maps the sortable fields to their column, so the sort of a filter is never written into a query as is.
+var sqlSortColumns = map[string]string{
+	"date": "date",
+	"max":  "max",
+	"min":  "min",
+	"diff": "diff",
+}
+
+type sqlRepository struct {
+	logger *logrus.Logger
+	db     *sqldb.DB
+}
+
+// NewSQLRepository is a constructor of a Repository on the weight table of a PostgreSQL or SQLite database.
+func NewSQLRepository(logger *logrus.Logger, db *sqldb.DB) Repository {
+	return &sqlRepository{logger, db}
+}
+
+func (r sqlRepository) InsertOne(ctx context.Context, weight entity.Weight) (err error) {
+	_, err = r.db.Executor(ctx).ExecContext(ctx, r.db.Rebind(insertWeightQuery), weight.Owner, weight.Date, weight.Day, weight.Max, weight.Min, weight.Diff)
+	if err != nil {
+		r.log(ctx).Error(err)
+		err = r.writeError(err)
+		return
+	}
+	return
+}
+
+// UpdateOne updates the weight of the key. When the version of weight is set the update only applies
+// to that version, ErrConflict is returned when the weight has been changed since.
+func (r sqlRepository) UpdateOne(ctx context.Context, owner string, key int64, weight entity.Weight) (err error) {
+	query := updateWeightQuery
+	args := []interface{}{weight.Day, weight.Max, weight.Min, weight.Diff, owner, key}
+	if weight.Version > 0 {
+		query += " AND version = ?"
+		args = append(args, weight.Version)
+	}
+
+	result, err := r.db.Executor(ctx).ExecContext(ctx, r.db.Rebind(query), args...)
+	if err != nil {
+		r.log(ctx).Error(err)
+		err = r.writeError(err)
+		return
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		r.log(ctx).Error(err)
+		err = exception.ErrInternalServer
+		return
+	}
+	if affected < 1 {
+		err = r.unmatchedError(ctx, owner, key, weight.Version)
+		return
+	}
+
+	return
+}
+
+// UpsertOne replaces the weight of the key or creates it when it does not exist yet.
+// When the version of weight is set it behaves as a conditional UpdateOne, as a weight of an expected version must exist.
+func (r sqlRepository) UpsertOne(ctx context.Context, owner string, key int64, weight entity.Weight) (created bool, err error) {
+	if weight.Version > 0 {
+		err = r.UpdateOne(ctx, owner, key, weight)
+		return
+	}
+
+	var version int64
+	err = r.db.Executor(ctx).QueryRowContext(ctx, r.db.Rebind(upsertWeightQuery), owner, key, weight.Day, weight.Max, weight.Min, weight.Diff).Scan(&version)
+	if err != nil {
+		r.log(ctx).Error(err)
+		err = r.writeError(err)
+		return
+	}
+
+	// an updated weight is at least at its second version.
+	created = version == 1
+	return
+}
+
+func (r sqlRepository) FindMany(ctx context.Context, filter model.WeightFilter) (bunchOfWeight []entity.Weight, err error) {
+	query, args := r.buildQuery(selectWeightsQuery, filter)
+	query += r.orderClause(filter)
+	if filter.Limit > 0 {
+		offset := (filter.Page - 1) * filter.Limit
+		if offset < 0 {
+			offset = 0
+		}
+		query += sqlPaginationClause
+		args = append(args, filter.Limit, offset)
+	}
+
+	err = r.query(ctx, query, args, func(weight entity.Weight) error {
+		bunchOfWeight = append(bunchOfWeight, weight)
+		return nil
+	})
+	if err != nil {
+		return
+	}
+
+	if len(bunchOfWeight) < 1 {
+		err = exception.ErrNotFound
+		return
+	}
+
+	return
+}
+
+// FindEach iterates the rows of the matching weights and calls fn for each of them,
+// so the caller does not need to hold the whole result set in memory. Pagination of the filter is ignored.
+// The connection is held while iterating, fn must not write to the repository as SQLite only has one.
+func (r sqlRepository) FindEach(ctx context.Context, filter model.WeightFilter, fn func(weight entity.Weight) error) (err error) {
+	query, args := r.buildQuery(selectWeightsQuery, filter)
+	err = r.query(ctx, query+r.orderClause(filter), args, fn)
+	return
+}
+
+func (r sqlRepository) CountMany(ctx context.Context, filter model.WeightFilter) (total int64, err error) {
+	query, args := r.buildQuery(countWeightsQuery, filter)
+	if err = r.db.Executor(ctx).QueryRowContext(ctx, r.db.Rebind(query), args...).Scan(&total); err != nil {
+		r.log(ctx).Error(err)
+		err = exception.ErrInternalServer
+		return
+	}
+
+	return
+}
+
+// Averages averages the max, min and diff of the matching weights, the averages are zero when no weight matches.
+func (r sqlRepository) Averages(ctx context.Context, filter model.WeightFilter) (average entity.WeightAverage, err error) {
+	query, args := r.buildQuery(averageWeightsQuery, filter)
+	if err = r.db.Executor(ctx).QueryRowContext(ctx, r.db.Rebind(query), args...).Scan(&average.Max, &average.Min, &average.Diff); err != nil {
+		r.log(ctx).Error(err)
+		err = exception.ErrInternalServer
+		return
+	}
+
+	return
+}
+
+func (r sqlRepository) FindOne(ctx context.Context, owner string, key int64) (weight entity.Weight, err error) {
+	row := r.db.Executor(ctx).QueryRowContext(ctx, r.db.Rebind(selectWeightQuery), owner, key)
+	if err = row.Scan(&weight.Owner, &weight.Date, &weight.Day, &weight.Max, &weight.Min, &weight.Diff, &weight.Version); err != nil {
+		if err != sql.ErrNoRows {
+			r.log(ctx).Error(err)
+			err = exception.ErrInternalServer
+			return
+		}
+		err = exception.ErrNotFound
+		return
+	}
+
+	return
+}
+
+func (r sqlRepository) DeleteOne(ctx context.Context, owner string, key int64) (err error) {
+	result, err := r.db.Executor(ctx).ExecContext(ctx, r.db.Rebind(deleteWeightQuery), owner, key)
+	if err != nil {
+		r.log(ctx).Error(err)
+		err = exception.ErrInternalServer
+		return
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		r.log(ctx).Error(err)
+		err = exception.ErrInternalServer
+		return
+	}
+	if affected < 1 {
+		err = exception.ErrNotFound
+		return
+	}
+
+	return
+}
+
+// Statistics groups the matching weights by period. The date functions differ between the dialects,
+// so the weights are grouped in the same way as the in-memory repository does.
+func (r sqlRepository) Statistics(ctx context.Context, filter model.StatisticFilter) (bunchOfStatistic []entity.WeightStatistic, err error) {
+	var bunchOfWeight []entity.Weight
+	err = r.FindEach(ctx, model.WeightFilter{Owner: filter.Owner, From: filter.From, To: filter.To, SortBy: defaultSortBy, Sort: 1}, func(weight entity.Weight) error {
+		bunchOfWeight = append(bunchOfWeight, weight)
+		return nil
+	})
+	if err != nil {
+		return
+	}
+
+	bunchOfStatistic = groupStatistics(bunchOfWeight, filter.GroupBy)
+	if len(bunchOfStatistic) < 1 {
+		err = exception.ErrNotFound
+		return
+	}
+
+	return
+}
+
+// BulkUpsert writes weights in order in one transaction. Existing dates are overwritten when overwrite is true,
+// otherwise they are left untouched. The returned slice tells which weights were newly inserted.
+func (r sqlRepository) BulkUpsert(ctx context.Context, owner string, bunchOfWeight []entity.Weight, overwrite bool) (inserted []bool, err error) {
+	result := make([]bool, len(bunchOfWeight))
+	err = r.db.Transaction(ctx, func(ctx context.Context) (err error) {
+		tx := r.db.Executor(ctx)
+		for i, weight := range bunchOfWeight {
+			args := []interface{}{owner, weight.Date, weight.Day, weight.Max, weight.Min, weight.Diff}
+			if overwrite {
+				var version int64
+				if err = tx.QueryRowContext(ctx, r.db.Rebind(upsertWeightQuery), args...).Scan(&version); err != nil {
+					return
+				}
+				result[i] = version == 1
+				continue
+			}
+
+			written, err := tx.ExecContext(ctx, r.db.Rebind(insertMissingQuery), args...)
+			if err != nil {
+				return err
+			}
+			affected, err := written.RowsAffected()
+			if err != nil {
+				return err
+			}
+			result[i] = affected > 0
+		}
+		return
+	})
+	if err != nil {
+		r.log(ctx).Error(err)
+		err = r.writeError(err)
+		return
+	}
+
+	inserted = result
+	return
+}
+
+func (r sqlRepository) log(ctx context.Context) *logrus.Entry {
+	return requestctx.Logger(ctx, r.logger)
+}
+
+// writeError maps the error of a write, a unique violation means the weight of the date already exists.
+func (r sqlRepository) writeError(err error) error {
+	if sqldb.IsUniqueViolation(err) {
+		return exception.ErrConflict
+	}
+	return exception.ErrInternalServer
+}
+
+// unmatchedError tells why a conditional update did not match, the weight is either missing or of another version.
+func (r sqlRepository) unmatchedError(ctx context.Context, owner string, key int64, version int64) error {
+	if version < 1 {
+		return exception.ErrNotFound
+	}
+
+	var total int64
+	if err := r.db.Executor(ctx).QueryRowContext(ctx, r.db.Rebind(countWeightQuery), owner, key).Scan(&total); err != nil {
+		r.log(ctx).Error(err)
+		return exception.ErrInternalServer
+	}
+	if total > 0 {
+		return exception.ErrConflict
+	}
+	return exception.ErrNotFound
+}
+
+// query runs query and calls fn with the weight of each row.
+func (r sqlRepository) query(ctx context.Context, query string, args []interface{}, fn func(weight entity.Weight) error) (err error) {
+	rows, err := r.db.Executor(ctx).QueryContext(ctx, r.db.Rebind(query), args...)
+	if err != nil {
+		r.log(ctx).Error(err)
+		err = exception.ErrInternalServer
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		weight := entity.Weight{}
+		if err = rows.Scan(&weight.Owner, &weight.Date, &weight.Day, &weight.Max, &weight.Min, &weight.Diff, &weight.Version); err != nil {
+			r.log(ctx).Error(err)
+			err = exception.ErrInternalServer
+			return
+		}
+
+		if err = fn(weight); err != nil {
+			return
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		r.log(ctx).Error(err)
+		err = exception.ErrInternalServer
+		return
+	}
+
+	return
+}
+
+func (r sqlRepository) buildQuery(query string, filter model.WeightFilter) (string, []interface{}) {
+	conditions := []string{"owner = ?"}
+	args := []interface{}{filter.Owner}
+	if filter.From != 0 {
+		conditions = append(conditions, "date >= ?")
+		args = append(args, filter.From)
+	}
+	if filter.To != 0 {
+		conditions = append(conditions, "date <= ?")
+		args = append(args, filter.To)
+	}
+	return query + " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// orderClause sorts by the field of filter, weights of the same value are sorted by date.
+func (r sqlRepository) orderClause(filter model.WeightFilter) string {
+	column, ok := sqlSortColumns[filter.SortBy]
+	if !ok {
+		column = "date"
+	}
+	direction := "ASC"
+	if filter.Sort < 0 {
+		direction = "DESC"
+	}
+	return fmt.Sprintf(" ORDER BY %s %s, date %s", column, direction, direction)
+}
@@ -3,14 +3,17 @@ package weight_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/ijalalfrz/sirclo-weight-test/entity"
 	"github.com/ijalalfrz/sirclo-weight-test/exception"
+	"github.com/ijalalfrz/sirclo-weight-test/model"
 	"github.com/ijalalfrz/sirclo-weight-test/mongodb/mocks"
 	"github.com/ijalalfrz/sirclo-weight-test/weight"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -54,12 +57,12 @@ func TestUpdateOne_Success(t *testing.T) {
 	col := new(mocks.Collection)
 	db := new(mocks.Database)
 
-	col.On("UpdateOne", mock.Anything, mock.Anything, mock.Anything, options.Update().SetUpsert(true)).Return(updateResult, nil)
+	col.On("UpdateOne", mock.Anything, mock.Anything, mock.Anything).Return(updateResult, nil)
 	db.On("Collection", mock.AnythingOfType("string")).Return(col)
 
 	repo := weight.NewWeightRepository(logrus.New(), db)
 
-	err := repo.UpdateOne(context.TODO(), 1, entity.Weight{})
+	err := repo.UpdateOne(context.TODO(), "", 1, entity.Weight{})
 	assert.NoError(t, err, "should be no error")
 	col.AssertExpectations(t)
 	db.AssertExpectations(t)
@@ -70,12 +73,12 @@ func TestUpdateOne_Error_Unexpected(t *testing.T) {
 	col := new(mocks.Collection)
 	db := new(mocks.Database)
 
-	col.On("UpdateOne", mock.Anything, mock.Anything, mock.Anything, options.Update().SetUpsert(true)).Return(nil, exception.ErrInternalServer)
+	col.On("UpdateOne", mock.Anything, mock.Anything, mock.Anything).Return(nil, exception.ErrInternalServer)
 	db.On("Collection", mock.AnythingOfType("string")).Return(col)
 
 	repo := weight.NewWeightRepository(logrus.New(), db)
 
-	err := repo.UpdateOne(context.TODO(), 1, entity.Weight{})
+	err := repo.UpdateOne(context.TODO(), "", 1, entity.Weight{})
 	assert.Error(t, err, "should be error")
 	assert.Equal(t, err, exception.ErrInternalServer, "should be error")
 	col.AssertExpectations(t)
@@ -90,17 +93,71 @@ func TestUpdateOne_Error_NotFound(t *testing.T) {
 	col := new(mocks.Collection)
 	db := new(mocks.Database)
 
-	col.On("UpdateOne", mock.Anything, mock.Anything, mock.Anything, options.Update().SetUpsert(true)).Return(updateResult, nil)
+	col.On("UpdateOne", mock.Anything, mock.Anything, mock.Anything).Return(updateResult, nil)
 	db.On("Collection", mock.AnythingOfType("string")).Return(col)
 
 	repo := weight.NewWeightRepository(logrus.New(), db)
 
-	err := repo.UpdateOne(context.TODO(), 1, entity.Weight{})
+	err := repo.UpdateOne(context.TODO(), "", 1, entity.Weight{})
 	assert.Error(t, err, "should be error")
 	assert.Equal(t, err, exception.ErrNotFound, "should be not found error")
 	col.AssertExpectations(t)
 	db.AssertExpectations(t)
 }
+
+func TestUpsertOne_Success_Created(t *testing.T) {
+	updateResult := &mongo.UpdateResult{
+		UpsertedCount: 1,
+	}
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+
+	col.On("UpdateOne", mock.Anything, bson.M{"owner": "john", "date": int64(1)}, mock.Anything, options.Update().SetUpsert(true)).Return(updateResult, nil)
+	db.On("Collection", mock.AnythingOfType("string")).Return(col)
+
+	repo := weight.NewWeightRepository(logrus.New(), db)
+
+	created, err := repo.UpsertOne(context.TODO(), "john", 1, entity.Weight{})
+	assert.NoError(t, err, "should be no error")
+	assert.True(t, created, "should be created")
+	col.AssertExpectations(t)
+	db.AssertExpectations(t)
+}
+
+func TestUpsertOne_Success_Updated(t *testing.T) {
+	updateResult := &mongo.UpdateResult{
+		MatchedCount:  1,
+		ModifiedCount: 1,
+	}
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+
+	col.On("UpdateOne", mock.Anything, mock.Anything, mock.Anything, options.Update().SetUpsert(true)).Return(updateResult, nil)
+	db.On("Collection", mock.AnythingOfType("string")).Return(col)
+
+	repo := weight.NewWeightRepository(logrus.New(), db)
+
+	created, err := repo.UpsertOne(context.TODO(), "john", 1, entity.Weight{})
+	assert.NoError(t, err, "should be no error")
+	assert.False(t, created, "should be updated")
+	col.AssertExpectations(t)
+	db.AssertExpectations(t)
+}
+
+func TestUpsertOne_Error_Unexpected(t *testing.T) {
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+
+	col.On("UpdateOne", mock.Anything, mock.Anything, mock.Anything, options.Update().SetUpsert(true)).Return(nil, mongo.ErrClientDisconnected)
+	db.On("Collection", mock.AnythingOfType("string")).Return(col)
+
+	repo := weight.NewWeightRepository(logrus.New(), db)
+
+	_, err := repo.UpsertOne(context.TODO(), "john", 1, entity.Weight{})
+	assert.Equal(t, exception.ErrInternalServer, err, "should be internal server error")
+	col.AssertExpectations(t)
+	db.AssertExpectations(t)
+}
 func TestFindMany_Success(t *testing.T) {
 	cursorMock := new(mocks.Cursor)
 
@@ -117,7 +174,7 @@ func TestFindMany_Success(t *testing.T) {
 
 	repo := weight.NewWeightRepository(logrus.New(), db)
 
-	result, err := repo.FindMany(context.TODO(), "date", -1)
+	result, err := repo.FindMany(context.TODO(), model.WeightFilter{SortBy: "date", Sort: -1})
 	assert.NoError(t, err, "should be no error")
 	assert.Equal(t, result[0].Date, int64(1656633600000000000), "should be the same")
 	cursorMock.AssertExpectations(t)
@@ -137,7 +194,7 @@ func TestFindMany_Error_Unexpected_When_Decode(t *testing.T) {
 
 	repo := weight.NewWeightRepository(logrus.New(), db)
 
-	result, err := repo.FindMany(context.TODO(), "date", -1)
+	result, err := repo.FindMany(context.TODO(), model.WeightFilter{SortBy: "date", Sort: -1})
 	assert.Nil(t, result, "should  be null")
 	assert.Error(t, err, "should be error")
 	assert.Equal(t, err, exception.ErrInternalServer, "should be not internal server error")
@@ -155,7 +212,7 @@ func TestFindMany_Error_Unexpected(t *testing.T) {
 
 	repo := weight.NewWeightRepository(logrus.New(), db)
 
-	result, err := repo.FindMany(context.TODO(), "date", -1)
+	result, err := repo.FindMany(context.TODO(), model.WeightFilter{SortBy: "date", Sort: -1})
 	assert.Nil(t, result, "should  be null")
 	assert.Error(t, err, "should be error")
 	assert.Equal(t, err, exception.ErrInternalServer, "should be not internal server error")
@@ -174,7 +231,7 @@ func TestFindMany_Error_NotFound(t *testing.T) {
 
 	repo := weight.NewWeightRepository(logrus.New(), db)
 
-	result, err := repo.FindMany(context.TODO(), "date", -1)
+	result, err := repo.FindMany(context.TODO(), model.WeightFilter{SortBy: "date", Sort: -1})
 	assert.Nil(t, result)
 	assert.Equal(t, err, exception.ErrNotFound, "should be not found error")
 	assert.Error(t, err, "should be error")
@@ -198,7 +255,7 @@ func TestFindOne_Success(t *testing.T) {
 
 	repo := weight.NewWeightRepository(logrus.New(), db)
 
-	result, err := repo.FindOne(context.TODO(), date)
+	result, err := repo.FindOne(context.TODO(), "", date)
 	assert.NoError(t, err, "should be no error")
 	assert.Equal(t, result.Date, date, "should be the same")
 	singleResultMock.AssertExpectations(t)
@@ -219,7 +276,7 @@ func TestFindOne_Error_NotFound(t *testing.T) {
 
 	repo := weight.NewWeightRepository(logrus.New(), db)
 
-	result, err := repo.FindOne(context.TODO(), date)
+	result, err := repo.FindOne(context.TODO(), "", date)
 	assert.Error(t, err, "should be error")
 	assert.Equal(t, exception.ErrNotFound, err)
 	assert.Equal(t, int64(0), result.Date, "should be 0")
@@ -241,7 +298,7 @@ func TestFindOne_Error_Unexpected(t *testing.T) {
 
 	repo := weight.NewWeightRepository(logrus.New(), db)
 
-	result, err := repo.FindOne(context.TODO(), date)
+	result, err := repo.FindOne(context.TODO(), "", date)
 	assert.Error(t, err, "should be error")
 	assert.Equal(t, exception.ErrInternalServer, err)
 	assert.Equal(t, int64(0), result.Date, "should be 0")
@@ -249,3 +306,650 @@ func TestFindOne_Error_Unexpected(t *testing.T) {
 	col.AssertExpectations(t)
 	db.AssertExpectations(t)
 }
+
+func TestDeleteOne_Success(t *testing.T) {
+	deleteResult := &mongo.DeleteResult{
+		DeletedCount: 1,
+	}
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+
+	col.On("DeleteOne", mock.Anything, mock.Anything).Return(deleteResult, nil)
+	db.On("Collection", mock.AnythingOfType("string")).Return(col)
+
+	repo := weight.NewWeightRepository(logrus.New(), db)
+
+	err := repo.DeleteOne(context.TODO(), "", 1)
+	assert.NoError(t, err, "should be no error")
+	col.AssertExpectations(t)
+	db.AssertExpectations(t)
+}
+
+func TestDeleteOne_Error_Unexpected(t *testing.T) {
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+
+	col.On("DeleteOne", mock.Anything, mock.Anything).Return(nil, mongo.ErrClientDisconnected)
+	db.On("Collection", mock.AnythingOfType("string")).Return(col)
+
+	repo := weight.NewWeightRepository(logrus.New(), db)
+
+	err := repo.DeleteOne(context.TODO(), "", 1)
+	assert.Error(t, err, "should be error")
+	assert.Equal(t, exception.ErrInternalServer, err)
+	col.AssertExpectations(t)
+	db.AssertExpectations(t)
+}
+
+func TestDeleteOne_Error_NotFound(t *testing.T) {
+	deleteResult := &mongo.DeleteResult{
+		DeletedCount: 0,
+	}
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+
+	col.On("DeleteOne", mock.Anything, mock.Anything).Return(deleteResult, nil)
+	db.On("Collection", mock.AnythingOfType("string")).Return(col)
+
+	repo := weight.NewWeightRepository(logrus.New(), db)
+
+	err := repo.DeleteOne(context.TODO(), "", 1)
+	assert.Error(t, err, "should be error")
+	assert.Equal(t, exception.ErrNotFound, err, "should be not found error")
+	col.AssertExpectations(t)
+	db.AssertExpectations(t)
+}
+
+func TestFindMany_Success_WithDateRangeAndPagination(t *testing.T) {
+	cursorMock := new(mocks.Cursor)
+
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+	filter := model.WeightFilter{Owner: "john", From: 1, To: 2, Page: 2, Limit: 10, SortBy: "max", Sort: 1}
+	expectedQuery := bson.M{"owner": "john", "date": bson.M{"$gte": int64(1), "$lte": int64(2)}}
+	expectedOpt := options.Find().SetSort(bson.D{{Key: "max", Value: 1}, {Key: "date", Value: 1}}).SetSkip(10).SetLimit(10)
+
+	cursorMock.On("Next", mock.Anything).Return(true).Once()
+	cursorMock.On("Next", mock.Anything).Return(false).Once()
+	cursorMock.On("Decode", mock.AnythingOfType("*entity.Weight")).Return(nil)
+	col.On("Find", mock.Anything, expectedQuery, expectedOpt).Return(cursorMock, nil)
+	db.On("Collection", mock.AnythingOfType("string")).Return(col)
+
+	repo := weight.NewWeightRepository(logrus.New(), db)
+
+	result, err := repo.FindMany(context.TODO(), filter)
+	assert.NoError(t, err, "should be no error")
+	assert.Len(t, result, 1)
+	cursorMock.AssertExpectations(t)
+	col.AssertExpectations(t)
+	db.AssertExpectations(t)
+}
+
+func TestCountMany_Success(t *testing.T) {
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+
+	col.On("CountDocuments", mock.Anything, bson.M{"owner": nil, "date": bson.M{"$gte": int64(1)}}).Return(int64(3), nil)
+	db.On("Collection", mock.AnythingOfType("string")).Return(col)
+
+	repo := weight.NewWeightRepository(logrus.New(), db)
+
+	total, err := repo.CountMany(context.TODO(), model.WeightFilter{From: 1})
+	assert.NoError(t, err, "should be no error")
+	assert.Equal(t, int64(3), total)
+	col.AssertExpectations(t)
+	db.AssertExpectations(t)
+}
+
+func TestCountMany_Error_Unexpected(t *testing.T) {
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+
+	col.On("CountDocuments", mock.Anything, mock.Anything).Return(int64(0), mongo.ErrClientDisconnected)
+	db.On("Collection", mock.AnythingOfType("string")).Return(col)
+
+	repo := weight.NewWeightRepository(logrus.New(), db)
+
+	total, err := repo.CountMany(context.TODO(), model.WeightFilter{})
+	assert.Error(t, err, "should be error")
+	assert.Equal(t, exception.ErrInternalServer, err)
+	assert.Equal(t, int64(0), total)
+	col.AssertExpectations(t)
+	db.AssertExpectations(t)
+}
+
+func TestStatistics_Success(t *testing.T) {
+	cursorMock := new(mocks.Cursor)
+
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+	cursorMock.On("Next", mock.Anything).Return(true).Once()
+	cursorMock.On("Next", mock.Anything).Return(false).Once()
+	cursorMock.On("Decode", mock.AnythingOfType("*entity.WeightStatistic")).Return(nil).Run(func(args mock.Arguments) {
+		arg := args.Get(0).(*entity.WeightStatistic)
+		arg.Period.Year = 2022
+		arg.Period.Month = 7
+		arg.Count = 2
+	})
+	col.On("Aggregate", mock.Anything, mock.Anything).Return(cursorMock, nil)
+	db.On("Collection", mock.AnythingOfType("string")).Return(col)
+
+	repo := weight.NewWeightRepository(logrus.New(), db)
+
+	result, err := repo.Statistics(context.TODO(), model.StatisticFilter{GroupBy: "month"})
+	assert.NoError(t, err, "should be no error")
+	assert.Equal(t, 2022, result[0].Period.Year, "should be the same")
+	assert.Equal(t, int64(2), result[0].Count, "should be the same")
+	cursorMock.AssertExpectations(t)
+	col.AssertExpectations(t)
+	db.AssertExpectations(t)
+}
+
+func TestStatistics_Error_Unexpected(t *testing.T) {
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+
+	col.On("Aggregate", mock.Anything, mock.Anything).Return(nil, mongo.ErrClientDisconnected)
+	db.On("Collection", mock.AnythingOfType("string")).Return(col)
+
+	repo := weight.NewWeightRepository(logrus.New(), db)
+
+	result, err := repo.Statistics(context.TODO(), model.StatisticFilter{})
+	assert.Nil(t, result, "should  be null")
+	assert.Equal(t, exception.ErrInternalServer, err, "should be internal server error")
+	col.AssertExpectations(t)
+	db.AssertExpectations(t)
+}
+
+func TestStatistics_Error_Unexpected_When_Decode(t *testing.T) {
+	cursorMock := new(mocks.Cursor)
+
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+	cursorMock.On("Next", mock.Anything).Return(true).Once()
+	cursorMock.On("Decode", mock.AnythingOfType("*entity.WeightStatistic")).Return(mongo.ErrNilDocument)
+	col.On("Aggregate", mock.Anything, mock.Anything).Return(cursorMock, nil)
+	db.On("Collection", mock.AnythingOfType("string")).Return(col)
+
+	repo := weight.NewWeightRepository(logrus.New(), db)
+
+	result, err := repo.Statistics(context.TODO(), model.StatisticFilter{GroupBy: "week"})
+	assert.Nil(t, result, "should  be null")
+	assert.Equal(t, exception.ErrInternalServer, err, "should be internal server error")
+	cursorMock.AssertExpectations(t)
+	col.AssertExpectations(t)
+	db.AssertExpectations(t)
+}
+
+func TestStatistics_Error_NotFound(t *testing.T) {
+	cursorMock := new(mocks.Cursor)
+
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+	cursorMock.On("Next", mock.Anything).Return(false).Once()
+	col.On("Aggregate", mock.Anything, mock.Anything).Return(cursorMock, nil)
+	db.On("Collection", mock.AnythingOfType("string")).Return(col)
+
+	repo := weight.NewWeightRepository(logrus.New(), db)
+
+	result, err := repo.Statistics(context.TODO(), model.StatisticFilter{GroupBy: "year"})
+	assert.Nil(t, result, "should  be null")
+	assert.Equal(t, exception.ErrNotFound, err, "should be not found error")
+	cursorMock.AssertExpectations(t)
+	col.AssertExpectations(t)
+	db.AssertExpectations(t)
+}
+
+func TestAverages_Success(t *testing.T) {
+	cursorMock := new(mocks.Cursor)
+
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+	cursorMock.On("Next", mock.Anything).Return(true).Once()
+	cursorMock.On("Decode", mock.AnythingOfType("*entity.WeightAverage")).Return(nil).Run(func(args mock.Arguments) {
+		arg := args.Get(0).(*entity.WeightAverage)
+		arg.Max = 5.5
+	})
+	cursorMock.On("Close", mock.Anything).Return(nil)
+	col.On("Aggregate", mock.Anything, mock.MatchedBy(func(pipeline []bson.M) bool {
+		group, _ := pipeline[1]["$group"].(bson.M)
+		return len(pipeline) == 2 && group["max"] != nil && group["maxValues"] == nil
+	})).Return(cursorMock, nil)
+	db.On("Collection", mock.AnythingOfType("string")).Return(col)
+
+	repo := weight.NewWeightRepository(logrus.New(), db)
+
+	result, err := repo.Averages(context.TODO(), model.WeightFilter{Owner: "john"})
+	assert.NoError(t, err, "should be no error")
+	assert.Equal(t, 5.5, result.Max, "should be the same")
+	cursorMock.AssertExpectations(t)
+	col.AssertExpectations(t)
+	db.AssertExpectations(t)
+}
+
+func TestAverages_Error_Unexpected(t *testing.T) {
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+
+	col.On("Aggregate", mock.Anything, mock.Anything).Return(nil, mongo.ErrClientDisconnected)
+	db.On("Collection", mock.AnythingOfType("string")).Return(col)
+
+	repo := weight.NewWeightRepository(logrus.New(), db)
+
+	_, err := repo.Averages(context.TODO(), model.WeightFilter{})
+	assert.Equal(t, exception.ErrInternalServer, err, "should be internal server error")
+	col.AssertExpectations(t)
+	db.AssertExpectations(t)
+}
+
+func TestFindOne_Success_ScopedToOwner(t *testing.T) {
+	singleResultMock := new(mocks.SingleResult)
+
+	date := int64(1656633600000000000)
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+	singleResultMock.On("Decode", mock.AnythingOfType("*entity.Weight")).Return(nil)
+	col.On("FindOne", mock.Anything, bson.M{"owner": "john", "date": date}).Return(singleResultMock)
+	db.On("Collection", mock.AnythingOfType("string")).Return(col)
+
+	repo := weight.NewWeightRepository(logrus.New(), db)
+
+	_, err := repo.FindOne(context.TODO(), "john", date)
+	assert.NoError(t, err, "should be no error")
+	singleResultMock.AssertExpectations(t)
+	col.AssertExpectations(t)
+	db.AssertExpectations(t)
+}
+
+func TestBulkUpsert_Success(t *testing.T) {
+	bulkWriteResult := &mongo.BulkWriteResult{
+		UpsertedIDs: map[int64]interface{}{1: "id"},
+	}
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+
+	col.On("BulkWrite", mock.Anything, mock.AnythingOfType("[]mongo.WriteModel"), options.BulkWrite().SetOrdered(true)).Return(bulkWriteResult, nil)
+	db.On("Collection", mock.AnythingOfType("string")).Return(col)
+
+	repo := weight.NewWeightRepository(logrus.New(), db)
+
+	inserted, err := repo.BulkUpsert(context.TODO(), "john", []entity.Weight{{Date: 1}, {Date: 2}}, false)
+	assert.NoError(t, err, "should be no error")
+	assert.Equal(t, []bool{false, true}, inserted)
+	col.AssertExpectations(t)
+	db.AssertExpectations(t)
+}
+
+func TestBulkUpsert_Error_Unexpected(t *testing.T) {
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+
+	col.On("BulkWrite", mock.Anything, mock.Anything, mock.Anything).Return(nil, mongo.ErrClientDisconnected)
+	db.On("Collection", mock.AnythingOfType("string")).Return(col)
+
+	repo := weight.NewWeightRepository(logrus.New(), db)
+
+	inserted, err := repo.BulkUpsert(context.TODO(), "john", []entity.Weight{{Date: 1}}, true)
+	assert.Error(t, err, "should be error")
+	assert.Equal(t, exception.ErrInternalServer, err)
+	assert.Nil(t, inserted)
+	col.AssertExpectations(t)
+	db.AssertExpectations(t)
+}
+
+func TestFindEach_Success(t *testing.T) {
+	cursorMock := new(mocks.Cursor)
+
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+	cursorMock.On("Next", mock.Anything).Return(true).Twice()
+	cursorMock.On("Next", mock.Anything).Return(false).Once()
+	cursorMock.On("Decode", mock.AnythingOfType("*entity.Weight")).Return(nil).Run(func(args mock.Arguments) {
+		arg := args.Get(0).(*entity.Weight)
+		arg.Date = 1656633600000000000
+	})
+	cursorMock.On("Close", mock.Anything).Return(nil)
+	col.On("Find", mock.Anything, mock.Anything, mock.Anything).Return(cursorMock, nil)
+	db.On("Collection", mock.AnythingOfType("string")).Return(col)
+
+	repo := weight.NewWeightRepository(logrus.New(), db)
+
+	count := 0
+	err := repo.FindEach(context.TODO(), model.WeightFilter{SortBy: "date", Sort: -1}, func(w entity.Weight) error {
+		count++
+		assert.Equal(t, int64(1656633600000000000), w.Date)
+		return nil
+	})
+	assert.NoError(t, err, "should be no error")
+	assert.Equal(t, 2, count)
+	cursorMock.AssertExpectations(t)
+	col.AssertExpectations(t)
+	db.AssertExpectations(t)
+}
+
+func TestFindEach_Error_Unexpected(t *testing.T) {
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+
+	col.On("Find", mock.Anything, mock.Anything, mock.Anything).Return(nil, mongo.ErrClientDisconnected)
+	db.On("Collection", mock.AnythingOfType("string")).Return(col)
+
+	repo := weight.NewWeightRepository(logrus.New(), db)
+
+	err := repo.FindEach(context.TODO(), model.WeightFilter{SortBy: "date", Sort: -1}, func(w entity.Weight) error {
+		return nil
+	})
+	assert.Error(t, err, "should be error")
+	assert.Equal(t, exception.ErrInternalServer, err)
+	col.AssertExpectations(t)
+	db.AssertExpectations(t)
+}
+
+func TestFindEach_Error_Unexpected_When_Decode(t *testing.T) {
+	cursorMock := new(mocks.Cursor)
+
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+	cursorMock.On("Next", mock.Anything).Return(true).Once()
+	cursorMock.On("Decode", mock.AnythingOfType("*entity.Weight")).Return(mongo.ErrNoDocuments)
+	cursorMock.On("Close", mock.Anything).Return(nil)
+	col.On("Find", mock.Anything, mock.Anything, mock.Anything).Return(cursorMock, nil)
+	db.On("Collection", mock.AnythingOfType("string")).Return(col)
+
+	repo := weight.NewWeightRepository(logrus.New(), db)
+
+	err := repo.FindEach(context.TODO(), model.WeightFilter{SortBy: "date", Sort: -1}, func(w entity.Weight) error {
+		return nil
+	})
+	assert.Error(t, err, "should be error")
+	assert.Equal(t, exception.ErrInternalServer, err)
+	cursorMock.AssertExpectations(t)
+	col.AssertExpectations(t)
+	db.AssertExpectations(t)
+}
+
+func TestInsertOne_Error_Conflict(t *testing.T) {
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+
+	duplicateKeyErr := mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000, Message: "E11000 duplicate key error"}}}
+	col.On("InsertOne", mock.Anything, mock.Anything).Return(nil, duplicateKeyErr)
+	db.On("Collection", mock.AnythingOfType("string")).Return(col)
+
+	repo := weight.NewWeightRepository(logrus.New(), db)
+
+	err := repo.InsertOne(context.TODO(), entity.Weight{})
+	assert.Error(t, err, "should be error")
+	assert.Equal(t, exception.ErrConflict, err, "should be conflict error")
+	col.AssertExpectations(t)
+	db.AssertExpectations(t)
+}
+
+func TestMigrations(t *testing.T) {
+	indexView := new(mocks.IndexView)
+	cursorMock := new(mocks.Cursor)
+	singleResultMock := new(mocks.SingleResult)
+	col := new(mocks.Collection)
+	quarantineCol := new(mocks.Collection)
+	db := new(mocks.Database)
+
+	// weight 3 is kept as it sorts first, 2 and 1 have the same owner and date.
+	cursorMock.On("Next", mock.Anything).Return(true).Once()
+	cursorMock.On("Next", mock.Anything).Return(false).Once()
+	cursorMock.On("Decode", mock.AnythingOfType("*primitive.M")).Return(nil).Run(func(args mock.Arguments) {
+		(*args.Get(0).(*bson.M))["ids"] = bson.A{3, 2, 1}
+	})
+	cursorMock.On("Close", mock.Anything).Return(nil)
+	col.On("Aggregate", mock.Anything, mock.Anything, mock.Anything).Return(cursorMock, nil)
+	singleResultMock.On("Decode", mock.AnythingOfType("*primitive.M")).Return(nil)
+	col.On("FindOne", mock.Anything, bson.M{"_id": 2}).Return(singleResultMock)
+	col.On("FindOne", mock.Anything, bson.M{"_id": 1}).Return(singleResultMock)
+	quarantineCol.On("InsertOne", mock.Anything, mock.MatchedBy(func(document bson.M) bool {
+		return document["quarantineReason"] == "duplicate owner and date"
+	})).Return(&mongo.InsertOneResult{}, nil).Twice()
+	col.On("DeleteOne", mock.Anything, mock.Anything).Return(&mongo.DeleteResult{DeletedCount: 1}, nil).Twice()
+	indexView.On("CreateOne", mock.Anything, mock.MatchedBy(func(model mongo.IndexModel) bool {
+		return *model.Options.Unique && *model.Options.Name == "owner_1_date_1"
+	})).Return("owner_1_date_1", nil)
+	col.On("Indexes").Return(indexView)
+	db.On("Collection", "weight").Return(col)
+	db.On("Collection", "weight_quarantine").Return(quarantineCol)
+
+	migrations := weight.Migrations(logrus.New())
+	assert.Equal(t, 1, migrations[0].Version)
+	assert.NoError(t, migrations[0].Up(context.TODO(), db))
+	indexView.AssertExpectations(t)
+	quarantineCol.AssertExpectations(t)
+	col.AssertExpectations(t)
+	db.AssertExpectations(t)
+}
+
+func TestMigrations_CalendarDate_Success(t *testing.T) {
+	cursorMock := new(mocks.Cursor)
+	col := new(mocks.Collection)
+	quarantineCol := new(mocks.Collection)
+	db := new(mocks.Database)
+
+	// the form stored UTC midnights, which are still the same day for a service west of UTC.
+	legacyDates := []interface{}{
+		time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC).UnixNano(),
+		time.Date(2022, 7, 2, 0, 0, 0, 0, time.UTC).UnixNano(),
+		"2022-07-03",
+	}
+	decoded := 0
+	cursorMock.On("Next", mock.Anything).Return(true).Times(3)
+	cursorMock.On("Next", mock.Anything).Return(false).Once()
+	cursorMock.On("Decode", mock.AnythingOfType("*primitive.M")).Return(nil).Run(func(args mock.Arguments) {
+		arg := *args.Get(0).(*bson.M)
+		arg["_id"] = decoded
+		arg["date"] = legacyDates[decoded]
+		decoded++
+	})
+	cursorMock.On("Close", mock.Anything).Return(nil)
+	col.On("Find", mock.Anything, bson.M{"day": bson.M{"$exists": false}}).Return(cursorMock, nil)
+	col.On("UpdateOne", mock.Anything, bson.M{"_id": 0}, bson.M{
+		"$set": bson.M{"date": time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC).UnixNano(), "day": "2022-07-01"},
+	}).Return(&mongo.UpdateResult{MatchedCount: 1}, nil)
+	duplicateKeyErr := mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000, Message: "E11000 duplicate key error"}}}
+	col.On("UpdateOne", mock.Anything, bson.M{"_id": 1}, bson.M{
+		"$set": bson.M{"date": time.Date(2022, 7, 2, 0, 0, 0, 0, time.UTC).UnixNano(), "day": "2022-07-02"},
+	}).Return(nil, duplicateKeyErr)
+	quarantineCol.On("InsertOne", mock.Anything, mock.MatchedBy(func(document bson.M) bool {
+		return document["_id"] == 1 && document["quarantineReason"] == "calendar date already exists"
+	})).Return(&mongo.InsertOneResult{}, nil).Once()
+	quarantineCol.On("InsertOne", mock.Anything, mock.MatchedBy(func(document bson.M) bool {
+		return document["_id"] == 2 && document["quarantineReason"] == "invalid date"
+	})).Return(&mongo.InsertOneResult{}, nil).Once()
+	col.On("DeleteOne", mock.Anything, bson.M{"_id": 1}).Return(&mongo.DeleteResult{DeletedCount: 1}, nil).Once()
+	col.On("DeleteOne", mock.Anything, bson.M{"_id": 2}).Return(&mongo.DeleteResult{DeletedCount: 1}, nil).Once()
+	db.On("Collection", "weight").Return(col)
+	db.On("Collection", "weight_quarantine").Return(quarantineCol)
+
+	migrations := weight.Migrations(logrus.New())
+	assert.Equal(t, 2, migrations[1].Version)
+	assert.NoError(t, migrations[1].Up(context.TODO(), db), "should quarantine the conflicting and invalid weights")
+	cursorMock.AssertExpectations(t)
+	quarantineCol.AssertExpectations(t)
+	col.AssertExpectations(t)
+	db.AssertExpectations(t)
+}
+
+func TestMigrations_CalendarDate_Error_Unexpected(t *testing.T) {
+	cursorMock := new(mocks.Cursor)
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+
+	cursorMock.On("Next", mock.Anything).Return(true).Once()
+	cursorMock.On("Decode", mock.AnythingOfType("*primitive.M")).Return(nil).Run(func(args mock.Arguments) {
+		(*args.Get(0).(*bson.M))["date"] = int64(1656633600000000000)
+	})
+	cursorMock.On("Close", mock.Anything).Return(nil)
+	col.On("Find", mock.Anything, mock.Anything).Return(cursorMock, nil)
+	col.On("UpdateOne", mock.Anything, mock.Anything, mock.Anything).Return(nil, mongo.ErrClientDisconnected)
+	db.On("Collection", "weight").Return(col)
+
+	migrations := weight.Migrations(logrus.New())
+	err := migrations[1].Up(context.TODO(), db)
+	assert.ErrorIs(t, err, mongo.ErrClientDisconnected)
+	cursorMock.AssertExpectations(t)
+	col.AssertExpectations(t)
+}
+
+func TestMigrations_HistoryIndex(t *testing.T) {
+	indexView := new(mocks.IndexView)
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+
+	indexView.On("CreateOne", mock.Anything, mock.MatchedBy(func(model mongo.IndexModel) bool {
+		return *model.Options.Name == "owner_1_date_1_createdAt_-1"
+	})).Return("owner_1_date_1_createdAt_-1", nil)
+	col.On("Indexes").Return(indexView)
+	db.On("Collection", "weight_history").Return(col)
+
+	migrations := weight.Migrations(logrus.New())
+	assert.Equal(t, 3, migrations[2].Version)
+	assert.NoError(t, migrations[2].Up(context.TODO(), db))
+	indexView.AssertExpectations(t)
+	db.AssertExpectations(t)
+}
+
+func TestUpdateOne_Success_WithVersion(t *testing.T) {
+	updateResult := &mongo.UpdateResult{
+		MatchedCount: 1,
+	}
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+
+	col.On("UpdateOne", mock.Anything, bson.M{"owner": "john", "date": int64(1), "version": int64(2)}, mock.MatchedBy(func(update bson.M) bool {
+		return update["$inc"].(bson.M)["version"] == 1
+	})).Return(updateResult, nil)
+	db.On("Collection", mock.AnythingOfType("string")).Return(col)
+
+	repo := weight.NewWeightRepository(logrus.New(), db)
+
+	err := repo.UpdateOne(context.TODO(), "john", 1, entity.Weight{Version: 2})
+	assert.NoError(t, err, "should be no error")
+	col.AssertExpectations(t)
+	db.AssertExpectations(t)
+}
+
+func TestUpdateOne_Error_VersionConflict(t *testing.T) {
+	updateResult := &mongo.UpdateResult{
+		MatchedCount: 0,
+	}
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+
+	col.On("UpdateOne", mock.Anything, mock.Anything, mock.Anything).Return(updateResult, nil)
+	col.On("CountDocuments", mock.Anything, bson.M{"owner": "john", "date": int64(1)}).Return(int64(1), nil)
+	db.On("Collection", mock.AnythingOfType("string")).Return(col)
+
+	repo := weight.NewWeightRepository(logrus.New(), db)
+
+	err := repo.UpdateOne(context.TODO(), "john", 1, entity.Weight{Version: 2})
+	assert.Equal(t, exception.ErrConflict, err, "should be conflict error")
+	col.AssertExpectations(t)
+	db.AssertExpectations(t)
+}
+
+func TestUpdateOne_Error_NotFound_WithVersion(t *testing.T) {
+	updateResult := &mongo.UpdateResult{
+		MatchedCount: 0,
+	}
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+
+	col.On("UpdateOne", mock.Anything, mock.Anything, mock.Anything).Return(updateResult, nil)
+	col.On("CountDocuments", mock.Anything, mock.Anything).Return(int64(0), nil)
+	db.On("Collection", mock.AnythingOfType("string")).Return(col)
+
+	repo := weight.NewWeightRepository(logrus.New(), db)
+
+	err := repo.UpdateOne(context.TODO(), "john", 1, entity.Weight{Version: 2})
+	assert.Equal(t, exception.ErrNotFound, err, "should be not found error")
+	col.AssertExpectations(t)
+	db.AssertExpectations(t)
+}
+
+func TestMigrations_InitialVersion(t *testing.T) {
+	col := new(mocks.Collection)
+	db := new(mocks.Database)
+
+	col.On("UpdateMany", mock.Anything, bson.M{"version": bson.M{"$exists": false}}, bson.M{"$set": bson.M{"version": 1}}).
+		Return(&mongo.UpdateResult{ModifiedCount: 2}, nil)
+	db.On("Collection", "weight").Return(col)
+
+	migrations := weight.Migrations(logrus.New())
+	assert.Equal(t, 4, migrations[3].Version)
+	assert.NoError(t, migrations[3].Up(context.TODO(), db))
+	col.AssertExpectations(t)
+	db.AssertExpectations(t)
+}
+
+func TestMigrations_WebhookIndexes(t *testing.T) {
+	indexView := new(mocks.IndexView)
+	webhookCol := new(mocks.Collection)
+	deliveryCol := new(mocks.Collection)
+	db := new(mocks.Database)
+
+	indexView.On("CreateOne", mock.Anything, mock.MatchedBy(func(model mongo.IndexModel) bool {
+		return *model.Options.Name == "owner_1_createdAt_1"
+	})).Return("owner_1_createdAt_1", nil)
+	indexView.On("CreateMany", mock.Anything, mock.MatchedBy(func(models []mongo.IndexModel) bool {
+		return len(models) == 2 && *models[0].Options.Name == "status_1_nextAttemptAt_1"
+	})).Return([]string{"status_1_nextAttemptAt_1", "owner_1_subscriptionId_1_createdAt_-1"}, nil)
+	webhookCol.On("Indexes").Return(indexView)
+	deliveryCol.On("Indexes").Return(indexView)
+	db.On("Collection", "webhook").Return(webhookCol)
+	db.On("Collection", "webhook_delivery").Return(deliveryCol)
+
+	migrations := weight.Migrations(logrus.New())
+	assert.Equal(t, 6, migrations[5].Version)
+	assert.NoError(t, migrations[5].Up(context.TODO(), db))
+	indexView.AssertExpectations(t)
+	db.AssertExpectations(t)
+}
+
+func TestMigrations_OutboxRetryIndex(t *testing.T) {
+	indexView := new(mocks.IndexView)
+	outboxCol := new(mocks.Collection)
+	db := new(mocks.Database)
+
+	indexView.On("CreateOne", mock.Anything, mock.MatchedBy(func(model mongo.IndexModel) bool {
+		return *model.Options.Name == "nextAttemptAt_1"
+	})).Return("nextAttemptAt_1", nil)
+	outboxCol.On("Indexes").Return(indexView)
+	db.On("Collection", "outbox").Return(outboxCol)
+
+	migrations := weight.Migrations(logrus.New())
+	assert.Equal(t, 7, migrations[6].Version)
+	assert.NoError(t, migrations[6].Up(context.TODO(), db))
+	indexView.AssertExpectations(t)
+}
+
+func TestMigrations_PreImages(t *testing.T) {
+	migrations := weight.Migrations(logrus.New())
+	assert.Equal(t, 8, migrations[7].Version)
+
+	enabled := new(mocks.SingleResult)
+	enabled.On("Err").Return(nil)
+	db := new(mocks.Database)
+	db.On("RunCommand", mock.Anything, bson.D{
+		{Key: "collMod", Value: "weight"},
+		{Key: "changeStreamPreAndPostImages", Value: bson.M{"enabled": true}},
+	}).Return(enabled)
+	assert.NoError(t, migrations[7].Up(context.TODO(), db))
+	db.AssertExpectations(t)
+
+	unsupported := new(mocks.SingleResult)
+	unsupported.On("Err").Return(mongo.CommandError{Code: 72, Message: "unknown option to collMod: changeStreamPreAndPostImages"})
+	db = new(mocks.Database)
+	db.On("RunCommand", mock.Anything, mock.Anything).Return(unsupported)
+	assert.NoError(t, migrations[7].Up(context.TODO(), db), "should not fail before MongoDB 6.0")
+
+	failed := new(mocks.SingleResult)
+	failed.On("Err").Return(mongo.CommandError{Code: 13, Message: "not authorized"})
+	db = new(mocks.Database)
+	db.On("RunCommand", mock.Anything, mock.Anything).Return(failed)
+	assert.Error(t, migrations[7].Up(context.TODO(), db), "should be error")
+}
@@ -0,0 +1,128 @@
+package weight
+
+import (
+	"context"
+
+	"github.com/ijalalfrz/sirclo-weight-test/entity"
+	"github.com/ijalalfrz/sirclo-weight-test/exception"
+	"github.com/ijalalfrz/sirclo-weight-test/model"
+	"github.com/ijalalfrz/sirclo-weight-test/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracingRepository is a decorator of Repository which wraps every call in a span.
+type tracingRepository struct {
+	next   Repository
+	tracer trace.Tracer
+}
+
+// NewTracingRepository is a constructor.
+func NewTracingRepository(next Repository, tracer trace.Tracer) Repository {
+	return tracingRepository{
+		next:   next,
+		tracer: tracer,
+	}
+}
+
+func (r tracingRepository) InsertOne(ctx context.Context, weight entity.Weight) (err error) {
+	ctx, span := r.start(ctx, "InsertOne", attribute.Int64("weight.date", weight.Date))
+	defer r.end(span, &err)
+
+	err = r.next.InsertOne(ctx, weight)
+	return
+}
+
+func (r tracingRepository) UpdateOne(ctx context.Context, owner string, key int64, weight entity.Weight) (err error) {
+	ctx, span := r.start(ctx, "UpdateOne", attribute.Int64("weight.date", key))
+	defer r.end(span, &err)
+
+	err = r.next.UpdateOne(ctx, owner, key, weight)
+	return
+}
+
+func (r tracingRepository) UpsertOne(ctx context.Context, owner string, key int64, weight entity.Weight) (created bool, err error) {
+	ctx, span := r.start(ctx, "UpsertOne", attribute.Int64("weight.date", key))
+	defer r.end(span, &err)
+
+	created, err = r.next.UpsertOne(ctx, owner, key, weight)
+	span.SetAttributes(attribute.Bool("weight.created", created))
+	return
+}
+
+func (r tracingRepository) FindMany(ctx context.Context, filter model.WeightFilter) (bunchOfWeight []entity.Weight, err error) {
+	ctx, span := r.start(ctx, "FindMany")
+	defer r.end(span, &err)
+
+	bunchOfWeight, err = r.next.FindMany(ctx, filter)
+	span.SetAttributes(attribute.Int("weight.count", len(bunchOfWeight)))
+	return
+}
+
+func (r tracingRepository) FindEach(ctx context.Context, filter model.WeightFilter, fn func(weight entity.Weight) error) (err error) {
+	ctx, span := r.start(ctx, "FindEach")
+	defer r.end(span, &err)
+
+	err = r.next.FindEach(ctx, filter, fn)
+	return
+}
+
+func (r tracingRepository) CountMany(ctx context.Context, filter model.WeightFilter) (total int64, err error) {
+	ctx, span := r.start(ctx, "CountMany")
+	defer r.end(span, &err)
+
+	total, err = r.next.CountMany(ctx, filter)
+	return
+}
+
+func (r tracingRepository) Averages(ctx context.Context, filter model.WeightFilter) (average entity.WeightAverage, err error) {
+	ctx, span := r.start(ctx, "Averages")
+	defer r.end(span, &err)
+
+	average, err = r.next.Averages(ctx, filter)
+	return
+}
+
+func (r tracingRepository) FindOne(ctx context.Context, owner string, key int64) (weight entity.Weight, err error) {
+	ctx, span := r.start(ctx, "FindOne", attribute.Int64("weight.date", key))
+	defer r.end(span, &err)
+
+	weight, err = r.next.FindOne(ctx, owner, key)
+	return
+}
+
+func (r tracingRepository) DeleteOne(ctx context.Context, owner string, key int64) (err error) {
+	ctx, span := r.start(ctx, "DeleteOne", attribute.Int64("weight.date", key))
+	defer r.end(span, &err)
+
+	err = r.next.DeleteOne(ctx, owner, key)
+	return
+}
+
+func (r tracingRepository) Statistics(ctx context.Context, filter model.StatisticFilter) (bunchOfStatistic []entity.WeightStatistic, err error) {
+	ctx, span := r.start(ctx, "Statistics", attribute.String("weight.filter.group_by", filter.GroupBy))
+	defer r.end(span, &err)
+
+	bunchOfStatistic, err = r.next.Statistics(ctx, filter)
+	return
+}
+
+func (r tracingRepository) BulkUpsert(ctx context.Context, owner string, bunchOfWeight []entity.Weight, overwrite bool) (inserted []bool, err error) {
+	ctx, span := r.start(ctx, "BulkUpsert", attribute.Int("weight.count", len(bunchOfWeight)), attribute.Bool("weight.overwrite", overwrite))
+	defer r.end(span, &err)
+
+	inserted, err = r.next.BulkUpsert(ctx, owner, bunchOfWeight, overwrite)
+	return
+}
+
+func (r tracingRepository) start(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return r.tracer.Start(ctx, "weight.Repository/"+method, trace.WithAttributes(attrs...))
+}
+
+// end records the error on the span, not found is an expected outcome so it is not recorded.
+func (r tracingRepository) end(span trace.Span, err *error) {
+	if *err != exception.ErrNotFound {
+		tracing.RecordError(span, *err)
+	}
+	span.End()
+}
@@ -2,12 +2,15 @@ package weight
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"sort"
 	"time"
 
 	"github.com/ijalalfrz/sirclo-weight-test/entity"
 	"github.com/ijalalfrz/sirclo-weight-test/exception"
 	"github.com/ijalalfrz/sirclo-weight-test/model"
+	"github.com/ijalalfrz/sirclo-weight-test/requestctx"
 	"github.com/ijalalfrz/sirclo-weight-test/response"
 	"github.com/sirupsen/logrus"
 )
@@ -18,40 +21,110 @@ const (
 	insertOneSuccessMessage       = "Weight has been successfully inserted"
 	updateOneUnexpectedErrMessage = "Unexpected error while updating weight"
 	updateOneSuccessMessage       = "Weight has been successfully updated"
+	upsertOneCreatedMessage       = "Weight has been successfully created"
+	deleteOneUnexpectedErrMessage = "Unexpected error while deleting weight"
+	deleteOneSuccessMessage       = "Weight has been successfully deleted"
 	weightNotFoundErrMessage      = "Weight not found"
 	weightSuccessMessage          = "List of weight"
 	weightUnexpectedErrMessage    = "Unexpected error while geting weight data"
 	weightAllreadyExistErrMessage = "Weight is already exist"
+	versionConflictErrMessage     = "Weight has been changed by someone else, reload it and try again"
+	invalidSortErrMessage         = "Invalid sort field"
+	invalidGroupByErrMessage      = "Invalid group by"
+	statisticSuccessMessage       = "Statistic of weight"
+	importUnexpectedErrMessage    = "Unexpected error while importing weight"
+	importSuccessMessage          = "Weight has been imported"
+	exportUnexpectedErrMessage    = "Unexpected error while exporting weight"
+	exportSuccessMessage          = "Weight has been exported"
+	historySuccessMessage         = "History of weight"
+	historyUnexpectedErrMessage   = "Unexpected error while geting weight history"
+	revisionNotFoundErrMessage    = "Revision not found"
+	restoreUnexpectedErrMessage   = "Unexpected error while restoring weight"
+	restoreSuccessMessage         = "Weight has been successfully restored"
+	notifyFailedMessage           = "Notifying %s of weight %s failed: %v"
+	anonymousWriteErrMessage      = "Sign in to change weight"
 )
 
+// collection of import row status
+const (
+	importStatusInserted = "INSERTED"
+	importStatusUpdated  = "UPDATED"
+	importStatusSkipped  = "SKIPPED_DUPLICATE"
+	importStatusInvalid  = "INVALID"
+)
+
+const (
+	importBatchSize = 500
+)
+
+// collection of default filter
+const (
+	defaultSortBy = "date"
+	defaultSort   = -1
+	defaultPage   = 1
+	defaultLimit  = 30
+	maxLimit      = 100
+)
+
+var sortableFields = map[string]bool{
+	"date": true,
+	"max":  true,
+	"min":  true,
+	"diff": true,
+}
+
 // Usecase is collection of behaviour usecase
 type Usecase interface {
 	InsertOne(ctx context.Context, payload model.WeightPayload) (resp response.Response)
 	UpdateOne(ctx context.Context, key int64, payload model.WeightPayload) (resp response.Response)
-	FindMany(ctx context.Context) (resp response.Response)
+	UpsertOne(ctx context.Context, key int64, payload model.WeightPayload) (resp response.Response)
+	FindMany(ctx context.Context, filter model.WeightFilter) (resp response.Response)
 	FindOne(ctx context.Context, key int64) (resp response.Response)
+	DeleteOne(ctx context.Context, key int64) (resp response.Response)
+	Statistics(ctx context.Context, filter model.StatisticFilter) (resp response.Response)
+	ImportMany(ctx context.Context, rows []model.WeightImportRow, overwrite bool) (resp response.Response)
+	ExportMany(ctx context.Context, filter model.WeightFilter, fn func(weight model.WeightExportResponse) error) (resp response.Response)
+	History(ctx context.Context, key int64) (resp response.Response)
+	Restore(ctx context.Context, key int64, revisionID string) (resp response.Response)
+}
+
+// Notifier is collection of behaviour of a receiver of the weight events, such as the webhooks.
+// Notify records the event of owner, data is the payload of the event.
+type Notifier interface {
+	Notify(ctx context.Context, eventType string, owner string, data interface{}) (err error)
 }
 
 type weightUsecase struct {
-	serviceName string
-	logger      *logrus.Logger
-	repository  Repository
+	serviceName  string
+	logger       *logrus.Logger
+	repository   Repository
+	history      HistoryRepository
+	notifier     Notifier
+	requireOwner bool
 }
 
 // NewWeightUsecase is constructor
 func NewWeightUsecase(property UsecaseProperty) Usecase {
 	return &weightUsecase{
-		serviceName: property.ServiceName,
-		logger:      property.Logger,
-		repository:  property.Repository,
+		serviceName:  property.ServiceName,
+		logger:       property.Logger,
+		repository:   property.Repository,
+		history:      property.History,
+		notifier:     property.Notifier,
+		requireOwner: property.RequireOwner,
 	}
 }
 
 func (u weightUsecase) InsertOne(ctx context.Context, payload model.WeightPayload) (resp response.Response) {
+	if resp = u.authorizeWrite(ctx); resp != nil {
+		return
+	}
+
+	owner := requestctx.Owner(ctx)
 
-	findWeight, err := u.repository.FindOne(ctx, payload.Date)
+	findWeight, err := u.repository.FindOne(ctx, owner, payload.Date)
 	if err != nil {
-		u.logger.Error(err)
+		u.log(ctx).Error(err)
 		if err != exception.ErrNotFound {
 			return response.NewErrorResponse(err, http.StatusInternalServerError, nil, response.StatUnexpectedError, weightUnexpectedErrMessage)
 		}
@@ -63,40 +136,115 @@ func (u weightUsecase) InsertOne(ctx context.Context, payload model.WeightPayloa
 	}
 
 	weight := entity.Weight{
-		Date: payload.Date,
-		Max:  payload.Max,
-		Min:  payload.Min,
-		Diff: payload.Max - payload.Min,
+		Owner: owner,
+		Date:  payload.Date,
+		Day:   formatDate(payload.Date),
+		Max:   payload.Max,
+		Min:   payload.Min,
+		Diff:  payload.Max - payload.Min,
 	}
 	err = u.repository.InsertOne(ctx, weight)
 	if err != nil {
-		u.logger.Error(err)
+		u.log(ctx).Error(err)
+		if err == exception.ErrConflict {
+			return response.NewErrorResponse(err, http.StatusConflict, nil, response.StatAlreadyExist, weightAllreadyExistErrMessage)
+		}
 		return response.NewErrorResponse(err, http.StatusInternalServerError, nil, response.StatUnexpectedError, insertOneUnexpectedErrMessage)
 
 	}
+	u.notify(ctx, eventWeightCreated, owner, payload.Date)
 	return response.NewSuccessResponse(nil, response.StatCreated, insertOneSuccessMessage)
 }
 
+// UpdateOne updates the weight of the key, it fails with not found when the weight does not exist.
+// The key is authoritative, the date of the payload is ignored.
+// When the payload carries a version, it fails with conflict if the weight has been changed since that version.
 func (u weightUsecase) UpdateOne(ctx context.Context, key int64, payload model.WeightPayload) (resp response.Response) {
+	if resp = u.authorizeWrite(ctx); resp != nil {
+		return
+	}
+
+	owner := requestctx.Owner(ctx)
+	weight := entity.Weight{
+		Owner:   owner,
+		Date:    key,
+		Day:     formatDate(key),
+		Max:     payload.Max,
+		Min:     payload.Min,
+		Diff:    payload.Max - payload.Min,
+		Version: payload.Version,
+	}
+	err := u.repository.UpdateOne(ctx, owner, key, weight)
+	if err != nil {
+		u.log(ctx).Error(err)
+		if err == exception.ErrNotFound {
+			return response.NewErrorResponse(err, http.StatusNotFound, nil, response.StatNotFound, weightNotFoundErrMessage)
+		}
+		if err == exception.ErrConflict {
+			return response.NewErrorResponse(err, http.StatusConflict, nil, response.StatVersionConflict, versionConflictErrMessage)
+		}
+		return response.NewErrorResponse(err, http.StatusInternalServerError, nil, response.StatUnexpectedError, updateOneUnexpectedErrMessage)
+
+	}
+	u.notify(ctx, eventWeightUpdated, owner, key)
+	return response.NewSuccessResponse(nil, response.StatOK, updateOneSuccessMessage)
+}
+
+// UpsertOne replaces the weight of the key or creates it when it does not exist yet.
+// The key is authoritative, the date of the payload is ignored.
+// With an expected version the weight must exist, so it is not created.
+func (u weightUsecase) UpsertOne(ctx context.Context, key int64, payload model.WeightPayload) (resp response.Response) {
+	if resp = u.authorizeWrite(ctx); resp != nil {
+		return
+	}
+
+	owner := requestctx.Owner(ctx)
 	weight := entity.Weight{
-		Date: payload.Date,
-		Max:  payload.Max,
-		Min:  payload.Min,
-		Diff: payload.Max - payload.Min,
+		Owner:   owner,
+		Date:    key,
+		Day:     formatDate(key),
+		Max:     payload.Max,
+		Min:     payload.Min,
+		Diff:    payload.Max - payload.Min,
+		Version: payload.Version,
 	}
-	err := u.repository.UpdateOne(ctx, payload.Date, weight)
+	created, err := u.repository.UpsertOne(ctx, owner, key, weight)
 	if err != nil {
-		u.logger.Error(err)
+		u.log(ctx).Error(err)
+		switch {
+		case err == exception.ErrNotFound:
+			return response.NewErrorResponse(err, http.StatusNotFound, nil, response.StatNotFound, weightNotFoundErrMessage)
+		case err == exception.ErrConflict && payload.Version > 0:
+			return response.NewErrorResponse(err, http.StatusConflict, nil, response.StatVersionConflict, versionConflictErrMessage)
+		case err == exception.ErrConflict:
+			return response.NewErrorResponse(err, http.StatusConflict, nil, response.StatAlreadyExist, weightAllreadyExistErrMessage)
+		}
 		return response.NewErrorResponse(err, http.StatusInternalServerError, nil, response.StatUnexpectedError, updateOneUnexpectedErrMessage)
+	}
 
+	if created {
+		u.notify(ctx, eventWeightCreated, owner, key)
+		return response.NewSuccessResponse(nil, response.StatCreated, upsertOneCreatedMessage)
 	}
+	u.notify(ctx, eventWeightUpdated, owner, key)
 	return response.NewSuccessResponse(nil, response.StatOK, updateOneSuccessMessage)
 }
-func (u weightUsecase) FindMany(ctx context.Context) (resp response.Response) {
+func (u weightUsecase) FindMany(ctx context.Context, filter model.WeightFilter) (resp response.Response) {
+	filter.Owner = requestctx.Owner(ctx)
+	filter, err := u.normalizeFilter(filter)
+	if err != nil {
+		return response.NewErrorResponse(err, http.StatusBadRequest, nil, response.StatBadRequest, invalidSortErrMessage)
+	}
+
+	total, err := u.repository.CountMany(ctx, filter)
+	if err != nil {
+		u.log(ctx).Error(err)
+		return response.NewErrorResponse(err, http.StatusInternalServerError, nil, response.StatUnexpectedError, weightUnexpectedErrMessage)
+	}
 
-	weight, err := u.repository.FindMany(ctx, "date", -1)
+	weight, err := u.repository.FindMany(ctx, filter)
 	if err != nil {
-		u.logger.Error(err)
+		u.log(ctx).Error(err)
 		if err != exception.ErrNotFound {
 			return response.NewErrorResponse(err, http.StatusInternalServerError, nil, response.StatUnexpectedError, weightUnexpectedErrMessage)
 		}
@@ -104,37 +252,43 @@ func (u weightUsecase) FindMany(ctx context.Context) (resp response.Response) {
 		return response.NewErrorResponse(exception.ErrNotFound, http.StatusNotFound, nil, response.StatNotFound, weightNotFoundErrMessage)
 
 	}
-	sumMax := 0
-	sumMin := 0
-	sumDiff := 0
+	// the averages are aggregated over every weight of the filter, not only over the current page.
+	average, err := u.repository.Averages(ctx, filter)
+	if err != nil {
+		u.log(ctx).Error(err)
+		return response.NewErrorResponse(err, http.StatusInternalServerError, nil, response.StatUnexpectedError, weightUnexpectedErrMessage)
+	}
+
 	var weightDetail []model.WeighDetailResponse
 	for _, w := range weight {
-		sumMax += w.Max
-		sumMin += w.Min
-		sumDiff += w.Diff
-
 		wd := model.WeighDetailResponse{
-			DateString: u.unixToDateString(w.Date),
+			DateString: formatDate(w.Date),
 			Date:       w.Date,
 			Max:        w.Max,
 			Min:        w.Min,
 			Diff:       w.Diff,
+			Version:    w.Version,
 		}
 		weightDetail = append(weightDetail, wd)
 	}
-	totalData := len(weight)
 	weightResponse := model.WeightResponse{
 		List:        weightDetail,
-		AverageMax:  float32(sumMax) / float32(totalData),
-		AverageMin:  float32(sumMin) / float32(totalData),
-		AverageDiff: float32(sumDiff) / float32(totalData),
+		AverageMax:  float32(average.Max),
+		AverageMin:  float32(average.Min),
+		AverageDiff: float32(average.Diff),
 	}
-	return response.NewSuccessResponse(weightResponse, response.StatOK, weightSuccessMessage)
+	meta := model.WeightMeta{
+		Page:      filter.Page,
+		Limit:     filter.Limit,
+		TotalData: total,
+		TotalPage: (total + filter.Limit - 1) / filter.Limit,
+	}
+	return response.NewSuccessResponseWithMeta(weightResponse, response.StatOK, weightSuccessMessage, meta)
 }
 func (u weightUsecase) FindOne(ctx context.Context, key int64) (resp response.Response) {
-	weight, err := u.repository.FindOne(ctx, key)
+	weight, err := u.repository.FindOne(ctx, requestctx.Owner(ctx), key)
 	if err != nil {
-		u.logger.Error(err)
+		u.log(ctx).Error(err)
 		if err != exception.ErrNotFound {
 			return response.NewErrorResponse(err, http.StatusInternalServerError, nil, response.StatUnexpectedError, weightUnexpectedErrMessage)
 		}
@@ -144,17 +298,399 @@ func (u weightUsecase) FindOne(ctx context.Context, key int64) (resp response.Re
 	}
 	weightDetail := model.WeighDetailResponse{
 		Date:       weight.Date,
-		DateString: u.unixToDateString(weight.Date),
+		DateString: formatDate(weight.Date),
 		Max:        weight.Max,
 		Min:        weight.Min,
 		Diff:       weight.Diff,
+		Version:    weight.Version,
 	}
 	return response.NewSuccessResponse(weightDetail, response.StatOK, weightSuccessMessage)
 }
 
-func (u weightUsecase) unixToDateString(timestamp int64) string {
-	tUnix := timestamp / int64(time.Second)
-	tUnixNanoRemainder := (timestamp % int64(time.Second))
-	date := time.Unix(tUnix, tUnixNanoRemainder)
-	return date.Format("2006-01-02")
+func (u weightUsecase) DeleteOne(ctx context.Context, key int64) (resp response.Response) {
+	if resp = u.authorizeWrite(ctx); resp != nil {
+		return
+	}
+
+	owner := requestctx.Owner(ctx)
+	// the deleted weight can not be read back, so it is read before for the notification.
+	var deleted *entity.Weight
+	if u.notifier != nil {
+		if weight, err := u.repository.FindOne(ctx, owner, key); err == nil {
+			deleted = &weight
+		}
+	}
+
+	err := u.repository.DeleteOne(ctx, owner, key)
+	if err != nil {
+		u.log(ctx).Error(err)
+		if err != exception.ErrNotFound {
+			return response.NewErrorResponse(err, http.StatusInternalServerError, nil, response.StatUnexpectedError, deleteOneUnexpectedErrMessage)
+		}
+
+		return response.NewErrorResponse(exception.ErrNotFound, http.StatusNotFound, nil, response.StatNotFound, weightNotFoundErrMessage)
+
+	}
+	if deleted != nil {
+		u.publish(ctx, eventWeightDeleted, owner, *deleted)
+	}
+	return response.NewSuccessResponse(nil, response.StatOK, deleteOneSuccessMessage)
+}
+
+func (u weightUsecase) Statistics(ctx context.Context, filter model.StatisticFilter) (resp response.Response) {
+	switch filter.GroupBy {
+	case "", groupByWeek, groupByMonth, groupByYear:
+	default:
+		return response.NewErrorResponse(exception.ErrBadRequest, http.StatusBadRequest, nil, response.StatBadRequest, invalidGroupByErrMessage)
+	}
+
+	filter.Owner = requestctx.Owner(ctx)
+	statistics, err := u.repository.Statistics(ctx, filter)
+	if err != nil {
+		u.log(ctx).Error(err)
+		if err != exception.ErrNotFound {
+			return response.NewErrorResponse(err, http.StatusInternalServerError, nil, response.StatUnexpectedError, weightUnexpectedErrMessage)
+		}
+
+		return response.NewErrorResponse(exception.ErrNotFound, http.StatusNotFound, nil, response.StatNotFound, weightNotFoundErrMessage)
+	}
+
+	var statisticResponse []model.StatisticResponse
+	for _, s := range statistics {
+		sr := model.StatisticResponse{
+			Period: u.periodString(filter.GroupBy, s.Period),
+			Count:  s.Count,
+			Max:    u.statisticSummary(s.Max),
+			Min:    u.statisticSummary(s.Min),
+			Diff:   u.statisticSummary(s.Diff),
+		}
+		statisticResponse = append(statisticResponse, sr)
+	}
+	return response.NewSuccessResponse(statisticResponse, response.StatOK, statisticSuccessMessage)
+}
+
+func (u weightUsecase) ImportMany(ctx context.Context, rows []model.WeightImportRow, overwrite bool) (resp response.Response) {
+	if resp = u.authorizeWrite(ctx); resp != nil {
+		return
+	}
+
+	owner := requestctx.Owner(ctx)
+	report := model.ImportReport{
+		Rows: make([]model.ImportRowReport, len(rows)),
+	}
+
+	var batch []entity.Weight
+	var batchIndexes []int
+	flush := func() error {
+		if len(batch) < 1 {
+			return nil
+		}
+
+		inserted, err := u.repository.BulkUpsert(ctx, owner, batch, overwrite)
+		if err != nil {
+			return err
+		}
+
+		written := make(map[int64]string)
+		for i, index := range batchIndexes {
+			switch {
+			case inserted[i]:
+				report.Rows[index].Status = importStatusInserted
+				report.Inserted++
+				written[batch[i].Date] = eventWeightCreated
+			case overwrite:
+				report.Rows[index].Status = importStatusUpdated
+				report.Updated++
+				written[batch[i].Date] = eventWeightUpdated
+			default:
+				report.Rows[index].Status = importStatusSkipped
+				report.Skipped++
+			}
+		}
+		u.notifyMany(ctx, owner, written)
+
+		batch = nil
+		batchIndexes = nil
+		return nil
+	}
+
+	for index, row := range rows {
+		report.Rows[index] = model.ImportRowReport{
+			Row:  row.Row,
+			Date: row.Payload.Date,
+		}
+
+		if row.Error != "" {
+			report.Rows[index].Status = importStatusInvalid
+			report.Rows[index].Error = row.Error
+			report.Invalid++
+			continue
+		}
+
+		batch = append(batch, entity.Weight{
+			Owner: owner,
+			Date:  row.Payload.Date,
+			Day:   formatDate(row.Payload.Date),
+			Max:   row.Payload.Max,
+			Min:   row.Payload.Min,
+			Diff:  row.Payload.Max - row.Payload.Min,
+		})
+		batchIndexes = append(batchIndexes, index)
+
+		if len(batch) >= importBatchSize {
+			if err := flush(); err != nil {
+				u.log(ctx).Error(err)
+				return response.NewErrorResponse(err, http.StatusInternalServerError, report, response.StatUnexpectedError, importUnexpectedErrMessage)
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		u.log(ctx).Error(err)
+		return response.NewErrorResponse(err, http.StatusInternalServerError, report, response.StatUnexpectedError, importUnexpectedErrMessage)
+	}
+
+	return response.NewSuccessResponse(report, response.StatOK, importSuccessMessage)
+}
+
+func (u weightUsecase) ExportMany(ctx context.Context, filter model.WeightFilter, fn func(weight model.WeightExportResponse) error) (resp response.Response) {
+	filter.Owner = requestctx.Owner(ctx)
+	filter, err := u.normalizeFilter(filter)
+	if err != nil {
+		return response.NewErrorResponse(err, http.StatusBadRequest, nil, response.StatBadRequest, invalidSortErrMessage)
+	}
+
+	err = u.repository.FindEach(ctx, filter, func(w entity.Weight) error {
+		return fn(model.WeightExportResponse{
+			Date:       w.Date,
+			DateString: formatDate(w.Date),
+			Max:        w.Max,
+			Min:        w.Min,
+			Diff:       w.Diff,
+		})
+	})
+	if err != nil {
+		u.log(ctx).Error(err)
+		return response.NewErrorResponse(err, http.StatusInternalServerError, nil, response.StatUnexpectedError, exportUnexpectedErrMessage)
+	}
+
+	return response.NewSuccessResponse(nil, response.StatOK, exportSuccessMessage)
+}
+
+// History returns the revisions of the weight of the key, the newest first.
+// The time of each revision is formatted in the timezone of the user.
+func (u weightUsecase) History(ctx context.Context, key int64) (resp response.Response) {
+	revisions, err := u.history.FindMany(ctx, requestctx.Owner(ctx), key)
+	if err != nil {
+		u.log(ctx).Error(err)
+		if err != exception.ErrNotFound {
+			return response.NewErrorResponse(err, http.StatusInternalServerError, nil, response.StatUnexpectedError, historyUnexpectedErrMessage)
+		}
+
+		return response.NewErrorResponse(exception.ErrNotFound, http.StatusNotFound, nil, response.StatNotFound, weightNotFoundErrMessage)
+	}
+
+	location := requestctx.Location(ctx)
+	var revisionResponse []model.WeightRevisionResponse
+	for _, revision := range revisions {
+		revisionResponse = append(revisionResponse, model.WeightRevisionResponse{
+			ID:        revision.ID,
+			Action:    revision.Action,
+			Actor:     revision.Actor,
+			RequestID: revision.RequestID,
+			CreatedAt: time.Unix(0, revision.CreatedAt).In(location).Format(time.RFC3339),
+			Before:    revisionSnapshot(revision.Before),
+			After:     revisionSnapshot(revision.After),
+		})
+	}
+	return response.NewSuccessResponse(revisionResponse, response.StatOK, historySuccessMessage)
+}
+
+// Restore puts the weight of the key back as it was after the revision,
+// or as it was before the revision when the revision is a delete.
+func (u weightUsecase) Restore(ctx context.Context, key int64, revisionID string) (resp response.Response) {
+	if resp = u.authorizeWrite(ctx); resp != nil {
+		return
+	}
+
+	owner := requestctx.Owner(ctx)
+	revision, err := u.history.FindOne(ctx, owner, key, revisionID)
+	if err != nil {
+		u.log(ctx).Error(err)
+		if err != exception.ErrNotFound {
+			return response.NewErrorResponse(err, http.StatusInternalServerError, nil, response.StatUnexpectedError, restoreUnexpectedErrMessage)
+		}
+
+		return response.NewErrorResponse(exception.ErrNotFound, http.StatusNotFound, nil, response.StatNotFound, revisionNotFoundErrMessage)
+	}
+
+	snapshot := revision.After
+	if snapshot == nil {
+		snapshot = revision.Before
+	}
+	if snapshot == nil {
+		return response.NewErrorResponse(exception.ErrNotFound, http.StatusNotFound, nil, response.StatNotFound, revisionNotFoundErrMessage)
+	}
+
+	weight := entity.Weight{
+		Owner: owner,
+		Date:  key,
+		Day:   formatDate(key),
+		Max:   snapshot.Max,
+		Min:   snapshot.Min,
+		Diff:  snapshot.Max - snapshot.Min,
+	}
+	created, err := u.repository.UpsertOne(contextWithRevisionAction(ctx, revisionActionRestore), owner, key, weight)
+	if err != nil {
+		u.log(ctx).Error(err)
+		return response.NewErrorResponse(err, http.StatusInternalServerError, nil, response.StatUnexpectedError, restoreUnexpectedErrMessage)
+	}
+
+	eventType := eventWeightUpdated
+	if created {
+		eventType = eventWeightCreated
+	}
+	u.notify(ctx, eventType, owner, key)
+
+	return response.NewSuccessResponse(nil, response.StatOK, restoreSuccessMessage)
+}
+
+// authorizeWrite rejects the write of an anonymous caller when every weight must have an owner,
+// otherwise anonymous callers would share the weights without owner.
+func (u weightUsecase) authorizeWrite(ctx context.Context) (resp response.Response) {
+	if u.requireOwner && requestctx.Owner(ctx) == "" {
+		return response.NewErrorResponse(exception.ErrUnauthorized, http.StatusUnauthorized, nil, response.StatUnauthorized, anonymousWriteErrMessage)
+	}
+	return nil
+}
+
+// log returns the request scoped logger of ctx.
+func (u weightUsecase) log(ctx context.Context) *logrus.Entry {
+	return requestctx.Logger(ctx, u.logger)
+}
+
+// notify passes the event of the weight of the key, as it is stored, to the notifier.
+// The weight is already written, so a failure is only logged.
+func (u weightUsecase) notify(ctx context.Context, eventType string, owner string, key int64) {
+	if u.notifier == nil {
+		return
+	}
+
+	weight, err := u.repository.FindOne(ctx, owner, key)
+	if err != nil {
+		u.log(ctx).Error(fmt.Sprintf(notifyFailedMessage, eventType, formatDate(key), err))
+		return
+	}
+	u.publish(ctx, eventType, owner, weight)
+}
+
+// notifyMany passes the event of each written date of owner to the notifier, the weights are read in one go
+// in the order of the dates.
+func (u weightUsecase) notifyMany(ctx context.Context, owner string, written map[int64]string) {
+	if u.notifier == nil || len(written) < 1 {
+		return
+	}
+
+	filter := model.WeightFilter{Owner: owner, SortBy: defaultSortBy, Sort: 1}
+	for date := range written {
+		if filter.From == 0 || date < filter.From {
+			filter.From = date
+		}
+		if date > filter.To {
+			filter.To = date
+		}
+	}
+
+	err := u.repository.FindEach(ctx, filter, func(weight entity.Weight) error {
+		if eventType, ok := written[weight.Date]; ok {
+			u.publish(ctx, eventType, owner, weight)
+		}
+		return nil
+	})
+	if err != nil {
+		u.log(ctx).Error(fmt.Sprintf(notifyFailedMessage, "events", formatDate(filter.From)+" to "+formatDate(filter.To), err))
+	}
+}
+
+// publish passes the event of weight to the notifier, a failure is only logged.
+func (u weightUsecase) publish(ctx context.Context, eventType string, owner string, weight entity.Weight) {
+	if err := u.notifier.Notify(ctx, eventType, owner, revisionSnapshot(&weight)); err != nil {
+		u.log(ctx).Error(fmt.Sprintf(notifyFailedMessage, eventType, formatDate(weight.Date), err))
+	}
+}
+
+func revisionSnapshot(weight *entity.Weight) *model.WeighDetailResponse {
+	if weight == nil {
+		return nil
+	}
+
+	return &model.WeighDetailResponse{
+		Date:       weight.Date,
+		DateString: formatDate(weight.Date),
+		Max:        weight.Max,
+		Min:        weight.Min,
+		Diff:       weight.Diff,
+		Version:    weight.Version,
+	}
+}
+
+func (u weightUsecase) statisticSummary(field entity.WeightStatisticField) model.StatisticSummary {
+	return model.StatisticSummary{
+		Min:    field.Min,
+		Max:    field.Max,
+		Mean:   field.Mean,
+		Median: u.median(field.Values),
+		StdDev: field.StdDev,
+	}
+}
+
+func (u weightUsecase) median(values []int) float64 {
+	if len(values) < 1 {
+		return 0
+	}
+
+	sorted := make([]int, len(values))
+	copy(sorted, values)
+	sort.Ints(sorted)
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return float64(sorted[mid-1]+sorted[mid]) / 2
+	}
+	return float64(sorted[mid])
+}
+
+func (u weightUsecase) periodString(groupBy string, period entity.WeightStatisticPeriod) string {
+	switch groupBy {
+	case groupByWeek:
+		return fmt.Sprintf("%04d-W%02d", period.Year, period.Week)
+	case groupByMonth:
+		return fmt.Sprintf("%04d-%02d", period.Year, period.Month)
+	case groupByYear:
+		return fmt.Sprintf("%04d", period.Year)
+	default:
+		return "all"
+	}
+}
+
+func (u weightUsecase) normalizeFilter(filter model.WeightFilter) (model.WeightFilter, error) {
+	if filter.SortBy == "" {
+		filter.SortBy = defaultSortBy
+	}
+	if !sortableFields[filter.SortBy] {
+		return filter, exception.ErrBadRequest
+	}
+	if filter.Sort != 1 {
+		filter.Sort = defaultSort
+	}
+	if filter.Page < 1 {
+		filter.Page = defaultPage
+	}
+	if filter.Limit < 1 {
+		filter.Limit = defaultLimit
+	}
+	if filter.Limit > maxLimit {
+		filter.Limit = maxLimit
+	}
+	return filter, nil
 }
@@ -0,0 +1,93 @@
+package weight
+
+import (
+	"context"
+
+	"github.com/ijalalfrz/sirclo-weight-test/model"
+	"github.com/ijalalfrz/sirclo-weight-test/response"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// metricsUsecase is a decorator of Usecase which counts the outcome of every call by its response status.
+type metricsUsecase struct {
+	next     Usecase
+	outcomes *prometheus.CounterVec
+}
+
+// NewMetricsUsecase is a constructor. The outcomes counter must be labeled by method and status.
+func NewMetricsUsecase(next Usecase, outcomes *prometheus.CounterVec) Usecase {
+	return metricsUsecase{
+		next:     next,
+		outcomes: outcomes,
+	}
+}
+
+func (u metricsUsecase) InsertOne(ctx context.Context, payload model.WeightPayload) (resp response.Response) {
+	resp = u.next.InsertOne(ctx, payload)
+	u.count("InsertOne", resp)
+	return
+}
+
+func (u metricsUsecase) UpdateOne(ctx context.Context, key int64, payload model.WeightPayload) (resp response.Response) {
+	resp = u.next.UpdateOne(ctx, key, payload)
+	u.count("UpdateOne", resp)
+	return
+}
+
+func (u metricsUsecase) UpsertOne(ctx context.Context, key int64, payload model.WeightPayload) (resp response.Response) {
+	resp = u.next.UpsertOne(ctx, key, payload)
+	u.count("UpsertOne", resp)
+	return
+}
+
+func (u metricsUsecase) FindMany(ctx context.Context, filter model.WeightFilter) (resp response.Response) {
+	resp = u.next.FindMany(ctx, filter)
+	u.count("FindMany", resp)
+	return
+}
+
+func (u metricsUsecase) FindOne(ctx context.Context, key int64) (resp response.Response) {
+	resp = u.next.FindOne(ctx, key)
+	u.count("FindOne", resp)
+	return
+}
+
+func (u metricsUsecase) DeleteOne(ctx context.Context, key int64) (resp response.Response) {
+	resp = u.next.DeleteOne(ctx, key)
+	u.count("DeleteOne", resp)
+	return
+}
+
+func (u metricsUsecase) Statistics(ctx context.Context, filter model.StatisticFilter) (resp response.Response) {
+	resp = u.next.Statistics(ctx, filter)
+	u.count("Statistics", resp)
+	return
+}
+
+func (u metricsUsecase) ImportMany(ctx context.Context, rows []model.WeightImportRow, overwrite bool) (resp response.Response) {
+	resp = u.next.ImportMany(ctx, rows, overwrite)
+	u.count("ImportMany", resp)
+	return
+}
+
+func (u metricsUsecase) ExportMany(ctx context.Context, filter model.WeightFilter, fn func(weight model.WeightExportResponse) error) (resp response.Response) {
+	resp = u.next.ExportMany(ctx, filter, fn)
+	u.count("ExportMany", resp)
+	return
+}
+
+func (u metricsUsecase) History(ctx context.Context, key int64) (resp response.Response) {
+	resp = u.next.History(ctx, key)
+	u.count("History", resp)
+	return
+}
+
+func (u metricsUsecase) Restore(ctx context.Context, key int64, revisionID string) (resp response.Response) {
+	resp = u.next.Restore(ctx, key, revisionID)
+	u.count("Restore", resp)
+	return
+}
+
+func (u metricsUsecase) count(method string, resp response.Response) {
+	u.outcomes.WithLabelValues(method, resp.Status()).Inc()
+}
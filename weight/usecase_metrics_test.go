@@ -0,0 +1,38 @@
+package weight_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/ijalalfrz/sirclo-weight-test/exception"
+	"github.com/ijalalfrz/sirclo-weight-test/model"
+	"github.com/ijalalfrz/sirclo-weight-test/response"
+	"github.com/ijalalfrz/sirclo-weight-test/weight"
+	"github.com/ijalalfrz/sirclo-weight-test/weight/mocks"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMetricsUsecase_Outcomes(t *testing.T) {
+	outcomes := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "outcomes"}, []string{"method", "status"})
+	usecaseMock := new(mocks.Usecase)
+	usecase := weight.NewMetricsUsecase(usecaseMock, outcomes)
+
+	usecaseMock.On("FindOne", mock.Anything, int64(1)).Return(response.NewSuccessResponse(nil, response.StatOK, "ok"))
+	usecaseMock.On("FindOne", mock.Anything, int64(2)).Return(response.NewErrorResponse(exception.ErrNotFound, http.StatusNotFound, nil, response.StatNotFound, "not found"))
+	usecaseMock.On("InsertOne", mock.Anything, mock.Anything).Return(response.NewErrorResponse(exception.ErrConflict, http.StatusConflict, nil, response.StatAlreadyExist, "exist"))
+
+	usecase.FindOne(context.TODO(), 1)
+	usecase.FindOne(context.TODO(), 1)
+	result := usecase.FindOne(context.TODO(), 2)
+	usecase.InsertOne(context.TODO(), model.WeightPayload{})
+
+	assert.Equal(t, exception.ErrNotFound, result.Error(), "should return the response of the decorated usecase")
+	assert.Equal(t, float64(2), testutil.ToFloat64(outcomes.WithLabelValues("FindOne", response.StatOK)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(outcomes.WithLabelValues("FindOne", response.StatNotFound)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(outcomes.WithLabelValues("InsertOne", response.StatAlreadyExist)))
+	usecaseMock.AssertExpectations(t)
+}
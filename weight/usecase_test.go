@@ -2,14 +2,19 @@ package weight_test
 
 import (
 	"context"
+	"net/http"
 	"testing"
+	"time"
 
 	"github.com/ijalalfrz/sirclo-weight-test/entity"
 	"github.com/ijalalfrz/sirclo-weight-test/exception"
 	"github.com/ijalalfrz/sirclo-weight-test/model"
+	"github.com/ijalalfrz/sirclo-weight-test/requestctx"
+	"github.com/ijalalfrz/sirclo-weight-test/response"
 	"github.com/ijalalfrz/sirclo-weight-test/weight"
 	"github.com/ijalalfrz/sirclo-weight-test/weight/mocks"
 	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -29,7 +34,7 @@ func TestUsecaseInsertOne_Success(t *testing.T) {
 		Diff: 0,
 	}
 
-	repoMock.On("FindOne", mock.Anything, mock.Anything).Return(resultRepo, nil)
+	repoMock.On("FindOne", mock.Anything, mock.Anything, mock.Anything).Return(resultRepo, nil)
 	repoMock.On("InsertOne", mock.Anything, mock.Anything).Return(nil)
 
 	result := usecase.InsertOne(context.TODO(), model.WeightPayload{})
@@ -53,7 +58,7 @@ func TestUsecaseInsertOne_Unexpected(t *testing.T) {
 		Diff: 0,
 	}
 
-	repoMock.On("FindOne", mock.Anything, mock.Anything).Return(resultRepo, nil)
+	repoMock.On("FindOne", mock.Anything, mock.Anything, mock.Anything).Return(resultRepo, nil)
 	repoMock.On("InsertOne", mock.Anything, mock.Anything).Return(exception.ErrInternalServer)
 
 	result := usecase.InsertOne(context.TODO(), model.WeightPayload{})
@@ -72,7 +77,7 @@ func TestUsecaseInsertOne_Error_Unexpected_When_FindWeight(t *testing.T) {
 		Repository:  repoMock,
 	})
 
-	repoMock.On("FindOne", mock.Anything, mock.Anything).Return(entity.Weight{}, exception.ErrInternalServer)
+	repoMock.On("FindOne", mock.Anything, mock.Anything, mock.Anything).Return(entity.Weight{}, exception.ErrInternalServer)
 	result := usecase.InsertOne(context.TODO(), model.WeightPayload{})
 
 	assert.Error(t, result.Error(), "should be error")
@@ -89,7 +94,7 @@ func TestUsecaseInsertOne_Error_Already_Exist_Weight(t *testing.T) {
 		Repository:  repoMock,
 	})
 
-	repoMock.On("FindOne", mock.Anything, mock.Anything).Return(entity.Weight{Date: 1}, nil)
+	repoMock.On("FindOne", mock.Anything, mock.Anything, mock.Anything).Return(entity.Weight{Date: 1}, nil)
 	result := usecase.InsertOne(context.TODO(), model.WeightPayload{})
 
 	assert.Error(t, result.Error(), "should be error")
@@ -98,6 +103,25 @@ func TestUsecaseInsertOne_Error_Already_Exist_Weight(t *testing.T) {
 
 }
 
+func TestUsecaseInsertOne_Error_Conflict(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logrus.New(),
+		Repository:  repoMock,
+	})
+
+	repoMock.On("FindOne", mock.Anything, mock.Anything, mock.Anything).Return(entity.Weight{}, exception.ErrNotFound)
+	repoMock.On("InsertOne", mock.Anything, mock.Anything).Return(exception.ErrConflict)
+
+	result := usecase.InsertOne(context.TODO(), model.WeightPayload{Date: 1, Max: 2, Min: 1})
+
+	assert.Equal(t, exception.ErrConflict, result.Error(), "should be conflict error")
+	assert.Equal(t, http.StatusConflict, result.HTTPStatusCode())
+	assert.Equal(t, response.StatAlreadyExist, result.Status())
+	repoMock.AssertExpectations(t)
+}
+
 func TestUsecaseUpdateOne_Success(t *testing.T) {
 	repoMock := new(mocks.Repository)
 	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
@@ -112,7 +136,7 @@ func TestUsecaseUpdateOne_Success(t *testing.T) {
 		Min:  1,
 	}
 
-	repoMock.On("UpdateOne", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	repoMock.On("UpdateOne", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
 	result := usecase.UpdateOne(context.TODO(), payload.Date, payload)
 
@@ -135,7 +159,7 @@ func TestUsecaseUpdateOne_Error_Unexpected(t *testing.T) {
 		Min:  1,
 	}
 
-	repoMock.On("UpdateOne", mock.Anything, mock.Anything, mock.Anything).Return(exception.ErrInternalServer)
+	repoMock.On("UpdateOne", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(exception.ErrInternalServer)
 
 	result := usecase.UpdateOne(context.TODO(), payload.Date, payload)
 
@@ -144,6 +168,145 @@ func TestUsecaseUpdateOne_Error_Unexpected(t *testing.T) {
 	repoMock.AssertExpectations(t)
 }
 
+func TestUsecaseUpdateOne_Error_NotFound(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logrus.New(),
+		Repository:  repoMock,
+	})
+
+	repoMock.On("UpdateOne", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(exception.ErrNotFound)
+
+	result := usecase.UpdateOne(context.TODO(), 1, model.WeightPayload{Max: 2, Min: 1})
+
+	assert.Equal(t, exception.ErrNotFound, result.Error(), "should be not found error")
+	assert.Equal(t, http.StatusNotFound, result.HTTPStatusCode())
+	repoMock.AssertExpectations(t)
+}
+
+func TestUsecaseUpdateOne_Success_KeyIsAuthoritative(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logrus.New(),
+		Repository:  repoMock,
+	})
+
+	expected := entity.Weight{Date: 1, Day: "1970-01-01", Max: 3, Min: 1, Diff: 2}
+	repoMock.On("UpdateOne", mock.Anything, "", int64(1), expected).Return(nil)
+
+	result := usecase.UpdateOne(context.TODO(), 1, model.WeightPayload{Date: 2, Max: 3, Min: 1})
+
+	assert.Nil(t, result.Error(), "should be no error")
+	repoMock.AssertExpectations(t)
+}
+
+func TestUsecaseUpdateOne_Error_VersionConflict(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logrus.New(),
+		Repository:  repoMock,
+	})
+
+	expected := entity.Weight{Date: 1, Day: "1970-01-01", Max: 3, Min: 1, Diff: 2, Version: 2}
+	repoMock.On("UpdateOne", mock.Anything, "", int64(1), expected).Return(exception.ErrConflict)
+
+	result := usecase.UpdateOne(context.TODO(), 1, model.WeightPayload{Max: 3, Min: 1, Version: 2})
+
+	assert.Equal(t, exception.ErrConflict, result.Error(), "should be conflict error")
+	assert.Equal(t, http.StatusConflict, result.HTTPStatusCode())
+	assert.Equal(t, response.StatVersionConflict, result.Status())
+	repoMock.AssertExpectations(t)
+}
+
+func TestUsecaseUpsertOne_Error_VersionConflict(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logrus.New(),
+		Repository:  repoMock,
+	})
+
+	repoMock.On("UpsertOne", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(false, exception.ErrConflict)
+
+	result := usecase.UpsertOne(context.TODO(), 1, model.WeightPayload{Max: 3, Min: 1, Version: 2})
+
+	assert.Equal(t, http.StatusConflict, result.HTTPStatusCode())
+	assert.Equal(t, response.StatVersionConflict, result.Status())
+	repoMock.AssertExpectations(t)
+}
+
+func TestUsecaseUpsertOne_Error_NotFound_WithVersion(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logrus.New(),
+		Repository:  repoMock,
+	})
+
+	repoMock.On("UpsertOne", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(false, exception.ErrNotFound)
+
+	result := usecase.UpsertOne(context.TODO(), 1, model.WeightPayload{Max: 3, Min: 1, Version: 2})
+
+	assert.Equal(t, exception.ErrNotFound, result.Error(), "should be not found error")
+	assert.Equal(t, http.StatusNotFound, result.HTTPStatusCode())
+	repoMock.AssertExpectations(t)
+}
+
+func TestUsecaseUpsertOne_Success_Created(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logrus.New(),
+		Repository:  repoMock,
+	})
+
+	expected := entity.Weight{Owner: "john", Date: 1, Day: "1970-01-01", Max: 3, Min: 1, Diff: 2}
+	repoMock.On("UpsertOne", mock.Anything, "john", int64(1), expected).Return(true, nil)
+
+	ctx := requestctx.WithOwner(context.TODO(), "john")
+	result := usecase.UpsertOne(ctx, 1, model.WeightPayload{Date: 2, Max: 3, Min: 1})
+
+	assert.Nil(t, result.Error(), "should be no error")
+	assert.Equal(t, http.StatusCreated, result.HTTPStatusCode())
+	repoMock.AssertExpectations(t)
+}
+
+func TestUsecaseUpsertOne_Success_Updated(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logrus.New(),
+		Repository:  repoMock,
+	})
+
+	repoMock.On("UpsertOne", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(false, nil)
+
+	result := usecase.UpsertOne(context.TODO(), 1, model.WeightPayload{Max: 3, Min: 1})
+
+	assert.Nil(t, result.Error(), "should be no error")
+	assert.Equal(t, http.StatusOK, result.HTTPStatusCode())
+	repoMock.AssertExpectations(t)
+}
+
+func TestUsecaseUpsertOne_Error_Unexpected(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logrus.New(),
+		Repository:  repoMock,
+	})
+
+	repoMock.On("UpsertOne", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(false, exception.ErrInternalServer)
+
+	result := usecase.UpsertOne(context.TODO(), 1, model.WeightPayload{Max: 3, Min: 1})
+
+	assert.Equal(t, exception.ErrInternalServer, result.Error(), "should be internal server error")
+	repoMock.AssertExpectations(t)
+}
+
 func TestUsecaseFindMany_Error_Unexpected(t *testing.T) {
 	repoMock := new(mocks.Repository)
 	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
@@ -152,9 +315,10 @@ func TestUsecaseFindMany_Error_Unexpected(t *testing.T) {
 		Repository:  repoMock,
 	})
 
-	repoMock.On("FindMany", mock.Anything, mock.Anything, mock.Anything).Return([]entity.Weight{}, exception.ErrInternalServer)
+	repoMock.On("CountMany", mock.Anything, mock.Anything).Return(int64(0), nil)
+	repoMock.On("FindMany", mock.Anything, mock.Anything).Return([]entity.Weight{}, exception.ErrInternalServer)
 
-	result := usecase.FindMany(context.TODO())
+	result := usecase.FindMany(context.TODO(), model.WeightFilter{})
 
 	assert.Error(t, result.Error(), "should be error")
 	assert.Equal(t, result.Error(), exception.ErrInternalServer, "should be internal server error")
@@ -169,9 +333,10 @@ func TestUsecaseFindMany_Error_NotFound(t *testing.T) {
 		Repository:  repoMock,
 	})
 
-	repoMock.On("FindMany", mock.Anything, mock.Anything, mock.Anything).Return([]entity.Weight{}, exception.ErrNotFound)
+	repoMock.On("CountMany", mock.Anything, mock.Anything).Return(int64(0), nil)
+	repoMock.On("FindMany", mock.Anything, mock.Anything).Return([]entity.Weight{}, exception.ErrNotFound)
 
-	result := usecase.FindMany(context.TODO())
+	result := usecase.FindMany(context.TODO(), model.WeightFilter{})
 
 	assert.Error(t, result.Error(), "should be error")
 	assert.Equal(t, result.Error(), exception.ErrNotFound, "should be not found error")
@@ -193,13 +358,92 @@ func TestUsecaseFindMany_Success(t *testing.T) {
 			Diff: 1,
 		},
 	}
-	repoMock.On("FindMany", mock.Anything, mock.Anything, mock.Anything).Return(data, nil)
+	average := entity.WeightAverage{Max: 3.5, Min: 1.5, Diff: 2}
+	expectedFilter := model.WeightFilter{SortBy: "date", Sort: -1, Page: 1, Limit: 30}
+	repoMock.On("CountMany", mock.Anything, expectedFilter).Return(int64(31), nil)
+	repoMock.On("FindMany", mock.Anything, expectedFilter).Return(data, nil)
+	repoMock.On("Averages", mock.Anything, expectedFilter).Return(average, nil)
 
-	result := usecase.FindMany(context.TODO())
+	result := usecase.FindMany(context.TODO(), model.WeightFilter{})
 
 	assert.Nil(t, result.Error(), "should be no error")
 	resultData := result.Data().(model.WeightResponse)
 	assert.Equal(t, len(resultData.List), 1, "should be equal one")
+	assert.Equal(t, float32(3.5), resultData.AverageMax, "should be averaged over the whole filter")
+	assert.Equal(t, float32(1.5), resultData.AverageMin, "should be averaged over the whole filter")
+	assert.Equal(t, float32(2), resultData.AverageDiff, "should be averaged over the whole filter")
+	resultMeta := result.Meta().(model.WeightMeta)
+	assert.Equal(t, int64(31), resultMeta.TotalData)
+	assert.Equal(t, int64(2), resultMeta.TotalPage)
+	repoMock.AssertExpectations(t)
+}
+
+func TestUsecaseFindMany_Success_LimitIsCapped(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logrus.New(),
+		Repository:  repoMock,
+	})
+
+	expectedFilter := model.WeightFilter{SortBy: "date", Sort: -1, Page: 1, Limit: 100}
+	repoMock.On("CountMany", mock.Anything, expectedFilter).Return(int64(1), nil)
+	repoMock.On("FindMany", mock.Anything, expectedFilter).Return([]entity.Weight{{Date: 1}}, nil)
+	repoMock.On("Averages", mock.Anything, expectedFilter).Return(entity.WeightAverage{}, nil)
+
+	result := usecase.FindMany(context.TODO(), model.WeightFilter{Limit: 1000000})
+
+	assert.Nil(t, result.Error(), "should be no error")
+	assert.Equal(t, int64(100), result.Meta().(model.WeightMeta).Limit)
+	repoMock.AssertExpectations(t)
+}
+
+func TestUsecaseFindMany_Error_Unexpected_When_Count(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logrus.New(),
+		Repository:  repoMock,
+	})
+
+	repoMock.On("CountMany", mock.Anything, mock.Anything).Return(int64(0), exception.ErrInternalServer)
+
+	result := usecase.FindMany(context.TODO(), model.WeightFilter{})
+
+	assert.Error(t, result.Error(), "should be error")
+	assert.Equal(t, result.Error(), exception.ErrInternalServer, "should be internal server error")
+	repoMock.AssertExpectations(t)
+}
+
+func TestUsecaseFindMany_Error_InvalidSort(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logrus.New(),
+		Repository:  repoMock,
+	})
+
+	result := usecase.FindMany(context.TODO(), model.WeightFilter{SortBy: "unknown"})
+
+	assert.Error(t, result.Error(), "should be error")
+	assert.Equal(t, result.Error(), exception.ErrBadRequest, "should be bad request error")
+	repoMock.AssertExpectations(t)
+}
+
+func TestUsecaseFindOne_Success_CalendarDate(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logrus.New(),
+		Repository:  repoMock,
+	})
+
+	repoMock.On("FindOne", mock.Anything, mock.Anything, int64(1656633600000000000)).Return(entity.Weight{Date: 1656633600000000000, Day: "2022-07-01", Max: 3, Min: 1, Diff: 2}, nil)
+
+	result := usecase.FindOne(context.TODO(), 1656633600000000000)
+
+	assert.Nil(t, result.Error(), "should be no error")
+	assert.Equal(t, "2022-07-01", result.Data().(model.WeighDetailResponse).DateString, "should not depend on the timezone of the server")
 	repoMock.AssertExpectations(t)
 }
 
@@ -211,7 +455,7 @@ func TestUsecaseFindOne_Error_Unexpected(t *testing.T) {
 		Repository:  repoMock,
 	})
 
-	repoMock.On("FindOne", mock.Anything, mock.Anything).Return(entity.Weight{}, exception.ErrInternalServer)
+	repoMock.On("FindOne", mock.Anything, mock.Anything, mock.Anything).Return(entity.Weight{}, exception.ErrInternalServer)
 
 	result := usecase.FindOne(context.TODO(), 1)
 
@@ -220,6 +464,24 @@ func TestUsecaseFindOne_Error_Unexpected(t *testing.T) {
 	repoMock.AssertExpectations(t)
 }
 
+func TestUsecaseFindOne_Error_Unexpected_LogsWithRequestLogger(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	repoMock := new(mocks.Repository)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logger,
+		Repository:  repoMock,
+	})
+
+	repoMock.On("FindOne", mock.Anything, mock.Anything, mock.Anything).Return(entity.Weight{}, exception.ErrInternalServer)
+
+	ctx := requestctx.WithLogger(context.TODO(), logger.WithField("request_id", "abc-123"))
+	usecase.FindOne(ctx, 1)
+
+	assert.Equal(t, "abc-123", hook.LastEntry().Data["request_id"], "should log with the request scoped logger")
+	repoMock.AssertExpectations(t)
+}
+
 func TestUsecaseFindOne_Error_NotFound(t *testing.T) {
 	repoMock := new(mocks.Repository)
 	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
@@ -228,7 +490,7 @@ func TestUsecaseFindOne_Error_NotFound(t *testing.T) {
 		Repository:  repoMock,
 	})
 
-	repoMock.On("FindOne", mock.Anything, mock.Anything).Return(entity.Weight{}, exception.ErrNotFound)
+	repoMock.On("FindOne", mock.Anything, mock.Anything, mock.Anything).Return(entity.Weight{}, exception.ErrNotFound)
 
 	result := usecase.FindOne(context.TODO(), 1)
 
@@ -252,7 +514,7 @@ func TestUsecaseFindOne_Success(t *testing.T) {
 		Diff: 1,
 	}
 
-	repoMock.On("FindOne", mock.Anything, mock.Anything).Return(data, nil)
+	repoMock.On("FindOne", mock.Anything, mock.Anything, mock.Anything).Return(data, nil)
 
 	result := usecase.FindOne(context.TODO(), 1)
 
@@ -262,3 +524,712 @@ func TestUsecaseFindOne_Success(t *testing.T) {
 	repoMock.AssertExpectations(t)
 	repoMock.AssertExpectations(t)
 }
+
+func TestUsecaseDeleteOne_Success(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logrus.New(),
+		Repository:  repoMock,
+	})
+
+	repoMock.On("DeleteOne", mock.Anything, "", int64(1)).Return(nil)
+
+	result := usecase.DeleteOne(context.TODO(), 1)
+
+	assert.Nil(t, result.Error(), "should be no error")
+	repoMock.AssertExpectations(t)
+}
+
+func TestUsecaseDeleteOne_Error_NotFound(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logrus.New(),
+		Repository:  repoMock,
+	})
+
+	repoMock.On("DeleteOne", mock.Anything, mock.Anything, mock.Anything).Return(exception.ErrNotFound)
+
+	result := usecase.DeleteOne(context.TODO(), 1)
+
+	assert.Error(t, result.Error(), "should be error")
+	assert.Equal(t, result.Error(), exception.ErrNotFound, "should be not found error")
+	assert.Equal(t, response.StatNotFound, result.Status())
+	repoMock.AssertExpectations(t)
+}
+
+func TestUsecaseDeleteOne_Error_Unexpected(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logrus.New(),
+		Repository:  repoMock,
+	})
+
+	repoMock.On("DeleteOne", mock.Anything, mock.Anything, mock.Anything).Return(exception.ErrInternalServer)
+
+	result := usecase.DeleteOne(context.TODO(), 1)
+
+	assert.Error(t, result.Error(), "should be error")
+	assert.Equal(t, result.Error(), exception.ErrInternalServer, "should be internal server error")
+	repoMock.AssertExpectations(t)
+}
+
+func TestUsecaseStatistics_Success(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logrus.New(),
+		Repository:  repoMock,
+	})
+
+	data := []entity.WeightStatistic{
+		{
+			Period: entity.WeightStatisticPeriod{Year: 2022, Week: 5},
+			Count:  4,
+			Max:    entity.WeightStatisticField{Min: 1, Max: 9, Mean: 4.5, StdDev: 1.5, Values: []int{9, 1, 4, 4}},
+			Min:    entity.WeightStatisticField{Values: []int{3, 1, 2}},
+		},
+	}
+	repoMock.On("Statistics", mock.Anything, model.StatisticFilter{GroupBy: "week"}).Return(data, nil)
+
+	result := usecase.Statistics(context.TODO(), model.StatisticFilter{GroupBy: "week"})
+
+	assert.Nil(t, result.Error(), "should be no error")
+	resultData := result.Data().([]model.StatisticResponse)
+	assert.Equal(t, "2022-W05", resultData[0].Period)
+	assert.Equal(t, int64(4), resultData[0].Count)
+	assert.Equal(t, 9, resultData[0].Max.Max)
+	assert.Equal(t, float64(4), resultData[0].Max.Median)
+	assert.Equal(t, float64(2), resultData[0].Min.Median)
+	assert.Equal(t, float64(0), resultData[0].Diff.Median)
+	repoMock.AssertExpectations(t)
+}
+
+func TestUsecaseStatistics_Success_Period(t *testing.T) {
+	cases := map[string]string{
+		"":      "all",
+		"month": "2022-07",
+		"year":  "2022",
+	}
+	for groupBy, expected := range cases {
+		repoMock := new(mocks.Repository)
+		usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+			ServiceName: "test-service",
+			Logger:      logrus.New(),
+			Repository:  repoMock,
+		})
+
+		data := []entity.WeightStatistic{
+			{Period: entity.WeightStatisticPeriod{Year: 2022, Month: 7}},
+		}
+		repoMock.On("Statistics", mock.Anything, mock.Anything).Return(data, nil)
+
+		result := usecase.Statistics(context.TODO(), model.StatisticFilter{GroupBy: groupBy})
+
+		assert.Nil(t, result.Error(), "should be no error")
+		resultData := result.Data().([]model.StatisticResponse)
+		assert.Equal(t, expected, resultData[0].Period)
+		repoMock.AssertExpectations(t)
+	}
+}
+
+func TestUsecaseStatistics_Error_InvalidGroupBy(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logrus.New(),
+		Repository:  repoMock,
+	})
+
+	result := usecase.Statistics(context.TODO(), model.StatisticFilter{GroupBy: "day"})
+
+	assert.Error(t, result.Error(), "should be error")
+	assert.Equal(t, result.Error(), exception.ErrBadRequest, "should be bad request error")
+	repoMock.AssertExpectations(t)
+}
+
+func TestUsecaseStatistics_Error_NotFound(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logrus.New(),
+		Repository:  repoMock,
+	})
+
+	repoMock.On("Statistics", mock.Anything, mock.Anything).Return(nil, exception.ErrNotFound)
+
+	result := usecase.Statistics(context.TODO(), model.StatisticFilter{})
+
+	assert.Error(t, result.Error(), "should be error")
+	assert.Equal(t, result.Error(), exception.ErrNotFound, "should be not found error")
+	repoMock.AssertExpectations(t)
+}
+
+func TestUsecaseStatistics_Error_Unexpected(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logrus.New(),
+		Repository:  repoMock,
+	})
+
+	repoMock.On("Statistics", mock.Anything, mock.Anything).Return(nil, exception.ErrInternalServer)
+
+	result := usecase.Statistics(context.TODO(), model.StatisticFilter{})
+
+	assert.Error(t, result.Error(), "should be error")
+	assert.Equal(t, result.Error(), exception.ErrInternalServer, "should be internal server error")
+	repoMock.AssertExpectations(t)
+}
+
+func TestUsecaseInsertOne_Success_ScopedToOwner(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logrus.New(),
+		Repository:  repoMock,
+	})
+
+	ctx := requestctx.WithOwner(context.TODO(), "john")
+	payload := model.WeightPayload{
+		Date: 1,
+		Max:  2,
+		Min:  1,
+	}
+	expectedWeight := entity.Weight{
+		Owner: "john",
+		Date:  1,
+		Day:   "1970-01-01",
+		Max:   2,
+		Min:   1,
+		Diff:  1,
+	}
+
+	repoMock.On("FindOne", mock.Anything, "john", int64(1)).Return(entity.Weight{}, exception.ErrNotFound)
+	repoMock.On("InsertOne", mock.Anything, expectedWeight).Return(nil)
+
+	result := usecase.InsertOne(ctx, payload)
+
+	assert.Nil(t, result.Error(), "should be no error")
+	repoMock.AssertExpectations(t)
+}
+
+func TestUsecaseWrite_Error_Anonymous(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName:  "test-service",
+		Logger:       logrus.New(),
+		Repository:   repoMock,
+		RequireOwner: true,
+	})
+	payload := model.WeightPayload{Date: 1, Max: 2, Min: 1}
+
+	results := []response.Response{
+		usecase.InsertOne(context.TODO(), payload),
+		usecase.UpdateOne(context.TODO(), 1, payload),
+		usecase.UpsertOne(context.TODO(), 1, payload),
+		usecase.DeleteOne(context.TODO(), 1),
+		usecase.ImportMany(context.TODO(), []model.WeightImportRow{{Row: 1, Payload: payload}}, false),
+		usecase.Restore(context.TODO(), 1, "a"),
+	}
+
+	for _, result := range results {
+		assert.Equal(t, exception.ErrUnauthorized, result.Error(), "should reject the write of an anonymous caller")
+		assert.Equal(t, http.StatusUnauthorized, result.HTTPStatusCode())
+	}
+	repoMock.AssertExpectations(t)
+}
+
+func TestUsecaseFindMany_Success_ScopedToOwner(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logrus.New(),
+		Repository:  repoMock,
+	})
+
+	ctx := requestctx.WithOwner(context.TODO(), "john")
+	expectedFilter := model.WeightFilter{Owner: "john", SortBy: "date", Sort: -1, Page: 1, Limit: 30}
+	repoMock.On("CountMany", mock.Anything, expectedFilter).Return(int64(1), nil)
+	repoMock.On("FindMany", mock.Anything, expectedFilter).Return([]entity.Weight{{Owner: "john", Date: 1}}, nil)
+	repoMock.On("Averages", mock.Anything, expectedFilter).Return(entity.WeightAverage{}, nil)
+
+	result := usecase.FindMany(ctx, model.WeightFilter{})
+
+	assert.Nil(t, result.Error(), "should be no error")
+	repoMock.AssertExpectations(t)
+}
+
+func TestUsecaseImportMany_Success(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logrus.New(),
+		Repository:  repoMock,
+	})
+
+	rows := []model.WeightImportRow{
+		{Row: 1, Payload: model.WeightPayload{Date: 1656633600000000000, Max: 3, Min: 1}},
+		{Row: 2, Error: "Max must be greater than min"},
+		{Row: 3, Payload: model.WeightPayload{Date: 1656720000000000000, Max: 4, Min: 1}},
+	}
+	expectedWeights := []entity.Weight{
+		{Date: 1656633600000000000, Day: "2022-07-01", Max: 3, Min: 1, Diff: 2},
+		{Date: 1656720000000000000, Day: "2022-07-02", Max: 4, Min: 1, Diff: 3},
+	}
+	repoMock.On("BulkUpsert", mock.Anything, "", expectedWeights, false).Return([]bool{true, false}, nil)
+
+	result := usecase.ImportMany(context.TODO(), rows, false)
+
+	assert.Nil(t, result.Error(), "should be no error")
+	report := result.Data().(model.ImportReport)
+	assert.Equal(t, 1, report.Inserted)
+	assert.Equal(t, 0, report.Updated)
+	assert.Equal(t, 1, report.Skipped)
+	assert.Equal(t, 1, report.Invalid)
+	assert.Equal(t, "INSERTED", report.Rows[0].Status)
+	assert.Equal(t, "INVALID", report.Rows[1].Status)
+	assert.Equal(t, "SKIPPED_DUPLICATE", report.Rows[2].Status)
+	repoMock.AssertExpectations(t)
+}
+
+func TestUsecaseImportMany_Success_Overwrite(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logrus.New(),
+		Repository:  repoMock,
+	})
+
+	rows := []model.WeightImportRow{
+		{Row: 1, Payload: model.WeightPayload{Date: 1656633600000000000, Max: 3, Min: 1}},
+	}
+	repoMock.On("BulkUpsert", mock.Anything, "", mock.Anything, true).Return([]bool{false}, nil)
+
+	result := usecase.ImportMany(context.TODO(), rows, true)
+
+	assert.Nil(t, result.Error(), "should be no error")
+	report := result.Data().(model.ImportReport)
+	assert.Equal(t, 1, report.Updated)
+	assert.Equal(t, "UPDATED", report.Rows[0].Status)
+	repoMock.AssertExpectations(t)
+}
+
+func TestUsecaseImportMany_Success_Batched(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logrus.New(),
+		Repository:  repoMock,
+	})
+
+	var rows []model.WeightImportRow
+	for i := 1; i <= 501; i++ {
+		rows = append(rows, model.WeightImportRow{Row: i, Payload: model.WeightPayload{Date: int64(i), Max: 2, Min: 1}})
+	}
+	repoMock.On("BulkUpsert", mock.Anything, "", mock.Anything, false).Return(func(ctx context.Context, owner string, bunchOfWeight []entity.Weight, overwrite bool) []bool {
+		return make([]bool, len(bunchOfWeight))
+	}, nil).Twice()
+
+	result := usecase.ImportMany(context.TODO(), rows, false)
+
+	assert.Nil(t, result.Error(), "should be no error")
+	report := result.Data().(model.ImportReport)
+	assert.Equal(t, 501, report.Skipped)
+	repoMock.AssertExpectations(t)
+}
+
+func TestUsecaseImportMany_Error_Unexpected(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logrus.New(),
+		Repository:  repoMock,
+	})
+
+	rows := []model.WeightImportRow{
+		{Row: 1, Payload: model.WeightPayload{Date: 1656633600000000000, Max: 3, Min: 1}},
+	}
+	repoMock.On("BulkUpsert", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, exception.ErrInternalServer)
+
+	result := usecase.ImportMany(context.TODO(), rows, false)
+
+	assert.Error(t, result.Error(), "should be error")
+	assert.Equal(t, result.Error(), exception.ErrInternalServer, "should be internal server error")
+	repoMock.AssertExpectations(t)
+}
+
+func TestUsecaseExportMany_Success(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logrus.New(),
+		Repository:  repoMock,
+	})
+
+	expectedFilter := model.WeightFilter{Owner: "john", SortBy: "date", Sort: -1, Page: 1, Limit: 30}
+	repoMock.On("FindEach", mock.Anything, expectedFilter, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		fn := args.Get(2).(func(entity.Weight) error)
+		fn(entity.Weight{Date: 1656633600000000000, Max: 3, Min: 1, Diff: 2})
+	})
+
+	var exported []model.WeightExportResponse
+	ctx := requestctx.WithOwner(context.TODO(), "john")
+	result := usecase.ExportMany(ctx, model.WeightFilter{}, func(w model.WeightExportResponse) error {
+		exported = append(exported, w)
+		return nil
+	})
+
+	assert.Nil(t, result.Error(), "should be no error")
+	assert.Equal(t, 1, len(exported))
+	assert.Equal(t, "2022-07-01", exported[0].DateString)
+	assert.Equal(t, 2, exported[0].Diff)
+	repoMock.AssertExpectations(t)
+}
+
+func TestUsecaseExportMany_Error_Unexpected(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logrus.New(),
+		Repository:  repoMock,
+	})
+
+	repoMock.On("FindEach", mock.Anything, mock.Anything, mock.Anything).Return(exception.ErrInternalServer)
+
+	result := usecase.ExportMany(context.TODO(), model.WeightFilter{}, func(w model.WeightExportResponse) error {
+		return nil
+	})
+
+	assert.Error(t, result.Error(), "should be error")
+	assert.Equal(t, result.Error(), exception.ErrInternalServer, "should be internal server error")
+	repoMock.AssertExpectations(t)
+}
+
+func TestUsecaseExportMany_Error_InvalidSort(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logrus.New(),
+		Repository:  repoMock,
+	})
+
+	result := usecase.ExportMany(context.TODO(), model.WeightFilter{SortBy: "unknown"}, func(w model.WeightExportResponse) error {
+		return nil
+	})
+
+	assert.Error(t, result.Error(), "should be error")
+	assert.Equal(t, result.Error(), exception.ErrBadRequest, "should be bad request error")
+	repoMock.AssertExpectations(t)
+}
+
+func TestUsecaseHistory_Success(t *testing.T) {
+	historyMock := new(mocks.HistoryRepository)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logrus.New(),
+		History:     historyMock,
+	})
+
+	historyMock.On("FindMany", mock.Anything, "john", int64(1656633600000000000)).Return([]entity.WeightRevision{
+		{
+			ID:        "abc",
+			Action:    "UPDATE",
+			Before:    &entity.Weight{Date: 1656633600000000000, Max: 2, Min: 1, Diff: 1},
+			After:     &entity.Weight{Date: 1656633600000000000, Max: 3, Min: 1, Diff: 2},
+			Actor:     "john",
+			RequestID: "abc-123",
+			CreatedAt: time.Date(2022, 7, 1, 17, 30, 0, 0, time.UTC).UnixNano(),
+		},
+		{ID: "def", Action: "DELETE", Before: &entity.Weight{Date: 1656633600000000000, Max: 2, Min: 1, Diff: 1}},
+	}, nil)
+
+	jakarta, _ := time.LoadLocation("Asia/Jakarta")
+	ctx := requestctx.WithOwner(context.TODO(), "john")
+	ctx = requestctx.WithLocation(ctx, jakarta)
+	result := usecase.History(ctx, 1656633600000000000)
+
+	assert.Nil(t, result.Error(), "should be no error")
+	revisions := result.Data().([]model.WeightRevisionResponse)
+	assert.Len(t, revisions, 2)
+	assert.Equal(t, "2022-07-02T00:30:00+07:00", revisions[0].CreatedAt, "should be in the timezone of the user")
+	assert.Equal(t, 3, revisions[0].After.Max)
+	assert.Equal(t, "2022-07-01", revisions[0].After.DateString)
+	assert.Nil(t, revisions[1].After)
+	historyMock.AssertExpectations(t)
+}
+
+func TestUsecaseHistory_Error_NotFound(t *testing.T) {
+	historyMock := new(mocks.HistoryRepository)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logrus.New(),
+		History:     historyMock,
+	})
+
+	historyMock.On("FindMany", mock.Anything, mock.Anything, mock.Anything).Return(nil, exception.ErrNotFound)
+
+	result := usecase.History(context.TODO(), 1)
+
+	assert.Equal(t, exception.ErrNotFound, result.Error(), "should be not found error")
+	assert.Equal(t, http.StatusNotFound, result.HTTPStatusCode())
+	historyMock.AssertExpectations(t)
+}
+
+func TestUsecaseHistory_Error_Unexpected(t *testing.T) {
+	historyMock := new(mocks.HistoryRepository)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logrus.New(),
+		History:     historyMock,
+	})
+
+	historyMock.On("FindMany", mock.Anything, mock.Anything, mock.Anything).Return(nil, exception.ErrInternalServer)
+
+	result := usecase.History(context.TODO(), 1)
+
+	assert.Equal(t, exception.ErrInternalServer, result.Error(), "should be internal server error")
+	historyMock.AssertExpectations(t)
+}
+
+func TestUsecaseRestore_Success_Deleted(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	historyMock := new(mocks.HistoryRepository)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logrus.New(),
+		Repository:  repoMock,
+		History:     historyMock,
+	})
+
+	historyMock.On("FindOne", mock.Anything, "", int64(1656633600000000000), "abc").Return(entity.WeightRevision{
+		ID:     "abc",
+		Action: "DELETE",
+		Before: &entity.Weight{Date: 1656633600000000000, Max: 3, Min: 1, Diff: 2},
+	}, nil)
+	expected := entity.Weight{Date: 1656633600000000000, Day: "2022-07-01", Max: 3, Min: 1, Diff: 2}
+	repoMock.On("UpsertOne", mock.Anything, "", int64(1656633600000000000), expected).Return(true, nil)
+
+	result := usecase.Restore(context.TODO(), 1656633600000000000, "abc")
+
+	assert.Nil(t, result.Error(), "should be no error")
+	assert.Equal(t, http.StatusOK, result.HTTPStatusCode())
+	repoMock.AssertExpectations(t)
+	historyMock.AssertExpectations(t)
+}
+
+func TestUsecaseRestore_Error_NotFound(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	historyMock := new(mocks.HistoryRepository)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logrus.New(),
+		Repository:  repoMock,
+		History:     historyMock,
+	})
+
+	historyMock.On("FindOne", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(entity.WeightRevision{}, exception.ErrNotFound)
+
+	result := usecase.Restore(context.TODO(), 1, "abc")
+
+	assert.Equal(t, exception.ErrNotFound, result.Error(), "should be not found error")
+	assert.Equal(t, http.StatusNotFound, result.HTTPStatusCode())
+	repoMock.AssertNotCalled(t, "UpsertOne", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUsecaseRestore_Error_Unexpected(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	historyMock := new(mocks.HistoryRepository)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logrus.New(),
+		Repository:  repoMock,
+		History:     historyMock,
+	})
+
+	historyMock.On("FindOne", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(entity.WeightRevision{After: &entity.Weight{Max: 2, Min: 1}}, nil)
+	repoMock.On("UpsertOne", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(false, exception.ErrInternalServer)
+
+	result := usecase.Restore(context.TODO(), 1, "abc")
+
+	assert.Equal(t, exception.ErrInternalServer, result.Error(), "should be internal server error")
+	repoMock.AssertExpectations(t)
+}
+
+func TestUsecaseInsertOne_Success_Notify(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	notifierMock := new(mocks.Notifier)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logrus.New(),
+		Repository:  repoMock,
+		Notifier:    notifierMock,
+	})
+
+	stored := entity.Weight{Owner: "john", Date: 1656633600000000000, Day: "2022-07-01", Max: 3, Min: 1, Diff: 2, Version: 1}
+	repoMock.On("FindOne", mock.Anything, "john", stored.Date).Return(entity.Weight{}, exception.ErrNotFound).Once()
+	repoMock.On("InsertOne", mock.Anything, mock.Anything).Return(nil)
+	repoMock.On("FindOne", mock.Anything, "john", stored.Date).Return(stored, nil).Once()
+	notifierMock.On("Notify", mock.Anything, "weight.created", "john", &model.WeighDetailResponse{
+		Date:       stored.Date,
+		DateString: "2022-07-01",
+		Max:        3,
+		Min:        1,
+		Diff:       2,
+		Version:    1,
+	}).Return(nil)
+
+	ctx := requestctx.WithOwner(context.TODO(), "john")
+	result := usecase.InsertOne(ctx, model.WeightPayload{Date: stored.Date, Max: 3, Min: 1})
+
+	assert.Nil(t, result.Error(), "should be no error")
+	repoMock.AssertExpectations(t)
+	notifierMock.AssertExpectations(t)
+}
+
+func TestUsecaseUpdateOne_Success_NotifyFailed(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	notifierMock := new(mocks.Notifier)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logrus.New(),
+		Repository:  repoMock,
+		Notifier:    notifierMock,
+	})
+
+	repoMock.On("UpdateOne", mock.Anything, mock.Anything, int64(1), mock.Anything).Return(nil)
+	repoMock.On("FindOne", mock.Anything, mock.Anything, int64(1)).Return(entity.Weight{Date: 1, Max: 3, Min: 1}, nil)
+	notifierMock.On("Notify", mock.Anything, "weight.updated", mock.Anything, mock.Anything).Return(exception.ErrInternalServer)
+
+	result := usecase.UpdateOne(context.TODO(), 1, model.WeightPayload{Max: 3, Min: 1})
+
+	assert.Nil(t, result.Error(), "should not fail the write when the notification fails")
+	assert.Equal(t, http.StatusOK, result.HTTPStatusCode())
+	notifierMock.AssertExpectations(t)
+}
+
+func TestUsecaseUpsertOne_Success_NotifyCreated(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	notifierMock := new(mocks.Notifier)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logrus.New(),
+		Repository:  repoMock,
+		Notifier:    notifierMock,
+	})
+
+	repoMock.On("UpsertOne", mock.Anything, mock.Anything, int64(1), mock.Anything).Return(true, nil)
+	repoMock.On("FindOne", mock.Anything, mock.Anything, int64(1)).Return(entity.Weight{Date: 1, Max: 3, Min: 1}, nil)
+	notifierMock.On("Notify", mock.Anything, "weight.created", mock.Anything, mock.Anything).Return(nil)
+
+	result := usecase.UpsertOne(context.TODO(), 1, model.WeightPayload{Max: 3, Min: 1})
+
+	assert.Nil(t, result.Error(), "should be no error")
+	notifierMock.AssertExpectations(t)
+}
+
+func TestUsecaseUpdateOne_Error_NotNotified(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	notifierMock := new(mocks.Notifier)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logrus.New(),
+		Repository:  repoMock,
+		Notifier:    notifierMock,
+	})
+
+	repoMock.On("UpdateOne", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(exception.ErrNotFound)
+
+	result := usecase.UpdateOne(context.TODO(), 1, model.WeightPayload{Max: 3, Min: 1})
+
+	assert.Equal(t, exception.ErrNotFound, result.Error(), "should be not found error")
+	notifierMock.AssertNotCalled(t, "Notify", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUsecaseDeleteOne_Success_Notify(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	notifierMock := new(mocks.Notifier)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logrus.New(),
+		Repository:  repoMock,
+		Notifier:    notifierMock,
+	})
+
+	stored := entity.Weight{Owner: "john", Date: 1656633600000000000, Day: "2022-07-01", Max: 3, Min: 1, Diff: 2, Version: 2}
+	repoMock.On("FindOne", mock.Anything, "john", stored.Date).Return(stored, nil).Once()
+	repoMock.On("DeleteOne", mock.Anything, "john", stored.Date).Return(nil)
+	notifierMock.On("Notify", mock.Anything, "weight.deleted", "john", &model.WeighDetailResponse{
+		Date:       stored.Date,
+		DateString: "2022-07-01",
+		Max:        3,
+		Min:        1,
+		Diff:       2,
+		Version:    2,
+	}).Return(nil)
+
+	ctx := requestctx.WithOwner(context.TODO(), "john")
+	result := usecase.DeleteOne(ctx, stored.Date)
+
+	assert.Nil(t, result.Error(), "should be no error")
+	repoMock.AssertExpectations(t)
+	notifierMock.AssertExpectations(t)
+}
+
+func TestUsecaseDeleteOne_Error_NotNotified(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	notifierMock := new(mocks.Notifier)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logrus.New(),
+		Repository:  repoMock,
+		Notifier:    notifierMock,
+	})
+
+	repoMock.On("FindOne", mock.Anything, mock.Anything, int64(1)).Return(entity.Weight{}, exception.ErrNotFound)
+	repoMock.On("DeleteOne", mock.Anything, mock.Anything, int64(1)).Return(exception.ErrNotFound)
+
+	result := usecase.DeleteOne(context.TODO(), 1)
+
+	assert.Equal(t, exception.ErrNotFound, result.Error(), "should be not found error")
+	notifierMock.AssertNotCalled(t, "Notify", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUsecaseImportMany_Success_Notify(t *testing.T) {
+	repoMock := new(mocks.Repository)
+	notifierMock := new(mocks.Notifier)
+	usecase := weight.NewWeightUsecase(weight.UsecaseProperty{
+		ServiceName: "test-service",
+		Logger:      logrus.New(),
+		Repository:  repoMock,
+		Notifier:    notifierMock,
+	})
+
+	rows := []model.WeightImportRow{
+		{Row: 1, Payload: model.WeightPayload{Date: 1656633600000000000, Max: 3, Min: 1}},
+		{Row: 2, Payload: model.WeightPayload{Date: 1656720000000000000, Max: 4, Min: 1}},
+		{Row: 3, Payload: model.WeightPayload{Date: 1656806400000000000, Max: 5, Min: 1}},
+	}
+	repoMock.On("BulkUpsert", mock.Anything, "john", mock.Anything, false).Return([]bool{true, false, true}, nil)
+	repoMock.On("FindEach", mock.Anything, mock.MatchedBy(func(filter model.WeightFilter) bool {
+		return filter.Owner == "john" && filter.From == 1656633600000000000 && filter.To == 1656806400000000000
+	}), mock.Anything).Return(func(ctx context.Context, filter model.WeightFilter, fn func(weight entity.Weight) error) error {
+		for _, row := range rows {
+			if err := fn(entity.Weight{Owner: "john", Date: row.Payload.Date, Max: row.Payload.Max, Min: row.Payload.Min, Version: 1}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	notifierMock.On("Notify", mock.Anything, "weight.created", "john", mock.Anything).Return(nil)
+
+	ctx := requestctx.WithOwner(context.TODO(), "john")
+	result := usecase.ImportMany(ctx, rows, false)
+
+	assert.Nil(t, result.Error(), "should be no error")
+	notifierMock.AssertNumberOfCalls(t, "Notify", 2)
+	notifierMock.AssertNotCalled(t, "Notify", mock.Anything, mock.Anything, mock.Anything,
+		mock.MatchedBy(func(data *model.WeighDetailResponse) bool { return data.Date == 1656720000000000000 }))
+}
@@ -0,0 +1,129 @@
+package weight
+
+import (
+	"context"
+
+	"github.com/ijalalfrz/sirclo-weight-test/model"
+	"github.com/ijalalfrz/sirclo-weight-test/response"
+	"github.com/ijalalfrz/sirclo-weight-test/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracingUsecase is a decorator of Usecase which wraps every call in a span.
+type tracingUsecase struct {
+	next   Usecase
+	tracer trace.Tracer
+}
+
+// NewTracingUsecase is a constructor.
+func NewTracingUsecase(next Usecase, tracer trace.Tracer) Usecase {
+	return tracingUsecase{
+		next:   next,
+		tracer: tracer,
+	}
+}
+
+func (u tracingUsecase) InsertOne(ctx context.Context, payload model.WeightPayload) (resp response.Response) {
+	ctx, span := u.start(ctx, "InsertOne", attribute.Int64("weight.date", payload.Date))
+	defer u.end(span, &resp)
+
+	resp = u.next.InsertOne(ctx, payload)
+	return
+}
+
+func (u tracingUsecase) UpdateOne(ctx context.Context, key int64, payload model.WeightPayload) (resp response.Response) {
+	ctx, span := u.start(ctx, "UpdateOne", attribute.Int64("weight.date", key))
+	defer u.end(span, &resp)
+
+	resp = u.next.UpdateOne(ctx, key, payload)
+	return
+}
+
+func (u tracingUsecase) UpsertOne(ctx context.Context, key int64, payload model.WeightPayload) (resp response.Response) {
+	ctx, span := u.start(ctx, "UpsertOne", attribute.Int64("weight.date", key))
+	defer u.end(span, &resp)
+
+	resp = u.next.UpsertOne(ctx, key, payload)
+	return
+}
+
+func (u tracingUsecase) FindMany(ctx context.Context, filter model.WeightFilter) (resp response.Response) {
+	ctx, span := u.start(ctx, "FindMany",
+		attribute.Int64("weight.filter.page", filter.Page),
+		attribute.Int64("weight.filter.limit", filter.Limit),
+		attribute.String("weight.filter.sort_by", filter.SortBy),
+	)
+	defer u.end(span, &resp)
+
+	resp = u.next.FindMany(ctx, filter)
+	return
+}
+
+func (u tracingUsecase) FindOne(ctx context.Context, key int64) (resp response.Response) {
+	ctx, span := u.start(ctx, "FindOne", attribute.Int64("weight.date", key))
+	defer u.end(span, &resp)
+
+	resp = u.next.FindOne(ctx, key)
+	return
+}
+
+func (u tracingUsecase) DeleteOne(ctx context.Context, key int64) (resp response.Response) {
+	ctx, span := u.start(ctx, "DeleteOne", attribute.Int64("weight.date", key))
+	defer u.end(span, &resp)
+
+	resp = u.next.DeleteOne(ctx, key)
+	return
+}
+
+func (u tracingUsecase) Statistics(ctx context.Context, filter model.StatisticFilter) (resp response.Response) {
+	ctx, span := u.start(ctx, "Statistics", attribute.String("weight.filter.group_by", filter.GroupBy))
+	defer u.end(span, &resp)
+
+	resp = u.next.Statistics(ctx, filter)
+	return
+}
+
+func (u tracingUsecase) ImportMany(ctx context.Context, rows []model.WeightImportRow, overwrite bool) (resp response.Response) {
+	ctx, span := u.start(ctx, "ImportMany", attribute.Int("weight.import.rows", len(rows)), attribute.Bool("weight.import.overwrite", overwrite))
+	defer u.end(span, &resp)
+
+	resp = u.next.ImportMany(ctx, rows, overwrite)
+	return
+}
+
+func (u tracingUsecase) ExportMany(ctx context.Context, filter model.WeightFilter, fn func(weight model.WeightExportResponse) error) (resp response.Response) {
+	ctx, span := u.start(ctx, "ExportMany")
+	defer u.end(span, &resp)
+
+	resp = u.next.ExportMany(ctx, filter, fn)
+	return
+}
+
+func (u tracingUsecase) History(ctx context.Context, key int64) (resp response.Response) {
+	ctx, span := u.start(ctx, "History", attribute.Int64("weight.date", key))
+	defer u.end(span, &resp)
+
+	resp = u.next.History(ctx, key)
+	return
+}
+
+func (u tracingUsecase) Restore(ctx context.Context, key int64, revisionID string) (resp response.Response) {
+	ctx, span := u.start(ctx, "Restore", attribute.Int64("weight.date", key), attribute.String("weight.revision", revisionID))
+	defer u.end(span, &resp)
+
+	resp = u.next.Restore(ctx, key, revisionID)
+	return
+}
+
+func (u tracingUsecase) start(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return u.tracer.Start(ctx, "weight.Usecase/"+method, trace.WithAttributes(attrs...))
+}
+
+func (u tracingUsecase) end(span trace.Span, resp *response.Response) {
+	if *resp != nil {
+		span.SetAttributes(attribute.String("response.status", (*resp).Status()))
+		tracing.RecordError(span, (*resp).Error())
+	}
+	span.End()
+}
@@ -0,0 +1,56 @@
+package weight_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/ijalalfrz/sirclo-weight-test/entity"
+	"github.com/ijalalfrz/sirclo-weight-test/exception"
+	"github.com/ijalalfrz/sirclo-weight-test/response"
+	"github.com/ijalalfrz/sirclo-weight-test/weight"
+	"github.com/ijalalfrz/sirclo-weight-test/weight/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracingUsecase_FindOne(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	usecaseMock := new(mocks.Usecase)
+	usecase := weight.NewTracingUsecase(usecaseMock, tracer)
+
+	usecaseMock.On("FindOne", mock.Anything, int64(1)).Return(response.NewErrorResponse(exception.ErrInternalServer, http.StatusInternalServerError, nil, response.StatUnexpectedError, "error"))
+
+	result := usecase.FindOne(context.TODO(), 1)
+
+	assert.Equal(t, exception.ErrInternalServer, result.Error())
+	spans := recorder.Ended()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "weight.Usecase/FindOne", spans[0].Name())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	usecaseMock.AssertExpectations(t)
+}
+
+func TestTracingRepository_FindOne(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	repoMock := new(mocks.Repository)
+	repo := weight.NewTracingRepository(repoMock, tracer)
+
+	repoMock.On("FindOne", mock.Anything, "john", int64(1)).Return(entity.Weight{}, exception.ErrNotFound)
+
+	_, err := repo.FindOne(context.TODO(), "john", 1)
+
+	assert.Equal(t, exception.ErrNotFound, err)
+	spans := recorder.Ended()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "weight.Repository/FindOne", spans[0].Name())
+	assert.Equal(t, codes.Unset, spans[0].Status().Code, "not found should not be recorded as error")
+	repoMock.AssertExpectations(t)
+}